@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"payments-backend-app/internal/memory"
 	imodels "payments-backend-app/internal/models"
 	"payments-backend-app/pkg/models"
+	"sync"
+	"time"
 
 	"payments-backend-app/pkg/server"
 
@@ -34,8 +38,60 @@ type PaymentsAppBuilder struct {
 	useInsecureDatabaseConnection bool
 
 	// services
-	AccountsService    models.AccountsService
-	TransactionService models.TransactionService
+	AccountsService      models.AccountsService
+	TransactionService   models.TransactionService
+	IdempotencyService   models.IdempotencyService
+	OperationTypeService models.OperationTypeService
+	InstallmentService   models.InstallmentService
+	AuthorizationService models.AuthorizationService
+	TransferService      models.TransferService
+	LedgerService        models.LedgerService
+	AllocationService    models.AllocationService
+	StatementService     models.StatementService
+	AccrualService       models.AccrualService
+	ScheduleService      models.ScheduleService
+	FXService            models.FXService
+	OutboxService        models.OutboxService
+	WebhookService       models.WebhookService
+	AccountEventService  models.AccountEventService
+
+	// idempotency config
+	idempotencyKeyTTL time.Duration
+
+	// operation types config
+	operationTypesCacheTTL time.Duration
+
+	// authorization holds config
+	authorizationHoldTTL time.Duration
+
+	// accruals config
+	lateFee models.Money
+
+	// settlement config
+	settlementStrategy   string
+	settlementStrategies map[string]models.SettlementStrategy
+
+	// documents config
+	documentValidators map[models.DocumentType]models.DocumentValidator
+
+	// currencies config
+	currencyConverter models.CurrencyConverter
+
+	// fx config
+	fxQuoteTTL  time.Duration
+	fxRatesFile string
+
+	// events config
+	eventPublisher     models.EventPublisher
+	eventsFile         string
+	outboxMaxAttempts  int
+	outboxRetryBackoff time.Duration
+
+	// webhooks config
+	webhookMaxAttempts  int
+	webhookRetryBackoff time.Duration
+	webhookClient       *http.Client
+	webhookHostCheck    models.WebhookHostCheck
 
 	// payments server config
 	paymentsServerAddr string
@@ -100,6 +156,194 @@ func (pab *PaymentsAppBuilder) WithTransactionService(as models.TransactionServi
 	return pab
 }
 
+func (pab *PaymentsAppBuilder) WithIdempotencyService(is models.IdempotencyService) *PaymentsAppBuilder {
+	pab.IdempotencyService = is
+	return pab
+}
+
+// WithIdempotencyKeyTTL sets how long idempotency keys are retained before they expire
+func (pab *PaymentsAppBuilder) WithIdempotencyKeyTTL(ttl time.Duration) *PaymentsAppBuilder {
+	pab.idempotencyKeyTTL = ttl
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithOperationTypeService(ots models.OperationTypeService) *PaymentsAppBuilder {
+	pab.OperationTypeService = ots
+	return pab
+}
+
+// WithOperationTypesCacheTTL sets how long operation types are cached before they are reloaded
+func (pab *PaymentsAppBuilder) WithOperationTypesCacheTTL(ttl time.Duration) *PaymentsAppBuilder {
+	pab.operationTypesCacheTTL = ttl
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithInstallmentService(is models.InstallmentService) *PaymentsAppBuilder {
+	pab.InstallmentService = is
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithAuthorizationService(as models.AuthorizationService) *PaymentsAppBuilder {
+	pab.AuthorizationService = as
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithTransferService(ts models.TransferService) *PaymentsAppBuilder {
+	pab.TransferService = ts
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithLedgerService(ls models.LedgerService) *PaymentsAppBuilder {
+	pab.LedgerService = ls
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithAllocationService(as models.AllocationService) *PaymentsAppBuilder {
+	pab.AllocationService = as
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithStatementService(ss models.StatementService) *PaymentsAppBuilder {
+	pab.StatementService = ss
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithAccrualService(as models.AccrualService) *PaymentsAppBuilder {
+	pab.AccrualService = as
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithScheduleService(ss models.ScheduleService) *PaymentsAppBuilder {
+	pab.ScheduleService = ss
+	return pab
+}
+
+// WithLateFee sets the fee charged for every statement whose minimum payment was not paid by its due date
+func (pab *PaymentsAppBuilder) WithLateFee(fee models.Money) *PaymentsAppBuilder {
+	pab.lateFee = fee
+	return pab
+}
+
+// WithAuthorizationHoldTTL sets how long authorization holds are kept before they expire
+func (pab *PaymentsAppBuilder) WithAuthorizationHoldTTL(ttl time.Duration) *PaymentsAppBuilder {
+	pab.authorizationHoldTTL = ttl
+	return pab
+}
+
+// WithSettlementStrategy selects by name the strategy used for the accounts that did not select one,
+// either one shipped with the app or one added with WithNamedSettlementStrategy
+func (pab *PaymentsAppBuilder) WithSettlementStrategy(name string) *PaymentsAppBuilder {
+	pab.settlementStrategy = name
+	return pab
+}
+
+// WithNamedSettlementStrategy adds a strategy accounts can select by name, or replaces a shipped one
+func (pab *PaymentsAppBuilder) WithNamedSettlementStrategy(name string, strategy models.SettlementStrategy) *PaymentsAppBuilder {
+	if pab.settlementStrategies == nil {
+		pab.settlementStrategies = map[string]models.SettlementStrategy{}
+	}
+	pab.settlementStrategies[name] = strategy
+	return pab
+}
+
+// WithDocumentValidator adds a document type accounts can be opened with, or replaces a shipped one
+func (pab *PaymentsAppBuilder) WithDocumentValidator(documentType models.DocumentType, validator models.DocumentValidator) *PaymentsAppBuilder {
+	if pab.documentValidators == nil {
+		pab.documentValidators = map[models.DocumentType]models.DocumentValidator{}
+	}
+	pab.documentValidators[documentType] = validator
+	return pab
+}
+
+// WithCurrencyConverter sets the converter of the transactions that request a conversion into the
+// currency of their account, by default they are converted at the current rates of the fx service
+func (pab *PaymentsAppBuilder) WithCurrencyConverter(converter models.CurrencyConverter) *PaymentsAppBuilder {
+	pab.currencyConverter = converter
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithFXService(fs models.FXService) *PaymentsAppBuilder {
+	pab.FXService = fs
+	return pab
+}
+
+// WithFXQuoteTTL sets how long fx quotes lock the rate of their currency pair
+func (pab *PaymentsAppBuilder) WithFXQuoteTTL(ttl time.Duration) *PaymentsAppBuilder {
+	pab.fxQuoteTTL = ttl
+	return pab
+}
+
+// WithFXRatesFile sets a json file of rates loaded into the fx service when the app is built
+func (pab *PaymentsAppBuilder) WithFXRatesFile(path string) *PaymentsAppBuilder {
+	pab.fxRatesFile = path
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithOutboxService(obs models.OutboxService) *PaymentsAppBuilder {
+	pab.OutboxService = obs
+	return pab
+}
+
+// WithEventPublisher sets the publisher the events of the outbox are relayed to
+func (pab *PaymentsAppBuilder) WithEventPublisher(publisher models.EventPublisher) *PaymentsAppBuilder {
+	pab.eventPublisher = publisher
+	return pab
+}
+
+// WithEventsFile relays the events of the outbox to a file, one json object per line,
+// unless a publisher is set with WithEventPublisher
+func (pab *PaymentsAppBuilder) WithEventsFile(path string) *PaymentsAppBuilder {
+	pab.eventsFile = path
+	return pab
+}
+
+// WithOutboxMaxAttempts sets how many times an event of the outbox is published before it is parked
+func (pab *PaymentsAppBuilder) WithOutboxMaxAttempts(maxAttempts int) *PaymentsAppBuilder {
+	pab.outboxMaxAttempts = maxAttempts
+	return pab
+}
+
+// WithOutboxRetryBackoff sets the wait before the first retry of an event of the outbox, it doubles after every attempt
+func (pab *PaymentsAppBuilder) WithOutboxRetryBackoff(backoff time.Duration) *PaymentsAppBuilder {
+	pab.outboxRetryBackoff = backoff
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithWebhookService(whs models.WebhookService) *PaymentsAppBuilder {
+	pab.WebhookService = whs
+	return pab
+}
+
+// WithWebhookMaxAttempts sets how many times a webhook delivery is attempted before it is dead lettered
+func (pab *PaymentsAppBuilder) WithWebhookMaxAttempts(maxAttempts int) *PaymentsAppBuilder {
+	pab.webhookMaxAttempts = maxAttempts
+	return pab
+}
+
+// WithWebhookRetryBackoff sets the wait before the first retry of a webhook delivery, it doubles after every attempt
+func (pab *PaymentsAppBuilder) WithWebhookRetryBackoff(backoff time.Duration) *PaymentsAppBuilder {
+	pab.webhookRetryBackoff = backoff
+	return pab
+}
+
+// WithWebhookHostCheck sets the check of the hosts webhooks are created for, by default only public hosts are accepted
+func (pab *PaymentsAppBuilder) WithWebhookHostCheck(check models.WebhookHostCheck) *PaymentsAppBuilder {
+	pab.webhookHostCheck = check
+	return pab
+}
+
+// WithWebhookClient sets the http client webhook deliveries are posted with, by default a client that only connects
+// to public addresses and does not follow redirects
+func (pab *PaymentsAppBuilder) WithWebhookClient(client *http.Client) *PaymentsAppBuilder {
+	pab.webhookClient = client
+	return pab
+}
+
+func (pab *PaymentsAppBuilder) WithAccountEventService(aes models.AccountEventService) *PaymentsAppBuilder {
+	pab.AccountEventService = aes
+	return pab
+}
+
 func (pab *PaymentsAppBuilder) DisableDatabase() *PaymentsAppBuilder {
 	pab.disableDatabase = true
 	return pab
@@ -119,15 +363,190 @@ func (pab *PaymentsAppBuilder) GetTransactionService() (models.TransactionServic
 	return pab.TransactionService, nil
 }
 
+func (pab *PaymentsAppBuilder) GetIdempotencyService() (models.IdempotencyService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.IdempotencyService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetOperationTypeService() (models.OperationTypeService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.OperationTypeService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetInstallmentService() (models.InstallmentService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.InstallmentService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetAuthorizationService() (models.AuthorizationService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.AuthorizationService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetTransferService() (models.TransferService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.TransferService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetLedgerService() (models.LedgerService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.LedgerService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetAllocationService() (models.AllocationService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.AllocationService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetStatementService() (models.StatementService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.StatementService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetAccrualService() (models.AccrualService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.AccrualService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetScheduleService() (models.ScheduleService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.ScheduleService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetFXService() (models.FXService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.FXService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetOutboxService() (models.OutboxService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.OutboxService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetWebhookService() (models.WebhookService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.WebhookService, nil
+}
+
+func (pab *PaymentsAppBuilder) GetAccountEventService() (models.AccountEventService, error) {
+	if !pab.isBuilt {
+		return nil, fmt.Errorf("not built")
+	}
+	return pab.AccountEventService, nil
+}
+
 func (pab *PaymentsAppBuilder) Build() (Runner, error) {
 
 	par := &paymentsAppRunner{}
+	par.jobsCtx, par.cancelJobs = context.WithCancel(context.Background())
 
-	if pab.db != nil {
-		par.db = pab.db
+	if pab.logger == nil {
+		handlerOptions := &slog.HandlerOptions{
+			AddSource: true,
+			Level:     slog.LevelDebug,
+		}
+		pab.logger = slog.New(slog.NewJSONHandler(os.Stdout, handlerOptions))
+	}
+
+	if pab.idempotencyKeyTTL == 0 {
+		pab.idempotencyKeyTTL = defaultIdempotencyKeyTTL
 	}
 
-	if !pab.disableDatabase {
+	if pab.operationTypesCacheTTL == 0 {
+		pab.operationTypesCacheTTL = defaultOperationTypesCacheTTL
+	}
+
+	if pab.authorizationHoldTTL == 0 {
+		pab.authorizationHoldTTL = defaultAuthorizationHoldTTL
+	}
+
+	if pab.lateFee == 0 {
+		pab.lateFee = defaultLateFee
+	}
+
+	if pab.fxQuoteTTL == 0 {
+		pab.fxQuoteTTL = defaultFXQuoteTTL
+	}
+
+	if pab.outboxMaxAttempts == 0 {
+		pab.outboxMaxAttempts = defaultOutboxMaxAttempts
+	}
+
+	if pab.outboxRetryBackoff == 0 {
+		pab.outboxRetryBackoff = defaultOutboxRetryBackoff
+	}
+
+	if pab.webhookMaxAttempts == 0 {
+		pab.webhookMaxAttempts = defaultWebhookMaxAttempts
+	}
+
+	if pab.webhookRetryBackoff == 0 {
+		pab.webhookRetryBackoff = defaultWebhookRetryBackoff
+	}
+
+	if pab.webhookHostCheck == nil {
+		pab.webhookHostCheck = models.CheckPublicWebhookHost
+	}
+
+	if pab.webhookClient == nil {
+		pab.webhookClient = models.NewWebhookClient(defaultWebhookTimeout)
+	}
+
+	// without a publisher the events are relayed within the app
+	if pab.eventPublisher == nil {
+		if pab.eventsFile != "" {
+			pab.eventPublisher = models.NewFilePublisher(pab.eventsFile)
+		} else {
+			pab.eventPublisher = models.NewInProcessPublisher()
+		}
+	}
+
+	settlement := models.NewSettlementStrategies()
+	for name, strategy := range pab.settlementStrategies {
+		settlement.ByName[name] = strategy
+	}
+	if pab.settlementStrategy != "" {
+		strategy, ok := settlement.ByName[pab.settlementStrategy]
+		if !ok {
+			return nil, fmt.Errorf("unknown settlement strategy %s", pab.settlementStrategy)
+		}
+		settlement.Default = strategy
+	}
+
+	documents := models.NewDocumentValidators()
+	for documentType, validator := range pab.documentValidators {
+		documents.ByType[documentType] = validator
+	}
+
+	if pab.db != nil {
+		par.db = pab.db
+	} else if !pab.disableDatabase {
 		var err error
 		par.db, err = NewDatabase(
 			pab.databaseAddr,
@@ -140,15 +559,189 @@ func (pab *PaymentsAppBuilder) Build() (Runner, error) {
 		}
 	}
 
-	if pab.AccountsService == nil {
-		pab.AccountsService = imodels.NewAccountsService(par.db)
+	if par.db != nil {
+		if pab.AccountsService == nil {
+			pab.AccountsService = imodels.NewAccountsService(par.db)
+		}
+
+		if pab.TransactionService == nil {
+			pab.TransactionService = imodels.NewTransactionService(par.db, settlement)
+		}
+
+		if pab.IdempotencyService == nil {
+			pab.IdempotencyService = imodels.NewIdempotencyService(par.db)
+		}
+
+		if pab.OperationTypeService == nil {
+			pab.OperationTypeService = imodels.NewCachedOperationTypeService(
+				imodels.NewOperationTypeService(par.db),
+				pab.operationTypesCacheTTL)
+		}
+
+		if pab.InstallmentService == nil {
+			pab.InstallmentService = imodels.NewInstallmentService(par.db, settlement)
+		}
+
+		if pab.AuthorizationService == nil {
+			pab.AuthorizationService = imodels.NewAuthorizationService(par.db, pab.authorizationHoldTTL, settlement)
+		}
+
+		if pab.TransferService == nil {
+			pab.TransferService = imodels.NewTransferService(par.db, settlement)
+		}
+
+		if pab.LedgerService == nil {
+			pab.LedgerService = imodels.NewLedgerService(par.db)
+		}
+
+		if pab.AllocationService == nil {
+			pab.AllocationService = imodels.NewAllocationService(par.db)
+		}
+
+		if pab.StatementService == nil {
+			pab.StatementService = imodels.NewStatementService(par.db)
+		}
+
+		if pab.AccrualService == nil {
+			pab.AccrualService = imodels.NewAccrualService(par.db, pab.lateFee, settlement)
+		}
+
+		if pab.ScheduleService == nil {
+			pab.ScheduleService = imodels.NewScheduleService(par.db)
+		}
+
+		if pab.FXService == nil {
+			pab.FXService = imodels.NewFXService(par.db, pab.fxQuoteTTL)
+		}
+
+		if pab.OutboxService == nil {
+			pab.OutboxService = imodels.NewOutboxService(par.db)
+		}
+
+		if pab.WebhookService == nil {
+			pab.WebhookService = imodels.NewWebhookService(par.db)
+		}
+
+		if pab.AccountEventService == nil {
+			pab.AccountEventService = imodels.NewAccountEventService(par.db)
+		}
+	} else {
+		// without a database the services default to their in-memory implementations
+		store := memory.NewStore(settlement)
+
+		if pab.AccountsService == nil {
+			pab.AccountsService = memory.NewAccountsService(store)
+		}
+
+		if pab.TransactionService == nil {
+			pab.TransactionService = memory.NewTransactionService(store)
+		}
+
+		if pab.IdempotencyService == nil {
+			pab.IdempotencyService = memory.NewIdempotencyService(store)
+		}
+
+		if pab.OperationTypeService == nil {
+			pab.OperationTypeService = memory.NewOperationTypeService(store)
+		}
+
+		if pab.InstallmentService == nil {
+			pab.InstallmentService = memory.NewInstallmentService(store)
+		}
+
+		if pab.AuthorizationService == nil {
+			pab.AuthorizationService = memory.NewAuthorizationService(store, pab.authorizationHoldTTL)
+		}
+
+		if pab.TransferService == nil {
+			pab.TransferService = memory.NewTransferService(store)
+		}
+
+		if pab.LedgerService == nil {
+			pab.LedgerService = memory.NewLedgerService(store)
+		}
+
+		if pab.AllocationService == nil {
+			pab.AllocationService = memory.NewAllocationService(store)
+		}
+
+		if pab.StatementService == nil {
+			pab.StatementService = memory.NewStatementService(store)
+		}
+
+		if pab.AccrualService == nil {
+			pab.AccrualService = memory.NewAccrualService(store, pab.lateFee)
+		}
+
+		if pab.ScheduleService == nil {
+			pab.ScheduleService = memory.NewScheduleService(store)
+		}
+
+		if pab.FXService == nil {
+			pab.FXService = memory.NewFXService(store, pab.fxQuoteTTL)
+		}
+
+		if pab.OutboxService == nil {
+			pab.OutboxService = memory.NewOutboxService(store)
+		}
+
+		if pab.WebhookService == nil {
+			pab.WebhookService = memory.NewWebhookService(store)
+		}
+
+		if pab.AccountEventService == nil {
+			pab.AccountEventService = memory.NewAccountEventService(store)
+		}
+	}
+
+	if pab.fxRatesFile != "" {
+		if err := loadFXRates(context.Background(), pab.FXService, pab.fxRatesFile); err != nil {
+			return nil, err
+		}
 	}
 
-	if pab.TransactionService == nil {
-		pab.TransactionService = imodels.NewTransactionService(par.db)
+	if pab.currencyConverter == nil {
+		pab.currencyConverter = models.NewRateConverter(pab.FXService)
 	}
 
-	pah := server.NewPaymentsAppHandler(pab.AccountsService, pab.TransactionService, server.WithLogger(pab.logger))
+	par.jobs = append(par.jobs, expireIdempotencyKeysJob(pab.IdempotencyService, pab.idempotencyKeyTTL, pab.logger))
+	par.jobs = append(par.jobs, postDueInstallmentsJob(pab.InstallmentService, defaultInstallmentsPostingInterval, pab.logger))
+	par.jobs = append(par.jobs, expireAuthorizationsJob(pab.AuthorizationService, defaultAuthorizationExpiryInterval, pab.logger))
+	par.jobs = append(par.jobs, verifyLedgerJob(pab.LedgerService, defaultLedgerVerificationInterval, pab.logger))
+	par.jobs = append(par.jobs, generateStatementsJob(pab.StatementService, defaultStatementGenerationInterval, pab.logger))
+	par.jobs = append(par.jobs, accrueJob(pab.AccrualService, defaultAccrualInterval, pab.logger))
+
+	scheduler := models.NewScheduler(pab.ScheduleService, pab.TransactionService, pab.OperationTypeService, pab.IdempotencyService, defaultScheduleLeaseTTL)
+	par.jobs = append(par.jobs, runSchedulesJob(scheduler, defaultScheduleRunInterval, pab.logger))
+
+	relay := models.NewOutboxRelay(pab.OutboxService, pab.eventPublisher, pab.outboxMaxAttempts, pab.outboxRetryBackoff)
+	par.jobs = append(par.jobs, relayOutboxJob(relay, defaultOutboxRelayInterval, pab.logger))
+
+	dispatcher := models.NewWebhookDispatcher(pab.WebhookService, pab.webhookClient, pab.webhookMaxAttempts, pab.webhookRetryBackoff)
+	par.jobs = append(par.jobs, deliverWebhooksJob(dispatcher, defaultWebhookDeliveryInterval, pab.logger))
+
+	par.jobs = append(par.jobs, listenAccountEventsJob(pab.AccountEventService, defaultAccountEventsRetryInterval, pab.logger))
+
+	pah := server.NewPaymentsAppHandler(
+		pab.AccountsService,
+		pab.TransactionService,
+		server.WithLogger(pab.logger),
+		server.WithIdempotencyService(pab.IdempotencyService),
+		server.WithOperationTypeService(pab.OperationTypeService),
+		server.WithInstallmentService(pab.InstallmentService),
+		server.WithAuthorizationService(pab.AuthorizationService),
+		server.WithTransferService(pab.TransferService),
+		server.WithAllocationService(pab.AllocationService),
+		server.WithStatementService(pab.StatementService),
+		server.WithAccrualService(pab.AccrualService),
+		server.WithScheduleService(pab.ScheduleService),
+		server.WithSettlementStrategies(settlement),
+		server.WithDocumentValidators(documents),
+		server.WithCurrencyConverter(pab.currencyConverter),
+		server.WithFXService(pab.FXService),
+		server.WithWebhookService(pab.WebhookService),
+		server.WithWebhookHostCheck(pab.webhookHostCheck),
+		server.WithAccountEventService(pab.AccountEventService))
 
 	router := httprouter.New()
 	router.PanicHandler = pah.PanicHandler
@@ -157,12 +750,51 @@ func (pab *PaymentsAppBuilder) Build() (Runner, error) {
 	router.GET(server.ReadinessExtension, pah.Readiness)
 	router.POST(server.CreateAccountExtension, pah.CreateAccount)
 	router.GET(server.GetAccountExtension, pah.GetAccount)
+	router.GET(server.GetAccountBalanceExtension, pah.GetAccountBalance)
+	router.GET(server.ListAccountTransactionsExtension, pah.ListAccountTransactions)
+	router.PATCH(server.UpdateCreditLimitExtension, pah.UpdateCreditLimit)
+	router.PATCH(server.UpdateSettlementStrategyExtension, pah.UpdateSettlementStrategy)
+	router.PATCH(server.UpdateBillingCycleExtension, pah.UpdateBillingCycle)
+	router.POST(server.FreezeAccountExtension, pah.FreezeAccount)
+	router.POST(server.UnfreezeAccountExtension, pah.UnfreezeAccount)
+	router.POST(server.CloseAccountExtension, pah.CloseAccount)
+	router.GET(server.ListAccountStatusHistoryExtension, pah.ListAccountStatusHistory)
+	router.GET(server.StreamAccountEventsExtension, pah.StreamAccountEvents)
+	router.GET(server.ListAccountStatementsExtension, pah.ListAccountStatements)
+	router.GET(server.GetStatementExtension, pah.GetStatement)
+	router.GET(server.ListAccountAccrualsExtension, pah.ListAccountAccruals)
+	router.POST(server.AccrueExtension, pah.Accrue)
 	router.POST(server.CreateTransactionExtension, pah.CreateTransaction)
+	router.GET(server.GetTransactionExtension, pah.GetTransaction)
+	router.POST(server.ReverseTransactionExtension, pah.ReverseTransaction)
+	router.GET(server.ListTransactionInstallmentsExtension, pah.ListTransactionInstallments)
+	router.GET(server.ListTransactionAllocationsExtension, pah.ListTransactionAllocations)
+	router.POST(server.CreateAuthorizationExtension, pah.CreateAuthorization)
+	router.GET(server.GetAuthorizationExtension, pah.GetAuthorization)
+	router.POST(server.CaptureAuthorizationExtension, pah.CaptureAuthorization)
+	router.POST(server.ReleaseAuthorizationExtension, pah.ReleaseAuthorization)
+	router.POST(server.CreateScheduleExtension, pah.CreateSchedule)
+	router.GET(server.GetScheduleExtension, pah.GetSchedule)
+	router.POST(server.CancelScheduleExtension, pah.CancelSchedule)
+	router.GET(server.ListScheduleRunsExtension, pah.ListScheduleRuns)
+	router.POST(server.CreateTransferExtension, pah.CreateTransfer)
+	router.GET(server.GetTransferExtension, pah.GetTransfer)
+	router.PUT(server.SetFXRateExtension, pah.SetFXRate)
+	router.GET(server.ListFXRatesExtension, pah.ListFXRates)
+	router.POST(server.CreateFXQuoteExtension, pah.CreateFXQuote)
+	router.GET(server.GetFXQuoteExtension, pah.GetFXQuote)
+	router.POST(server.CreateWebhookExtension, pah.CreateWebhook)
+	router.GET(server.ListWebhookDeliveriesExtension, pah.ListWebhookDeliveries)
+	router.GET(server.ListOperationTypesExtension, pah.ListOperationTypes)
+	router.POST(server.CreateOperationTypeExtension, pah.CreateOperationType)
+	router.PATCH(server.UpdateOperationTypeExtension, pah.UpdateOperationType)
 
 	server := &http.Server{
 		Addr:    pab.paymentsServerAddr,
 		Handler: router,
 	}
+	// the event streams never end on their own, shutting down would wait for them forever
+	server.RegisterOnShutdown(pah.CloseStreams)
 
 	par.server = server
 	pab.isBuilt = true
@@ -173,10 +805,24 @@ func (pab *PaymentsAppBuilder) Build() (Runner, error) {
 type paymentsAppRunner struct {
 	db     *bun.DB
 	server *http.Server
+
+	// background jobs
+	jobs       []job
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+	jobsWg     sync.WaitGroup
 }
 
 func (par *paymentsAppRunner) Start(_ context.Context) error {
 
+	for _, j := range par.jobs {
+		par.jobsWg.Add(1)
+		go func(j job) {
+			defer par.jobsWg.Done()
+			j(par.jobsCtx)
+		}(j)
+	}
+
 	if err := par.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("unable to start server [%s]", err.Error())
 	}
@@ -185,5 +831,11 @@ func (par *paymentsAppRunner) Start(_ context.Context) error {
 }
 
 func (par *paymentsAppRunner) Stop(_ context.Context) error {
-	return par.server.Shutdown(context.Background())
+
+	par.cancelJobs()
+
+	err := par.server.Shutdown(context.Background())
+	par.jobsWg.Wait()
+
+	return err
 }
@@ -0,0 +1,255 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"payments-backend-app/pkg/models"
+	"time"
+)
+
+var (
+	defaultIdempotencyKeyTTL      = 24 * time.Hour
+	defaultOperationTypesCacheTTL = 30 * time.Second
+
+	defaultInstallmentsPostingInterval = time.Minute
+
+	defaultAuthorizationHoldTTL        = 7 * 24 * time.Hour
+	defaultAuthorizationExpiryInterval = time.Minute
+
+	defaultLedgerVerificationInterval = 10 * time.Minute
+
+	defaultStatementGenerationInterval = time.Hour
+
+	defaultLateFee         = models.MustParseMoney("10.00")
+	defaultAccrualInterval = time.Hour
+
+	defaultScheduleRunInterval = time.Minute
+	defaultScheduleLeaseTTL    = 5 * time.Minute
+
+	defaultFXQuoteTTL = 30 * time.Second
+
+	defaultOutboxRelayInterval = time.Second
+	defaultOutboxMaxAttempts   = 10
+	defaultOutboxRetryBackoff  = 5 * time.Second
+
+	defaultWebhookDeliveryInterval = time.Second
+	defaultWebhookMaxAttempts      = 8
+	defaultWebhookRetryBackoff     = 30 * time.Second
+	defaultWebhookTimeout          = 10 * time.Second
+
+	defaultAccountEventsRetryInterval = 5 * time.Second
+)
+
+// job is a background task run alongside the payments server until it is stopped
+type job func(ctx context.Context)
+
+// periodicJob runs fn every interval until the context is cancelled
+func periodicJob(interval time.Duration, fn func(ctx context.Context)) job {
+	return func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	}
+}
+
+// expireIdempotencyKeysJob removes idempotency keys older than the ttl
+func expireIdempotencyKeysJob(idempotencyService models.IdempotencyService, ttl time.Duration, logger *slog.Logger) job {
+
+	interval := ttl / 24
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+
+	return periodicJob(interval, func(ctx context.Context) {
+		deleted, err := idempotencyService.DeleteExpired(ctx, time.Now().Add(-ttl))
+		if err != nil {
+			logger.ErrorContext(ctx, "unable to expire idempotency keys", "err", err)
+			return
+		}
+		logger.DebugContext(ctx, "expired idempotency keys", "count", deleted)
+	})
+}
+
+// postDueInstallmentsJob posts the installments that fell due since the last run
+func postDueInstallmentsJob(installmentService models.InstallmentService, interval time.Duration, logger *slog.Logger) job {
+
+	return periodicJob(interval, func(ctx context.Context) {
+		posted, err := installmentService.PostDue(ctx, time.Now())
+		if err != nil && !logBatchError(ctx, logger, "unable to post due installment", "installmentID", err) {
+			logger.ErrorContext(ctx, "unable to post due installments", "err", err)
+			return
+		}
+		logger.DebugContext(ctx, "posted due installments", "count", posted)
+	})
+}
+
+// expireAuthorizationsJob releases the authorization holds that were neither captured nor released in time
+func expireAuthorizationsJob(authorizationService models.AuthorizationService, interval time.Duration, logger *slog.Logger) job {
+
+	return periodicJob(interval, func(ctx context.Context) {
+		expired, err := authorizationService.ExpireStale(ctx, time.Now())
+		if err != nil {
+			logger.ErrorContext(ctx, "unable to expire authorization holds", "err", err)
+			return
+		}
+		logger.DebugContext(ctx, "expired authorization holds", "count", expired)
+	})
+}
+
+// verifyLedgerJob reports the journal entries whose postings do not sum to zero,
+// they can only come from a bug or from writes made outside of the services
+func verifyLedgerJob(ledgerService models.LedgerService, interval time.Duration, logger *slog.Logger) job {
+
+	return periodicJob(interval, func(ctx context.Context) {
+		unbalanced, err := ledgerService.Verify(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "unable to verify ledger", "err", err)
+			return
+		}
+		if len(unbalanced) > 0 {
+			logger.ErrorContext(ctx, "unbalanced journal entries", "count", len(unbalanced), "journalEntryIDs", unbalanced)
+			return
+		}
+		logger.DebugContext(ctx, "verified ledger")
+	})
+}
+
+// generateStatementsJob closes the billing cycles that ended since the last run
+func generateStatementsJob(statementService models.StatementService, interval time.Duration, logger *slog.Logger) job {
+
+	return periodicJob(interval, func(ctx context.Context) {
+		generated, err := statementService.GenerateDue(ctx, time.Now())
+		if err != nil && !logBatchError(ctx, logger, "unable to generate statement", "accountID", err) {
+			logger.ErrorContext(ctx, "unable to generate statements", "err", err)
+			return
+		}
+		logger.DebugContext(ctx, "generated statements", "count", generated)
+	})
+}
+
+// accrueJob posts the interest and late fees of the days that ended since the last accrued one, so the
+// days missed while the server was down or the job failed are caught up. Accruing a day again is a no-op,
+// the last accrued day is accrued again on start in case it was interrupted
+func accrueJob(accrualService models.AccrualService, interval time.Duration, logger *slog.Logger) job {
+
+	// next is the first day that is not accrued yet, the zero time until it is read on the first run
+	var next time.Time
+
+	return periodicJob(interval, func(ctx context.Context) {
+		yesterday := models.AccrualDay(time.Now()).AddDate(0, 0, -1)
+
+		if next.IsZero() {
+			last, err := accrualService.LastAccrualDay(ctx)
+			if err != nil {
+				logger.ErrorContext(ctx, "unable to fetch the last accrued day", "err", err)
+				return
+			}
+			next = yesterday
+			if !last.IsZero() && last.Before(yesterday) {
+				next = last
+			}
+		}
+
+		for ; !next.After(yesterday); next = next.AddDate(0, 0, 1) {
+			accrued, err := accrualService.Accrue(ctx, next)
+			if err != nil && !logBatchError(ctx, logger, "unable to accrue account", "accountID", err) {
+				logger.ErrorContext(ctx, "unable to accrue", "day", next, "err", err)
+				return
+			}
+			logger.DebugContext(ctx, "accrued", "day", next, "count", accrued)
+		}
+	})
+}
+
+// logBatchError logs every record of a models.BatchError with its id under idKey and reports whether err
+// was one, the failed records were skipped and the run went on with the others
+func logBatchError(ctx context.Context, logger *slog.Logger, msg string, idKey string, err error) bool {
+
+	var batchErr models.BatchError
+	if !errors.As(err, &batchErr) {
+		return false
+	}
+
+	for id, err := range batchErr {
+		logger.ErrorContext(ctx, msg, idKey, id, "err", err)
+	}
+	logger.ErrorContext(ctx, "skipped failed records", "count", len(batchErr))
+
+	return true
+}
+
+// runSchedulesJob books the transactions of the schedules that fell due since the last run,
+// the schedules are leased so that replicas running the job at the same time split them
+func runSchedulesJob(scheduler *models.Scheduler, interval time.Duration, logger *slog.Logger) job {
+
+	return periodicJob(interval, func(ctx context.Context) {
+		runs, err := scheduler.RunDue(ctx, time.Now())
+		if err != nil {
+			logger.ErrorContext(ctx, "unable to run due schedules", "err", err)
+			return
+		}
+		logger.DebugContext(ctx, "ran due schedules", "count", runs)
+	})
+}
+
+// relayOutboxJob publishes the events written to the outbox since the last run, it reports the events
+// that were parked and the accounts whose events are held back behind a failed one
+func relayOutboxJob(relay *models.OutboxRelay, interval time.Duration, logger *slog.Logger) job {
+
+	return periodicJob(interval, func(ctx context.Context) {
+		result, err := relay.Relay(ctx, time.Now())
+		if err != nil {
+			logger.ErrorContext(ctx, "unable to relay outbox events", "err", err)
+			return
+		}
+		if len(result.Parked) > 0 {
+			logger.ErrorContext(ctx, "parked outbox events that ran out of attempts", "eventIDs", result.Parked)
+		}
+		if len(result.Blocked) > 0 {
+			logger.WarnContext(ctx, "outbox events held back behind a failed event", "accountIDs", result.Blocked)
+		}
+		logger.DebugContext(ctx, "relayed outbox events", "count", result.Published)
+	})
+}
+
+// deliverWebhooksJob posts the webhook deliveries that fell due since the last run, the deliveries
+// are leased so that replicas running the job at the same time split them
+func deliverWebhooksJob(dispatcher *models.WebhookDispatcher, interval time.Duration, logger *slog.Logger) job {
+
+	return periodicJob(interval, func(ctx context.Context) {
+		attempted, err := dispatcher.DeliverDue(ctx, time.Now())
+		if err != nil {
+			logger.ErrorContext(ctx, "unable to deliver webhooks", "err", err)
+			return
+		}
+		logger.DebugContext(ctx, "delivered webhooks", "count", attempted)
+	})
+}
+
+// listenAccountEventsJob feeds the event streams of the accounts with the events written by every replica,
+// listening again after retryInterval whenever the listener fails
+func listenAccountEventsJob(accountEventService models.AccountEventService, retryInterval time.Duration, logger *slog.Logger) job {
+
+	return func(ctx context.Context) {
+		for {
+			if err := accountEventService.Listen(ctx); err != nil {
+				logger.ErrorContext(ctx, "unable to listen to account events", "err", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+		}
+	}
+}
@@ -3,8 +3,12 @@ package builder
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"os"
 	"payments-backend-app/internal/migrate"
+	"payments-backend-app/pkg/models"
+	"time"
 
 	"github.com/spf13/viper"
 	"github.com/uptrace/bun"
@@ -20,6 +24,18 @@ var (
 	DATABASE_PASSWORD_ENV      = "DATABASE_PASSWORD"
 	DATABASE_WITH_INSECURE_ENV = "DATABASE_WITH_INSECURE"
 	PAYMENTS_APP_ADDR_ENV      = "PAYMENTS_APP_ADDR"
+	IDEMPOTENCY_KEY_TTL_ENV    = "IDEMPOTENCY_KEY_TTL"
+	OPERATION_TYPES_TTL_ENV    = "OPERATION_TYPES_CACHE_TTL"
+	AUTHORIZATION_HOLD_TTL_ENV = "AUTHORIZATION_HOLD_TTL"
+	SETTLEMENT_STRATEGY_ENV    = "SETTLEMENT_STRATEGY"
+	LATE_FEE_ENV               = "LATE_FEE"
+	FX_QUOTE_TTL_ENV           = "FX_QUOTE_TTL"
+	FX_RATES_FILE_ENV          = "FX_RATES_FILE"
+	EVENTS_FILE_ENV            = "EVENTS_FILE"
+	OUTBOX_MAX_ATTEMPTS_ENV    = "OUTBOX_MAX_ATTEMPTS"
+	OUTBOX_RETRY_BACKOFF_ENV   = "OUTBOX_RETRY_BACKOFF"
+	WEBHOOK_MAX_ATTEMPTS_ENV   = "WEBHOOK_MAX_ATTEMPTS"
+	WEBHOOK_RETRY_BACKOFF_ENV  = "WEBHOOK_RETRY_BACKOFF"
 )
 
 type EnvConfig struct {
@@ -29,6 +45,18 @@ type EnvConfig struct {
 	DatabasePassword    string
 	UseInsecureDatabase bool
 	PaymentsAppAddr     string
+	IdempotencyKeyTTL   time.Duration
+	OperationTypesTTL   time.Duration
+	AuthorizationTTL    time.Duration
+	SettlementStrategy  string
+	LateFee             models.Money
+	FXQuoteTTL          time.Duration
+	FXRatesFile         string
+	EventsFile          string
+	OutboxMaxAttempts   int
+	OutboxRetryBackoff  time.Duration
+	WebhookMaxAttempts  int
+	WebhookRetryBackoff time.Duration
 }
 
 func GetEnvConfig() EnvConfig {
@@ -39,6 +67,18 @@ func GetEnvConfig() EnvConfig {
 	viper.SetDefault(DATABASE_PASSWORD_ENV, "payments-password")
 	viper.SetDefault(DATABASE_WITH_INSECURE_ENV, "true")
 	viper.SetDefault(PAYMENTS_APP_ADDR_ENV, ":8080")
+	viper.SetDefault(IDEMPOTENCY_KEY_TTL_ENV, defaultIdempotencyKeyTTL.String())
+	viper.SetDefault(OPERATION_TYPES_TTL_ENV, defaultOperationTypesCacheTTL.String())
+	viper.SetDefault(AUTHORIZATION_HOLD_TTL_ENV, defaultAuthorizationHoldTTL.String())
+	viper.SetDefault(SETTLEMENT_STRATEGY_ENV, models.FIFOSettlementStrategy)
+	viper.SetDefault(LATE_FEE_ENV, defaultLateFee.String())
+	viper.SetDefault(FX_QUOTE_TTL_ENV, defaultFXQuoteTTL.String())
+	viper.SetDefault(FX_RATES_FILE_ENV, "")
+	viper.SetDefault(EVENTS_FILE_ENV, "")
+	viper.SetDefault(OUTBOX_MAX_ATTEMPTS_ENV, defaultOutboxMaxAttempts)
+	viper.SetDefault(OUTBOX_RETRY_BACKOFF_ENV, defaultOutboxRetryBackoff.String())
+	viper.SetDefault(WEBHOOK_MAX_ATTEMPTS_ENV, defaultWebhookMaxAttempts)
+	viper.SetDefault(WEBHOOK_RETRY_BACKOFF_ENV, defaultWebhookRetryBackoff.String())
 
 	// bind env variables
 	viper.BindEnv(DATABASE_ADDR_ENV)
@@ -47,6 +87,18 @@ func GetEnvConfig() EnvConfig {
 	viper.BindEnv(DATABASE_PASSWORD_ENV)
 	viper.BindEnv(DATABASE_WITH_INSECURE_ENV)
 	viper.BindEnv(PAYMENTS_APP_ADDR_ENV)
+	viper.BindEnv(IDEMPOTENCY_KEY_TTL_ENV)
+	viper.BindEnv(OPERATION_TYPES_TTL_ENV)
+	viper.BindEnv(AUTHORIZATION_HOLD_TTL_ENV)
+	viper.BindEnv(SETTLEMENT_STRATEGY_ENV)
+	viper.BindEnv(LATE_FEE_ENV)
+	viper.BindEnv(FX_QUOTE_TTL_ENV)
+	viper.BindEnv(FX_RATES_FILE_ENV)
+	viper.BindEnv(EVENTS_FILE_ENV)
+	viper.BindEnv(OUTBOX_MAX_ATTEMPTS_ENV)
+	viper.BindEnv(OUTBOX_RETRY_BACKOFF_ENV)
+	viper.BindEnv(WEBHOOK_MAX_ATTEMPTS_ENV)
+	viper.BindEnv(WEBHOOK_RETRY_BACKOFF_ENV)
 
 	// fetch config from env variables
 	databaseAddr := viper.GetString(DATABASE_ADDR_ENV)
@@ -55,6 +107,19 @@ func GetEnvConfig() EnvConfig {
 	databasePassword := viper.GetString(DATABASE_PASSWORD_ENV)
 	useInsecureDatabase := viper.GetBool(DATABASE_WITH_INSECURE_ENV)
 	paymentsAppAddr := viper.GetString(PAYMENTS_APP_ADDR_ENV)
+	idempotencyKeyTTL := viper.GetDuration(IDEMPOTENCY_KEY_TTL_ENV)
+	operationTypesTTL := viper.GetDuration(OPERATION_TYPES_TTL_ENV)
+	authorizationTTL := viper.GetDuration(AUTHORIZATION_HOLD_TTL_ENV)
+	settlementStrategy := viper.GetString(SETTLEMENT_STRATEGY_ENV)
+	// like the durations an invalid amount reads as zero, which the builder replaces with the default
+	lateFee, _ := models.ParseMoney(viper.GetString(LATE_FEE_ENV))
+	fxQuoteTTL := viper.GetDuration(FX_QUOTE_TTL_ENV)
+	fxRatesFile := viper.GetString(FX_RATES_FILE_ENV)
+	eventsFile := viper.GetString(EVENTS_FILE_ENV)
+	outboxMaxAttempts := viper.GetInt(OUTBOX_MAX_ATTEMPTS_ENV)
+	outboxRetryBackoff := viper.GetDuration(OUTBOX_RETRY_BACKOFF_ENV)
+	webhookMaxAttempts := viper.GetInt(WEBHOOK_MAX_ATTEMPTS_ENV)
+	webhookRetryBackoff := viper.GetDuration(WEBHOOK_RETRY_BACKOFF_ENV)
 
 	envConfig := EnvConfig{
 		DatabaseAddr:        databaseAddr,
@@ -63,6 +128,18 @@ func GetEnvConfig() EnvConfig {
 		DatabasePassword:    databasePassword,
 		UseInsecureDatabase: useInsecureDatabase,
 		PaymentsAppAddr:     paymentsAppAddr,
+		IdempotencyKeyTTL:   idempotencyKeyTTL,
+		OperationTypesTTL:   operationTypesTTL,
+		AuthorizationTTL:    authorizationTTL,
+		SettlementStrategy:  settlementStrategy,
+		LateFee:             lateFee,
+		FXQuoteTTL:          fxQuoteTTL,
+		FXRatesFile:         fxRatesFile,
+		EventsFile:          eventsFile,
+		OutboxMaxAttempts:   outboxMaxAttempts,
+		OutboxRetryBackoff:  outboxRetryBackoff,
+		WebhookMaxAttempts:  webhookMaxAttempts,
+		WebhookRetryBackoff: webhookRetryBackoff,
 	}
 
 	return envConfig
@@ -93,3 +170,29 @@ func NewDatabase(databaseAddr string, databaseName string, databaseUser string,
 
 	return db, nil
 }
+
+// loadFXRates sets the rates of a json file holding an array of rates such as
+// [{"from": "USD", "to": "BRL", "rate": "5.1234"}], replacing the current rates of their pairs
+func loadFXRates(ctx context.Context, fxService models.FXService, path string) error {
+
+	ba, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read fx rates file [%s]", err.Error())
+	}
+
+	rates := []models.FXRate{}
+	if err := json.Unmarshal(ba, &rates); err != nil {
+		return fmt.Errorf("unable to parse fx rates file [%s]", err.Error())
+	}
+
+	for _, rate := range rates {
+		if err := rate.Validate(); err != nil {
+			return fmt.Errorf("invalid rate from %s to %s in fx rates file [%s]", rate.From, rate.To, err.Error())
+		}
+		if _, err := fxService.SetRate(ctx, rate); err != nil {
+			return fmt.Errorf("unable to set rate from %s to %s [%s]", rate.From, rate.To, err.Error())
+		}
+	}
+
+	return nil
+}
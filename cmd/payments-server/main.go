@@ -23,7 +23,19 @@ func main() {
 		"databaseUser", envConfig.DatabaseUser,
 		"databasePassword", envConfig.DatabasePassword,
 		"useInsecureDatabase", envConfig.UseInsecureDatabase,
-		"paymentsAppAddr", envConfig.PaymentsAppAddr)
+		"paymentsAppAddr", envConfig.PaymentsAppAddr,
+		"idempotencyKeyTTL", envConfig.IdempotencyKeyTTL,
+		"operationTypesTTL", envConfig.OperationTypesTTL,
+		"authorizationTTL", envConfig.AuthorizationTTL,
+		"settlementStrategy", envConfig.SettlementStrategy,
+		"lateFee", envConfig.LateFee,
+		"fxQuoteTTL", envConfig.FXQuoteTTL,
+		"fxRatesFile", envConfig.FXRatesFile,
+		"eventsFile", envConfig.EventsFile,
+		"outboxMaxAttempts", envConfig.OutboxMaxAttempts,
+		"outboxRetryBackoff", envConfig.OutboxRetryBackoff,
+		"webhookMaxAttempts", envConfig.WebhookMaxAttempts,
+		"webhookRetryBackoff", envConfig.WebhookRetryBackoff)
 
 	// build the runner
 	paymentsAppBuilder := builder.
@@ -32,7 +44,20 @@ func main() {
 		WithDatabaseName(envConfig.DatabaseName).
 		WithDatabaseUser(envConfig.DatabaseUser).
 		WithDatabasePassword(envConfig.DatabasePassword).
-		WithPaymentsServerAddr(envConfig.PaymentsAppAddr)
+		WithPaymentsServerAddr(envConfig.PaymentsAppAddr).
+		WithIdempotencyKeyTTL(envConfig.IdempotencyKeyTTL).
+		WithOperationTypesCacheTTL(envConfig.OperationTypesTTL).
+		WithAuthorizationHoldTTL(envConfig.AuthorizationTTL).
+		WithSettlementStrategy(envConfig.SettlementStrategy).
+		WithLateFee(envConfig.LateFee).
+		WithFXQuoteTTL(envConfig.FXQuoteTTL).
+		WithFXRatesFile(envConfig.FXRatesFile).
+		WithEventsFile(envConfig.EventsFile).
+		WithOutboxMaxAttempts(envConfig.OutboxMaxAttempts).
+		WithOutboxRetryBackoff(envConfig.OutboxRetryBackoff).
+		WithWebhookMaxAttempts(envConfig.WebhookMaxAttempts).
+		WithWebhookRetryBackoff(envConfig.WebhookRetryBackoff).
+		WithLogger(logger)
 
 	if envConfig.UseInsecureDatabase {
 		paymentsAppBuilder = paymentsAppBuilder.UseInsecureDatabaseConnection()
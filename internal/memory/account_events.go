@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+)
+
+type accountEventService struct {
+	store *Store
+}
+
+func NewAccountEventService(store *Store) *accountEventService {
+	return &accountEventService{
+		store: store,
+	}
+}
+
+func (aes *accountEventService) ListForAccount(_ context.Context, accountID int64, afterID int64, limit int) ([]models.Event, error) {
+	aes.store.mu.RLock()
+	defer aes.store.mu.RUnlock()
+
+	events := make([]models.Event, 0)
+	for _, event := range aes.store.events {
+		if event.AccountID == accountID && event.ID > afterID {
+			events = append(events, event)
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ID < events[j].ID
+	})
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	return events, nil
+}
+
+func (aes *accountEventService) LatestID(_ context.Context, accountID int64) (int64, error) {
+	aes.store.mu.RLock()
+	defer aes.store.mu.RUnlock()
+
+	latestID := int64(0)
+	for _, event := range aes.store.events {
+		if event.AccountID == accountID && event.ID > latestID {
+			latestID = event.ID
+		}
+	}
+
+	return latestID, nil
+}
+
+func (aes *accountEventService) Subscribe(accountID int64) (<-chan struct{}, func()) {
+	return aes.store.eventHub.Subscribe(accountID)
+}
+
+// Listen has nothing to relay, the events are signalled as they are recorded
+func (aes *accountEventService) Listen(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
@@ -0,0 +1,289 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type accountsService struct {
+	store *Store
+}
+
+func NewAccountsService(store *Store) *accountsService {
+	return &accountsService{
+		store: store,
+	}
+}
+
+func (as *accountsService) Create(ctx context.Context, account models.Account) (models.Account, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	release, err := as.store.claimIdempotencyKey(ctx)
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	for _, existing := range as.store.accounts {
+		if existing.DocumentNumber == account.DocumentNumber {
+			release()
+			return models.Account{}, models.DuplicateRecordErr
+		}
+	}
+
+	as.store.nextAccountID++
+	account.AccountID = as.store.nextAccountID
+	account.Status = models.AccountActive
+	account.CreditLimit = account.AvailableCreditLimit
+	if account.Currency == "" {
+		account.Currency = models.DefaultCurrency
+	}
+	account.CreatedAt = time.Now()
+
+	event, err := models.NewAccountEvent(models.AccountCreatedEvent, account, time.Now())
+	if err != nil {
+		release()
+		return models.Account{}, err
+	}
+
+	if err := as.store.completeIdempotencyKey(ctx, account); err != nil {
+		release()
+		return models.Account{}, err
+	}
+
+	as.store.accounts[account.AccountID] = account
+	as.store.recordEvent(event)
+
+	return account, nil
+}
+
+func (as *accountsService) GetForID(_ context.Context, accountID int64) (models.Account, error) {
+	as.store.mu.RLock()
+	defer as.store.mu.RUnlock()
+
+	account, ok := as.store.accounts[accountID]
+	if !ok {
+		return models.Account{}, models.NoRecordErr
+	}
+
+	return account, nil
+}
+
+func (as *accountsService) DeleteForID(_ context.Context, accountID int64) error {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	// mirrors the foreign key from transaction to account
+	if len(as.store.accountTransactions(accountID)) > 0 {
+		return fmt.Errorf("account %d has transactions", accountID)
+	}
+
+	// and from the status history to account
+	for _, change := range as.store.statusChanges {
+		if change.AccountID == accountID {
+			return fmt.Errorf("account %d has a status history", accountID)
+		}
+	}
+
+	delete(as.store.accounts, accountID)
+
+	return nil
+}
+
+func (as *accountsService) UpdateCreditLimit(_ context.Context, accountID int64, limit *models.Money) (models.Account, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	account, ok := as.store.accounts[accountID]
+	if !ok {
+		return models.Account{}, models.NoRecordErr
+	}
+
+	account.SetCreditLimit(limit)
+	as.store.accounts[accountID] = account
+
+	return account, nil
+}
+
+func (as *accountsService) UpdateSettlementStrategy(_ context.Context, accountID int64, strategy *string) (models.Account, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	account, ok := as.store.accounts[accountID]
+	if !ok {
+		return models.Account{}, models.NoRecordErr
+	}
+
+	account.SettlementStrategy = nil
+	if strategy != nil {
+		rstrategy := *strategy
+		account.SettlementStrategy = &rstrategy
+	}
+	as.store.accounts[accountID] = account
+
+	return account, nil
+}
+
+func (as *accountsService) UpdateBillingCycle(_ context.Context, accountID int64, closingDay *int, dueDay *int) (models.Account, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	account, ok := as.store.accounts[accountID]
+	if !ok {
+		return models.Account{}, models.NoRecordErr
+	}
+
+	account.ClosingDay = nil
+	if closingDay != nil {
+		rclosingDay := *closingDay
+		account.ClosingDay = &rclosingDay
+	}
+	account.DueDay = nil
+	if dueDay != nil {
+		rdueDay := *dueDay
+		account.DueDay = &rdueDay
+	}
+	as.store.accounts[accountID] = account
+
+	return account, nil
+}
+
+func (as *accountsService) UpdateStatus(_ context.Context, accountID int64, status models.AccountStatus, reason string) (models.Account, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	account, ok := as.store.accounts[accountID]
+	if !ok {
+		return models.Account{}, models.NoRecordErr
+	}
+
+	now := time.Now()
+	change, err := account.ChangeStatus(status, reason, now)
+	if err != nil {
+		return as.store.accounts[accountID], err
+	}
+
+	if status == models.AccountClosed {
+		if !as.store.isSettled(accountID, now) {
+			return as.store.accounts[accountID], models.OutstandingBalanceErr
+		}
+
+		for _, schedule := range as.store.schedules {
+			if schedule.AccountID == accountID && schedule.Status == models.ScheduleActive {
+				schedule.Status = models.ScheduleCancelled
+				schedule.UpdatedAt = now
+				as.store.schedules[schedule.ID] = schedule
+			}
+		}
+	}
+
+	as.store.accounts[accountID] = account
+
+	as.store.nextStatusChangeID++
+	change.ID = as.store.nextStatusChangeID
+	as.store.statusChanges[change.ID] = change
+
+	return account, nil
+}
+
+// isSettled reports whether every transaction of the account is settled, no installment is left
+// to post and no hold is pending, callers must hold the lock
+func (s *Store) isSettled(accountID int64, now time.Time) bool {
+
+	for _, transaction := range s.accountTransactions(accountID) {
+		if transaction.Balance != 0 {
+			return false
+		}
+	}
+
+	for _, installment := range s.installments {
+		if installment.AccountID == accountID && installment.PostedAt == nil && installment.Amount > 0 {
+			return false
+		}
+	}
+
+	for _, authorization := range s.authorizations {
+		if authorization.AccountID == accountID && authorization.Status == models.AuthorizationPending && !authorization.IsExpired(now) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (as *accountsService) ListStatusHistory(_ context.Context, accountID int64) ([]models.AccountStatusChange, error) {
+	as.store.mu.RLock()
+	defer as.store.mu.RUnlock()
+
+	if _, ok := as.store.accounts[accountID]; !ok {
+		return nil, models.NoRecordErr
+	}
+
+	rchanges := make([]models.AccountStatusChange, 0)
+	for _, change := range as.store.statusChanges {
+		if change.AccountID == accountID {
+			rchanges = append(rchanges, change)
+		}
+	}
+
+	sort.Slice(rchanges, func(i, j int) bool {
+		return rchanges[i].ID > rchanges[j].ID
+	})
+
+	return rchanges, nil
+}
+
+func (as *accountsService) GetBalance(_ context.Context, accountID int64) (models.AccountBalance, error) {
+	as.store.mu.RLock()
+	defer as.store.mu.RUnlock()
+
+	balance := models.AccountBalance{AccountID: accountID}
+
+	if _, ok := as.store.accounts[accountID]; !ok {
+		return balance, models.NoRecordErr
+	}
+
+	byOperationType := map[int64]*models.OperationTypeBalance{}
+
+	for _, transaction := range as.store.accountTransactions(accountID) {
+		operationTypeBalance, ok := byOperationType[transaction.OperationTypeID]
+		if !ok {
+			operationTypeBalance = &models.OperationTypeBalance{
+				OperationTypeID: transaction.OperationTypeID,
+				Description:     as.store.operationTypes[transaction.OperationTypeID].Description,
+			}
+			byOperationType[transaction.OperationTypeID] = operationTypeBalance
+		}
+
+		switch {
+		case transaction.Balance > 0:
+			operationTypeBalance.AvailableCredit += transaction.Balance
+			balance.AvailableCredit += transaction.Balance
+		case transaction.Balance < 0:
+			operationTypeBalance.OutstandingDebt -= transaction.Balance
+			balance.OutstandingDebt -= transaction.Balance
+		}
+	}
+
+	now := time.Now()
+	for _, authorization := range as.store.authorizations {
+		if authorization.AccountID == accountID && authorization.Status == models.AuthorizationPending && !authorization.IsExpired(now) {
+			balance.Held += authorization.Amount
+		}
+	}
+	balance.AvailableCredit = max(balance.AvailableCredit-balance.Held, 0)
+
+	balance.ByOperationType = make([]models.OperationTypeBalance, 0, len(byOperationType))
+	for _, operationTypeBalance := range byOperationType {
+		balance.ByOperationType = append(balance.ByOperationType, *operationTypeBalance)
+	}
+
+	sort.Slice(balance.ByOperationType, func(i, j int) bool {
+		return balance.ByOperationType[i].OperationTypeID < balance.ByOperationType[j].OperationTypeID
+	})
+
+	return balance, nil
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type accrualService struct {
+	store   *Store
+	lateFee models.Money
+}
+
+// NewAccrualService returns an accrual service that charges lateFee for every statement
+// whose minimum payment was missed
+func NewAccrualService(store *Store, lateFee models.Money) *accrualService {
+	return &accrualService{
+		store:   store,
+		lateFee: lateFee,
+	}
+}
+
+func (as *accrualService) ListForAccount(_ context.Context, accountID int64) ([]models.Accrual, error) {
+	as.store.mu.RLock()
+	defer as.store.mu.RUnlock()
+
+	if _, ok := as.store.accounts[accountID]; !ok {
+		return nil, models.NoRecordErr
+	}
+
+	raccruals := make([]models.Accrual, 0)
+	for _, accrual := range as.store.accruals {
+		if accrual.AccountID == accountID {
+			raccruals = append(raccruals, accrual)
+		}
+	}
+
+	sort.Slice(raccruals, func(i, j int) bool {
+		if raccruals[i].AccrualDate.Equal(raccruals[j].AccrualDate) {
+			return raccruals[i].ID > raccruals[j].ID
+		}
+		return raccruals[i].AccrualDate.After(raccruals[j].AccrualDate)
+	})
+
+	return raccruals, nil
+}
+
+func (as *accrualService) Accrue(_ context.Context, day time.Time) (int, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	day = models.AccrualDay(day)
+	nextDay := day.AddDate(0, 0, 1)
+	accrued := 0
+	failed := models.BatchError{}
+
+	for _, account := range as.store.accounts {
+
+		if !as.store.isAccrued(account.AccountID, day, models.InterestAccrual) {
+			// interest is charged on what was open at the end of the day, not on what is open now
+			balances := make([]models.InterestBearingBalance, 0)
+			for transactionID, balance := range as.store.balancesAsOf(account.AccountID, nextDay) {
+				if balance < 0 {
+					balances = append(balances, models.InterestBearingBalance{
+						Balance:        balance,
+						APRBasisPoints: as.store.operationTypes[as.store.transactions[transactionID].OperationTypeID].APRBasisPoints,
+					})
+				}
+			}
+
+			// charges are booked in whole minor units of the currency of the account
+			if interest := account.Currency.Round(models.DailyInterest(balances)); interest > 0 {
+				if err := as.store.charge(account.AccountID, day, models.InterestAccrual, interest, nil); err != nil {
+					failed[account.AccountID] = err
+					continue
+				}
+				accrued++
+			}
+		}
+
+		lateFee := account.Currency.Round(as.lateFee)
+		if as.store.isAccrued(account.AccountID, day, models.LateFeeAccrual) || lateFee <= 0 {
+			continue
+		}
+
+		for _, statement := range as.store.accountStatements(account.AccountID) {
+			if statement.DueDate.Before(day) || !statement.DueDate.Before(nextDay) {
+				continue
+			}
+
+			var paid models.Money
+			for _, transaction := range as.store.accountTransactions(account.AccountID) {
+				if models.IsPayment(transaction) && !transaction.EventDate.Before(statement.PeriodEnd) && transaction.EventDate.Before(nextDay) {
+					paid += transaction.Amount
+				}
+			}
+
+			if models.IsLateFeeDue(statement, paid) {
+				statementID := statement.ID
+				if err := as.store.charge(account.AccountID, day, models.LateFeeAccrual, lateFee, &statementID); err != nil {
+					failed[account.AccountID] = err
+					break
+				}
+				accrued++
+			}
+			break
+		}
+	}
+
+	return accrued, failed.Err()
+}
+
+func (as *accrualService) LastAccrualDay(_ context.Context) (time.Time, error) {
+	as.store.mu.RLock()
+	defer as.store.mu.RUnlock()
+
+	var day time.Time
+	for _, accrual := range as.store.accruals {
+		if accrual.AccrualDate.After(day) {
+			day = accrual.AccrualDate
+		}
+	}
+
+	return day, nil
+}
+
+// isAccrued reports whether the account was already charged the kind of accrual on the day,
+// callers must hold the lock
+func (s *Store) isAccrued(accountID int64, day time.Time, kind models.AccrualKind) bool {
+	for _, accrual := range s.accruals {
+		if accrual.AccountID == accountID && accrual.AccrualDate.Equal(day) && accrual.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// charge books the charge as a transaction of the account and records the accrual of the day,
+// callers must hold the lock
+func (s *Store) charge(accountID int64, day time.Time, kind models.AccrualKind, amount models.Money, statementID *int64) error {
+
+	operationTypeID := models.Interest
+	if kind == models.LateFeeAccrual {
+		operationTypeID = models.LateFee
+	}
+
+	return s.runInTx(func() error {
+		transactionStatus, err := s.createTransaction(models.Transaction{
+			AccountID:       accountID,
+			OperationTypeID: int64(operationTypeID),
+			Amount:          -amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		s.nextAccrualID++
+		put(s, s.accruals, s.nextAccrualID, models.Accrual{
+			ID:            s.nextAccrualID,
+			AccountID:     accountID,
+			AccrualDate:   day,
+			Kind:          kind,
+			Amount:        amount,
+			TransactionID: transactionStatus.TransactionID,
+			StatementID:   statementID,
+			CreatedAt:     time.Now(),
+		})
+
+		return nil
+	})
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type allocationService struct {
+	store *Store
+}
+
+func NewAllocationService(store *Store) *allocationService {
+	return &allocationService{
+		store: store,
+	}
+}
+
+func (as *allocationService) ListForTransaction(_ context.Context, transactionID int64) ([]models.Allocation, error) {
+	as.store.mu.RLock()
+	defer as.store.mu.RUnlock()
+
+	if _, ok := as.store.transactions[transactionID]; !ok {
+		return nil, models.NoRecordErr
+	}
+
+	rallocations := make([]models.Allocation, 0)
+	for _, allocation := range as.store.allocations {
+		if allocation.CreditTransactionID == transactionID || allocation.DebitTransactionID == transactionID {
+			rallocations = append(rallocations, allocation)
+		}
+	}
+
+	sort.Slice(rallocations, func(i, j int) bool {
+		if rallocations[i].AllocatedAt.Equal(rallocations[j].AllocatedAt) {
+			return rallocations[i].ID < rallocations[j].ID
+		}
+		return rallocations[i].AllocatedAt.Before(rallocations[j].AllocatedAt)
+	})
+
+	return rallocations, nil
+}
+
+// recordAllocations stores the allocations of a settlement, the side of each allocation
+// that was not known while discharging is set to transactionID, callers must hold the lock
+func (s *Store) recordAllocations(allocations []models.Allocation, transactionID int64, allocatedAt time.Time) {
+
+	for _, allocation := range allocations {
+		if allocation.CreditTransactionID == 0 {
+			allocation.CreditTransactionID = transactionID
+		}
+		if allocation.DebitTransactionID == 0 {
+			allocation.DebitTransactionID = transactionID
+		}
+		allocation.AllocatedAt = allocatedAt
+
+		s.nextAllocationID++
+		allocation.ID = s.nextAllocationID
+		put(s, s.allocations, allocation.ID, allocation)
+	}
+}
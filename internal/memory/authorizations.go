@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type authorizationService struct {
+	store   *Store
+	holdTTL time.Duration
+}
+
+// NewAuthorizationService returns an authorization service whose holds expire after holdTTL
+func NewAuthorizationService(store *Store, holdTTL time.Duration) *authorizationService {
+	return &authorizationService{
+		store:   store,
+		holdTTL: holdTTL,
+	}
+}
+
+func (as *authorizationService) Create(ctx context.Context, authorization models.Authorization) (models.Authorization, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	release, err := as.store.claimIdempotencyKey(ctx)
+	if err != nil {
+		return models.Authorization{}, err
+	}
+
+	account, ok := as.store.accounts[authorization.AccountID]
+	if !ok {
+		release()
+		return models.Authorization{}, models.NoRecordErr
+	}
+
+	if !account.AcceptsTransaction(authorization.OperationTypeID) {
+		release()
+		return models.Authorization{}, models.AccountNotActiveErr
+	}
+
+	// holds are in the currency of the account, as the transactions they are captured into
+	if err := account.Currency.CheckAmount(authorization.Amount); err != nil {
+		release()
+		return models.Authorization{}, err
+	}
+
+	// the hold reserves the credit limit until it is captured or released
+	if err := account.ApplyToCreditLimit(-authorization.Amount); err != nil {
+		release()
+		return models.Authorization{}, err
+	}
+
+	now := time.Now()
+	as.store.nextAuthorizationID++
+	authorization.ID = as.store.nextAuthorizationID
+	authorization.Status = models.AuthorizationPending
+	authorization.CapturedAmount = 0
+	authorization.TransactionID = nil
+	authorization.CreatedAt = now
+	authorization.UpdatedAt = now
+	authorization.ExpiresAt = now.Add(as.holdTTL)
+
+	if err := as.store.completeIdempotencyKey(ctx, authorization); err != nil {
+		release()
+		return models.Authorization{}, err
+	}
+
+	as.store.accounts[account.AccountID] = account
+	as.store.authorizations[authorization.ID] = authorization
+
+	return authorization, nil
+}
+
+func (as *authorizationService) GetForID(_ context.Context, authorizationID int64) (models.Authorization, error) {
+	as.store.mu.RLock()
+	defer as.store.mu.RUnlock()
+
+	authorization, ok := as.store.authorizations[authorizationID]
+	if !ok {
+		return models.Authorization{}, models.NoRecordErr
+	}
+
+	return authorization, nil
+}
+
+func (as *authorizationService) Capture(ctx context.Context, authorizationID int64, amount models.Money) (models.Authorization, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	var authorization models.Authorization
+
+	// the hold is only given back to the limit when the captured debit is booked
+	err := as.store.runInTx(func() error {
+		if _, err := as.store.claimIdempotencyKey(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		authorization, err = as.store.captureAuthorization(authorizationID, amount)
+		if err != nil {
+			return err
+		}
+
+		return as.store.completeIdempotencyKey(ctx, authorization)
+	})
+	if err != nil {
+		return models.Authorization{}, err
+	}
+
+	return authorization, nil
+}
+
+// captureAuthorization books up to amount of a pending hold as a debit, all of it when amount is zero,
+// callers must hold the lock
+func (s *Store) captureAuthorization(authorizationID int64, amount models.Money) (models.Authorization, error) {
+
+	authorization, err := s.pendingAuthorization(authorizationID)
+	if err != nil {
+		return models.Authorization{}, err
+	}
+
+	// stale holds are left for the expiry job
+	if authorization.IsExpired(time.Now()) {
+		return models.Authorization{}, models.AuthorizationNotPendingErr
+	}
+
+	if amount == 0 {
+		amount = authorization.Amount
+	}
+
+	if amount < 0 || amount > authorization.Amount {
+		return models.Authorization{}, models.CaptureAmountExceededErr
+	}
+
+	// give the hold back to the limit, the captured debit consumes it again
+	account := s.accounts[authorization.AccountID]
+	if err := account.ApplyToCreditLimit(authorization.Amount); err != nil {
+		return models.Authorization{}, err
+	}
+	put(s, s.accounts, account.AccountID, account)
+
+	transactionStatus, err := s.createTransaction(models.Transaction{
+		AccountID:       authorization.AccountID,
+		OperationTypeID: authorization.OperationTypeID,
+		Amount:          -amount,
+	})
+	if err != nil {
+		return models.Authorization{}, err
+	}
+
+	authorization.Status = models.AuthorizationCaptured
+	authorization.CapturedAmount = amount
+	authorization.TransactionID = &transactionStatus.TransactionID
+	authorization.UpdatedAt = time.Now()
+	put(s, s.authorizations, authorization.ID, authorization)
+
+	return authorization, nil
+}
+
+func (as *authorizationService) Release(_ context.Context, authorizationID int64) (models.Authorization, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	authorization, err := as.store.pendingAuthorization(authorizationID)
+	if err != nil {
+		return models.Authorization{}, err
+	}
+
+	return as.store.releaseAuthorization(authorization, models.AuthorizationReleased), nil
+}
+
+func (as *authorizationService) ExpireStale(_ context.Context, now time.Time) (int, error) {
+	as.store.mu.Lock()
+	defer as.store.mu.Unlock()
+
+	stale := make([]models.Authorization, 0)
+	for _, authorization := range as.store.authorizations {
+		if authorization.IsExpired(now) {
+			stale = append(stale, authorization)
+		}
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].ID < stale[j].ID
+	})
+
+	for _, authorization := range stale {
+		as.store.releaseAuthorization(authorization, models.AuthorizationExpired)
+	}
+
+	return len(stale), nil
+}
+
+// pendingAuthorization returns the hold if it is still pending, callers must hold the lock
+func (s *Store) pendingAuthorization(authorizationID int64) (models.Authorization, error) {
+
+	authorization, ok := s.authorizations[authorizationID]
+	if !ok {
+		return models.Authorization{}, models.NoRecordErr
+	}
+
+	if authorization.Status != models.AuthorizationPending {
+		return models.Authorization{}, models.AuthorizationNotPendingErr
+	}
+
+	return authorization, nil
+}
+
+// releaseAuthorization gives the held amount back to the credit limit and closes the hold
+// with status, callers must hold the lock
+func (s *Store) releaseAuthorization(authorization models.Authorization, status models.AuthorizationStatus) models.Authorization {
+
+	account := s.accounts[authorization.AccountID]
+	// restoring the limit can not fail
+	_ = account.ApplyToCreditLimit(authorization.Amount)
+	s.accounts[account.AccountID] = account
+
+	authorization.Status = status
+	authorization.UpdatedAt = time.Now()
+	s.authorizations[authorization.ID] = authorization
+
+	return authorization
+}
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type fxService struct {
+	store    *Store
+	quoteTTL time.Duration
+}
+
+// NewFXService returns an fx service whose quotes lock the rate of their pair for quoteTTL
+func NewFXService(store *Store, quoteTTL time.Duration) *fxService {
+	return &fxService{
+		store:    store,
+		quoteTTL: quoteTTL,
+	}
+}
+
+func (fs *fxService) SetRate(_ context.Context, rate models.FXRate) (models.FXRate, error) {
+	fs.store.mu.Lock()
+	defer fs.store.mu.Unlock()
+
+	rate.UpdatedAt = time.Now()
+	fs.store.fxRates[fxPair{from: rate.From, to: rate.To}] = rate
+
+	return rate, nil
+}
+
+func (fs *fxService) GetRate(_ context.Context, from models.Currency, to models.Currency) (models.FXRate, error) {
+	fs.store.mu.RLock()
+	defer fs.store.mu.RUnlock()
+
+	rate, ok := fs.store.fxRates[fxPair{from: from, to: to}]
+	if !ok {
+		return models.FXRate{}, models.NoRecordErr
+	}
+
+	return rate, nil
+}
+
+func (fs *fxService) ListRates(_ context.Context) ([]models.FXRate, error) {
+	fs.store.mu.RLock()
+	defer fs.store.mu.RUnlock()
+
+	rrates := make([]models.FXRate, 0, len(fs.store.fxRates))
+	for _, rate := range fs.store.fxRates {
+		rrates = append(rrates, rate)
+	}
+
+	sort.Slice(rrates, func(i, j int) bool {
+		if rrates[i].From == rrates[j].From {
+			return rrates[i].To < rrates[j].To
+		}
+		return rrates[i].From < rrates[j].From
+	})
+
+	return rrates, nil
+}
+
+func (fs *fxService) CreateQuote(_ context.Context, from models.Currency, to models.Currency) (models.FXQuote, error) {
+	fs.store.mu.Lock()
+	defer fs.store.mu.Unlock()
+
+	rate, ok := fs.store.fxRates[fxPair{from: from, to: to}]
+	if !ok {
+		return models.FXQuote{}, fmt.Errorf("%w, no rate from %s to %s", models.ConversionUnavailableErr, from, to)
+	}
+
+	now := time.Now()
+
+	fs.store.nextFXQuoteID++
+	quote := models.FXQuote{
+		ID:        fs.store.nextFXQuoteID,
+		From:      from,
+		To:        to,
+		Rate:      rate.Rate,
+		CreatedAt: now,
+		ExpiresAt: now.Add(fs.quoteTTL),
+	}
+	fs.store.fxQuotes[quote.ID] = quote
+
+	return quote, nil
+}
+
+func (fs *fxService) GetQuote(_ context.Context, quoteID int64) (models.FXQuote, error) {
+	fs.store.mu.RLock()
+	defer fs.store.mu.RUnlock()
+
+	quote, ok := fs.store.fxQuotes[quoteID]
+	if !ok {
+		return models.FXQuote{}, models.NoRecordErr
+	}
+
+	return quote, nil
+}
+
+// useFXQuote marks the quote the transaction was converted with as used, callers must hold the lock and run it in runInTx
+func (s *Store) useFXQuote(transaction models.Transaction, now time.Time) error {
+
+	quote, ok := s.fxQuotes[*transaction.FXQuoteID]
+	if !ok {
+		return models.NoRecordErr
+	}
+
+	if err := quote.CheckBooks(transaction, now); err != nil {
+		return err
+	}
+
+	quote.UsedAt = &now
+	put(s, s.fxQuotes, quote.ID, quote)
+
+	return nil
+}
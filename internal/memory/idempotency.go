@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"time"
+)
+
+type idempotencyService struct {
+	store *Store
+}
+
+func NewIdempotencyService(store *Store) *idempotencyService {
+	return &idempotencyService{
+		store: store,
+	}
+}
+
+func (is *idempotencyService) GetForKey(_ context.Context, scope string, key string) (models.IdempotencyRecord, error) {
+	is.store.mu.RLock()
+	defer is.store.mu.RUnlock()
+
+	record, ok := is.store.idempotency[idempotencyRecordKey{scope: scope, key: key}]
+	if !ok {
+		return models.IdempotencyRecord{}, models.NoRecordErr
+	}
+
+	return record, nil
+}
+
+func (is *idempotencyService) Complete(_ context.Context, record models.IdempotencyRecord) error {
+	is.store.mu.Lock()
+	defer is.store.mu.Unlock()
+
+	recordKey := idempotencyRecordKey{scope: record.Scope, key: record.Key}
+
+	// the claim of a failed request was released with it, so the response claims the key again
+	existing, ok := is.store.idempotency[recordKey]
+	switch {
+	case !ok:
+		existing = models.IdempotencyRecord{
+			Scope:       record.Scope,
+			Key:         record.Key,
+			RequestHash: record.RequestHash,
+			CreatedAt:   time.Now(),
+		}
+	case existing.RequestHash != record.RequestHash || existing.IsComplete():
+		return models.NoRecordErr
+	}
+
+	existing.StatusCode = record.StatusCode
+	existing.ResponseBody = record.ResponseBody
+	is.store.idempotency[recordKey] = existing
+
+	return nil
+}
+
+func (is *idempotencyService) DeleteExpired(_ context.Context, before time.Time) (int64, error) {
+	is.store.mu.Lock()
+	defer is.store.mu.Unlock()
+
+	var deleted int64
+	for recordKey, record := range is.store.idempotency {
+		if record.CreatedAt.Before(before) {
+			delete(is.store.idempotency, recordKey)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// claimIdempotencyKey registers the idempotency key present in the context,
+// callers must hold the lock and release the claim if the create operation fails
+func (s *Store) claimIdempotencyKey(ctx context.Context) (release func(), err error) {
+
+	key, ok := models.IdempotencyKeyFromContext(ctx)
+	if !ok {
+		return func() {}, nil
+	}
+
+	recordKey := idempotencyRecordKey{scope: key.Scope, key: key.Key}
+
+	existing, ok := s.idempotency[recordKey]
+	switch {
+	case !ok:
+		put(s, s.idempotency, recordKey, models.IdempotencyRecord{
+			Scope:       key.Scope,
+			Key:         key.Key,
+			RequestHash: key.RequestHash,
+			CreatedAt:   time.Now(),
+		})
+		return func() { delete(s.idempotency, recordKey) }, nil
+	case existing.RequestHash != key.RequestHash:
+		return nil, models.IdempotencyKeyMismatchErr
+	case !existing.IsComplete():
+		return nil, models.IdempotencyKeyInProgressErr
+	default:
+		return nil, models.IdempotencyKeyReplayErr
+	}
+}
+
+// completeIdempotencyKey stores the response for the result of the request along with the claim
+// of the idempotency key present in the context, callers must hold the lock
+func (s *Store) completeIdempotencyKey(ctx context.Context, result any) error {
+
+	key, ok := models.IdempotencyKeyFromContext(ctx)
+	if !ok || key.Response == nil {
+		return nil
+	}
+
+	statusCode, body, err := key.Response(result)
+	if err != nil {
+		return err
+	}
+
+	recordKey := idempotencyRecordKey{scope: key.Scope, key: key.Key}
+	record := s.idempotency[recordKey]
+	record.StatusCode = statusCode
+	record.ResponseBody = body
+	put(s, s.idempotency, recordKey, record)
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type installmentService struct {
+	store *Store
+}
+
+func NewInstallmentService(store *Store) *installmentService {
+	return &installmentService{
+		store: store,
+	}
+}
+
+func (is *installmentService) ListForTransaction(_ context.Context, transactionID int64) ([]models.Installment, error) {
+	is.store.mu.RLock()
+	defer is.store.mu.RUnlock()
+
+	if _, ok := is.store.transactions[transactionID]; !ok {
+		return nil, models.NoRecordErr
+	}
+
+	return is.store.transactionInstallments(transactionID), nil
+}
+
+func (is *installmentService) PostDue(_ context.Context, until time.Time) (int, error) {
+	is.store.mu.Lock()
+	defer is.store.mu.Unlock()
+
+	due := make([]models.Installment, 0)
+	for _, installment := range is.store.installments {
+		if installment.PostedAt == nil && !installment.DueDate.After(until) {
+			due = append(due, installment)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].DueDate.Equal(due[j].DueDate) {
+			return due[i].ID < due[j].ID
+		}
+		return due[i].DueDate.Before(due[j].DueDate)
+	})
+
+	posted := 0
+	failed := models.BatchError{}
+
+	for _, installment := range due {
+		err := is.store.runInTx(func() error {
+			postedAt := time.Now()
+
+			transaction := is.store.transactions[installment.TransactionID]
+
+			openBalance, allocations := is.store.dischargeCredits(installment.AccountID, -installment.Amount)
+			is.store.recordAllocations(allocations, installment.TransactionID, postedAt)
+			if err := is.store.recordSettledEvents(installment.TransactionID, allocations, postedAt); err != nil {
+				return err
+			}
+
+			transaction.Balance += openBalance
+			put(is.store, is.store.transactions, transaction.ID, transaction)
+
+			installment.PostedAt = &postedAt
+			put(is.store, is.store.installments, installment.ID, installment)
+
+			return nil
+		})
+		if err != nil {
+			failed[installment.ID] = err
+			continue
+		}
+		posted++
+	}
+
+	return posted, failed.Err()
+}
+
+// transactionInstallments returns the installments of a transaction by number,
+// callers must hold the lock
+func (s *Store) transactionInstallments(transactionID int64) []models.Installment {
+
+	installments := make([]models.Installment, 0)
+	for _, installment := range s.installments {
+		if installment.TransactionID == transactionID {
+			installments = append(installments, installment)
+		}
+	}
+
+	sort.Slice(installments, func(i, j int) bool {
+		return installments[i].Number < installments[j].Number
+	})
+
+	return installments
+}
+
+// cancelPendingInstallments reduces the installments of a transaction that are not posted yet,
+// latest first, by up to amount and returns how much was cancelled, callers must hold the lock
+func (s *Store) cancelPendingInstallments(transactionID int64, amount models.Money) models.Money {
+
+	installments := s.transactionInstallments(transactionID)
+	remaining := amount
+
+	for i := len(installments) - 1; i >= 0 && remaining > 0; i-- {
+		installment := installments[i]
+		if installment.PostedAt != nil {
+			continue
+		}
+
+		cancelled := min(remaining, installment.Amount)
+		remaining -= cancelled
+
+		installment.Amount -= cancelled
+		put(s, s.installments, installment.ID, installment)
+	}
+
+	return amount - remaining
+}
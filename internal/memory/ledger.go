@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+)
+
+type ledgerService struct {
+	store *Store
+}
+
+func NewLedgerService(store *Store) *ledgerService {
+	return &ledgerService{
+		store: store,
+	}
+}
+
+func (ls *ledgerService) ListForTransaction(_ context.Context, transactionID int64) ([]models.JournalEntry, error) {
+	ls.store.mu.RLock()
+	defer ls.store.mu.RUnlock()
+
+	if _, ok := ls.store.transactions[transactionID]; !ok {
+		return nil, models.NoRecordErr
+	}
+
+	rentries := make([]models.JournalEntry, 0)
+	for _, entry := range ls.store.journalEntries {
+		if entry.TransactionID == transactionID {
+			rentries = append(rentries, entry)
+		}
+	}
+
+	sort.Slice(rentries, func(i, j int) bool { return rentries[i].ID < rentries[j].ID })
+
+	return rentries, nil
+}
+
+func (ls *ledgerService) Verify(_ context.Context) ([]int64, error) {
+	ls.store.mu.RLock()
+	defer ls.store.mu.RUnlock()
+
+	unbalanced := []int64{}
+	for _, entry := range ls.store.journalEntries {
+		if !entry.IsBalanced() {
+			unbalanced = append(unbalanced, entry.ID)
+		}
+	}
+
+	sort.Slice(unbalanced, func(i, j int) bool { return unbalanced[i] < unbalanced[j] })
+
+	return unbalanced, nil
+}
+
+// postJournalEntry records a booked transaction in the ledger, callers must hold the lock
+func (s *Store) postJournalEntry(transaction models.Transaction) {
+
+	entry := models.NewJournalEntry(transaction)
+
+	s.nextJournalEntryID++
+	entry.ID = s.nextJournalEntryID
+
+	for i := range entry.Postings {
+		s.nextPostingID++
+		entry.Postings[i].ID = s.nextPostingID
+		entry.Postings[i].JournalEntryID = entry.ID
+	}
+
+	put(s, s.journalEntries, entry.ID, entry)
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+)
+
+type operationTypeService struct {
+	store *Store
+}
+
+func NewOperationTypeService(store *Store) *operationTypeService {
+	return &operationTypeService{
+		store: store,
+	}
+}
+
+func (ots *operationTypeService) Create(_ context.Context, operationType models.OperationType) (models.OperationType, error) {
+	ots.store.mu.Lock()
+	defer ots.store.mu.Unlock()
+
+	ots.store.nextOperationTypeID++
+	operationType.ID = ots.store.nextOperationTypeID
+	ots.store.operationTypes[operationType.ID] = operationType
+
+	return operationType, nil
+}
+
+func (ots *operationTypeService) GetForID(_ context.Context, operationTypeID int64) (models.OperationType, error) {
+	ots.store.mu.RLock()
+	defer ots.store.mu.RUnlock()
+
+	operationType, ok := ots.store.operationTypes[operationTypeID]
+	if !ok {
+		return models.OperationType{}, models.NoRecordErr
+	}
+
+	return operationType, nil
+}
+
+func (ots *operationTypeService) List(_ context.Context) ([]models.OperationType, error) {
+	ots.store.mu.RLock()
+	defer ots.store.mu.RUnlock()
+
+	operationTypes := make([]models.OperationType, 0, len(ots.store.operationTypes))
+	for _, operationType := range ots.store.operationTypes {
+		operationTypes = append(operationTypes, operationType)
+	}
+
+	sort.Slice(operationTypes, func(i, j int) bool {
+		return operationTypes[i].ID < operationTypes[j].ID
+	})
+
+	return operationTypes, nil
+}
+
+func (ots *operationTypeService) Update(_ context.Context, update models.OperationTypeUpdate) (models.OperationType, error) {
+	ots.store.mu.Lock()
+	defer ots.store.mu.Unlock()
+
+	operationType, ok := ots.store.operationTypes[update.ID]
+	if !ok {
+		return models.OperationType{}, models.NoRecordErr
+	}
+
+	if update.Description != nil {
+		operationType.Description = *update.Description
+	}
+
+	if update.Active != nil {
+		operationType.Active = *update.Active
+	}
+
+	if update.APRBasisPoints != nil {
+		operationType.APRBasisPoints = *update.APRBasisPoints
+	}
+
+	ots.store.operationTypes[update.ID] = operationType
+
+	return operationType, nil
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type outboxService struct {
+	store *Store
+}
+
+func NewOutboxService(store *Store) *outboxService {
+	return &outboxService{
+		store: store,
+	}
+}
+
+func (obs *outboxService) LockRelay(_ context.Context) (func(), bool, error) {
+
+	if !obs.store.relayMu.TryLock() {
+		return nil, false, nil
+	}
+
+	return obs.store.relayMu.Unlock, true, nil
+}
+
+func (obs *outboxService) ListPending(_ context.Context, afterID int64, limit int) ([]models.Event, error) {
+	obs.store.mu.RLock()
+	defer obs.store.mu.RUnlock()
+
+	pending := make([]models.Event, 0)
+	for _, event := range obs.store.events {
+		if event.PublishedAt == nil && event.ParkedAt == nil && event.ID > afterID {
+			pending = append(pending, event)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].ID < pending[j].ID
+	})
+
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+
+	return pending, nil
+}
+
+func (obs *outboxService) MarkPublished(_ context.Context, eventID int64, publishedAt time.Time) error {
+	obs.store.mu.Lock()
+	defer obs.store.mu.Unlock()
+
+	event, ok := obs.store.events[eventID]
+	if !ok {
+		return models.NoRecordErr
+	}
+
+	event.Attempts++
+	event.PublishedAt = &publishedAt
+	obs.store.events[eventID] = event
+
+	return nil
+}
+
+func (obs *outboxService) MarkFailed(_ context.Context, eventID int64, cause string, nextAttemptAt time.Time) error {
+	obs.store.mu.Lock()
+	defer obs.store.mu.Unlock()
+
+	event, ok := obs.store.events[eventID]
+	if !ok {
+		return models.NoRecordErr
+	}
+
+	event.Attempts++
+	event.LastError = cause
+	event.NextAttemptAt = &nextAttemptAt
+	obs.store.events[eventID] = event
+
+	return nil
+}
+
+func (obs *outboxService) Park(_ context.Context, eventID int64, cause string, parkedAt time.Time) error {
+	obs.store.mu.Lock()
+	defer obs.store.mu.Unlock()
+
+	event, ok := obs.store.events[eventID]
+	if !ok {
+		return models.NoRecordErr
+	}
+
+	event.Attempts++
+	event.LastError = cause
+	event.NextAttemptAt = nil
+	event.ParkedAt = &parkedAt
+	obs.store.events[eventID] = event
+
+	return nil
+}
+
+// recordEvent writes an event to the outbox along with its deliveries to the webhooks
+// that subscribe it and signals its streams, callers must hold the lock
+func (s *Store) recordEvent(event models.Event) {
+	s.nextEventID++
+	event.ID = s.nextEventID
+	put(s, s.events, event.ID, event)
+
+	s.enqueueDeliveries(event)
+	s.eventHub.Notify(event.AccountID)
+}
+
+// recordTransactionEvents writes the TransactionCreated event of a booked transaction, then the
+// TransactionSettled events of the transactions its allocations settled and its own when it is settled,
+// callers must hold the lock
+func (s *Store) recordTransactionEvents(transaction models.Transaction, allocations []models.Allocation) error {
+
+	event, err := models.NewTransactionEvent(models.TransactionCreatedEvent, transaction, transaction.EventDate)
+	if err != nil {
+		return err
+	}
+	s.recordEvent(event)
+
+	if err := s.recordSettledEvents(transaction.ID, allocations, transaction.EventDate); err != nil {
+		return err
+	}
+
+	if transaction.Balance != 0 {
+		return nil
+	}
+
+	event, err = models.NewTransactionEvent(models.TransactionSettledEvent, transaction, transaction.EventDate)
+	if err != nil {
+		return err
+	}
+	s.recordEvent(event)
+
+	return nil
+}
+
+// recordSettledEvents writes the TransactionSettled events of the transactions on the other side of the
+// allocations of transactionID whose balance they brought to zero, callers must hold the lock
+func (s *Store) recordSettledEvents(transactionID int64, allocations []models.Allocation, settledAt time.Time) error {
+
+	ids := []int64{}
+	for _, allocation := range allocations {
+		for _, id := range []int64{allocation.CreditTransactionID, allocation.DebitTransactionID} {
+			if id != 0 && id != transactionID {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	for i, id := range ids {
+		transaction, ok := s.transactions[id]
+		if !ok || transaction.Balance != 0 || (i > 0 && ids[i-1] == id) {
+			continue
+		}
+
+		event, err := models.NewTransactionEvent(models.TransactionSettledEvent, transaction, settledAt)
+		if err != nil {
+			return err
+		}
+		s.recordEvent(event)
+	}
+
+	return nil
+}
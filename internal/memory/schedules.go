@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type scheduleService struct {
+	store *Store
+}
+
+func NewScheduleService(store *Store) *scheduleService {
+	return &scheduleService{
+		store: store,
+	}
+}
+
+func (ss *scheduleService) Create(ctx context.Context, schedule models.Schedule) (models.Schedule, error) {
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+
+	release, err := ss.store.claimIdempotencyKey(ctx)
+	if err != nil {
+		return models.Schedule{}, err
+	}
+
+	account, ok := ss.store.accounts[schedule.AccountID]
+	if !ok {
+		release()
+		return models.Schedule{}, models.NoRecordErr
+	}
+
+	// frozen accounts may be unfrozen before the first run, closed ones never take a transaction again
+	if account.Status == models.AccountClosed {
+		release()
+		return models.Schedule{}, models.AccountNotActiveErr
+	}
+
+	if err := account.Currency.CheckAmount(schedule.Amount); err != nil {
+		release()
+		return models.Schedule{}, err
+	}
+
+	schedule = models.NewSchedule(schedule, time.Now())
+
+	ss.store.nextScheduleID++
+	schedule.ID = ss.store.nextScheduleID
+
+	if err := ss.store.completeIdempotencyKey(ctx, schedule); err != nil {
+		release()
+		return models.Schedule{}, err
+	}
+
+	ss.store.schedules[schedule.ID] = schedule
+
+	return schedule, nil
+}
+
+func (ss *scheduleService) GetForID(_ context.Context, scheduleID int64) (models.Schedule, error) {
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+
+	schedule, ok := ss.store.schedules[scheduleID]
+	if !ok {
+		return models.Schedule{}, models.NoRecordErr
+	}
+
+	return schedule, nil
+}
+
+func (ss *scheduleService) Cancel(_ context.Context, scheduleID int64) (models.Schedule, error) {
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+
+	schedule, ok := ss.store.schedules[scheduleID]
+	if !ok {
+		return models.Schedule{}, models.NoRecordErr
+	}
+
+	if schedule.Status != models.ScheduleActive {
+		return schedule, models.ScheduleNotActiveErr
+	}
+
+	schedule.Status = models.ScheduleCancelled
+	schedule.UpdatedAt = time.Now()
+	ss.store.schedules[scheduleID] = schedule
+
+	return schedule, nil
+}
+
+func (ss *scheduleService) ListRuns(_ context.Context, scheduleID int64) ([]models.ScheduleRun, error) {
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+
+	if _, ok := ss.store.schedules[scheduleID]; !ok {
+		return nil, models.NoRecordErr
+	}
+
+	rruns := make([]models.ScheduleRun, 0)
+	for _, run := range ss.store.scheduleRuns {
+		if run.ScheduleID == scheduleID {
+			rruns = append(rruns, run)
+		}
+	}
+
+	sort.Slice(rruns, func(i, j int) bool {
+		return rruns[i].ID > rruns[j].ID
+	})
+
+	return rruns, nil
+}
+
+func (ss *scheduleService) Lease(_ context.Context, owner string, now time.Time, ttl time.Duration, limit int) ([]models.Schedule, error) {
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+
+	due := make([]models.Schedule, 0)
+	for _, schedule := range ss.store.schedules {
+		if schedule.IsDue(now) && !schedule.IsLeased(now) {
+			due = append(due, schedule)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].DueAt().Equal(due[j].DueAt()) {
+			return due[i].ID < due[j].ID
+		}
+		return due[i].DueAt().Before(due[j].DueAt())
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	leasedUntil := now.Add(ttl)
+	for i := range due {
+		due[i].LeaseOwner = &owner
+		due[i].LeasedUntil = &leasedUntil
+		ss.store.schedules[due[i].ID] = due[i]
+	}
+
+	return due, nil
+}
+
+func (ss *scheduleService) RecordRun(_ context.Context, owner string, run models.ScheduleRun) (models.Schedule, error) {
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+
+	schedule, ok := ss.store.schedules[run.ScheduleID]
+	if !ok {
+		return models.Schedule{}, models.NoRecordErr
+	}
+
+	if !schedule.IsLeasedBy(owner, run) {
+		return schedule, models.ScheduleLeaseLostErr
+	}
+
+	ss.store.nextScheduleRunID++
+	run.ID = ss.store.nextScheduleRunID
+	ss.store.scheduleRuns[run.ID] = run
+
+	schedule.Record(run, run.CreatedAt)
+	ss.store.schedules[schedule.ID] = schedule
+
+	return schedule, nil
+}
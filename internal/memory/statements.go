@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type statementService struct {
+	store *Store
+}
+
+func NewStatementService(store *Store) *statementService {
+	return &statementService{
+		store: store,
+	}
+}
+
+func (ss *statementService) ListForAccount(_ context.Context, accountID int64) ([]models.Statement, error) {
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+
+	if _, ok := ss.store.accounts[accountID]; !ok {
+		return nil, models.NoRecordErr
+	}
+
+	rstatements := make([]models.Statement, 0)
+	for _, statement := range ss.store.accountStatements(accountID) {
+		statement.Lines = nil
+		rstatements = append(rstatements, statement)
+	}
+
+	return rstatements, nil
+}
+
+func (ss *statementService) GetForID(_ context.Context, statementID int64) (models.Statement, error) {
+	ss.store.mu.RLock()
+	defer ss.store.mu.RUnlock()
+
+	statement, ok := ss.store.statements[statementID]
+	if !ok {
+		return models.Statement{}, models.NoRecordErr
+	}
+
+	statement.Lines = append([]models.StatementLine{}, statement.Lines...)
+
+	return statement, nil
+}
+
+func (ss *statementService) GenerateDue(_ context.Context, now time.Time) (int, error) {
+	ss.store.mu.Lock()
+	defer ss.store.mu.Unlock()
+
+	generated := 0
+
+	for _, account := range ss.store.accounts {
+
+		var previous *models.Statement
+		if statements := ss.store.accountStatements(account.AccountID); len(statements) > 0 {
+			previous = &statements[0]
+		}
+
+		for {
+			statement, ok := models.NewStatement(account, previous, now)
+			if !ok {
+				break
+			}
+
+			// the lines keep the balances the transactions had when the cycle closed
+			balances := ss.store.balancesAsOf(account.AccountID, statement.PeriodEnd)
+			transactions := make([]models.Transaction, 0)
+			for _, transaction := range ss.store.accountTransactions(account.AccountID) {
+				if transaction.EventDate.Before(statement.PeriodEnd) && !transaction.EventDate.Before(statement.PeriodStart) {
+					transaction.Balance = balances[transaction.ID]
+					transactions = append(transactions, transaction)
+				}
+			}
+			closingBalance := ss.store.balanceAsOf(account.AccountID, statement.PeriodEnd)
+
+			statement.Close(transactions, closingBalance)
+			statement.CreatedAt = now
+
+			ss.store.nextStatementID++
+			statement.ID = ss.store.nextStatementID
+			for i := range statement.Lines {
+				ss.store.nextStatementLineID++
+				statement.Lines[i].ID = ss.store.nextStatementLineID
+				statement.Lines[i].StatementID = statement.ID
+			}
+			ss.store.statements[statement.ID] = statement
+
+			generated++
+			previous = &statement
+		}
+	}
+
+	return generated, nil
+}
+
+// accountStatements returns the statements of an account newest first,
+// callers must hold the lock
+func (s *Store) accountStatements(accountID int64) []models.Statement {
+
+	statements := make([]models.Statement, 0)
+	for _, statement := range s.statements {
+		if statement.AccountID == accountID {
+			statements = append(statements, statement)
+		}
+	}
+
+	sort.Slice(statements, func(i, j int) bool {
+		return statements[i].PeriodEnd.After(statements[j].PeriodEnd)
+	})
+
+	return statements
+}
+
+// balanceAsOf returns what the transactions of the account booked before at summed to at that time. An allocation
+// between two of those transactions moves money between them and leaves the sum as it is,
+// callers must hold the lock
+func (s *Store) balanceAsOf(accountID int64, at time.Time) models.Money {
+
+	var balance models.Money
+	for _, transactionBalance := range s.balancesAsOf(accountID, at) {
+		balance += transactionBalance
+	}
+
+	return balance
+}
+
+// balancesAsOf returns the balance each transaction of the account booked before at had at that time, its
+// current balance with the installments posted and the allocations made since at undone,
+// callers must hold the lock
+func (s *Store) balancesAsOf(accountID int64, at time.Time) map[int64]models.Money {
+
+	balances := make(map[int64]models.Money)
+	for _, transaction := range s.transactions {
+		if transaction.AccountID == accountID && transaction.EventDate.Before(at) {
+			balances[transaction.ID] = transaction.Balance
+		}
+	}
+
+	for _, installment := range s.installments {
+		if _, ok := balances[installment.TransactionID]; ok && installment.PostedAt != nil && !installment.PostedAt.Before(at) {
+			balances[installment.TransactionID] += installment.Amount
+		}
+	}
+
+	for _, allocation := range s.allocations {
+		if allocation.AllocatedAt.Before(at) {
+			continue
+		}
+		if _, ok := balances[allocation.DebitTransactionID]; ok {
+			balances[allocation.DebitTransactionID] -= allocation.Amount
+		}
+		if _, ok := balances[allocation.CreditTransactionID]; ok {
+			balances[allocation.CreditTransactionID] += allocation.Amount
+		}
+	}
+
+	return balances
+}
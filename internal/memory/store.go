@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"payments-backend-app/pkg/models"
+)
+
+// Store holds the in-memory state shared by the services of this package,
+// a single lock serializes writes the same way the account row lock does in postgres
+type Store struct {
+	mu sync.RWMutex
+
+	storeState
+
+	// relayMu is held by the relay publishing the outbox, apart from mu so that
+	// transactions are booked while it publishes
+	relayMu sync.Mutex
+
+	// eventHub signals the streams of the accounts whose events are recorded
+	eventHub *models.AccountEventHub
+
+	settlement models.SettlementStrategies
+
+	// undo holds how to take back the writes of the runInTx in progress, latest last
+	undo []func()
+}
+
+// storeState holds the records of the store and the counters their ids are taken from
+type storeState struct {
+	accounts       map[int64]models.Account
+	statusChanges  map[int64]models.AccountStatusChange
+	transactions   map[int64]models.Transaction
+	operationTypes map[int64]models.OperationType
+	installments   map[int64]models.Installment
+	authorizations map[int64]models.Authorization
+	transfers      map[int64]models.Transfer
+	journalEntries map[int64]models.JournalEntry
+	allocations    map[int64]models.Allocation
+	statements     map[int64]models.Statement
+	accruals       map[int64]models.Accrual
+	schedules      map[int64]models.Schedule
+	scheduleRuns   map[int64]models.ScheduleRun
+	idempotency    map[idempotencyRecordKey]models.IdempotencyRecord
+	fxRates        map[fxPair]models.FXRate
+	fxQuotes       map[int64]models.FXQuote
+	events         map[int64]models.Event
+	webhooks       map[int64]models.Webhook
+	deliveries     map[int64]models.WebhookDelivery
+
+	nextAccountID       int64
+	nextStatusChangeID  int64
+	nextTransactionID   int64
+	nextOperationTypeID int64
+	nextInstallmentID   int64
+	nextAuthorizationID int64
+	nextTransferID      int64
+	nextJournalEntryID  int64
+	nextPostingID       int64
+	nextAllocationID    int64
+	nextStatementID     int64
+	nextStatementLineID int64
+	nextAccrualID       int64
+	nextScheduleID      int64
+	nextScheduleRunID   int64
+	nextFXQuoteID       int64
+	nextEventID         int64
+	nextWebhookID       int64
+	nextDeliveryID      int64
+}
+
+type idempotencyRecordKey struct {
+	scope string
+	key   string
+}
+
+type fxPair struct {
+	from models.Currency
+	to   models.Currency
+}
+
+// NewStore returns an empty store whose accounts are settled with the strategy they selected in settlement
+func NewStore(settlement models.SettlementStrategies) *Store {
+	s := &Store{
+		settlement: settlement,
+		eventHub:   models.NewAccountEventHub(),
+		storeState: storeState{
+			accounts:       map[int64]models.Account{},
+			statusChanges:  map[int64]models.AccountStatusChange{},
+			transactions:   map[int64]models.Transaction{},
+			operationTypes: map[int64]models.OperationType{},
+			installments:   map[int64]models.Installment{},
+			authorizations: map[int64]models.Authorization{},
+			transfers:      map[int64]models.Transfer{},
+			journalEntries: map[int64]models.JournalEntry{},
+			allocations:    map[int64]models.Allocation{},
+			statements:     map[int64]models.Statement{},
+			accruals:       map[int64]models.Accrual{},
+			schedules:      map[int64]models.Schedule{},
+			scheduleRuns:   map[int64]models.ScheduleRun{},
+			idempotency:    map[idempotencyRecordKey]models.IdempotencyRecord{},
+			fxRates:        map[fxPair]models.FXRate{},
+			fxQuotes:       map[int64]models.FXQuote{},
+			events:         map[int64]models.Event{},
+			webhooks:       map[int64]models.Webhook{},
+			deliveries:     map[int64]models.WebhookDelivery{},
+			// ids below 100 are reserved for the operation types shipped with the migrations
+			nextOperationTypeID: 99,
+		},
+	}
+
+	for _, operationType := range models.DefaultOperationTypes() {
+		s.operationTypes[operationType.ID] = operationType
+	}
+
+	return s
+}
+
+// runInTx runs fn and takes back what it wrote when it fails, the way the postgres services roll back
+// their transactions, fn must write through put, callers must hold the lock
+func (s *Store) runInTx(fn func() error) error {
+
+	// the outer call rolls back the writes of nested ones
+	if s.undo != nil {
+		return fn()
+	}
+
+	// the copy shares the maps with the store, it only keeps the counters
+	counters := s.storeState
+	s.undo = []func(){}
+	defer func() { s.undo = nil }()
+
+	if err := fn(); err != nil {
+		for i := len(s.undo) - 1; i >= 0; i-- {
+			s.undo[i]()
+		}
+		s.storeState = counters
+		return err
+	}
+
+	return nil
+}
+
+// put writes the record under key in m, within runInTx along with how to take the write back,
+// callers must hold the lock
+func put[K comparable, V any](s *Store, m map[K]V, key K, record V) {
+
+	if s.undo != nil {
+		previous, existed := m[key]
+		s.undo = append(s.undo, func() {
+			if existed {
+				m[key] = previous
+			} else {
+				delete(m, key)
+			}
+		})
+	}
+
+	m[key] = record
+}
+
+// accountTransactions returns the transactions of an account oldest first,
+// callers must hold the lock
+func (s *Store) accountTransactions(accountID int64) []models.Transaction {
+
+	transactions := make([]models.Transaction, 0)
+	for _, transaction := range s.transactions {
+		if transaction.AccountID == accountID {
+			transactions = append(transactions, transaction)
+		}
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		if transactions[i].EventDate.Equal(transactions[j].EventDate) {
+			return transactions[i].ID < transactions[j].ID
+		}
+		return transactions[i].EventDate.Before(transactions[j].EventDate)
+	})
+
+	return transactions
+}
+
+// withOperationType attaches the operation type the way the bun relation does,
+// callers must hold the lock
+func (s *Store) withOperationType(transaction models.Transaction) models.Transaction {
+	if operationType, ok := s.operationTypes[transaction.OperationTypeID]; ok {
+		transaction.OperationType = &operationType
+	}
+	return transaction
+}
+
+// BackdateAccount moves the opening date of an account, so that tests can open accounts
+// before cycles that already closed
+func (s *Store) BackdateAccount(accountID int64, createdAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.accounts[accountID]
+	if !ok {
+		return models.NoRecordErr
+	}
+
+	account.CreatedAt = createdAt
+	s.accounts[accountID] = account
+
+	return nil
+}
+
+// Backdate moves the event date of a transaction, so that tests can book transactions
+// in cycles and days that already closed
+func (s *Store) Backdate(transactionID int64, eventDate time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction, ok := s.transactions[transactionID]
+	if !ok {
+		return models.NoRecordErr
+	}
+
+	transaction.EventDate = eventDate
+	s.transactions[transactionID] = transaction
+
+	return nil
+}
@@ -0,0 +1,385 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"time"
+)
+
+type transactionService struct {
+	store *Store
+}
+
+func NewTransactionService(store *Store) *transactionService {
+	return &transactionService{
+		store: store,
+	}
+}
+
+func (ts *transactionService) Create(ctx context.Context, transaction models.Transaction) (models.TransactionStatus, error) {
+	ts.store.mu.Lock()
+	defer ts.store.mu.Unlock()
+
+	var transactionStatus models.TransactionStatus
+
+	// the claim of the idempotency key is rolled back with the transaction when it fails
+	err := ts.store.runInTx(func() error {
+		if _, err := ts.store.claimIdempotencyKey(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		transactionStatus, err = ts.store.createTransaction(transaction)
+		if err != nil {
+			return err
+		}
+
+		return ts.store.completeIdempotencyKey(ctx, transactionStatus)
+	})
+	if err != nil {
+		return models.TransactionStatus{}, err
+	}
+
+	return transactionStatus, nil
+}
+
+// createTransaction books a transaction, settling it against the open balances
+// of the account, it writes as it goes so callers must hold the lock and run it in runInTx
+func (s *Store) createTransaction(transaction models.Transaction) (models.TransactionStatus, error) {
+
+	transactionStatus := models.TransactionStatus{}
+
+	account, ok := s.accounts[transaction.AccountID]
+	if !ok {
+		return transactionStatus, models.NoRecordErr
+	}
+
+	// postgres enforces it with the foreign key of the transaction
+	if _, ok := s.operationTypes[transaction.OperationTypeID]; !ok {
+		return transactionStatus, models.NoRecordErr
+	}
+
+	if !account.AcceptsTransaction(transaction.OperationTypeID) {
+		return transactionStatus, models.AccountNotActiveErr
+	}
+
+	currency, err := account.TransactionCurrency(transaction.Currency, transaction.Amount)
+	if err != nil {
+		return transactionStatus, err
+	}
+	transaction.Currency = currency
+
+	if transaction.FXQuoteID != nil {
+		if err := s.useFXQuote(transaction, time.Now()); err != nil {
+			return transactionStatus, err
+		}
+	}
+
+	if models.IsChargeOperationType(transaction.OperationTypeID) {
+		account.ApplyChargeToCreditLimit(transaction.Amount)
+	} else if err := account.ApplyToCreditLimit(transaction.Amount); err != nil {
+		return transactionStatus, err
+	}
+	put(s, s.accounts, account.AccountID, account)
+
+	transaction.EventDate = time.Now()
+
+	currBalance := transaction.Amount
+
+	// only the first installment is due right away, the rest is posted by PostDue
+	var schedule []models.Installment
+	if transaction.Installments > 1 {
+		schedule = models.NewInstallmentSchedule(-transaction.Amount, transaction.Installments, transaction.EventDate, transaction.Currency)
+		schedule[0].PostedAt = &transaction.EventDate
+		currBalance = -schedule[0].Amount
+	}
+
+	var allocations []models.Allocation
+	if transaction.Amount > 0 {
+		currBalance, allocations = s.dischargeDebits(transaction.AccountID, currBalance)
+	} else {
+		currBalance, allocations = s.dischargeCredits(transaction.AccountID, currBalance)
+	}
+
+	s.nextTransactionID++
+	transaction.ID = s.nextTransactionID
+	transaction.Balance = currBalance
+	transaction.OperationType = nil
+	put(s, s.transactions, transaction.ID, transaction)
+	s.postJournalEntry(transaction)
+	s.recordAllocations(allocations, transaction.ID, transaction.EventDate)
+
+	for _, installment := range schedule {
+		s.nextInstallmentID++
+		installment.ID = s.nextInstallmentID
+		installment.TransactionID = transaction.ID
+		installment.AccountID = transaction.AccountID
+		put(s, s.installments, installment.ID, installment)
+	}
+
+	if err := s.recordTransactionEvents(transaction, allocations); err != nil {
+		return transactionStatus, err
+	}
+
+	transactionStatus.TransactionID = transaction.ID
+	transactionStatus.AccountID = transaction.AccountID
+
+	return transactionStatus, nil
+}
+
+func (ts *transactionService) GetForID(_ context.Context, transactionID int64) (models.Transaction, error) {
+	ts.store.mu.RLock()
+	defer ts.store.mu.RUnlock()
+
+	transaction, ok := ts.store.transactions[transactionID]
+	if !ok {
+		return models.Transaction{}, models.NoRecordErr
+	}
+
+	return ts.store.withOperationType(transaction), nil
+}
+
+func (ts *transactionService) ListForAccount(_ context.Context, filter models.TransactionFilter) (models.TransactionPage, error) {
+	ts.store.mu.RLock()
+	defer ts.store.mu.RUnlock()
+
+	page := models.TransactionPage{}
+
+	if filter.Limit <= 0 || filter.Limit > models.MaxPageLimit {
+		filter.Limit = models.DefaultPageLimit
+	}
+
+	if _, ok := ts.store.accounts[filter.AccountID]; !ok {
+		return page, models.NoRecordErr
+	}
+
+	operationTypes := map[int64]bool{}
+	for _, operationTypeID := range filter.OperationTypeIDs {
+		operationTypes[operationTypeID] = true
+	}
+
+	transactions := ts.store.accountTransactions(filter.AccountID)
+
+	// newest first
+	for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+		transactions[i], transactions[j] = transactions[j], transactions[i]
+	}
+
+	rtransactions := make([]models.Transaction, 0)
+	for _, transaction := range transactions {
+		switch {
+		case len(operationTypes) > 0 && !operationTypes[transaction.OperationTypeID]:
+			continue
+		case filter.From != nil && transaction.EventDate.Before(*filter.From):
+			continue
+		case filter.To != nil && !transaction.EventDate.Before(*filter.To):
+			continue
+		case filter.Settled != nil && *filter.Settled != (transaction.Balance == 0):
+			continue
+		case filter.After != nil && !isBefore(transaction, *filter.After):
+			continue
+		}
+
+		rtransactions = append(rtransactions, ts.store.withOperationType(transaction))
+
+		// one more than the limit to know if there is a next page
+		if len(rtransactions) > filter.Limit {
+			break
+		}
+	}
+
+	if len(rtransactions) > filter.Limit {
+		rtransactions = rtransactions[:filter.Limit]
+		last := rtransactions[len(rtransactions)-1]
+		page.NextCursor = &models.TransactionCursor{EventDate: last.EventDate, ID: last.ID}
+	}
+
+	page.Transactions = rtransactions
+
+	return page, nil
+}
+
+func (ts *transactionService) Reverse(ctx context.Context, transactionID int64, amount models.Money) (models.TransactionStatus, error) {
+	ts.store.mu.Lock()
+	defer ts.store.mu.Unlock()
+
+	var transactionStatus models.TransactionStatus
+
+	err := ts.store.runInTx(func() error {
+		if _, err := ts.store.claimIdempotencyKey(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		transactionStatus, err = ts.store.reverseTransaction(transactionID, amount)
+		if err != nil {
+			return err
+		}
+
+		return ts.store.completeIdempotencyKey(ctx, transactionStatus)
+	})
+	if err != nil {
+		return models.TransactionStatus{}, err
+	}
+
+	return transactionStatus, nil
+}
+
+// reverseTransaction books the reversal of up to amount of what is left to reverse of a debit, callers must hold the lock
+func (s *Store) reverseTransaction(transactionID int64, amount models.Money) (models.TransactionStatus, error) {
+
+	transactionStatus := models.TransactionStatus{}
+
+	original, ok := s.transactions[transactionID]
+	if !ok {
+		return transactionStatus, models.NoRecordErr
+	}
+
+	account := s.accounts[original.AccountID]
+	if !account.AcceptsTransaction(int64(models.PurchaseReversal)) {
+		return transactionStatus, models.AccountNotActiveErr
+	}
+
+	var reversed models.Money
+	for _, transaction := range s.transactions {
+		if transaction.ReversesTransactionID != nil && *transaction.ReversesTransactionID == transactionID {
+			reversed += transaction.Amount
+		}
+	}
+
+	reversal, err := models.NewReversal(original, reversed, amount)
+	if err != nil {
+		return transactionStatus, err
+	}
+
+	if err := account.ApplyToCreditLimit(reversal.Amount); err != nil {
+		return transactionStatus, err
+	}
+	put(s, s.accounts, account.AccountID, account)
+
+	// the reversal first cancels the installments that are not due yet, then what is
+	// still open in the original debit, the part that was already paid is given back as a credit
+	cancelled := s.cancelPendingInstallments(original.ID, reversal.Amount)
+
+	var allocations []models.Allocation
+
+	applied := min(reversal.Amount-cancelled, -original.Balance)
+	if applied > 0 {
+		allocations = append(allocations, models.Allocation{DebitTransactionID: original.ID, Amount: applied})
+		original.Balance += applied
+		put(s, s.transactions, original.ID, original)
+	}
+
+	var discharged []models.Allocation
+	reversal.Balance, discharged = s.dischargeDebits(original.AccountID, reversal.Amount-cancelled-applied)
+	allocations = append(allocations, discharged...)
+
+	s.nextTransactionID++
+	reversal.ID = s.nextTransactionID
+	put(s, s.transactions, reversal.ID, reversal)
+	s.postJournalEntry(reversal)
+	s.recordAllocations(allocations, reversal.ID, reversal.EventDate)
+
+	if err := s.recordTransactionEvents(reversal, allocations); err != nil {
+		return transactionStatus, err
+	}
+
+	transactionStatus.TransactionID = reversal.ID
+	transactionStatus.AccountID = reversal.AccountID
+
+	return transactionStatus, nil
+}
+
+// isBefore compares (event_date, id) of the transaction with the cursor
+func isBefore(transaction models.Transaction, cursor models.TransactionCursor) bool {
+	if transaction.EventDate.Equal(cursor.EventDate) {
+		return transaction.ID < cursor.ID
+	}
+	return transaction.EventDate.Before(cursor.EventDate)
+}
+
+// dischargeDebits uses a credit to pay off the negative balances of the previous transactions
+// of the account, in the order of its settlement strategy, and returns the part of the credit that is left over
+// along with the allocations to the debits it paid, callers must hold the lock
+func (s *Store) dischargeDebits(accountID int64, credit models.Money) (models.Money, []models.Allocation) {
+
+	currBalance := credit
+	allocations := []models.Allocation{}
+
+	for _, unresolvedTransaction := range s.settlementOrder(accountID) {
+		if currBalance <= 0 {
+			break
+		}
+
+		if unresolvedTransaction.Balance >= 0 {
+			continue
+		}
+
+		previousBalance := unresolvedTransaction.Balance
+
+		if unresolvedTransaction.Balance+currBalance > 0 {
+			currBalance = currBalance + unresolvedTransaction.Balance
+			unresolvedTransaction.Balance = 0
+		} else {
+			unresolvedTransaction.Balance = unresolvedTransaction.Balance + currBalance
+			currBalance = 0
+		}
+
+		put(s, s.transactions, unresolvedTransaction.ID, unresolvedTransaction)
+
+		allocations = append(allocations, models.Allocation{
+			DebitTransactionID: unresolvedTransaction.ID,
+			Amount:             unresolvedTransaction.Balance - previousBalance,
+		})
+	}
+
+	return currBalance, allocations
+}
+
+// dischargeCredits consumes the positive balances of the previous transactions
+// of the account, in the order of its settlement strategy, and returns the part of the debit that is still open
+// along with the allocations from the credits it consumed, callers must hold the lock
+func (s *Store) dischargeCredits(accountID int64, debit models.Money) (models.Money, []models.Allocation) {
+
+	currBalance := debit
+	allocations := []models.Allocation{}
+
+	for _, unresolvedTransaction := range s.settlementOrder(accountID) {
+		if currBalance == 0 {
+			break
+		}
+
+		if unresolvedTransaction.Balance <= 0 {
+			continue
+		}
+
+		previousBalance := unresolvedTransaction.Balance
+
+		if unresolvedTransaction.Balance+currBalance > 0 {
+			unresolvedTransaction.Balance = unresolvedTransaction.Balance + currBalance
+			currBalance = 0
+		} else {
+			currBalance = currBalance + unresolvedTransaction.Balance
+			unresolvedTransaction.Balance = 0
+		}
+
+		put(s, s.transactions, unresolvedTransaction.ID, unresolvedTransaction)
+
+		allocations = append(allocations, models.Allocation{
+			CreditTransactionID: unresolvedTransaction.ID,
+			Amount:              previousBalance - unresolvedTransaction.Balance,
+		})
+	}
+
+	return currBalance, allocations
+}
+
+// settlementOrder returns the transactions of an account in the order its settlement
+// strategy settles them, callers must hold the lock
+func (s *Store) settlementOrder(accountID int64) []models.Transaction {
+
+	transactions := s.accountTransactions(accountID)
+	s.settlement.ForAccount(s.accounts[accountID]).Order(transactions)
+
+	return transactions
+}
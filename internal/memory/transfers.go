@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"time"
+)
+
+type transferService struct {
+	store *Store
+}
+
+func NewTransferService(store *Store) *transferService {
+	return &transferService{
+		store: store,
+	}
+}
+
+func (ts *transferService) Create(ctx context.Context, transfer models.Transfer) (models.Transfer, error) {
+	ts.store.mu.Lock()
+	defer ts.store.mu.Unlock()
+
+	if transfer.SourceAccountID == transfer.DestinationAccountID {
+		return models.Transfer{}, models.SameAccountTransferErr
+	}
+
+	// both legs and the claim of the idempotency key are rolled back when either leg fails
+	err := ts.store.runInTx(func() error {
+		if _, err := ts.store.claimIdempotencyKey(ctx); err != nil {
+			return err
+		}
+
+		var err error
+		transfer, err = ts.store.createTransfer(transfer)
+		if err != nil {
+			return err
+		}
+
+		return ts.store.completeIdempotencyKey(ctx, transfer)
+	})
+	if err != nil {
+		return models.Transfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// createTransfer books the debit of the source account and the credit of the destination account
+// of a transfer, callers must hold the lock
+func (s *Store) createTransfer(transfer models.Transfer) (models.Transfer, error) {
+
+	source, sourceOk := s.accounts[transfer.SourceAccountID]
+	destination, destinationOk := s.accounts[transfer.DestinationAccountID]
+	if !sourceOk || !destinationOk {
+		return models.Transfer{}, models.NoRecordErr
+	}
+
+	if !source.AcceptsTransaction(int64(models.TransferOut)) || !destination.AcceptsTransaction(int64(models.TransferIn)) {
+		return models.Transfer{}, models.AccountNotActiveErr
+	}
+
+	// transfers move the same amount out of and into both accounts, so they must share a currency
+	if source.Currency != destination.Currency {
+		return models.Transfer{}, models.CurrencyMismatchErr
+	}
+
+	s.nextTransferID++
+	transfer.ID = s.nextTransferID
+	transfer.CreatedAt = time.Now()
+
+	debit, err := s.createTransaction(models.Transaction{
+		AccountID:       transfer.SourceAccountID,
+		OperationTypeID: int64(models.TransferOut),
+		Amount:          -transfer.Amount,
+		TransferID:      &transfer.ID,
+	})
+	if err != nil {
+		return models.Transfer{}, err
+	}
+
+	credit, err := s.createTransaction(models.Transaction{
+		AccountID:       transfer.DestinationAccountID,
+		OperationTypeID: int64(models.TransferIn),
+		Amount:          transfer.Amount,
+		TransferID:      &transfer.ID,
+	})
+	if err != nil {
+		return models.Transfer{}, err
+	}
+
+	transfer.DebitTransactionID = &debit.TransactionID
+	transfer.CreditTransactionID = &credit.TransactionID
+
+	put(s, s.transfers, transfer.ID, transfer)
+
+	return transfer, nil
+}
+
+func (ts *transferService) GetForID(_ context.Context, transferID int64) (models.Transfer, error) {
+	ts.store.mu.RLock()
+	defer ts.store.mu.RUnlock()
+
+	transfer, ok := ts.store.transfers[transferID]
+	if !ok {
+		return models.Transfer{}, models.NoRecordErr
+	}
+
+	return transfer, nil
+}
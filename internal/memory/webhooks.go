@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"time"
+)
+
+type webhookService struct {
+	store *Store
+}
+
+func NewWebhookService(store *Store) *webhookService {
+	return &webhookService{
+		store: store,
+	}
+}
+
+func (whs *webhookService) Create(_ context.Context, webhook models.Webhook) (models.Webhook, error) {
+	whs.store.mu.Lock()
+	defer whs.store.mu.Unlock()
+
+	if webhook.AccountID != nil {
+		if _, ok := whs.store.accounts[*webhook.AccountID]; !ok {
+			return models.Webhook{}, models.NoRecordErr
+		}
+	}
+
+	whs.store.nextWebhookID++
+	webhook.ID = whs.store.nextWebhookID
+	webhook.CreatedAt = time.Now()
+	whs.store.webhooks[webhook.ID] = webhook
+
+	return webhook, nil
+}
+
+func (whs *webhookService) GetForID(_ context.Context, webhookID int64) (models.Webhook, error) {
+	whs.store.mu.RLock()
+	defer whs.store.mu.RUnlock()
+
+	webhook, ok := whs.store.webhooks[webhookID]
+	if !ok {
+		return models.Webhook{}, models.NoRecordErr
+	}
+
+	webhook.Secret = ""
+
+	return webhook, nil
+}
+
+func (whs *webhookService) ListDeliveries(_ context.Context, webhookID int64) ([]models.WebhookDelivery, error) {
+	whs.store.mu.RLock()
+	defer whs.store.mu.RUnlock()
+
+	if _, ok := whs.store.webhooks[webhookID]; !ok {
+		return nil, models.NoRecordErr
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for _, delivery := range whs.store.deliveries {
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].ID > deliveries[j].ID
+	})
+
+	return deliveries, nil
+}
+
+func (whs *webhookService) LeaseDue(_ context.Context, now time.Time, ttl time.Duration, limit int) ([]models.WebhookDelivery, map[int64]models.Webhook, error) {
+	whs.store.mu.Lock()
+	defer whs.store.mu.Unlock()
+
+	leased := make([]models.WebhookDelivery, 0)
+	for _, delivery := range whs.store.deliveries {
+		if delivery.Status == models.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			leased = append(leased, delivery)
+		}
+	}
+
+	sort.Slice(leased, func(i, j int) bool {
+		if !leased[i].NextAttemptAt.Equal(leased[j].NextAttemptAt) {
+			return leased[i].NextAttemptAt.Before(leased[j].NextAttemptAt)
+		}
+		return leased[i].ID < leased[j].ID
+	})
+
+	if len(leased) > limit {
+		leased = leased[:limit]
+	}
+
+	webhooks := map[int64]models.Webhook{}
+	for i := range leased {
+		leased[i].NextAttemptAt = now.Add(ttl)
+		whs.store.deliveries[leased[i].ID] = leased[i]
+		webhooks[leased[i].WebhookID] = whs.store.webhooks[leased[i].WebhookID]
+	}
+
+	return leased, webhooks, nil
+}
+
+func (whs *webhookService) RecordAttempt(_ context.Context, delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+	whs.store.mu.Lock()
+	defer whs.store.mu.Unlock()
+
+	rdelivery, ok := whs.store.deliveries[delivery.ID]
+	if !ok {
+		return models.WebhookDelivery{}, models.NoRecordErr
+	}
+
+	// a delivery another dispatcher finished after its lease lapsed keeps its outcome
+	if rdelivery.Status != models.WebhookDeliveryPending {
+		return delivery, nil
+	}
+
+	rdelivery.Status = delivery.Status
+	rdelivery.Attempts = delivery.Attempts
+	rdelivery.NextAttemptAt = delivery.NextAttemptAt
+	rdelivery.LastAttemptAt = delivery.LastAttemptAt
+	rdelivery.ResponseStatus = delivery.ResponseStatus
+	rdelivery.LastError = delivery.LastError
+	whs.store.deliveries[delivery.ID] = rdelivery
+
+	return rdelivery, nil
+}
+
+// enqueueDeliveries writes the deliveries of a recorded event to the webhooks that subscribe it,
+// callers must hold the lock
+func (s *Store) enqueueDeliveries(event models.Event) {
+
+	ids := []int64{}
+	for id, webhook := range s.webhooks {
+		if webhook.Subscribes(event) {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+
+	for _, id := range ids {
+		// the payload of the event is marshaled by its constructor, so it is always valid json
+		delivery, err := models.NewWebhookDelivery(s.webhooks[id], event)
+		if err != nil {
+			continue
+		}
+
+		s.nextDeliveryID++
+		delivery.ID = s.nextDeliveryID
+		put(s, s.deliveries, delivery.ID, delivery)
+	}
+}
@@ -0,0 +1,40 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS idempotency_key (
+			scope VARCHAR NOT NULL,
+			key VARCHAR NOT NULL,
+			request_hash VARCHAR NOT NULL,
+			status_code INTEGER,
+			response_body BYTEA,
+			created_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (scope, key)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS idempotency_key_created_at_idx ON idempotency_key (created_at);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,33 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// amounts are stored as integer minor units (cents) to keep settlement exact
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE transaction ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE transaction ALTER COLUMN balance TYPE BIGINT USING ROUND(balance * 100)::BIGINT;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
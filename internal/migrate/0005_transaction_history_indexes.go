@@ -0,0 +1,34 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// keyset pagination of the transaction history of an account
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS transaction_account_event_date_idx ON transaction (account_id, event_date DESC, id DESC);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// transactions with an open balance, used when filtering and when discharging
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS transaction_account_open_balance_idx ON transaction (account_id, event_date) WHERE balance <> 0;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
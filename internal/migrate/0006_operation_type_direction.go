@@ -0,0 +1,43 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE operation_type ADD COLUMN IF NOT EXISTS direction VARCHAR NOT NULL DEFAULT 'debit'
+				CHECK (direction IN ('debit', 'credit'));
+			ALTER TABLE operation_type ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			UPDATE operation_type SET direction = 'credit' WHERE id = 4;
+		`)
+		if err != nil {
+			return err
+		}
+
+		// the seeded rows were inserted with explicit ids, ids below 100 are
+		// reserved for operation types shipped with migrations so the first one created is 100
+		_, err = db.ExecContext(ctx, `
+			SELECT setval('operation_type_id_seq', GREATEST(99, (SELECT MAX(id) FROM operation_type)));
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
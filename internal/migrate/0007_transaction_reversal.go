@@ -0,0 +1,40 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE transaction ADD COLUMN IF NOT EXISTS reverses_transaction_id integer references transaction (id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// reversals are looked up by the debit they reverse, under the lock of its row
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS transaction_reverses_transaction_id_idx ON transaction (reverses_transaction_id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO operation_type (id, description, direction) VALUES (5, 'Purchase Reversal', 'credit') ON CONFLICT (id) DO NOTHING;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE transaction ADD COLUMN IF NOT EXISTS installments integer;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS installment(
+				id SERIAL PRIMARY KEY,
+				transaction_id integer references transaction (id) NOT NULL,
+				account_id integer references account (id) NOT NULL,
+				number integer NOT NULL,
+				amount BIGINT NOT NULL,
+				due_date TIMESTAMP WITH TIME ZONE NOT NULL,
+				posted_at TIMESTAMP WITH TIME ZONE,
+				UNIQUE (transaction_id, number)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// the posting job only looks for installments that are not posted yet
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS installment_pending_due_date_idx ON installment (due_date) WHERE posted_at IS NULL;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,26 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		// accounts without a limit are not capped, credit_limit is the limit they were granted
+		// and available_credit_limit what is left of it
+		_, err := db.ExecContext(ctx, `
+			ALTER TABLE account ADD COLUMN IF NOT EXISTS credit_limit BIGINT CHECK (credit_limit >= 0);
+			ALTER TABLE account ADD COLUMN IF NOT EXISTS available_credit_limit BIGINT CHECK (available_credit_limit >= 0);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS authorization_hold(
+				id SERIAL PRIMARY KEY,
+				account_id integer references account (id) NOT NULL,
+				operation_type_id integer references operation_type (id) NOT NULL,
+				amount BIGINT NOT NULL CHECK (amount > 0),
+				captured_amount BIGINT NOT NULL DEFAULT 0,
+				status TEXT NOT NULL CHECK (status IN ('pending', 'captured', 'released', 'expired')),
+				transaction_id integer references transaction (id),
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// pending holds are summed for the account balance and scanned by the expiry job
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS authorization_hold_pending_idx ON authorization_hold (account_id, expires_at) WHERE status = 'pending';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS authorization_hold_expires_at_idx ON authorization_hold (expires_at) WHERE status = 'pending';
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS transfer(
+				id SERIAL PRIMARY KEY,
+				source_account_id integer references account (id) NOT NULL,
+				destination_account_id integer references account (id) NOT NULL,
+				amount BIGINT NOT NULL CHECK (amount > 0),
+				debit_transaction_id integer references transaction (id),
+				credit_transaction_id integer references transaction (id),
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				CHECK (source_account_id <> destination_account_id)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE transaction ADD COLUMN IF NOT EXISTS transfer_id integer references transfer (id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO operation_type (id, description, direction) VALUES
+				(6, 'Transfer Out', 'debit'),
+				(7, 'Transfer In', 'credit')
+			ON CONFLICT (id) DO NOTHING;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
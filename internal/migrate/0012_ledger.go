@@ -0,0 +1,90 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS journal_entry(
+				id SERIAL PRIMARY KEY,
+				transaction_id integer references transaction (id) NOT NULL UNIQUE,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS posting(
+				id SERIAL PRIMARY KEY,
+				journal_entry_id integer references journal_entry (id) NOT NULL,
+				ledger_account TEXT NOT NULL CHECK (ledger_account IN ('customer', 'merchant_clearing', 'cash', 'transfer_clearing')),
+				account_id integer references account (id),
+				amount BIGINT NOT NULL,
+				CHECK ((ledger_account = 'customer') = (account_id IS NOT NULL))
+			);
+			CREATE INDEX IF NOT EXISTS posting_journal_entry_id_idx ON posting (journal_entry_id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// journal entries and postings are only ever appended, corrections are new entries
+		_, err = db.ExecContext(ctx, `
+			CREATE OR REPLACE FUNCTION forbid_ledger_mutation() RETURNS trigger AS $$
+			BEGIN
+				RAISE EXCEPTION 'ledger entries are immutable';
+			END;
+			$$ LANGUAGE plpgsql;
+
+			DROP TRIGGER IF EXISTS journal_entry_immutable ON journal_entry;
+			CREATE TRIGGER journal_entry_immutable BEFORE UPDATE OR DELETE ON journal_entry
+				FOR EACH ROW EXECUTE FUNCTION forbid_ledger_mutation();
+
+			DROP TRIGGER IF EXISTS posting_immutable ON posting;
+			CREATE TRIGGER posting_immutable BEFORE UPDATE OR DELETE ON posting
+				FOR EACH ROW EXECUTE FUNCTION forbid_ledger_mutation();
+		`)
+		if err != nil {
+			return err
+		}
+
+		// journal the transactions booked before the ledger existed
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO journal_entry (transaction_id, created_at)
+				SELECT id, event_date FROM transaction
+			ON CONFLICT (transaction_id) DO NOTHING;
+
+			INSERT INTO posting (journal_entry_id, ledger_account, account_id, amount)
+				SELECT je.id, 'customer', t.account_id, -t.amount
+				FROM journal_entry je JOIN transaction t ON t.id = je.transaction_id
+				WHERE NOT EXISTS (SELECT 1 FROM posting p WHERE p.journal_entry_id = je.id);
+
+			INSERT INTO posting (journal_entry_id, ledger_account, account_id, amount)
+				SELECT je.id,
+					CASE
+						WHEN t.operation_type_id IN (1, 2, 5) THEN 'merchant_clearing'
+						WHEN t.operation_type_id IN (6, 7) THEN 'transfer_clearing'
+						ELSE 'cash'
+					END,
+					NULL, t.amount
+				FROM journal_entry je JOIN transaction t ON t.id = je.transaction_id
+				WHERE (SELECT count(*) FROM posting p WHERE p.journal_entry_id = je.id) = 1;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
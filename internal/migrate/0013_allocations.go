@@ -0,0 +1,33 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS allocation(
+				id SERIAL PRIMARY KEY,
+				credit_transaction_id integer references transaction (id) NOT NULL,
+				debit_transaction_id integer references transaction (id) NOT NULL,
+				amount BIGINT NOT NULL CHECK (amount > 0),
+				allocated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+			CREATE INDEX IF NOT EXISTS allocation_credit_transaction_id_idx ON allocation (credit_transaction_id);
+			CREATE INDEX IF NOT EXISTS allocation_debit_transaction_id_idx ON allocation (debit_transaction_id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,26 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// the names are validated by the app since the strategies are configured through the builder
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE account ADD COLUMN IF NOT EXISTS settlement_strategy TEXT;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// the days stop at 28 so that every month has them
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE account ADD COLUMN IF NOT EXISTS closing_day SMALLINT CHECK (closing_day BETWEEN 1 AND 28);
+			ALTER TABLE account ADD COLUMN IF NOT EXISTS due_day SMALLINT CHECK (due_day BETWEEN 1 AND 28);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// the first cycle of an account starts when it was opened, the accounts that are already open
+		// are taken as opened with their first transaction
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE account ADD COLUMN IF NOT EXISTS created_at TIMESTAMP WITH TIME ZONE;
+			UPDATE account SET created_at = COALESCE(
+				(SELECT MIN(t.event_date) FROM transaction AS t WHERE t.account_id = account.id), now())
+				WHERE created_at IS NULL;
+			ALTER TABLE account ALTER COLUMN created_at SET DEFAULT now();
+			ALTER TABLE account ALTER COLUMN created_at SET NOT NULL;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS statement(
+				id SERIAL PRIMARY KEY,
+				account_id integer references account (id) NOT NULL,
+				period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+				period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+				opening_balance BIGINT NOT NULL,
+				closing_balance BIGINT NOT NULL,
+				minimum_payment BIGINT NOT NULL CHECK (minimum_payment >= 0),
+				due_date TIMESTAMP WITH TIME ZONE NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				UNIQUE (account_id, period_end)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS statement_line(
+				id SERIAL PRIMARY KEY,
+				statement_id integer references statement (id) NOT NULL,
+				transaction_id integer references transaction (id) NOT NULL,
+				operation_type_id integer NOT NULL,
+				amount BIGINT NOT NULL,
+				balance BIGINT NOT NULL,
+				event_date TIMESTAMP WITH TIME ZONE NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS statement_line_statement_id_idx ON statement_line (statement_id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
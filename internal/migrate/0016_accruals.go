@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE operation_type ADD COLUMN IF NOT EXISTS apr_bps BIGINT NOT NULL DEFAULT 0 CHECK (apr_bps >= 0);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO operation_type (id, description, direction) VALUES
+				(8, 'Interest', 'debit'),
+				(9, 'Late Fee', 'debit')
+			ON CONFLICT (id) DO NOTHING;
+		`)
+		if err != nil {
+			return err
+		}
+
+		// interest and late fees are income of the issuer
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE posting DROP CONSTRAINT IF EXISTS posting_ledger_account_check;
+			ALTER TABLE posting ADD CONSTRAINT posting_ledger_account_check
+				CHECK (ledger_account IN ('customer', 'merchant_clearing', 'cash', 'transfer_clearing', 'fee_income'));
+		`)
+		if err != nil {
+			return err
+		}
+
+		// the unique key is what keeps a day from being charged twice
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS accrual(
+				id SERIAL PRIMARY KEY,
+				account_id integer references account (id) NOT NULL,
+				accrual_date DATE NOT NULL,
+				kind TEXT NOT NULL CHECK (kind IN ('interest', 'late_fee')),
+				amount BIGINT NOT NULL CHECK (amount > 0),
+				transaction_id integer references transaction (id) NOT NULL,
+				statement_id integer references statement (id),
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				UNIQUE (account_id, accrual_date, kind)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS schedule(
+				id SERIAL PRIMARY KEY,
+				account_id integer references account (id) NOT NULL,
+				operation_type_id integer references operation_type (id) NOT NULL,
+				amount BIGINT NOT NULL CHECK (amount <> 0),
+				frequency TEXT NOT NULL CHECK (frequency IN ('once', 'daily', 'weekly', 'monthly')),
+				interval_count integer NOT NULL DEFAULT 1 CHECK (interval_count > 0),
+				start_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				end_at TIMESTAMP WITH TIME ZONE,
+				status TEXT NOT NULL CHECK (status IN ('active', 'completed', 'cancelled')),
+				next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				occurrences integer NOT NULL DEFAULT 0,
+				attempts integer NOT NULL DEFAULT 0,
+				retry_at TIMESTAMP WITH TIME ZONE,
+				lease_owner TEXT,
+				leased_until TIMESTAMP WITH TIME ZONE,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// the scheduler only looks for active schedules by the time they are due
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS schedule_active_due_idx ON schedule ((COALESCE(retry_at, next_run_at))) WHERE status = 'active';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS schedule_run(
+				id SERIAL PRIMARY KEY,
+				schedule_id integer references schedule (id) NOT NULL,
+				scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+				attempt integer NOT NULL CHECK (attempt > 0),
+				status TEXT NOT NULL CHECK (status IN ('succeeded', 'failed')),
+				transaction_id integer references transaction (id),
+				error TEXT,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// an occurrence succeeds at most once whatever happens to the leases
+		_, err = db.ExecContext(ctx, `
+			CREATE UNIQUE INDEX IF NOT EXISTS schedule_run_succeeded_idx ON schedule_run (schedule_id, scheduled_for) WHERE status = 'succeeded';
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,41 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// the accounts created before the status keep taking transactions
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE account ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'frozen', 'closed'));
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS account_status_change(
+				id SERIAL PRIMARY KEY,
+				account_id integer references account (id) NOT NULL,
+				from_status TEXT NOT NULL CHECK (from_status IN ('active', 'frozen', 'closed')),
+				to_status TEXT NOT NULL CHECK (to_status IN ('active', 'frozen', 'closed')),
+				reason TEXT,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+			CREATE INDEX IF NOT EXISTS account_status_change_account_id_idx ON account_status_change (account_id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
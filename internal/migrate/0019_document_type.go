@@ -0,0 +1,116 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// the accounts opened before the documents were validated keep no type
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE account ADD COLUMN IF NOT EXISTS document_type TEXT;
+		`)
+		if err != nil {
+			return err
+		}
+
+		// the documents stored before were not padded with their leading zeros, the valid ones are
+		// normalized the way new accounts are so that the unique constraint catches a second account
+		return db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+			return normalizeDocuments(ctx, tx)
+		})
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
+
+// normalizeDocuments stores the documents of the accounts without a type normalized and typed, the documents
+// that are not valid are left as they are and so are the ones whose normalized number another account already holds
+func normalizeDocuments(ctx context.Context, tx bun.Tx) error {
+
+	type document struct {
+		ID     int64  `bun:"id"`
+		Number string `bun:"document_number"`
+	}
+
+	documents := []document{}
+	if err := tx.NewSelect().
+		Table("account").
+		Column("id", "document_number").
+		Where("document_type IS NULL").
+		Where("document_number IS NOT NULL").
+		Order("id ASC").
+		Scan(ctx, &documents); err != nil {
+		return err
+	}
+
+	for _, document := range documents {
+		documentType, number, ok := normalizeDocument(document.Number)
+		if !ok {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE account SET document_number = ?, document_type = ?
+			WHERE id = ? AND NOT EXISTS (SELECT 1 FROM account WHERE document_number = ? AND id <> ?);
+		`, number, documentType, document.ID, number, document.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normalizeDocument types and normalizes a document the way the validators did when this migration was written,
+// numbers of up to 11 digits are CPFs and longer ones CNPJs, padded with the leading zeros they lost. It is kept
+// apart from the validators of the app so that changing them later does not change what this migration does
+func normalizeDocument(number string) (documentType string, digits string, ok bool) {
+
+	digits = strings.NewReplacer(".", "", "-", "", "/", "").Replace(number)
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return "", "", false
+	}
+
+	var firstWeights, secondWeights []int
+	switch {
+	case len(digits) <= 11:
+		documentType = "cpf"
+		digits = strings.Repeat("0", 11-len(digits)) + digits
+		firstWeights, secondWeights = []int{10, 9, 8, 7, 6, 5, 4, 3, 2}, []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2}
+	case len(digits) <= 14:
+		documentType = "cnpj"
+		digits = strings.Repeat("0", 14-len(digits)) + digits
+		firstWeights, secondWeights = []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}, []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	default:
+		return "", "", false
+	}
+
+	// numbers of a single repeated digit pass the check but are never issued
+	if strings.Count(digits, digits[:1]) == len(digits) {
+		return "", "", false
+	}
+
+	checkDigit := func(weights []int) int {
+		sum := 0
+		for i, weight := range weights {
+			sum += int(digits[i]-'0') * weight
+		}
+		if remainder := sum % 11; remainder >= 2 {
+			return 11 - remainder
+		}
+		return 0
+	}
+
+	if checkDigit(firstWeights) != int(digits[len(firstWeights)]-'0') || checkDigit(secondWeights) != int(digits[len(secondWeights)]-'0') {
+		return "", "", false
+	}
+
+	return documentType, digits, true
+}
@@ -0,0 +1,66 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// everything booked so far was implicitly in the default currency of the app
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE account ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'BRL';
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE transaction ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'BRL';
+		`)
+		if err != nil {
+			return err
+		}
+
+		// the shared ledger accounts take postings in every currency, which must never be summed together,
+		// the postings so far are in the currency of the transaction of their entry
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE posting ADD COLUMN IF NOT EXISTS currency TEXT;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			UPDATE posting AS p SET currency = t.currency
+			FROM journal_entry AS je JOIN transaction AS t ON t.id = je.transaction_id
+			WHERE p.journal_entry_id = je.id AND p.currency IS NULL;
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE posting ALTER COLUMN currency SET NOT NULL;
+		`)
+		if err != nil {
+			return err
+		}
+
+		// settlement only discharges the balances of the account in the currency of the new transaction
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS transaction_account_currency_idx ON transaction (account_id, currency);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// rates are in hundred millionths, one row per currency pair holds its current rate
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS fx_rate(
+				from_currency TEXT NOT NULL,
+				to_currency TEXT NOT NULL,
+				rate BIGINT NOT NULL CHECK (rate > 0),
+				updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				PRIMARY KEY (from_currency, to_currency)
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS fx_quote(
+				id SERIAL PRIMARY KEY,
+				from_currency TEXT NOT NULL,
+				to_currency TEXT NOT NULL,
+				rate BIGINT NOT NULL CHECK (rate > 0),
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				used_at TIMESTAMP WITH TIME ZONE
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// converted transactions keep what they were requested in and the rate they were converted at
+		_, err = db.ExecContext(ctx, `
+			ALTER TABLE transaction
+				ADD COLUMN IF NOT EXISTS original_amount BIGINT,
+				ADD COLUMN IF NOT EXISTS original_currency TEXT,
+				ADD COLUMN IF NOT EXISTS fx_rate BIGINT,
+				ADD COLUMN IF NOT EXISTS fx_quote_id integer references fx_quote (id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
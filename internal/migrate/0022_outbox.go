@@ -0,0 +1,45 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// events are written along with the change they describe and published by the relay in id order
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS outbox_event(
+				id SERIAL PRIMARY KEY,
+				account_id integer references account (id) NOT NULL,
+				type TEXT NOT NULL,
+				payload JSONB NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+				published_at TIMESTAMP WITH TIME ZONE,
+				attempts integer NOT NULL DEFAULT 0,
+				last_error TEXT,
+				next_attempt_at TIMESTAMP WITH TIME ZONE,
+				parked_at TIMESTAMP WITH TIME ZONE
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// the relay only looks for the events that are neither published nor parked
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS outbox_event_pending_idx ON outbox_event (id) WHERE published_at IS NULL AND parked_at IS NULL;
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
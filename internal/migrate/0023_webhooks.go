@@ -0,0 +1,70 @@
+package migrate
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+func init() {
+	Migrations.MustRegister(func(ctx context.Context, db *bun.DB) error {
+
+		var err error
+
+		// webhooks subscribe a url to the events of some types, of every account or of a single one
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS webhook(
+				id SERIAL PRIMARY KEY,
+				url TEXT NOT NULL,
+				event_types TEXT[] NOT NULL,
+				account_id integer references account (id),
+				secret TEXT NOT NULL,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// deliveries are enqueued along with the event they carry and retried until they succeed or are dead lettered
+		_, err = db.ExecContext(ctx, `
+			CREATE TABLE IF NOT EXISTS webhook_delivery(
+				id SERIAL PRIMARY KEY,
+				webhook_id integer references webhook (id) NOT NULL,
+				event_id integer references outbox_event (id) NOT NULL,
+				event_type TEXT NOT NULL,
+				payload JSONB NOT NULL,
+				status TEXT NOT NULL,
+				attempts integer NOT NULL DEFAULT 0,
+				next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+				last_attempt_at TIMESTAMP WITH TIME ZONE,
+				response_status integer,
+				last_error TEXT,
+				created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+			);
+		`)
+		if err != nil {
+			return err
+		}
+
+		// the dispatcher only looks for the deliveries that are still pending
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS webhook_delivery_pending_idx ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+		`)
+		if err != nil {
+			return err
+		}
+
+		// deliveries are listed per webhook
+		_, err = db.ExecContext(ctx, `
+			CREATE INDEX IF NOT EXISTS webhook_delivery_webhook_idx ON webhook_delivery (webhook_id, id);
+		`)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	}, func(ctx context.Context, db *bun.DB) error {
+		return nil
+	})
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+// accountEventsChannel is notified with the account id of the events written to the outbox
+const accountEventsChannel = "account_events"
+
+type accountEventService struct {
+	db  *bun.DB
+	hub *models.AccountEventHub
+}
+
+func NewAccountEventService(db *bun.DB) *accountEventService {
+	return &accountEventService{
+		db:  db,
+		hub: models.NewAccountEventHub(),
+	}
+}
+
+func (aes *accountEventService) ListForAccount(ctx context.Context, accountID int64, afterID int64, limit int) ([]models.Event, error) {
+
+	revents := []models.Event{}
+
+	err := aes.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().
+			Model(&revents).
+			Where("account_id = ?", accountID).
+			Where("id > ?", afterID).
+			Order("id ASC").
+			Limit(limit).
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	return revents, err
+}
+
+func (aes *accountEventService) LatestID(ctx context.Context, accountID int64) (int64, error) {
+
+	latestID := int64(0)
+
+	err := aes.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		return tx.NewSelect().
+			Model((*models.Event)(nil)).
+			ColumnExpr("COALESCE(MAX(id), 0)").
+			Where("account_id = ?", accountID).
+			Scan(ctx, &latestID)
+	})
+
+	return latestID, err
+}
+
+func (aes *accountEventService) Subscribe(accountID int64) (<-chan struct{}, func()) {
+	return aes.hub.Subscribe(accountID)
+}
+
+// Listen relays the notifications of the events committed by every replica to the subscriptions, the
+// listener reconnects on its own and the events missed meanwhile are read back when the streams poll
+func (aes *accountEventService) Listen(ctx context.Context) error {
+
+	ln := pgdriver.NewListener(aes.db)
+	defer ln.Close()
+
+	if err := ln.Listen(ctx, accountEventsChannel); err != nil {
+		return err
+	}
+
+	// the events written before the listener was up are read back by the streams
+	aes.hub.NotifyAll()
+
+	notifications := ln.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification, ok := <-notifications:
+			if !ok {
+				return fmt.Errorf("account events listener closed")
+			}
+
+			accountID, err := strconv.ParseInt(notification.Payload, 10, 64)
+			if err != nil {
+				continue
+			}
+			aes.hub.Notify(accountID)
+		}
+	}
+}
+
+// notifyAccountEvent notifies the listeners of every replica of an event written within tx,
+// postgres delivers the notification once tx commits and drops it if it rolls back
+func notifyAccountEvent(ctx context.Context, tx bun.Tx, event models.Event) error {
+
+	_, err := tx.ExecContext(ctx, "SELECT pg_notify(?, ?)", accountEventsChannel, strconv.FormatInt(event.AccountID, 10))
+
+	return err
+}
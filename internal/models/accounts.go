@@ -6,6 +6,7 @@ import (
 	"errors"
 	"payments-backend-app/pkg/models"
 	"strings"
+	"time"
 
 	"github.com/uptrace/bun"
 )
@@ -26,6 +27,17 @@ func (as *accountsService) Create(ctx context.Context, account models.Account) (
 
 	err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
 
+		if err := claimIdempotencyKey(ctx, tx); err != nil {
+			return err
+		}
+
+		account.Status = models.AccountActive
+		account.CreditLimit = account.AvailableCreditLimit
+		if account.Currency == "" {
+			account.Currency = models.DefaultCurrency
+		}
+		account.CreatedAt = time.Now()
+
 		_, err := tx.NewInsert().Model(&account).Exec(ctx)
 		if err != nil {
 			return err
@@ -35,7 +47,16 @@ func (as *accountsService) Create(ctx context.Context, account models.Account) (
 			return err
 		}
 
-		return nil
+		event, err := models.NewAccountEvent(models.AccountCreatedEvent, raccount, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if err := insertEvent(ctx, tx, event); err != nil {
+			return err
+		}
+
+		return completeIdempotencyKey(ctx, tx, raccount)
 	})
 
 	if err != nil {
@@ -91,3 +112,287 @@ func (as *accountsService) DeleteForID(ctx context.Context, accountID int64) err
 
 	return err
 }
+
+func (as *accountsService) UpdateCreditLimit(ctx context.Context, accountID int64, limit *models.Money) (models.Account, error) {
+
+	raccount := models.Account{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&raccount).Where("id = ?", accountID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		raccount.SetCreditLimit(limit)
+
+		_, err := tx.NewUpdate().Model(&raccount).
+			Set("credit_limit = ?", raccount.CreditLimit).
+			Set("available_credit_limit = ?", raccount.AvailableCreditLimit).
+			Where("id = ?", accountID).
+			Exec(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return raccount, err
+}
+
+func (as *accountsService) UpdateSettlementStrategy(ctx context.Context, accountID int64, strategy *string) (models.Account, error) {
+
+	raccount := models.Account{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&raccount).Where("id = ?", accountID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		raccount.SettlementStrategy = strategy
+
+		_, err := tx.NewUpdate().Model(&raccount).
+			Set("settlement_strategy = ?", strategy).
+			Where("id = ?", accountID).
+			Exec(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return raccount, err
+}
+
+func (as *accountsService) UpdateBillingCycle(ctx context.Context, accountID int64, closingDay *int, dueDay *int) (models.Account, error) {
+
+	raccount := models.Account{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&raccount).Where("id = ?", accountID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		raccount.ClosingDay = closingDay
+		raccount.DueDay = dueDay
+
+		_, err := tx.NewUpdate().Model(&raccount).
+			Set("closing_day = ?", closingDay).
+			Set("due_day = ?", dueDay).
+			Where("id = ?", accountID).
+			Exec(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return raccount, err
+}
+
+func (as *accountsService) UpdateStatus(ctx context.Context, accountID int64, status models.AccountStatus, reason string) (models.Account, error) {
+
+	raccount := models.Account{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		// the row lock keeps transactions from being booked while the balances are checked
+		if err := tx.NewSelect().Model(&raccount).Where("id = ?", accountID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		change, err := raccount.ChangeStatus(status, reason, now)
+		if err != nil {
+			return err
+		}
+
+		if status == models.AccountClosed {
+			if err := checkSettled(ctx, tx, accountID, now); err != nil {
+				return err
+			}
+
+			if _, err := tx.NewUpdate().
+				Model((*models.Schedule)(nil)).
+				Set("status = ?", models.ScheduleCancelled).
+				Set("updated_at = ?", now).
+				Where("account_id = ?", accountID).
+				Where("status = ?", models.ScheduleActive).
+				Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.NewUpdate().Model(&raccount).
+			Set("status = ?", raccount.Status).
+			Where("id = ?", accountID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		if _, err := tx.NewInsert().Model(&change).Exec(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return raccount, err
+}
+
+// checkSettled fails with OutstandingBalanceErr unless every transaction of the account is settled,
+// no installment is left to post and no hold is pending
+func checkSettled(ctx context.Context, tx bun.Tx, accountID int64, now time.Time) error {
+
+	open, err := tx.NewSelect().
+		Model((*models.Transaction)(nil)).
+		Where("account_id = ?", accountID).
+		Where("balance <> 0").
+		Exists(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !open {
+		open, err = tx.NewSelect().
+			Model((*models.Installment)(nil)).
+			Where("account_id = ?", accountID).
+			Where("posted_at IS NULL").
+			Where("amount > 0").
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !open {
+		open, err = tx.NewSelect().
+			Model((*models.Authorization)(nil)).
+			Where("account_id = ?", accountID).
+			Where("status = ?", models.AuthorizationPending).
+			Where("expires_at > ?", now).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if open {
+		return models.OutstandingBalanceErr
+	}
+
+	return nil
+}
+
+func (as *accountsService) ListStatusHistory(ctx context.Context, accountID int64) ([]models.AccountStatusChange, error) {
+
+	rchanges := []models.AccountStatusChange{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&models.Account{}).Where("id = ?", accountID).Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&rchanges).
+			Where("account_id = ?", accountID).
+			OrderExpr("id DESC").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rchanges, err
+}
+
+func (as *accountsService) GetBalance(ctx context.Context, accountID int64) (models.AccountBalance, error) {
+
+	balance := models.AccountBalance{AccountID: accountID}
+	byOperationType := []models.OperationTypeBalance{}
+
+	// a repeatable read snapshot keeps the totals consistent with the account row
+	err := as.db.RunInTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&models.Account{}).Where("id = ?", accountID).Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			TableExpr("transaction AS t").
+			Join("JOIN operation_type AS ot ON ot.id = t.operation_type_id").
+			ColumnExpr("t.operation_type_id").
+			ColumnExpr("ot.description").
+			ColumnExpr("COALESCE(SUM(t.balance) FILTER (WHERE t.balance > 0), 0)::BIGINT AS available_credit").
+			ColumnExpr("COALESCE(-SUM(t.balance) FILTER (WHERE t.balance < 0), 0)::BIGINT AS outstanding_debt").
+			Where("t.account_id = ?", accountID).
+			GroupExpr("t.operation_type_id, ot.description").
+			OrderExpr("t.operation_type_id ASC").
+			Scan(ctx, &byOperationType); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model((*models.Authorization)(nil)).
+			ColumnExpr("COALESCE(SUM(amount), 0)::BIGINT").
+			Where("account_id = ?", accountID).
+			Where("status = ?", models.AuthorizationPending).
+			Where("expires_at > now()").
+			Scan(ctx, &balance.Held); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+		return balance, err
+	}
+
+	// every transaction of the account is in its currency, so the balances add up
+	for _, operationTypeBalance := range byOperationType {
+		balance.AvailableCredit += operationTypeBalance.AvailableCredit
+		balance.OutstandingDebt += operationTypeBalance.OutstandingDebt
+	}
+
+	balance.AvailableCredit = max(balance.AvailableCredit-balance.Held, 0)
+	balance.ByOperationType = byOperationType
+
+	return balance, nil
+}
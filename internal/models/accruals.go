@@ -0,0 +1,259 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var (
+	accrueBatchSize = 500
+)
+
+type accrualService struct {
+	db         *bun.DB
+	lateFee    models.Money
+	settlement models.SettlementStrategies
+}
+
+// NewAccrualService returns an accrual service that charges lateFee for every statement whose
+// minimum payment was missed, the charges are settled with the strategy of each account
+func NewAccrualService(db *bun.DB, lateFee models.Money, settlement models.SettlementStrategies) *accrualService {
+	return &accrualService{
+		db:         db,
+		lateFee:    lateFee,
+		settlement: settlement,
+	}
+}
+
+func (as *accrualService) ListForAccount(ctx context.Context, accountID int64) ([]models.Accrual, error) {
+
+	raccruals := []models.Accrual{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&models.Account{}).Where("id = ?", accountID).Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&raccruals).
+			Where("account_id = ?", accountID).
+			OrderExpr("accrual_date DESC, id DESC").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return raccruals, err
+}
+
+func (as *accrualService) Accrue(ctx context.Context, day time.Time) (int, error) {
+
+	day = models.AccrualDay(day)
+	accrued := 0
+	failed := models.BatchError{}
+	lastAccountID := int64(0)
+
+	for {
+		accounts := []models.Account{}
+		if err := as.db.NewSelect().
+			Model(&accounts).
+			Where("id > ?", lastAccountID).
+			OrderExpr("id ASC").
+			Limit(accrueBatchSize).
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return accrued, err
+		}
+
+		for _, account := range accounts {
+			lastAccountID = account.AccountID
+
+			charged, err := as.accrue(ctx, account.AccountID, day)
+			if err != nil {
+				failed[account.AccountID] = err
+				continue
+			}
+			accrued += charged
+		}
+
+		if len(accounts) < accrueBatchSize {
+			return accrued, failed.Err()
+		}
+	}
+}
+
+func (as *accrualService) LastAccrualDay(ctx context.Context) (time.Time, error) {
+
+	var day sql.NullTime
+	if err := as.db.NewSelect().
+		Model((*models.Accrual)(nil)).
+		ColumnExpr("MAX(accrual_date)").
+		Scan(ctx, &day); err != nil {
+		return time.Time{}, err
+	}
+
+	return day.Time, nil
+}
+
+// accrue posts the charges of the day for the account and returns how many were posted,
+// the account row lock serializes concurrent runs for the same day
+func (as *accrualService) accrue(ctx context.Context, accountID int64, day time.Time) (int, error) {
+
+	charged := 0
+	nextDay := day.AddDate(0, 0, 1)
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		account := models.Account{}
+		if err := tx.NewSelect().Model(&account).Where("id = ?", accountID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		accrued := []models.AccrualKind{}
+		if err := tx.NewSelect().
+			Model((*models.Accrual)(nil)).
+			Column("kind").
+			Where("account_id = ?", accountID).
+			Where("accrual_date = ?", day).
+			Scan(ctx, &accrued); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		isAccrued := func(kind models.AccrualKind) bool {
+			for _, accruedKind := range accrued {
+				if accruedKind == kind {
+					return true
+				}
+			}
+			return false
+		}
+
+		if !isAccrued(models.InterestAccrual) {
+			// interest is charged on what was open at the end of the day, not on what is open now
+			balances, err := interestBearingBalancesAsOf(ctx, tx, accountID, nextDay)
+			if err != nil {
+				return err
+			}
+
+			// charges are booked in whole minor units of the currency of the account
+			if interest := account.Currency.Round(models.DailyInterest(balances)); interest > 0 {
+				if err := as.charge(ctx, tx, accountID, day, models.InterestAccrual, interest, nil); err != nil {
+					return err
+				}
+				charged++
+			}
+		}
+
+		lateFee := account.Currency.Round(as.lateFee)
+		if !isAccrued(models.LateFeeAccrual) && lateFee > 0 {
+			statement := models.Statement{}
+			err := tx.NewSelect().
+				Model(&statement).
+				Where("account_id = ?", accountID).
+				Where("due_date >= ?", day).
+				Where("due_date < ?", nextDay).
+				Limit(1).
+				Scan(ctx)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			if err == nil {
+				var paid models.Money
+				if err := tx.NewSelect().
+					Model((*models.Transaction)(nil)).
+					ColumnExpr("COALESCE(SUM(amount), 0)::BIGINT").
+					Where("account_id = ?", accountID).
+					Where("amount > 0").
+					Where("event_date >= ?", statement.PeriodEnd).
+					Where("event_date < ?", nextDay).
+					Scan(ctx, &paid); err != nil {
+					return err
+				}
+
+				if models.IsLateFeeDue(statement, paid) {
+					if err := as.charge(ctx, tx, accountID, day, models.LateFeeAccrual, lateFee, &statement.ID); err != nil {
+						return err
+					}
+					charged++
+				}
+			}
+		}
+
+		return nil
+	})
+
+	return charged, err
+}
+
+// interestBearingBalancesAsOf returns the open balances the debits of the account booked before at had at that
+// time along with the rate of their operation type, with the installments posted and the allocations made since undone
+func interestBearingBalancesAsOf(ctx context.Context, tx bun.Tx, accountID int64, at time.Time) ([]models.InterestBearingBalance, error) {
+
+	balances := []models.InterestBearingBalance{}
+
+	err := tx.NewRaw(`
+		SELECT b.balance, b.apr_bps FROM (
+			SELECT (t.balance
+				+ (SELECT COALESCE(SUM(i.amount), 0) FROM installment AS i
+					WHERE i.transaction_id = t.id AND i.posted_at >= ?)
+				- (SELECT COALESCE(SUM(al.amount), 0) FROM allocation AS al
+					WHERE al.debit_transaction_id = t.id AND al.allocated_at >= ?)
+				+ (SELECT COALESCE(SUM(al.amount), 0) FROM allocation AS al
+					WHERE al.credit_transaction_id = t.id AND al.allocated_at >= ?)
+			)::BIGINT AS balance, ot.apr_bps
+			FROM transaction AS t JOIN operation_type AS ot ON ot.id = t.operation_type_id
+			WHERE t.account_id = ? AND t.event_date < ? AND ot.apr_bps > 0
+		) AS b
+		WHERE b.balance < 0
+	`, at, at, at, accountID, at).Scan(ctx, &balances)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// charge books the charge as a transaction of the account and records the accrual of the day
+func (as *accrualService) charge(ctx context.Context, tx bun.Tx, accountID int64, day time.Time, kind models.AccrualKind, amount models.Money, statementID *int64) error {
+
+	operationTypeID := models.Interest
+	if kind == models.LateFeeAccrual {
+		operationTypeID = models.LateFee
+	}
+
+	transactionStatus, err := createTransaction(ctx, tx, as.settlement, models.Transaction{
+		AccountID:       accountID,
+		OperationTypeID: int64(operationTypeID),
+		Amount:          -amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NewInsert().Model(&models.Accrual{
+		AccountID:     accountID,
+		AccrualDate:   day,
+		Kind:          kind,
+		Amount:        amount,
+		TransactionID: transactionStatus.TransactionID,
+		StatementID:   statementID,
+		CreatedAt:     time.Now(),
+	}).Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+
+	"github.com/uptrace/bun"
+)
+
+type allocationService struct {
+	db *bun.DB
+}
+
+func NewAllocationService(db *bun.DB) *allocationService {
+	return &allocationService{
+		db: db,
+	}
+}
+
+func (as *allocationService) ListForTransaction(ctx context.Context, transactionID int64) ([]models.Allocation, error) {
+
+	rallocations := []models.Allocation{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&models.Transaction{}).Where("id = ?", transactionID).Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&rallocations).
+			WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.Where("credit_transaction_id = ?", transactionID).WhereOr("debit_transaction_id = ?", transactionID)
+			}).
+			OrderExpr("allocated_at ASC, id ASC").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rallocations, err
+}
@@ -0,0 +1,291 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var (
+	expireStaleBatchSize = 500
+)
+
+type authorizationService struct {
+	db         *bun.DB
+	holdTTL    time.Duration
+	settlement models.SettlementStrategies
+}
+
+// NewAuthorizationService returns an authorization service whose holds expire after holdTTL,
+// captured debits are settled like the transactions created directly
+func NewAuthorizationService(db *bun.DB, holdTTL time.Duration, settlement models.SettlementStrategies) *authorizationService {
+	return &authorizationService{
+		db:         db,
+		holdTTL:    holdTTL,
+		settlement: settlement,
+	}
+}
+
+func (as *authorizationService) Create(ctx context.Context, authorization models.Authorization) (models.Authorization, error) {
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := claimIdempotencyKey(ctx, tx); err != nil {
+			return err
+		}
+
+		account := models.Account{}
+		if err := tx.NewSelect().Model(&account).Where("id = ?", authorization.AccountID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		if !account.AcceptsTransaction(authorization.OperationTypeID) {
+			return models.AccountNotActiveErr
+		}
+
+		// holds are in the currency of the account, as the transactions they are captured into
+		if err := account.Currency.CheckAmount(authorization.Amount); err != nil {
+			return err
+		}
+
+		// the hold reserves the credit limit until it is captured or released
+		if err := updateCreditLimit(ctx, tx, &account, -authorization.Amount); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		authorization.Status = models.AuthorizationPending
+		authorization.CapturedAmount = 0
+		authorization.TransactionID = nil
+		authorization.CreatedAt = now
+		authorization.UpdatedAt = now
+		authorization.ExpiresAt = now.Add(as.holdTTL)
+
+		if _, err := tx.NewInsert().Model(&authorization).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+
+		return completeIdempotencyKey(ctx, tx, authorization)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return authorization, err
+}
+
+func (as *authorizationService) GetForID(ctx context.Context, authorizationID int64) (models.Authorization, error) {
+
+	rauthorization := models.Authorization{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&rauthorization).Where("id = ?", authorizationID).Scan(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rauthorization, err
+}
+
+func (as *authorizationService) Capture(ctx context.Context, authorizationID int64, amount models.Money) (models.Authorization, error) {
+
+	rauthorization := models.Authorization{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := claimIdempotencyKey(ctx, tx); err != nil {
+			return err
+		}
+
+		authorization, account, err := lockPendingAuthorization(ctx, tx, authorizationID)
+		if err != nil {
+			return err
+		}
+
+		// stale holds are left for the expiry job
+		if authorization.IsExpired(time.Now()) {
+			return models.AuthorizationNotPendingErr
+		}
+
+		if amount == 0 {
+			amount = authorization.Amount
+		}
+
+		if amount < 0 || amount > authorization.Amount {
+			return models.CaptureAmountExceededErr
+		}
+
+		// give the hold back to the limit, the captured debit consumes it again
+		if err := updateCreditLimit(ctx, tx, &account, authorization.Amount); err != nil {
+			return err
+		}
+
+		transactionStatus, err := createTransaction(ctx, tx, as.settlement, models.Transaction{
+			AccountID:       authorization.AccountID,
+			OperationTypeID: authorization.OperationTypeID,
+			Amount:          -amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		authorization.Status = models.AuthorizationCaptured
+		authorization.CapturedAmount = amount
+		authorization.TransactionID = &transactionStatus.TransactionID
+		authorization.UpdatedAt = time.Now()
+
+		if _, err := tx.NewUpdate().Model(&authorization).
+			Column("status", "captured_amount", "transaction_id", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		rauthorization = authorization
+		return completeIdempotencyKey(ctx, tx, rauthorization)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rauthorization, err
+}
+
+func (as *authorizationService) Release(ctx context.Context, authorizationID int64) (models.Authorization, error) {
+
+	rauthorization := models.Authorization{}
+
+	err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		authorization, account, err := lockPendingAuthorization(ctx, tx, authorizationID)
+		if err != nil {
+			return err
+		}
+
+		rauthorization, err = releaseAuthorization(ctx, tx, authorization, account, models.AuthorizationReleased)
+
+		return err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rauthorization, err
+}
+
+func (as *authorizationService) ExpireStale(ctx context.Context, now time.Time) (int, error) {
+
+	expired := 0
+
+	for {
+		stale := []models.Authorization{}
+		if err := as.db.NewSelect().
+			Model(&stale).
+			Where("status = ?", models.AuthorizationPending).
+			Where("expires_at <= ?", now).
+			OrderExpr("expires_at ASC, id ASC").
+			Limit(expireStaleBatchSize).
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return expired, err
+		}
+
+		for _, authorization := range stale {
+			err := as.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+				authorization, account, err := lockPendingAuthorization(ctx, tx, authorization.ID)
+				if err != nil {
+					return err
+				}
+
+				_, err = releaseAuthorization(ctx, tx, authorization, account, models.AuthorizationExpired)
+
+				return err
+			})
+
+			switch {
+			case errors.Is(err, models.AuthorizationNotPendingErr):
+				// captured or released concurrently
+			case err != nil:
+				return expired, err
+			default:
+				expired++
+			}
+		}
+
+		if len(stale) < expireStaleBatchSize {
+			return expired, nil
+		}
+	}
+}
+
+// lockPendingAuthorization locks the account of the hold, in the same order as
+// transactions do, and then the hold itself, which must still be pending
+func lockPendingAuthorization(ctx context.Context, tx bun.Tx, authorizationID int64) (models.Authorization, models.Account, error) {
+
+	authorization := models.Authorization{}
+	account := models.Account{}
+
+	if err := tx.NewSelect().Model(&authorization).Where("id = ?", authorizationID).Scan(ctx); err != nil {
+		return authorization, account, err
+	}
+
+	if err := tx.NewSelect().Model(&account).Where("id = ?", authorization.AccountID).For("UPDATE").Scan(ctx); err != nil {
+		return authorization, account, err
+	}
+
+	if err := tx.NewSelect().Model(&authorization).Where("id = ?", authorizationID).For("UPDATE").Scan(ctx); err != nil {
+		return authorization, account, err
+	}
+
+	if authorization.Status != models.AuthorizationPending {
+		return authorization, account, models.AuthorizationNotPendingErr
+	}
+
+	return authorization, account, nil
+}
+
+// releaseAuthorization gives the held amount back to the credit limit and closes the hold with status
+func releaseAuthorization(ctx context.Context, tx bun.Tx, authorization models.Authorization, account models.Account, status models.AuthorizationStatus) (models.Authorization, error) {
+
+	if err := updateCreditLimit(ctx, tx, &account, authorization.Amount); err != nil {
+		return authorization, err
+	}
+
+	authorization.Status = status
+	authorization.UpdatedAt = time.Now()
+
+	if _, err := tx.NewUpdate().Model(&authorization).
+		Column("status", "updated_at").
+		WherePK().
+		Exec(ctx); err != nil {
+		return authorization, err
+	}
+
+	return authorization, nil
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type fxService struct {
+	db       *bun.DB
+	quoteTTL time.Duration
+}
+
+// NewFXService returns an fx service whose quotes lock the rate of their pair for quoteTTL
+func NewFXService(db *bun.DB, quoteTTL time.Duration) *fxService {
+	return &fxService{
+		db:       db,
+		quoteTTL: quoteTTL,
+	}
+}
+
+func (fs *fxService) SetRate(ctx context.Context, rate models.FXRate) (models.FXRate, error) {
+
+	rate.UpdatedAt = time.Now()
+
+	err := fs.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		_, err := tx.NewInsert().
+			Model(&rate).
+			On("CONFLICT (from_currency, to_currency) DO UPDATE").
+			Set("rate = EXCLUDED.rate").
+			Set("updated_at = EXCLUDED.updated_at").
+			Exec(ctx)
+
+		return err
+	})
+
+	return rate, err
+}
+
+func (fs *fxService) GetRate(ctx context.Context, from models.Currency, to models.Currency) (models.FXRate, error) {
+
+	rrate := models.FXRate{}
+
+	err := fs.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		return tx.NewSelect().
+			Model(&rrate).
+			Where("from_currency = ?", from).
+			Where("to_currency = ?", to).
+			Scan(ctx)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rrate, err
+}
+
+func (fs *fxService) ListRates(ctx context.Context) ([]models.FXRate, error) {
+
+	rrates := []models.FXRate{}
+
+	err := fs.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().
+			Model(&rrates).
+			OrderExpr("from_currency ASC, to_currency ASC").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	return rrates, err
+}
+
+func (fs *fxService) CreateQuote(ctx context.Context, from models.Currency, to models.Currency) (models.FXQuote, error) {
+
+	quote := models.FXQuote{}
+
+	err := fs.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		rate := models.FXRate{}
+		if err := tx.NewSelect().
+			Model(&rate).
+			Where("from_currency = ?", from).
+			Where("to_currency = ?", to).
+			Scan(ctx); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w, no rate from %s to %s", models.ConversionUnavailableErr, from, to)
+			}
+			return err
+		}
+
+		now := time.Now()
+		quote = models.FXQuote{
+			From:      from,
+			To:        to,
+			Rate:      rate.Rate,
+			CreatedAt: now,
+			ExpiresAt: now.Add(fs.quoteTTL),
+		}
+
+		_, err := tx.NewInsert().Model(&quote).Returning("id").Exec(ctx)
+
+		return err
+	})
+
+	return quote, err
+}
+
+func (fs *fxService) GetQuote(ctx context.Context, quoteID int64) (models.FXQuote, error) {
+
+	rquote := models.FXQuote{}
+
+	err := fs.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		return tx.NewSelect().Model(&rquote).Where("id = ?", quoteID).Scan(ctx)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rquote, err
+}
+
+// useFXQuote locks the quote the transaction was converted with and marks it used within tx, so that
+// the quote books a single transaction and only while its rate is locked
+func useFXQuote(ctx context.Context, tx bun.Tx, transaction models.Transaction, now time.Time) error {
+
+	quote := models.FXQuote{}
+	if err := tx.NewSelect().Model(&quote).Where("id = ?", *transaction.FXQuoteID).For("UPDATE").Scan(ctx); err != nil {
+		return err
+	}
+
+	if err := quote.CheckBooks(transaction, now); err != nil {
+		return err
+	}
+
+	_, err := tx.NewUpdate().Model(&quote).Set("used_at = ?", now).WherePK().Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type idempotencyService struct {
+	db *bun.DB
+}
+
+func NewIdempotencyService(db *bun.DB) *idempotencyService {
+	return &idempotencyService{
+		db: db,
+	}
+}
+
+func (is *idempotencyService) GetForKey(ctx context.Context, scope string, key string) (models.IdempotencyRecord, error) {
+
+	record := models.IdempotencyRecord{}
+
+	err := is.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&record).
+			Where("scope = ?", scope).
+			Where("key = ?", key).
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return record, err
+}
+
+func (is *idempotencyService) Complete(ctx context.Context, record models.IdempotencyRecord) error {
+
+	record.CreatedAt = time.Now()
+
+	err := is.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		// the claim of a failed request was rolled back with it, so the response claims the key again
+		res, err := tx.NewInsert().Model(&record).
+			On("CONFLICT (scope, key) DO UPDATE").
+			Set("status_code = EXCLUDED.status_code").
+			Set("response_body = EXCLUDED.response_body").
+			Where("ik.request_hash = EXCLUDED.request_hash").
+			Where("ik.status_code IS NULL").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if count, _ := res.RowsAffected(); count == 0 {
+			return sql.ErrNoRows
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return err
+}
+
+func (is *idempotencyService) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+
+	var deleted int64
+
+	err := is.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		res, err := tx.NewDelete().Model(&models.IdempotencyRecord{}).
+			Where("created_at < ?", before).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		deleted, err = res.RowsAffected()
+		return err
+	})
+
+	return deleted, err
+}
+
+// claimIdempotencyKey registers the idempotency key present in the context
+// within the callers transaction, the claim is rolled back along with the
+// transaction if the create operation fails
+func claimIdempotencyKey(ctx context.Context, tx bun.Tx) error {
+
+	key, ok := models.IdempotencyKeyFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	record := models.IdempotencyRecord{
+		Scope:       key.Scope,
+		Key:         key.Key,
+		RequestHash: key.RequestHash,
+		CreatedAt:   time.Now(),
+	}
+
+	// a concurrent claim for the same key blocks here until the other
+	// transaction is done, after which the conflict is detected
+	res, err := tx.NewInsert().Model(&record).On("CONFLICT (scope, key) DO NOTHING").Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if count, _ := res.RowsAffected(); count == 1 {
+		return nil
+	}
+
+	existing := models.IdempotencyRecord{}
+	if err := tx.NewSelect().Model(&existing).
+		Where("scope = ?", key.Scope).
+		Where("key = ?", key.Key).
+		Scan(ctx); err != nil {
+		return err
+	}
+
+	switch {
+	case existing.RequestHash != key.RequestHash:
+		return models.IdempotencyKeyMismatchErr
+	case !existing.IsComplete():
+		return models.IdempotencyKeyInProgressErr
+	default:
+		return models.IdempotencyKeyReplayErr
+	}
+}
+
+// completeIdempotencyKey stores the response for the result of the request along with the claim
+// of the idempotency key present in the context, within the callers transaction
+func completeIdempotencyKey(ctx context.Context, tx bun.Tx, result any) error {
+
+	key, ok := models.IdempotencyKeyFromContext(ctx)
+	if !ok || key.Response == nil {
+		return nil
+	}
+
+	statusCode, body, err := key.Response(result)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.NewUpdate().Model((*models.IdempotencyRecord)(nil)).
+		Set("status_code = ?", statusCode).
+		Set("response_body = ?", body).
+		Where("scope = ?", key.Scope).
+		Where("key = ?", key.Key).
+		Exec(ctx)
+
+	return err
+}
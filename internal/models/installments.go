@@ -0,0 +1,195 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var (
+	postDueBatchSize = 500
+)
+
+type installmentService struct {
+	db         *bun.DB
+	settlement models.SettlementStrategies
+}
+
+func NewInstallmentService(db *bun.DB, settlement models.SettlementStrategies) *installmentService {
+	return &installmentService{
+		db:         db,
+		settlement: settlement,
+	}
+}
+
+func (is *installmentService) ListForTransaction(ctx context.Context, transactionID int64) ([]models.Installment, error) {
+
+	rinstallments := []models.Installment{}
+
+	err := is.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&models.Transaction{}).Where("id = ?", transactionID).Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&rinstallments).
+			Where("transaction_id = ?", transactionID).
+			OrderExpr("number ASC").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rinstallments, err
+}
+
+func (is *installmentService) PostDue(ctx context.Context, until time.Time) (int, error) {
+
+	posted := 0
+	failed := models.BatchError{}
+	last := models.Installment{}
+
+	for {
+		// the installments that failed are not posted, the batches go past them so that they are not listed again
+		due := []models.Installment{}
+		if err := is.db.NewSelect().
+			Model(&due).
+			Where("posted_at IS NULL").
+			Where("due_date <= ?", until).
+			Where("(due_date, id) > (?, ?)", last.DueDate, last.ID).
+			OrderExpr("due_date ASC, id ASC").
+			Limit(postDueBatchSize).
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return posted, err
+		}
+
+		for _, installment := range due {
+			last = installment
+
+			ok, err := is.post(ctx, installment)
+			if err != nil {
+				failed[installment.ID] = err
+				continue
+			}
+			if ok {
+				posted++
+			}
+		}
+
+		if len(due) < postDueBatchSize {
+			return posted, failed.Err()
+		}
+	}
+}
+
+// post adds the installment to the open balance of its purchase, settling it against
+// the credits of the account, and returns false if it was already posted concurrently
+func (is *installmentService) post(ctx context.Context, installment models.Installment) (bool, error) {
+
+	ok := false
+
+	err := is.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		account := models.Account{}
+		if err := tx.NewSelect().Model(&account).Where("id = ?", installment.AccountID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&installment).
+			Where("id = ?", installment.ID).
+			Where("posted_at IS NULL").
+			For("UPDATE").
+			Scan(ctx); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		}
+
+		openBalance, allocations, err := dischargeCredits(ctx, tx, is.settlement.ForAccount(account), installment.AccountID, -installment.Amount)
+		if err != nil {
+			return err
+		}
+
+		postedAt := time.Now()
+		if err := insertAllocations(ctx, tx, allocations, installment.TransactionID, postedAt); err != nil {
+			return err
+		}
+
+		if err := writeSettledEvents(ctx, tx, installment.TransactionID, allocations, postedAt); err != nil {
+			return err
+		}
+
+		if _, err := tx.NewUpdate().Model(&models.Transaction{}).
+			Set("balance = balance + ?", openBalance).
+			Where("id = ?", installment.TransactionID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		if _, err := tx.NewUpdate().Model(&installment).
+			Set("posted_at = ?", postedAt).
+			Where("id = ?", installment.ID).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		ok = true
+		return nil
+	})
+
+	return ok, err
+}
+
+// cancelPendingInstallments reduces the installments of a transaction that are not posted yet,
+// latest first, by up to amount and returns how much was cancelled
+func cancelPendingInstallments(ctx context.Context, tx bun.Tx, transactionID int64, amount models.Money) (models.Money, error) {
+
+	pending := []models.Installment{}
+
+	if err := tx.NewSelect().
+		Model(&pending).
+		Where("transaction_id = ?", transactionID).
+		Where("posted_at IS NULL").
+		OrderExpr("number DESC").
+		For("UPDATE").
+		Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	remaining := amount
+
+	for _, installment := range pending {
+		if remaining == 0 {
+			break
+		}
+
+		cancelled := min(remaining, installment.Amount)
+		remaining -= cancelled
+
+		_, err := tx.NewUpdate().Model(&installment).
+			Set("amount = ?", installment.Amount-cancelled).
+			Where("id = ?", installment.ID).
+			Exec(ctx)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return amount - remaining, nil
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+
+	"github.com/uptrace/bun"
+)
+
+type ledgerService struct {
+	db *bun.DB
+}
+
+func NewLedgerService(db *bun.DB) *ledgerService {
+	return &ledgerService{
+		db: db,
+	}
+}
+
+func (ls *ledgerService) ListForTransaction(ctx context.Context, transactionID int64) ([]models.JournalEntry, error) {
+
+	rentries := []models.JournalEntry{}
+
+	err := ls.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&models.Transaction{}).Where("id = ?", transactionID).Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&rentries).
+			Relation("Postings", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.OrderExpr("p.id ASC")
+			}).
+			Where("je.transaction_id = ?", transactionID).
+			OrderExpr("je.id ASC").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rentries, err
+}
+
+func (ls *ledgerService) Verify(ctx context.Context) ([]int64, error) {
+
+	unbalanced := []int64{}
+
+	// an entry without postings or with a single leg is as broken as one that does not sum to zero,
+	// amounts of different currencies never offset each other
+	if err := ls.db.NewSelect().
+		TableExpr("journal_entry AS je").
+		ColumnExpr("je.id").
+		Join("LEFT JOIN posting AS p ON p.journal_entry_id = je.id").
+		GroupExpr("je.id").
+		Having("COUNT(p.id) < 2 OR EXISTS (?)", ls.db.NewSelect().
+			TableExpr("posting AS pc").
+			ColumnExpr("1").
+			Where("pc.journal_entry_id = je.id").
+			GroupExpr("pc.currency").
+			Having("SUM(pc.amount) <> 0")).
+		OrderExpr("je.id ASC").
+		Scan(ctx, &unbalanced); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	return unbalanced, nil
+}
+
+// postJournalEntry records a booked transaction in the ledger within tx
+func postJournalEntry(ctx context.Context, tx bun.Tx, transaction models.Transaction) error {
+
+	entry := models.NewJournalEntry(transaction)
+
+	if _, err := tx.NewInsert().Model(&entry).Returning("id").Exec(ctx); err != nil {
+		return err
+	}
+
+	for i := range entry.Postings {
+		entry.Postings[i].JournalEntryID = entry.ID
+	}
+
+	_, err := tx.NewInsert().Model(&entry.Postings).Exec(ctx)
+
+	return err
+}
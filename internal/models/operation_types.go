@@ -0,0 +1,231 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type operationTypeService struct {
+	db *bun.DB
+}
+
+func NewOperationTypeService(db *bun.DB) *operationTypeService {
+	return &operationTypeService{
+		db: db,
+	}
+}
+
+func (ots *operationTypeService) Create(ctx context.Context, operationType models.OperationType) (models.OperationType, error) {
+
+	roperationType := models.OperationType{}
+
+	err := ots.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if _, err := tx.NewInsert().Model(&operationType).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().Model(&roperationType).Where("id = ?", operationType.ID).Scan(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return roperationType, err
+}
+
+func (ots *operationTypeService) GetForID(ctx context.Context, operationTypeID int64) (models.OperationType, error) {
+
+	roperationType := models.OperationType{}
+
+	err := ots.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&roperationType).Where("id = ?", operationTypeID).Scan(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return roperationType, err
+}
+
+func (ots *operationTypeService) List(ctx context.Context) ([]models.OperationType, error) {
+
+	roperationTypes := []models.OperationType{}
+
+	err := ots.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&roperationTypes).OrderExpr("id ASC").Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	return roperationTypes, err
+}
+
+func (ots *operationTypeService) Update(ctx context.Context, update models.OperationTypeUpdate) (models.OperationType, error) {
+
+	roperationType := models.OperationType{}
+
+	err := ots.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&roperationType).Where("id = ?", update.ID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		if update.Description != nil {
+			roperationType.Description = *update.Description
+		}
+
+		if update.Active != nil {
+			roperationType.Active = *update.Active
+		}
+
+		if update.APRBasisPoints != nil {
+			roperationType.APRBasisPoints = *update.APRBasisPoints
+		}
+
+		_, err := tx.NewUpdate().Model(&roperationType).
+			Column("description", "active", "apr_bps").
+			Where("id = ?", update.ID).
+			Exec(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return roperationType, err
+}
+
+// cachedOperationTypeService serves reads from a snapshot of all the operation types
+// that is refreshed after the ttl, writes go through and invalidate the snapshot
+type cachedOperationTypeService struct {
+	operationTypeService models.OperationTypeService
+	ttl                  time.Duration
+
+	mu             sync.RWMutex
+	operationTypes map[int64]models.OperationType
+	loadedAt       time.Time
+
+	// generation is bumped by every invalidation, a snapshot loaded while it moved may miss
+	// the write that bumped it and is not kept
+	generation uint64
+}
+
+func NewCachedOperationTypeService(operationTypeService models.OperationTypeService, ttl time.Duration) *cachedOperationTypeService {
+	return &cachedOperationTypeService{
+		operationTypeService: operationTypeService,
+		ttl:                  ttl,
+	}
+}
+
+func (cots *cachedOperationTypeService) snapshot(ctx context.Context) (map[int64]models.OperationType, error) {
+
+	cots.mu.RLock()
+	operationTypes, loadedAt, generation := cots.operationTypes, cots.loadedAt, cots.generation
+	cots.mu.RUnlock()
+
+	if operationTypes != nil && time.Since(loadedAt) < cots.ttl {
+		return operationTypes, nil
+	}
+
+	list, err := cots.operationTypeService.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	operationTypes = make(map[int64]models.OperationType, len(list))
+	for _, operationType := range list {
+		operationTypes[operationType.ID] = operationType
+	}
+
+	cots.mu.Lock()
+	if cots.generation == generation {
+		cots.operationTypes, cots.loadedAt = operationTypes, time.Now()
+	}
+	cots.mu.Unlock()
+
+	return operationTypes, nil
+}
+
+func (cots *cachedOperationTypeService) invalidate() {
+	cots.mu.Lock()
+	cots.operationTypes = nil
+	cots.generation++
+	cots.mu.Unlock()
+}
+
+func (cots *cachedOperationTypeService) Create(ctx context.Context, operationType models.OperationType) (models.OperationType, error) {
+	defer cots.invalidate()
+	return cots.operationTypeService.Create(ctx, operationType)
+}
+
+func (cots *cachedOperationTypeService) GetForID(ctx context.Context, operationTypeID int64) (models.OperationType, error) {
+
+	operationTypes, err := cots.snapshot(ctx)
+	if err != nil {
+		return models.OperationType{}, err
+	}
+
+	operationType, ok := operationTypes[operationTypeID]
+	if !ok {
+		return models.OperationType{}, models.NoRecordErr
+	}
+
+	return operationType, nil
+}
+
+func (cots *cachedOperationTypeService) List(ctx context.Context) ([]models.OperationType, error) {
+
+	operationTypes, err := cots.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]models.OperationType, 0, len(operationTypes))
+	for _, operationType := range operationTypes {
+		list = append(list, operationType)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+
+	return list, nil
+}
+
+func (cots *cachedOperationTypeService) Update(ctx context.Context, update models.OperationTypeUpdate) (models.OperationType, error) {
+	defer cots.invalidate()
+	return cots.operationTypeService.Update(ctx, update)
+}
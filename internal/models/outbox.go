@@ -0,0 +1,204 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// outboxRelayLockID is the key of the advisory lock held by the relay publishing the outbox
+const outboxRelayLockID = 7_302_348_107
+
+type outboxService struct {
+	db *bun.DB
+}
+
+func NewOutboxService(db *bun.DB) *outboxService {
+	return &outboxService{
+		db: db,
+	}
+}
+
+// LockRelay takes a session advisory lock on a connection of its own, it is released along
+// with the connection if the app stops before the returned func is called
+func (obs *outboxService) LockRelay(ctx context.Context) (func(), bool, error) {
+
+	conn, err := obs.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	locked := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(?)", outboxRelayLockID).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(?)", outboxRelayLockID)
+		conn.Close()
+	}
+
+	return release, true, nil
+}
+
+func (obs *outboxService) ListPending(ctx context.Context, afterID int64, limit int) ([]models.Event, error) {
+
+	revents := []models.Event{}
+
+	err := obs.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		return tx.NewSelect().
+			Model(&revents).
+			Where("published_at IS NULL").
+			Where("parked_at IS NULL").
+			Where("id > ?", afterID).
+			Order("id ASC").
+			Limit(limit).
+			Scan(ctx)
+	})
+
+	return revents, err
+}
+
+func (obs *outboxService) MarkPublished(ctx context.Context, eventID int64, publishedAt time.Time) error {
+
+	return obs.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		_, err := tx.NewUpdate().
+			Model((*models.Event)(nil)).
+			Set("published_at = ?", publishedAt).
+			Set("attempts = attempts + 1").
+			Where("id = ?", eventID).
+			Exec(ctx)
+
+		return err
+	})
+}
+
+func (obs *outboxService) MarkFailed(ctx context.Context, eventID int64, cause string, nextAttemptAt time.Time) error {
+
+	return obs.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		_, err := tx.NewUpdate().
+			Model((*models.Event)(nil)).
+			Set("attempts = attempts + 1").
+			Set("last_error = ?", cause).
+			Set("next_attempt_at = ?", nextAttemptAt).
+			Where("id = ?", eventID).
+			Exec(ctx)
+
+		return err
+	})
+}
+
+func (obs *outboxService) Park(ctx context.Context, eventID int64, cause string, parkedAt time.Time) error {
+
+	return obs.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		_, err := tx.NewUpdate().
+			Model((*models.Event)(nil)).
+			Set("attempts = attempts + 1").
+			Set("last_error = ?", cause).
+			Set("next_attempt_at = NULL").
+			Set("parked_at = ?", parkedAt).
+			Where("id = ?", eventID).
+			Exec(ctx)
+
+		return err
+	})
+}
+
+// insertEvent writes an event to the outbox within the transaction of the change it describes,
+// along with its deliveries to the webhooks that subscribe it and the notification of its streams
+func insertEvent(ctx context.Context, tx bun.Tx, event models.Event) error {
+
+	if _, err := tx.NewInsert().Model(&event).Returning("id").Exec(ctx); err != nil {
+		return err
+	}
+
+	if err := enqueueDeliveries(ctx, tx, event); err != nil {
+		return err
+	}
+
+	return notifyAccountEvent(ctx, tx, event)
+}
+
+// writeTransactionEvents writes the TransactionCreated event of a transaction booked within tx, then the
+// TransactionSettled events of the transactions its allocations settled and its own when it is settled
+func writeTransactionEvents(ctx context.Context, tx bun.Tx, transaction models.Transaction, allocations []models.Allocation) error {
+
+	event, err := models.NewTransactionEvent(models.TransactionCreatedEvent, transaction, transaction.EventDate)
+	if err != nil {
+		return err
+	}
+
+	if err := insertEvent(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := writeSettledEvents(ctx, tx, transaction.ID, allocations, transaction.EventDate); err != nil {
+		return err
+	}
+
+	if transaction.Balance != 0 {
+		return nil
+	}
+
+	event, err = models.NewTransactionEvent(models.TransactionSettledEvent, transaction, transaction.EventDate)
+	if err != nil {
+		return err
+	}
+
+	return insertEvent(ctx, tx, event)
+}
+
+// writeSettledEvents writes the TransactionSettled events of the transactions on the other side of the
+// allocations of transactionID whose balance they brought to zero
+func writeSettledEvents(ctx context.Context, tx bun.Tx, transactionID int64, allocations []models.Allocation, settledAt time.Time) error {
+
+	ids := []int64{}
+	for _, allocation := range allocations {
+		for _, id := range []int64{allocation.CreditTransactionID, allocation.DebitTransactionID} {
+			if id != 0 && id != transactionID {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	settled := []models.Transaction{}
+	if err := tx.NewSelect().
+		Model(&settled).
+		Where("id IN (?)", bun.In(ids)).
+		Where("balance = 0").
+		Order("id ASC").
+		Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	for _, transaction := range settled {
+		event, err := models.NewTransactionEvent(models.TransactionSettledEvent, transaction, settledAt)
+		if err != nil {
+			return err
+		}
+
+		if err := insertEvent(ctx, tx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
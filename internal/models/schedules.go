@@ -0,0 +1,237 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type scheduleService struct {
+	db *bun.DB
+}
+
+func NewScheduleService(db *bun.DB) *scheduleService {
+	return &scheduleService{
+		db: db,
+	}
+}
+
+func (ss *scheduleService) Create(ctx context.Context, schedule models.Schedule) (models.Schedule, error) {
+
+	err := ss.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := claimIdempotencyKey(ctx, tx); err != nil {
+			return err
+		}
+
+		account := models.Account{}
+		if err := tx.NewSelect().Model(&account).Where("id = ?", schedule.AccountID).Scan(ctx); err != nil {
+			return err
+		}
+
+		// frozen accounts may be unfrozen before the first run, closed ones never take a transaction again
+		if account.Status == models.AccountClosed {
+			return models.AccountNotActiveErr
+		}
+
+		if err := account.Currency.CheckAmount(schedule.Amount); err != nil {
+			return err
+		}
+
+		schedule = models.NewSchedule(schedule, time.Now())
+
+		if _, err := tx.NewInsert().Model(&schedule).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+
+		return completeIdempotencyKey(ctx, tx, schedule)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return schedule, err
+}
+
+func (ss *scheduleService) GetForID(ctx context.Context, scheduleID int64) (models.Schedule, error) {
+
+	rschedule := models.Schedule{}
+
+	err := ss.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&rschedule).Where("id = ?", scheduleID).Scan(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rschedule, err
+}
+
+func (ss *scheduleService) Cancel(ctx context.Context, scheduleID int64) (models.Schedule, error) {
+
+	rschedule := models.Schedule{}
+
+	err := ss.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&rschedule).Where("id = ?", scheduleID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		if rschedule.Status != models.ScheduleActive {
+			return models.ScheduleNotActiveErr
+		}
+
+		rschedule.Status = models.ScheduleCancelled
+		rschedule.UpdatedAt = time.Now()
+
+		if _, err := tx.NewUpdate().Model(&rschedule).
+			Column("status", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rschedule, err
+}
+
+func (ss *scheduleService) ListRuns(ctx context.Context, scheduleID int64) ([]models.ScheduleRun, error) {
+
+	rruns := []models.ScheduleRun{}
+
+	err := ss.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&models.Schedule{}).Where("id = ?", scheduleID).Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&rruns).
+			Where("schedule_id = ?", scheduleID).
+			OrderExpr("id DESC").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rruns, err
+}
+
+func (ss *scheduleService) Lease(ctx context.Context, owner string, now time.Time, ttl time.Duration, limit int) ([]models.Schedule, error) {
+
+	leased := []models.Schedule{}
+
+	err := ss.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		// the rows another replica is leasing right now are skipped instead of waited for
+		ids := []int64{}
+		if err := tx.NewSelect().
+			Model((*models.Schedule)(nil)).
+			Column("id").
+			Where("status = ?", models.ScheduleActive).
+			Where("COALESCE(retry_at, next_run_at) <= ?", now).
+			Where("leased_until IS NULL OR leased_until <= ?", now).
+			OrderExpr("COALESCE(retry_at, next_run_at) ASC, id ASC").
+			Limit(limit).
+			For("UPDATE SKIP LOCKED").
+			Scan(ctx, &ids); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if _, err := tx.NewUpdate().
+			Model((*models.Schedule)(nil)).
+			Set("lease_owner = ?", owner).
+			Set("leased_until = ?", now.Add(ttl)).
+			Where("id IN (?)", bun.In(ids)).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return tx.NewSelect().
+			Model(&leased).
+			Where("id IN (?)", bun.In(ids)).
+			OrderExpr("COALESCE(retry_at, next_run_at) ASC, id ASC").
+			Scan(ctx)
+	})
+
+	return leased, err
+}
+
+func (ss *scheduleService) RecordRun(ctx context.Context, owner string, run models.ScheduleRun) (models.Schedule, error) {
+
+	rschedule := models.Schedule{}
+
+	err := ss.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&rschedule).Where("id = ?", run.ScheduleID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		if !rschedule.IsLeasedBy(owner, run) {
+			return models.ScheduleLeaseLostErr
+		}
+
+		if _, err := tx.NewInsert().Model(&run).Exec(ctx); err != nil {
+			return err
+		}
+
+		rschedule.Record(run, run.CreatedAt)
+
+		if _, err := tx.NewUpdate().Model(&rschedule).
+			Column("status", "next_run_at", "occurrences", "attempts", "retry_at", "lease_owner", "leased_until", "updated_at").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rschedule, err
+}
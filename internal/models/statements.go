@@ -0,0 +1,228 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var (
+	generateStatementsBatchSize = 500
+)
+
+type statementService struct {
+	db *bun.DB
+}
+
+func NewStatementService(db *bun.DB) *statementService {
+	return &statementService{
+		db: db,
+	}
+}
+
+func (ss *statementService) ListForAccount(ctx context.Context, accountID int64) ([]models.Statement, error) {
+
+	rstatements := []models.Statement{}
+
+	err := ss.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&models.Account{}).Where("id = ?", accountID).Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&rstatements).
+			Where("account_id = ?", accountID).
+			OrderExpr("period_end DESC").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rstatements, err
+}
+
+func (ss *statementService) GetForID(ctx context.Context, statementID int64) (models.Statement, error) {
+
+	rstatement := models.Statement{}
+
+	err := ss.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		return tx.NewSelect().
+			Model(&rstatement).
+			Relation("Lines", func(q *bun.SelectQuery) *bun.SelectQuery {
+				return q.OrderExpr("sl.event_date ASC, sl.id ASC")
+			}).
+			Where("st.id = ?", statementID).
+			Scan(ctx)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rstatement, err
+}
+
+func (ss *statementService) GenerateDue(ctx context.Context, now time.Time) (int, error) {
+
+	generated := 0
+	failed := models.BatchError{}
+	lastAccountID := int64(0)
+
+	for {
+		accounts := []models.Account{}
+		if err := ss.db.NewSelect().
+			Model(&accounts).
+			Where("id > ?", lastAccountID).
+			OrderExpr("id ASC").
+			Limit(generateStatementsBatchSize).
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return generated, err
+		}
+
+		for _, account := range accounts {
+			lastAccountID = account.AccountID
+
+			statements, err := ss.generate(ctx, account.AccountID, now)
+			if err != nil {
+				failed[account.AccountID] = err
+				continue
+			}
+			generated += statements
+		}
+
+		if len(accounts) < generateStatementsBatchSize {
+			return generated, failed.Err()
+		}
+	}
+}
+
+// generate closes the cycles of the account that closed by now and have no statement yet, one statement each,
+// and returns how many it generated, the account row lock keeps new transactions out while the cycles are frozen
+func (ss *statementService) generate(ctx context.Context, accountID int64, now time.Time) (int, error) {
+
+	generated := 0
+
+	err := ss.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		account := models.Account{}
+		if err := tx.NewSelect().Model(&account).Where("id = ?", accountID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		var previous *models.Statement
+		last := models.Statement{}
+		if err := tx.NewSelect().
+			Model(&last).
+			Where("account_id = ?", accountID).
+			OrderExpr("period_end DESC").
+			Limit(1).
+			Scan(ctx); err == nil {
+			previous = &last
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		for {
+			statement, ok := models.NewStatement(account, previous, now)
+			if !ok {
+				return nil
+			}
+
+			// the lines keep the balances the transactions had when the cycle closed
+			end := statement.PeriodEnd
+			transactions := []models.Transaction{}
+			if err := tx.NewSelect().
+				Model(&transactions).
+				Column("id", "operation_type_id", "amount", "event_date").
+				ColumnExpr(`(t.balance
+					+ (SELECT COALESCE(SUM(i.amount), 0) FROM installment AS i
+						WHERE i.transaction_id = t.id AND i.posted_at >= ?)
+					- (SELECT COALESCE(SUM(al.amount), 0) FROM allocation AS al
+						WHERE al.debit_transaction_id = t.id AND al.allocated_at >= ?)
+					+ (SELECT COALESCE(SUM(al.amount), 0) FROM allocation AS al
+						WHERE al.credit_transaction_id = t.id AND al.allocated_at >= ?)
+				)::BIGINT AS balance`, end, end, end).
+				Where("t.account_id = ?", accountID).
+				Where("t.event_date >= ?", statement.PeriodStart).
+				Where("t.event_date < ?", end).
+				OrderExpr("t.event_date ASC, t.id ASC").
+				Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+
+			closingBalance, err := balanceAsOf(ctx, tx, accountID, end)
+			if err != nil {
+				return err
+			}
+
+			statement.Close(transactions, closingBalance)
+			statement.CreatedAt = now
+
+			if _, err := tx.NewInsert().Model(&statement).Returning("id").Exec(ctx); err != nil {
+				return err
+			}
+
+			if len(statement.Lines) > 0 {
+				for i := range statement.Lines {
+					statement.Lines[i].StatementID = statement.ID
+				}
+				if _, err := tx.NewInsert().Model(&statement.Lines).Exec(ctx); err != nil {
+					return err
+				}
+			}
+
+			generated++
+			previous = &statement
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return generated, nil
+}
+
+// balanceAsOf returns what the transactions of the account booked before at summed to at that time, the
+// current balances with the installments posted and the allocations made since at undone. An allocation
+// between two of those transactions moves money between them and leaves the sum as it is
+func balanceAsOf(ctx context.Context, tx bun.Tx, accountID int64, at time.Time) (models.Money, error) {
+
+	var balance models.Money
+
+	err := tx.NewRaw(`
+		SELECT (
+			(SELECT COALESCE(SUM(t.balance), 0)
+				FROM transaction AS t
+				WHERE t.account_id = ? AND t.event_date < ?)
+			+ (SELECT COALESCE(SUM(i.amount), 0)
+				FROM installment AS i JOIN transaction AS t ON t.id = i.transaction_id
+				WHERE t.account_id = ? AND t.event_date < ? AND i.posted_at >= ?)
+			- (SELECT COALESCE(SUM(al.amount), 0)
+				FROM allocation AS al JOIN transaction AS t ON t.id = al.debit_transaction_id
+				WHERE t.account_id = ? AND t.event_date < ? AND al.allocated_at >= ?)
+			+ (SELECT COALESCE(SUM(al.amount), 0)
+				FROM allocation AS al JOIN transaction AS t ON t.id = al.credit_transaction_id
+				WHERE t.account_id = ? AND t.event_date < ? AND al.allocated_at >= ?)
+		)::BIGINT
+	`, accountID, at, accountID, at, at, accountID, at, at, accountID, at, at).Scan(ctx, &balance)
+
+	return balance, err
+}
@@ -15,134 +15,407 @@ var (
 )
 
 type transactionService struct {
-	db *bun.DB
+	db         *bun.DB
+	settlement models.SettlementStrategies
 }
 
-func NewTransactionService(db *bun.DB) *transactionService {
+// NewTransactionService returns a transaction service that settles the transactions
+// of each account with the strategy the account selected in settlement
+func NewTransactionService(db *bun.DB, settlement models.SettlementStrategies) *transactionService {
 	return &transactionService{
-		db: db,
+		db:         db,
+		settlement: settlement,
 	}
 }
 
 func (ts *transactionService) Create(ctx context.Context, transaction models.Transaction) (models.TransactionStatus, error) {
 
+	transactionStatus := models.TransactionStatus{}
+
+	err := ts.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := claimIdempotencyKey(ctx, tx); err != nil {
+			return err
+		}
+
+		var err error
+		transactionStatus, err = createTransaction(ctx, tx, ts.settlement, transaction)
+		if err != nil {
+			return err
+		}
+
+		return completeIdempotencyKey(ctx, tx, transactionStatus)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return transactionStatus, err
+}
+
+// createTransaction books a transaction within tx, settling it against the open
+// balances of the account, so that other services can create transactions atomically
+func createTransaction(ctx context.Context, tx bun.Tx, settlement models.SettlementStrategies, transaction models.Transaction) (models.TransactionStatus, error) {
+
 	transactionStatus := models.TransactionStatus{}
 	rtransaction := models.Transaction{}
 
-	unresolvedTransactions := []models.Transaction{}
+	account := models.Account{}
+	if err := tx.NewSelect().Model(&account).Where("id = ?", transaction.AccountID).For("UPDATE").Scan(ctx); err != nil {
+		return transactionStatus, err
+	}
+
+	// checked up front so that an unknown type is a missing record and not a foreign key violation
+	exists, err := tx.NewSelect().Model((*models.OperationType)(nil)).Where("id = ?", transaction.OperationTypeID).Exists(ctx)
+	if err != nil {
+		return transactionStatus, err
+	}
+	if !exists {
+		return transactionStatus, models.NoRecordErr
+	}
+
+	if !account.AcceptsTransaction(transaction.OperationTypeID) {
+		return transactionStatus, models.AccountNotActiveErr
+	}
+
+	currency, err := account.TransactionCurrency(transaction.Currency, transaction.Amount)
+	if err != nil {
+		return transactionStatus, err
+	}
+	transaction.Currency = currency
+
+	if transaction.FXQuoteID != nil {
+		if err := useFXQuote(ctx, tx, transaction, time.Now()); err != nil {
+			return transactionStatus, err
+		}
+	}
+
+	if models.IsChargeOperationType(transaction.OperationTypeID) {
+		if err := chargeCreditLimit(ctx, tx, &account, transaction.Amount); err != nil {
+			return transactionStatus, err
+		}
+	} else if err := updateCreditLimit(ctx, tx, &account, transaction.Amount); err != nil {
+		return transactionStatus, err
+	}
+
+	transaction.EventDate = time.Now()
+
+	currBalance := transaction.Amount
+
+	// only the first installment is due right away, the rest is posted by PostDue
+	var schedule []models.Installment
+	if transaction.Installments > 1 {
+		schedule = models.NewInstallmentSchedule(-transaction.Amount, transaction.Installments, transaction.EventDate, transaction.Currency)
+		schedule[0].PostedAt = &transaction.EventDate
+		currBalance = -schedule[0].Amount
+	}
+
+	var allocations []models.Allocation
+	if transaction.Amount > 0 {
+		currBalance, allocations, err = dischargeDebits(ctx, tx, settlement.ForAccount(account), transaction.AccountID, currBalance)
+	} else {
+		currBalance, allocations, err = dischargeCredits(ctx, tx, settlement.ForAccount(account), transaction.AccountID, currBalance)
+	}
+	if err != nil {
+		return transactionStatus, err
+	}
+
+	transaction.Balance = currBalance
+	_, err = tx.NewInsert().Model(&transaction).Returning("id").Exec(ctx)
+	if err != nil {
+		return transactionStatus, err
+	}
+
+	if err := tx.NewSelect().Model(&rtransaction).Where("id = ?", transaction.ID).Scan(ctx); err != nil {
+		return transactionStatus, err
+	}
+
+	if err := postJournalEntry(ctx, tx, rtransaction); err != nil {
+		return transactionStatus, err
+	}
+
+	if err := insertAllocations(ctx, tx, allocations, rtransaction.ID, transaction.EventDate); err != nil {
+		return transactionStatus, err
+	}
+
+	if len(schedule) > 0 {
+		for i := range schedule {
+			schedule[i].TransactionID = rtransaction.ID
+			schedule[i].AccountID = rtransaction.AccountID
+		}
+
+		if _, err := tx.NewInsert().Model(&schedule).Exec(ctx); err != nil {
+			return transactionStatus, err
+		}
+	}
+
+	if err := writeTransactionEvents(ctx, tx, rtransaction, allocations); err != nil {
+		return transactionStatus, err
+	}
+
+	transactionStatus.TransactionID = rtransaction.ID
+	transactionStatus.AccountID = rtransaction.AccountID
+
+	return transactionStatus, nil
+}
+
+func (ts *transactionService) GetForID(ctx context.Context, transactionID int64) (models.Transaction, error) {
+
+	rtransaction := models.Transaction{}
 
 	err := ts.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
 
-		if err := tx.NewSelect().Model(&models.Account{}).Where("id = ?", transaction.AccountID).For("UPDATE").Scan(ctx); err != nil {
+		if err := tx.NewSelect().
+			Model(&rtransaction).
+			Relation("OperationType").
+			Where("t.id = ?", transactionID).
+			Scan(ctx); err != nil {
 			return err
 		}
 
-		currBalance := transaction.Amount
+		return nil
+	})
 
-		if transaction.Amount > 0 {
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
 
-			count, err := tx.NewSelect().
-				Model(&unresolvedTransactions).
-				Where("account_id = ?", transaction.AccountID).
-				OrderExpr("event_date ASC").ScanAndCount(ctx)
-			if err != nil {
-				if !errors.Is(err, sql.ErrNoRows) {
-					return err
-				}
-			}
+	return rtransaction, err
+}
 
-			if count > 0 {
-				// for each transaction, see if we complete the balance and update in db
-				for _, unresolvedTransaction := range unresolvedTransactions {
-					transactionRemainingBalance := 0.0
-
-					if currBalance > 0 {
-						// transaction is resolved set to 0
-						if unresolvedTransaction.Balance+currBalance > 0 {
-							transactionRemainingBalance = 0.0
-							currBalance = currBalance + unresolvedTransaction.Balance
-						} else {
-							transactionRemainingBalance = unresolvedTransaction.Balance + currBalance
-							currBalance = 0
-						}
-
-					} else {
-						break
-					}
-
-					// push to db
-					_, err := tx.NewUpdate().Model(&unresolvedTransaction).
-						Set("balance = ?", transactionRemainingBalance).
-						Where("id = ?", unresolvedTransaction.ID).
-						Exec(ctx)
-					if err != nil {
-						return err
-					}
-				}
-			}
+// updateCreditLimit applies the amount of a new transaction to the limit of the account,
+// the account row must be locked
+func updateCreditLimit(ctx context.Context, tx bun.Tx, account *models.Account, amount models.Money) error {
+
+	if account.AvailableCreditLimit == nil {
+		return nil
+	}
+
+	if err := account.ApplyToCreditLimit(amount); err != nil {
+		return err
+	}
+
+	_, err := tx.NewUpdate().Model(account).
+		Set("available_credit_limit = ?", *account.AvailableCreditLimit).
+		Where("id = ?", account.AccountID).
+		Exec(ctx)
+
+	return err
+}
+
+// chargeCreditLimit consumes the available credit limit of the account with a charge
+// that can not be declined, exhausting the limit if it is not enough
+func chargeCreditLimit(ctx context.Context, tx bun.Tx, account *models.Account, amount models.Money) error {
+
+	if account.AvailableCreditLimit == nil {
+		return nil
+	}
+
+	account.ApplyChargeToCreditLimit(amount)
 
+	_, err := tx.NewUpdate().Model(account).
+		Set("available_credit_limit = ?", *account.AvailableCreditLimit).
+		Where("id = ?", account.AccountID).
+		Exec(ctx)
+
+	return err
+}
+
+// dischargeDebits uses a credit to pay off the negative balances of the previous transactions
+// of the account, in the order of the strategy, and returns the part of the credit that is left over
+// along with the allocations to the debits it paid, the credit side is left for the caller to fill in.
+// The open credits of the account are left as they are, every credit keeps what it did not pay on its own balance
+func dischargeDebits(ctx context.Context, tx bun.Tx, strategy models.SettlementStrategy, accountID int64, credit models.Money) (models.Money, []models.Allocation, error) {
+
+	unresolvedTransactions := []models.Transaction{}
+
+	if err := tx.NewSelect().
+		Model(&unresolvedTransactions).
+		Where("account_id = ?", accountID).
+		Where("balance < 0").
+		OrderExpr("event_date ASC, id ASC").
+		Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return credit, nil, err
+	}
+
+	strategy.Order(unresolvedTransactions)
+
+	currBalance := credit
+	allocations := []models.Allocation{}
+
+	// for each transaction, see if we complete the balance and update in db
+	for _, unresolvedTransaction := range unresolvedTransactions {
+		var transactionRemainingBalance models.Money
+
+		if currBalance <= 0 {
+			break
+		}
+
+		if unresolvedTransaction.Balance+currBalance > 0 {
+			// transaction is resolved set to 0
+			transactionRemainingBalance = 0
+			currBalance = currBalance + unresolvedTransaction.Balance
 		} else {
-			// check if any of the previous transactions are positive
-			// if positive then subtract from it and update in the db
-
-			count, err := tx.NewSelect().
-				Model(&unresolvedTransactions).
-				Where("account_id = ?", transaction.AccountID).
-				Where("balance > 0").
-				OrderExpr("event_date ASC").
-				ScanAndCount(ctx)
-			if err != nil {
-				if !errors.Is(err, sql.ErrNoRows) {
-					return err
-				}
-			}
+			transactionRemainingBalance = unresolvedTransaction.Balance + currBalance
+			currBalance = 0
+		}
 
-			if count > 0 {
-				// for each transaction, see if we complete the balance and update in db
-				for _, unresolvedTransaction := range unresolvedTransactions {
-					transactionRemainingBalance := 0.0
-
-					if currBalance == 0 {
-						break
-					}
-
-					if unresolvedTransaction.Balance+currBalance > 0 {
-						// there is balance remaning in this transaction and the current transaction is resolved
-						transactionRemainingBalance = unresolvedTransaction.Balance + currBalance
-						currBalance = 0
-					} else {
-						// no balance in the transaction
-						currBalance = currBalance + unresolvedTransaction.Balance
-						transactionRemainingBalance = 0
-					}
-
-					// push to db
-					_, err := tx.NewUpdate().Model(&unresolvedTransaction).
-						Set("balance = ?", transactionRemainingBalance).
-						Where("id = ?", unresolvedTransaction.ID).
-						Exec(ctx)
-					if err != nil {
-						return err
-					}
-				}
-			}
+		// push to db
+		_, err := tx.NewUpdate().Model(&unresolvedTransaction).
+			Set("balance = ?", transactionRemainingBalance).
+			Where("id = ?", unresolvedTransaction.ID).
+			Exec(ctx)
+		if err != nil {
+			return credit, nil, err
+		}
+
+		allocations = append(allocations, models.Allocation{
+			DebitTransactionID: unresolvedTransaction.ID,
+			Amount:             transactionRemainingBalance - unresolvedTransaction.Balance,
+		})
+	}
+
+	return currBalance, allocations, nil
+}
+
+// dischargeCredits consumes the positive balances of the previous transactions
+// of the account, in the order of the strategy, and returns the part of the debit that is still open
+// along with the allocations from the credits it consumed, the debit side is left for the caller to fill in
+func dischargeCredits(ctx context.Context, tx bun.Tx, strategy models.SettlementStrategy, accountID int64, debit models.Money) (models.Money, []models.Allocation, error) {
+
+	unresolvedTransactions := []models.Transaction{}
+
+	if err := tx.NewSelect().
+		Model(&unresolvedTransactions).
+		Where("account_id = ?", accountID).
+		Where("balance > 0").
+		OrderExpr("event_date ASC, id ASC").
+		Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return debit, nil, err
+	}
 
+	strategy.Order(unresolvedTransactions)
+
+	currBalance := debit
+	allocations := []models.Allocation{}
+
+	// for each transaction, see if we complete the balance and update in db
+	for _, unresolvedTransaction := range unresolvedTransactions {
+		var transactionRemainingBalance models.Money
+
+		if currBalance == 0 {
+			break
 		}
 
-		transaction.EventDate = time.Now()
+		if unresolvedTransaction.Balance+currBalance > 0 {
+			// there is balance remaning in this transaction and the current transaction is resolved
+			transactionRemainingBalance = unresolvedTransaction.Balance + currBalance
+			currBalance = 0
+		} else {
+			// no balance in the transaction
+			currBalance = currBalance + unresolvedTransaction.Balance
+			transactionRemainingBalance = 0
+		}
 
-		transaction.Balance = currBalance
-		_, err := tx.NewInsert().Model(&transaction).Exec(ctx)
+		// push to db
+		_, err := tx.NewUpdate().Model(&unresolvedTransaction).
+			Set("balance = ?", transactionRemainingBalance).
+			Where("id = ?", unresolvedTransaction.ID).
+			Exec(ctx)
 		if err != nil {
-			return err
+			return debit, nil, err
+		}
+
+		allocations = append(allocations, models.Allocation{
+			CreditTransactionID: unresolvedTransaction.ID,
+			Amount:              unresolvedTransaction.Balance - transactionRemainingBalance,
+		})
+	}
+
+	return currBalance, allocations, nil
+}
+
+// insertAllocations records the allocations of a settlement, the side of each allocation
+// that was not known while discharging is set to transactionID
+func insertAllocations(ctx context.Context, tx bun.Tx, allocations []models.Allocation, transactionID int64, allocatedAt time.Time) error {
+
+	if len(allocations) == 0 {
+		return nil
+	}
+
+	for i := range allocations {
+		if allocations[i].CreditTransactionID == 0 {
+			allocations[i].CreditTransactionID = transactionID
 		}
+		if allocations[i].DebitTransactionID == 0 {
+			allocations[i].DebitTransactionID = transactionID
+		}
+		allocations[i].AllocatedAt = allocatedAt
+	}
+
+	_, err := tx.NewInsert().Model(&allocations).Exec(ctx)
+
+	return err
+}
 
-		if err := tx.NewSelect().Model(&rtransaction).Where("event_date = ?", transaction.EventDate).Scan(ctx); err != nil {
+func (ts *transactionService) ListForAccount(ctx context.Context, filter models.TransactionFilter) (models.TransactionPage, error) {
+
+	page := models.TransactionPage{}
+	rtransactions := []models.Transaction{}
+
+	if filter.Limit <= 0 || filter.Limit > models.MaxPageLimit {
+		filter.Limit = models.DefaultPageLimit
+	}
+
+	err := ts.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&models.Account{}).Where("id = ?", filter.AccountID).Scan(ctx); err != nil {
 			return err
 		}
 
-		transactionStatus.TransactionID = rtransaction.ID
-		transactionStatus.AccountID = rtransaction.AccountID
+		query := tx.NewSelect().
+			Model(&rtransactions).
+			Relation("OperationType").
+			Where("t.account_id = ?", filter.AccountID)
+
+		if len(filter.OperationTypeIDs) > 0 {
+			query = query.Where("t.operation_type_id IN (?)", bun.In(filter.OperationTypeIDs))
+		}
+
+		if filter.From != nil {
+			query = query.Where("t.event_date >= ?", *filter.From)
+		}
+
+		if filter.To != nil {
+			query = query.Where("t.event_date < ?", *filter.To)
+		}
+
+		if filter.Settled != nil {
+			if *filter.Settled {
+				query = query.Where("t.balance = 0")
+			} else {
+				query = query.Where("t.balance <> 0")
+			}
+		}
+
+		if filter.After != nil {
+			query = query.Where("(t.event_date, t.id) < (?, ?)", filter.After.EventDate, filter.After.ID)
+		}
+
+		// fetch one more than the limit to know if there is a next page
+		if err := query.
+			OrderExpr("t.event_date DESC, t.id DESC").
+			Limit(filter.Limit + 1).
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
 
 		return nil
 	})
@@ -152,22 +425,117 @@ func (ts *transactionService) Create(ctx context.Context, transaction models.Tra
 		case errors.Is(err, sql.ErrNoRows):
 			err = models.NoRecordErr
 		}
+		return page, err
 	}
 
-	return transactionStatus, err
+	if len(rtransactions) > filter.Limit {
+		rtransactions = rtransactions[:filter.Limit]
+		last := rtransactions[len(rtransactions)-1]
+		page.NextCursor = &models.TransactionCursor{EventDate: last.EventDate, ID: last.ID}
+	}
+
+	page.Transactions = rtransactions
+
+	return page, nil
 }
 
-func (ts *transactionService) GetForID(ctx context.Context, transactionID int64) (models.Transaction, error) {
+func (ts *transactionService) Reverse(ctx context.Context, transactionID int64, amount models.Money) (models.TransactionStatus, error) {
 
-	rtransaction := models.Transaction{}
+	transactionStatus := models.TransactionStatus{}
 
 	err := ts.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
 
-		if err := tx.NewSelect().Model(&rtransaction).Where("id = ?", transactionID).Scan(ctx); err != nil {
+		if err := claimIdempotencyKey(ctx, tx); err != nil {
 			return err
 		}
 
-		return nil
+		original := models.Transaction{}
+		if err := tx.NewSelect().Model(&original).Where("id = ?", transactionID).Scan(ctx); err != nil {
+			return err
+		}
+
+		// lock the account first, in the same order as Create, before re-reading the balance
+		account := models.Account{}
+		if err := tx.NewSelect().Model(&account).Where("id = ?", original.AccountID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		if !account.AcceptsTransaction(int64(models.PurchaseReversal)) {
+			return models.AccountNotActiveErr
+		}
+
+		if err := tx.NewSelect().Model(&original).Where("id = ?", transactionID).For("UPDATE").Scan(ctx); err != nil {
+			return err
+		}
+
+		// the lock on the original serializes its reversals, so what was reversed of it can not move
+		var reversed models.Money
+		if err := tx.NewSelect().
+			Model((*models.Transaction)(nil)).
+			ColumnExpr("COALESCE(SUM(amount), 0)::BIGINT").
+			Where("reverses_transaction_id = ?", transactionID).
+			Scan(ctx, &reversed); err != nil {
+			return err
+		}
+
+		reversal, err := models.NewReversal(original, reversed, amount)
+		if err != nil {
+			return err
+		}
+
+		if err := updateCreditLimit(ctx, tx, &account, reversal.Amount); err != nil {
+			return err
+		}
+
+		// the reversal first cancels the installments that are not due yet, then what is
+		// still open in the original debit, the part that was already paid is given back as a credit
+		cancelled, err := cancelPendingInstallments(ctx, tx, original.ID, reversal.Amount)
+		if err != nil {
+			return err
+		}
+
+		var allocations []models.Allocation
+
+		applied := min(reversal.Amount-cancelled, -original.Balance)
+		if applied > 0 {
+			allocations = append(allocations, models.Allocation{DebitTransactionID: original.ID, Amount: applied})
+
+			_, err := tx.NewUpdate().Model(&original).
+				Set("balance = ?", original.Balance+applied).
+				Where("id = ?", original.ID).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		var discharged []models.Allocation
+		reversal.Balance, discharged, err = dischargeDebits(ctx, tx, ts.settlement.ForAccount(account), original.AccountID, reversal.Amount-cancelled-applied)
+		if err != nil {
+			return err
+		}
+		allocations = append(allocations, discharged...)
+
+		if _, err := tx.NewInsert().Model(&reversal).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+
+		if err := postJournalEntry(ctx, tx, reversal); err != nil {
+			return err
+		}
+
+		if err := insertAllocations(ctx, tx, allocations, reversal.ID, reversal.EventDate); err != nil {
+			return err
+		}
+
+		if err := writeTransactionEvents(ctx, tx, reversal, allocations); err != nil {
+			return err
+		}
+
+		transactionStatus.TransactionID = reversal.ID
+		transactionStatus.AccountID = reversal.AccountID
+
+		return completeIdempotencyKey(ctx, tx, transactionStatus)
 	})
 
 	if err != nil {
@@ -177,5 +545,5 @@ func (ts *transactionService) GetForID(ctx context.Context, transactionID int64)
 		}
 	}
 
-	return rtransaction, err
+	return transactionStatus, err
 }
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type transferService struct {
+	db         *bun.DB
+	settlement models.SettlementStrategies
+}
+
+func NewTransferService(db *bun.DB, settlement models.SettlementStrategies) *transferService {
+	return &transferService{
+		db:         db,
+		settlement: settlement,
+	}
+}
+
+func (ts *transferService) Create(ctx context.Context, transfer models.Transfer) (models.Transfer, error) {
+
+	if transfer.SourceAccountID == transfer.DestinationAccountID {
+		return transfer, models.SameAccountTransferErr
+	}
+
+	err := ts.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := claimIdempotencyKey(ctx, tx); err != nil {
+			return err
+		}
+
+		// lock both accounts by id so that concurrent transfers in opposite directions can not deadlock,
+		// createTransaction locks them again which is a no-op within the same transaction
+		accounts := []models.Account{}
+		if err := tx.NewSelect().
+			Model(&accounts).
+			Where("id IN (?)", bun.In([]int64{transfer.SourceAccountID, transfer.DestinationAccountID})).
+			OrderExpr("id ASC").
+			For("UPDATE").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if len(accounts) != 2 {
+			return models.NoRecordErr
+		}
+
+		// transfers move the same amount out of and into both accounts, so they must share a currency
+		if accounts[0].Currency != accounts[1].Currency {
+			return models.CurrencyMismatchErr
+		}
+
+		transfer.CreatedAt = time.Now()
+		if _, err := tx.NewInsert().Model(&transfer).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+
+		debit, err := createTransaction(ctx, tx, ts.settlement, models.Transaction{
+			AccountID:       transfer.SourceAccountID,
+			OperationTypeID: int64(models.TransferOut),
+			Amount:          -transfer.Amount,
+			TransferID:      &transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		credit, err := createTransaction(ctx, tx, ts.settlement, models.Transaction{
+			AccountID:       transfer.DestinationAccountID,
+			OperationTypeID: int64(models.TransferIn),
+			Amount:          transfer.Amount,
+			TransferID:      &transfer.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		transfer.DebitTransactionID = &debit.TransactionID
+		transfer.CreditTransactionID = &credit.TransactionID
+
+		if _, err := tx.NewUpdate().Model(&transfer).
+			Column("debit_transaction_id", "credit_transaction_id").
+			WherePK().
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		return completeIdempotencyKey(ctx, tx, transfer)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return transfer, err
+}
+
+func (ts *transferService) GetForID(ctx context.Context, transferID int64) (models.Transfer, error) {
+
+	rtransfer := models.Transfer{}
+
+	err := ts.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		if err := tx.NewSelect().Model(&rtransfer).Where("id = ?", transferID).Scan(ctx); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rtransfer, err
+}
@@ -0,0 +1,213 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type webhookService struct {
+	db *bun.DB
+}
+
+func NewWebhookService(db *bun.DB) *webhookService {
+	return &webhookService{
+		db: db,
+	}
+}
+
+func (whs *webhookService) Create(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+
+	webhook.CreatedAt = time.Now()
+
+	err := whs.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		if webhook.AccountID != nil {
+			account := models.Account{}
+			if err := tx.NewSelect().Model(&account).Where("id = ?", *webhook.AccountID).Scan(ctx); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.NewInsert().Model(&webhook).Returning("id").Exec(ctx)
+
+		return err
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return webhook, err
+}
+
+func (whs *webhookService) GetForID(ctx context.Context, webhookID int64) (models.Webhook, error) {
+
+	rwebhook := models.Webhook{}
+
+	err := whs.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		return tx.NewSelect().
+			Model(&rwebhook).
+			ExcludeColumn("secret").
+			Where("id = ?", webhookID).
+			Scan(ctx)
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rwebhook, err
+}
+
+func (whs *webhookService) ListDeliveries(ctx context.Context, webhookID int64) ([]models.WebhookDelivery, error) {
+
+	rdeliveries := []models.WebhookDelivery{}
+
+	err := whs.db.RunInTx(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context, tx bun.Tx) error {
+
+		webhook := models.Webhook{}
+		if err := tx.NewSelect().Model(&webhook).Where("id = ?", webhookID).Scan(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&rdeliveries).
+			Where("webhook_id = ?", webhookID).
+			Order("id DESC").
+			Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			err = models.NoRecordErr
+		}
+	}
+
+	return rdeliveries, err
+}
+
+func (whs *webhookService) LeaseDue(ctx context.Context, now time.Time, ttl time.Duration, limit int) ([]models.WebhookDelivery, map[int64]models.Webhook, error) {
+
+	leased := []models.WebhookDelivery{}
+	webhooks := map[int64]models.Webhook{}
+
+	err := whs.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		// the rows another replica is leasing right now are skipped instead of waited for
+		ids := []int64{}
+		if err := tx.NewSelect().
+			Model((*models.WebhookDelivery)(nil)).
+			Column("id").
+			Where("status = ?", models.WebhookDeliveryPending).
+			Where("next_attempt_at <= ?", now).
+			OrderExpr("next_attempt_at ASC, id ASC").
+			Limit(limit).
+			For("UPDATE SKIP LOCKED").
+			Scan(ctx, &ids); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if _, err := tx.NewUpdate().
+			Model((*models.WebhookDelivery)(nil)).
+			Set("next_attempt_at = ?", now.Add(ttl)).
+			Where("id IN (?)", bun.In(ids)).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		if err := tx.NewSelect().
+			Model(&leased).
+			Where("id IN (?)", bun.In(ids)).
+			Order("id ASC").
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		webhookIDs := []int64{}
+		for _, delivery := range leased {
+			webhookIDs = append(webhookIDs, delivery.WebhookID)
+		}
+
+		rwebhooks := []models.Webhook{}
+		if err := tx.NewSelect().
+			Model(&rwebhooks).
+			Where("id IN (?)", bun.In(webhookIDs)).
+			Scan(ctx); err != nil {
+			return err
+		}
+
+		for _, webhook := range rwebhooks {
+			webhooks[webhook.ID] = webhook
+		}
+
+		return nil
+	})
+
+	return leased, webhooks, err
+}
+
+func (whs *webhookService) RecordAttempt(ctx context.Context, delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+
+	err := whs.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+
+		// a delivery another dispatcher finished after its lease lapsed keeps its outcome
+		_, err := tx.NewUpdate().
+			Model(&delivery).
+			Column("status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "last_error").
+			WherePK().
+			Where("status = ?", models.WebhookDeliveryPending).
+			Exec(ctx)
+
+		return err
+	})
+
+	return delivery, err
+}
+
+// enqueueDeliveries writes the deliveries of an event written within tx to the webhooks that subscribe it
+func enqueueDeliveries(ctx context.Context, tx bun.Tx, event models.Event) error {
+
+	webhooks := []models.Webhook{}
+	if err := tx.NewSelect().
+		Model(&webhooks).
+		Where("? = ANY(event_types)", event.Type).
+		Where("account_id IS NULL OR account_id = ?", event.AccountID).
+		Order("id ASC").
+		Scan(ctx); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		delivery, err := models.NewWebhookDelivery(webhook, event)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.NewInsert().Model(&delivery).Returning("id").Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
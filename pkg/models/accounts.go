@@ -1,9 +1,162 @@
 package models
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type AccountStatus string
+
+// Active accounts take any transaction, frozen ones only the charges posted by the app until they
+// are unfrozen and closed ones are final
+const (
+	AccountActive AccountStatus = "active"
+	AccountFrozen AccountStatus = "frozen"
+	AccountClosed AccountStatus = "closed"
+)
+
+// CanTransitionTo reports whether an account in status s can be moved to status to
+func (s AccountStatus) CanTransitionTo(to AccountStatus) bool {
+	switch s {
+	case AccountActive:
+		return to == AccountFrozen || to == AccountClosed
+	case AccountFrozen:
+		return to == AccountActive || to == AccountClosed
+	}
+	return false
+}
+
+// AccountStatusChange is an entry of the status history of an account
+type AccountStatusChange struct {
+	bun.BaseModel `bun:"table:account_status_change,alias:asch"`
+
+	ID         int64         `json:"id" bun:"id,pk,autoincrement"`
+	AccountID  int64         `json:"account_id" bun:"account_id"`
+	FromStatus AccountStatus `json:"from_status" bun:"from_status"`
+	ToStatus   AccountStatus `json:"to_status" bun:"to_status"`
+	Reason     string        `json:"reason,omitempty" bun:"reason,nullzero"`
+	CreatedAt  time.Time     `json:"created_at" bun:"created_at"`
+}
 
 type AccountsService interface {
+	// Create opens an active account, in DefaultCurrency when it has no currency
 	Create(ctx context.Context, account Account) (Account, error)
 	GetForID(ctx context.Context, accountID int64) (Account, error)
+	// DeleteForID removes an account that was never used for good, accounts with transactions
+	// or a status history are closed with UpdateStatus instead
 	DeleteForID(ctx context.Context, accountID int64) error
+	GetBalance(ctx context.Context, accountID int64) (AccountBalance, error)
+	// UpdateCreditLimit sets the credit limit of the account with SetCreditLimit, nil removes the limit
+	UpdateCreditLimit(ctx context.Context, accountID int64, limit *Money) (Account, error)
+	// UpdateSettlementStrategy sets the name of the settlement strategy of the account, nil restores the default
+	UpdateSettlementStrategy(ctx context.Context, accountID int64, strategy *string) (Account, error)
+	// UpdateBillingCycle sets the closing and due days of the account, nil restores the default
+	UpdateBillingCycle(ctx context.Context, accountID int64, closingDay *int, dueDay *int) (Account, error)
+	// UpdateStatus moves the account to status and records the change in its history, it fails with
+	// InvalidAccountStatusTransitionErr for a move CanTransitionTo does not allow and with OutstandingBalanceErr
+	// when closing an account with open balances, installments to post or pending holds.
+	// Closing an account cancels its active schedules
+	UpdateStatus(ctx context.Context, accountID int64, status AccountStatus, reason string) (Account, error)
+	// ListStatusHistory returns the status changes of an account, newest first
+	ListStatusHistory(ctx context.Context, accountID int64) ([]AccountStatusChange, error)
+}
+
+// ChangeStatus moves the account to status and returns the entry to record in its history
+func (a *Account) ChangeStatus(status AccountStatus, reason string, now time.Time) (AccountStatusChange, error) {
+
+	if !a.Status.CanTransitionTo(status) {
+		return AccountStatusChange{}, InvalidAccountStatusTransitionErr
+	}
+
+	change := AccountStatusChange{
+		AccountID:  a.AccountID,
+		FromStatus: a.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		CreatedAt:  now,
+	}
+
+	a.Status = status
+	return change, nil
+}
+
+// AcceptsTransaction reports whether a transaction of the operation type can be booked on the account,
+// the charges the app posts on its own are still booked on frozen accounts
+func (a Account) AcceptsTransaction(operationTypeID int64) bool {
+	switch a.Status {
+	case AccountActive:
+		return true
+	case AccountFrozen:
+		return IsChargeOperationType(operationTypeID)
+	}
+	return false
+}
+
+// TransactionCurrency returns the currency a transaction of the account is booked in, the currency
+// of the account when the transaction has none, it fails with CurrencyMismatchErr for any other currency
+// and with AmountPrecisionErr when the amount is finer than the minor unit of the currency
+func (a Account) TransactionCurrency(currency Currency, amount Money) (Currency, error) {
+
+	if currency == "" {
+		currency = a.Currency
+	}
+
+	if currency != a.Currency {
+		return currency, CurrencyMismatchErr
+	}
+
+	if err := currency.CheckAmount(amount); err != nil {
+		return currency, err
+	}
+
+	return currency, nil
+}
+
+// SetCreditLimit grants the account limit and moves the available credit limit by the change, so that
+// what was spent under the previous limit stays spent. An account that had no limit gets all of it available
+func (a *Account) SetCreditLimit(limit *Money) {
+
+	if limit == nil {
+		a.CreditLimit, a.AvailableCreditLimit = nil, nil
+		return
+	}
+
+	available := *limit
+	if a.CreditLimit != nil && a.AvailableCreditLimit != nil {
+		available = max(*a.AvailableCreditLimit+*limit-*a.CreditLimit, 0)
+	}
+
+	rlimit := *limit
+	a.CreditLimit, a.AvailableCreditLimit = &rlimit, &available
+}
+
+// ApplyToCreditLimit consumes the available credit limit with a debit or restores it
+// with a credit, accounts without a limit accept any amount
+func (a *Account) ApplyToCreditLimit(amount Money) error {
+
+	if a.AvailableCreditLimit == nil {
+		return nil
+	}
+
+	limit := *a.AvailableCreditLimit + amount
+	if limit < 0 {
+		return InsufficientLimitErr
+	}
+
+	a.AvailableCreditLimit = &limit
+	return nil
+}
+
+// ApplyChargeToCreditLimit consumes the available credit limit with a charge the account
+// can not decline, such as interest, which exhausts the limit instead of being rejected
+func (a *Account) ApplyChargeToCreditLimit(amount Money) {
+
+	if a.AvailableCreditLimit == nil {
+		return
+	}
+
+	limit := max(*a.AvailableCreditLimit+amount, 0)
+	a.AvailableCreditLimit = &limit
 }
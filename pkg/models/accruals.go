@@ -0,0 +1,88 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type AccrualKind string
+
+const (
+	InterestAccrual AccrualKind = "interest"
+	LateFeeAccrual  AccrualKind = "late_fee"
+)
+
+// basisPointsPerUnit and daysPerYear turn a yearly rate in basis points into a daily one
+const (
+	basisPointsPerUnit = 10000
+	daysPerYear        = 365
+)
+
+// Accrual records a charge posted for an account on a day, there is at most one of each kind
+// per account and day so that accruing a day again does not charge twice
+type Accrual struct {
+	bun.BaseModel `bun:"table:accrual,alias:ac"`
+
+	ID            int64       `json:"id" bun:"id,pk,autoincrement"`
+	AccountID     int64       `json:"account_id" bun:"account_id"`
+	AccrualDate   time.Time   `json:"accrual_date" bun:"accrual_date"`
+	Kind          AccrualKind `json:"kind" bun:"kind"`
+	Amount        Money       `json:"amount" bun:"amount"`
+	TransactionID int64       `json:"transaction_id" bun:"transaction_id"`
+	StatementID   *int64      `json:"statement_id,omitempty" bun:"statement_id"`
+	CreatedAt     time.Time   `json:"created_at" bun:"created_at"`
+}
+
+// InterestBearingBalance is the open balance of a debit along with the rate of its operation type
+type InterestBearingBalance struct {
+	Balance        Money `bun:"balance"`
+	APRBasisPoints int64 `bun:"apr_bps"`
+}
+
+type AccrualService interface {
+	// Accrue posts the interest of the day on the open debits of every account and the late fees
+	// of the statements due that day whose minimum payment was not met, days and statements that
+	// were already charged are skipped so past days can be accrued again, it returns how many
+	// charges were posted. The accounts that failed are skipped and returned in a BatchError
+	Accrue(ctx context.Context, day time.Time) (int, error)
+	// LastAccrualDay returns the latest day a charge was posted for, or the zero time if there is none
+	LastAccrualDay(ctx context.Context) (time.Time, error)
+	// ListForAccount returns the accruals of an account, newest first
+	ListForAccount(ctx context.Context, accountID int64) ([]Accrual, error)
+}
+
+// AccrualDay returns the start of the day of t in UTC, the day accruals are keyed by
+func AccrualDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// DailyInterest returns the interest of one day on the open debits, the balances are added up
+// before rounding half up to the cent so that splitting a debt does not change its interest
+func DailyInterest(balances []InterestBearingBalance) Money {
+
+	var numerator Money
+	for _, balance := range balances {
+		if balance.Balance < 0 && balance.APRBasisPoints > 0 {
+			numerator += -balance.Balance * Money(balance.APRBasisPoints)
+		}
+	}
+
+	denominator := Money(basisPointsPerUnit * daysPerYear)
+
+	return (numerator + denominator/2) / denominator
+}
+
+// IsPayment reports whether the transaction pays the account off, every credit settles the
+// balance of the account whether it is a payment, a reversal or an incoming transfer
+func IsPayment(transaction Transaction) bool {
+	return transaction.Amount > 0
+}
+
+// IsLateFeeDue reports whether the statement is charged a late fee because the credits
+// booked between its closing and the end of its due day, counted with IsPayment, did not cover the minimum payment
+func IsLateFeeDue(statement Statement, paid Money) bool {
+	return statement.MinimumPayment > 0 && paid < statement.MinimumPayment
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Allocation records the part of a debit that a credit paid off, it is written
+// every time settlement moves money between the balances of two transactions
+type Allocation struct {
+	bun.BaseModel `bun:"table:allocation,alias:al"`
+
+	ID                  int64     `json:"id" bun:"id,pk,autoincrement"`
+	CreditTransactionID int64     `json:"credit_transaction_id" bun:"credit_transaction_id"`
+	DebitTransactionID  int64     `json:"debit_transaction_id" bun:"debit_transaction_id"`
+	Amount              Money     `json:"amount" bun:"amount"`
+	AllocatedAt         time.Time `json:"allocated_at" bun:"allocated_at"`
+}
+
+type AllocationService interface {
+	// ListForTransaction returns the allocations of a transaction on either side, oldest first
+	ListForTransaction(ctx context.Context, transactionID int64) ([]Allocation, error)
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type AuthorizationStatus string
+
+const (
+	AuthorizationPending  AuthorizationStatus = "pending"
+	AuthorizationCaptured AuthorizationStatus = "captured"
+	AuthorizationReleased AuthorizationStatus = "released"
+	AuthorizationExpired  AuthorizationStatus = "expired"
+)
+
+// Authorization is a hold placed on an account before a purchase is settled,
+// while it is pending the amount counts against the available balance and credit limit
+type Authorization struct {
+	bun.BaseModel `bun:"table:authorization_hold,alias:ah"`
+
+	ID              int64               `json:"id" bun:"id,pk,autoincrement"`
+	AccountID       int64               `json:"account_id" bun:"account_id"`
+	OperationTypeID int64               `json:"operation_type_id" bun:"operation_type_id"`
+	Amount          Money               `json:"amount" bun:"amount"`
+	CapturedAmount  Money               `json:"captured_amount" bun:"captured_amount"`
+	Status          AuthorizationStatus `json:"status" bun:"status"`
+	TransactionID   *int64              `json:"transaction_id,omitempty" bun:"transaction_id"`
+	CreatedAt       time.Time           `json:"created_at" bun:"created_at"`
+	ExpiresAt       time.Time           `json:"expires_at" bun:"expires_at"`
+	UpdatedAt       time.Time           `json:"updated_at" bun:"updated_at"`
+}
+
+// IsExpired reports whether a pending hold is past its expiry
+func (a Authorization) IsExpired(now time.Time) bool {
+	return a.Status == AuthorizationPending && !now.Before(a.ExpiresAt)
+}
+
+type AuthorizationService interface {
+	Create(ctx context.Context, authorization Authorization) (Authorization, error)
+	GetForID(ctx context.Context, authorizationID int64) (Authorization, error)
+	// Capture books a debit for the whole hold when amount is 0 or part of it otherwise,
+	// the part that is not captured is released
+	Capture(ctx context.Context, authorizationID int64, amount Money) (Authorization, error)
+	Release(ctx context.Context, authorizationID int64) (Authorization, error)
+	// ExpireStale releases the pending holds past their expiry and returns how many were expired
+	ExpireStale(ctx context.Context, now time.Time) (int, error)
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code
+type Currency string
+
+// DefaultCurrency is the currency of the accounts opened without one, and of all the
+// accounts and transactions booked before currencies were tracked
+const DefaultCurrency Currency = "BRL"
+
+// currencyExponents holds the number of decimal places of the minor unit of the supported currencies,
+// amounts are tracked with MoneyScale decimal places so currencies with finer minor units are not supported
+var currencyExponents = map[Currency]int{
+	"ARS": 2,
+	"AUD": 2,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CLP": 0,
+	"CNY": 2,
+	"COP": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+	"KRW": 0,
+	"MXN": 2,
+	"PEN": 2,
+	"PYG": 0,
+	"USD": 2,
+	"UYU": 2,
+}
+
+// CurrencyConverter converts amounts between currencies for the transactions that request a conversion
+type CurrencyConverter interface {
+	// Convert returns the amount in the to currency, rounded to its minor unit, and the rate it was converted at
+	Convert(ctx context.Context, amount Money, from Currency, to Currency) (Money, ExchangeRate, error)
+}
+
+// ParseCurrency parses a currency code, case insensitively, and returns UnsupportedCurrencyErr
+// when the currency is not one the app tracks
+func ParseCurrency(s string) (Currency, error) {
+
+	currency := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	if !currency.IsSupported() {
+		return currency, fmt.Errorf("%w %q", UnsupportedCurrencyErr, s)
+	}
+
+	return currency, nil
+}
+
+// IsSupported reports whether amounts in the currency can be tracked
+func (c Currency) IsSupported() bool {
+	_, ok := currencyExponents[c]
+	return ok
+}
+
+// Exponent returns the number of decimal places of the minor unit of the currency,
+// unknown currencies are tracked with MoneyScale decimal places
+func (c Currency) Exponent() int {
+	if exponent, ok := currencyExponents[c]; ok {
+		return exponent
+	}
+	return MoneyScale
+}
+
+// MinorUnit returns the smallest amount of the currency in Money minor units
+func (c Currency) MinorUnit() Money {
+	unit := Money(1)
+	for i := c.Exponent(); i < MoneyScale; i++ {
+		unit *= 10
+	}
+	return unit
+}
+
+// CheckAmount returns AmountPrecisionErr when the amount is not a whole number of minor units of the currency
+func (c Currency) CheckAmount(amount Money) error {
+	if amount%c.MinorUnit() != 0 {
+		return fmt.Errorf("%w, %s amounts must be capped to %d decimal places", AmountPrecisionErr, c, c.Exponent())
+	}
+	return nil
+}
+
+// Round rounds the amount half away from zero to the minor unit of the currency
+func (c Currency) Round(amount Money) Money {
+
+	if amount < 0 {
+		return -c.Round(-amount)
+	}
+
+	unit := c.MinorUnit()
+	return (amount + unit/2) / unit * unit
+}
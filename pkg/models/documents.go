@@ -0,0 +1,181 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+type DocumentType string
+
+const (
+	CPFDocument  DocumentType = "cpf"
+	CNPJDocument DocumentType = "cnpj"
+)
+
+// Codes of the document errors, stable for clients to match on
+const (
+	DocumentRequiredCode           = "required"
+	DocumentInvalidCharactersCode  = "invalid_characters"
+	DocumentInvalidLengthCode      = "invalid_length"
+	DocumentRepeatedDigitsCode     = "repeated_digits"
+	DocumentInvalidCheckDigitsCode = "invalid_check_digits"
+	DocumentUnsupportedTypeCode    = "unsupported_type"
+)
+
+// DocumentError is a validation error of the document of an account, Field is the request field at fault
+type DocumentError struct {
+	Message string `json:"msg"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+}
+
+func (de *DocumentError) Error() string {
+	return de.Message
+}
+
+func newDocumentError(field string, code string, format string, args ...any) *DocumentError {
+	return &DocumentError{
+		Message: fmt.Sprintf(format, args...),
+		Field:   field,
+		Code:    code,
+	}
+}
+
+// DocumentValidator checks the numbers of one document type
+type DocumentValidator interface {
+	// Length is the number of digits of a document of the type, shorter numbers are padded with leading zeros
+	Length() int
+	// Validate checks a number of Length digits, returning a DocumentError when it is not valid
+	Validate(digits string) error
+}
+
+// DocumentValidators holds the validators of the document types accounts can be opened with
+type DocumentValidators struct {
+	ByType map[DocumentType]DocumentValidator
+}
+
+// NewDocumentValidators returns the validators shipped with the app, for CPFs and CNPJs
+func NewDocumentValidators() DocumentValidators {
+	return DocumentValidators{
+		ByType: map[DocumentType]DocumentValidator{
+			CPFDocument:  CPFValidator{},
+			CNPJDocument: CNPJValidator{},
+		},
+	}
+}
+
+// Validate checks number as a document of documentType and returns it normalized, without the dots,
+// dashes and slashes of its formatting and padded to the length of its type with the leading zeros
+// it lost when stored as a number. When documentType is empty it is the type with the shortest length
+// that fits the number
+func (dv DocumentValidators) Validate(documentType DocumentType, number string) (DocumentType, string, error) {
+
+	digits := strings.NewReplacer(".", "", "-", "", "/", "").Replace(number)
+
+	if digits == "" {
+		return documentType, number, newDocumentError("document_number", DocumentRequiredCode, "document number is required")
+	}
+
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return documentType, number, newDocumentError("document_number", DocumentInvalidCharactersCode, "document number must only have digits")
+		}
+	}
+
+	if documentType == "" {
+		documentType = dv.typeForLength(len(digits))
+		if documentType == "" {
+			return documentType, number, newDocumentError("document_number", DocumentInvalidLengthCode, "document number has too many digits")
+		}
+	}
+
+	validator, ok := dv.ByType[documentType]
+	if !ok {
+		return documentType, number, newDocumentError("document_type", DocumentUnsupportedTypeCode, "unsupported document type %s", documentType)
+	}
+
+	if len(digits) > validator.Length() {
+		return documentType, number, newDocumentError("document_number", DocumentInvalidLengthCode, "%s must have %d digits", documentType, validator.Length())
+	}
+
+	digits = strings.Repeat("0", validator.Length()-len(digits)) + digits
+
+	if err := validator.Validate(digits); err != nil {
+		return documentType, number, err
+	}
+
+	return documentType, digits, nil
+}
+
+// typeForLength returns the type with the shortest length of at least length digits, ties broken by name
+func (dv DocumentValidators) typeForLength(length int) DocumentType {
+
+	var documentType DocumentType
+	for t, validator := range dv.ByType {
+		if validator.Length() < length {
+			continue
+		}
+		if documentType == "" {
+			documentType = t
+			continue
+		}
+		shortest := dv.ByType[documentType].Length()
+		if validator.Length() < shortest || (validator.Length() == shortest && t < documentType) {
+			documentType = t
+		}
+	}
+
+	return documentType
+}
+
+// CPFValidator checks the two mod 11 check digits of a CPF, the tax id of individuals
+type CPFValidator struct{}
+
+func (CPFValidator) Length() int {
+	return 11
+}
+
+func (CPFValidator) Validate(digits string) error {
+	return validateCheckDigits(CPFDocument, digits, []int{10, 9, 8, 7, 6, 5, 4, 3, 2}, []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2})
+}
+
+// CNPJValidator checks the two mod 11 check digits of a CNPJ, the tax id of companies
+type CNPJValidator struct{}
+
+func (CNPJValidator) Length() int {
+	return 14
+}
+
+func (CNPJValidator) Validate(digits string) error {
+	return validateCheckDigits(CNPJDocument, digits, []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}, []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+}
+
+// validateCheckDigits checks the last two digits against the weighted sums of the digits before them,
+// numbers of a single repeated digit pass the check but are never issued
+func validateCheckDigits(documentType DocumentType, digits string, firstWeights []int, secondWeights []int) error {
+
+	if strings.Count(digits, digits[:1]) == len(digits) {
+		return newDocumentError("document_number", DocumentRepeatedDigitsCode, "%s can not be a single repeated digit", documentType)
+	}
+
+	if checkDigit(digits, firstWeights) != int(digits[len(firstWeights)]-'0') ||
+		checkDigit(digits, secondWeights) != int(digits[len(secondWeights)]-'0') {
+		return newDocumentError("document_number", DocumentInvalidCheckDigitsCode, "%s check digits do not match", documentType)
+	}
+
+	return nil
+}
+
+// checkDigit weighs the leading digits of the number and returns the mod 11 check digit that follows them
+func checkDigit(digits string, weights []int) int {
+
+	sum := 0
+	for i, weight := range weights {
+		sum += int(digits[i]-'0') * weight
+	}
+
+	if remainder := sum % 11; remainder >= 2 {
+		return 11 - remainder
+	}
+	return 0
+}
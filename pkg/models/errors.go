@@ -1,9 +1,73 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Add all the errors that might come up
 var (
 	DuplicateRecordErr = errors.New("duplicate record")
 	NoRecordErr        = errors.New("no record")
+
+	IdempotencyKeyReplayErr     = errors.New("idempotency key already used")
+	IdempotencyKeyMismatchErr   = errors.New("idempotency key reused with a different request")
+	IdempotencyKeyInProgressErr = errors.New("request with idempotency key in progress")
+
+	AlreadyReversedErr        = errors.New("transaction already reversed")
+	NotReversibleErr          = errors.New("only debits booked directly can be reversed")
+	ReversalAmountExceededErr = errors.New("reversal amount exceeds the transaction amount")
+
+	InsufficientLimitErr = errors.New("insufficient credit limit")
+
+	AccountNotActiveErr               = errors.New("account is not active")
+	InvalidAccountStatusTransitionErr = errors.New("invalid account status transition")
+	OutstandingBalanceErr             = errors.New("account has an outstanding balance")
+
+	AuthorizationNotPendingErr = errors.New("authorization is no longer pending")
+	CaptureAmountExceededErr   = errors.New("capture amount exceeds the authorized amount")
+
+	UnsupportedCurrencyErr   = errors.New("unsupported currency")
+	AmountPrecisionErr       = errors.New("amount is finer than the minor unit of the currency")
+	CurrencyMismatchErr      = errors.New("currency does not match the currency of the account")
+	ConversionUnavailableErr = errors.New("currency conversion is not available")
+	FXQuoteExpiredErr        = errors.New("fx quote expired")
+	FXQuoteMismatchErr       = errors.New("fx quote does not convert between the currencies of the transaction")
+	FXQuoteUsedErr           = errors.New("fx quote already booked a transaction")
+
+	SameAccountTransferErr = errors.New("source and destination accounts must be different")
+
+	ScheduleNotActiveErr = errors.New("schedule is no longer active")
+	ScheduleLeaseLostErr = errors.New("schedule lease was taken by another runner")
+
+	OperationTypeNotActiveErr = errors.New("operation type is not active")
+
+	InvalidWebhookURLErr = errors.New("webhook url must point to a public host")
 )
+
+// BatchError is returned by the jobs that go through many records, such as accruing or generating statements,
+// when some of the records failed. A failing record is skipped so that it does not hold back the others,
+// the errors are kept by the id of the record
+type BatchError map[int64]error
+
+func (be BatchError) Error() string {
+	return fmt.Sprintf("%d records failed", len(be))
+}
+
+func (be BatchError) Unwrap() []error {
+
+	errs := make([]error, 0, len(be))
+	for _, err := range be {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// Err returns the batch error, or nil if no record failed
+func (be BatchError) Err() error {
+	if len(be) == 0 {
+		return nil
+	}
+	return be
+}
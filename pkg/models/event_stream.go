@@ -0,0 +1,85 @@
+package models
+
+import (
+	"context"
+	"sync"
+)
+
+// AccountEventService streams the events of an account as they are written, the events are read back
+// from the outbox so that a stream resumes after the last event it sent
+type AccountEventService interface {
+	// ListForAccount returns up to limit events of the account written after afterID, in id order
+	ListForAccount(ctx context.Context, accountID int64, afterID int64, limit int) ([]Event, error)
+	// LatestID returns the id of the last event of the account, 0 when it has none
+	LatestID(ctx context.Context, accountID int64) (int64, error)
+	// Subscribe returns a channel signalled when events of the account are written, the returned func
+	// ends the subscription
+	Subscribe(accountID int64) (<-chan struct{}, func())
+	// Listen signals the subscriptions of the events written by every replica until ctx is cancelled
+	Listen(ctx context.Context) error
+}
+
+// AccountEventHub signals the subscribers of an account, the signals of a subscriber are coalesced
+// until it reads them so that a slow subscriber never blocks the others
+type AccountEventHub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan struct{}]struct{}
+}
+
+func NewAccountEventHub() *AccountEventHub {
+	return &AccountEventHub{
+		subscribers: map[int64]map[chan struct{}]struct{}{},
+	}
+}
+
+func (h *AccountEventHub) Subscribe(accountID int64) (<-chan struct{}, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if h.subscribers[accountID] == nil {
+		h.subscribers[accountID] = map[chan struct{}]struct{}{}
+	}
+	h.subscribers[accountID][ch] = struct{}{}
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers[accountID], ch)
+		if len(h.subscribers[accountID]) == 0 {
+			delete(h.subscribers, accountID)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// Notify signals the subscribers of the account that it has new events
+func (h *AccountEventHub) Notify(accountID int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[accountID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// NotifyAll signals every subscriber, the events written while notifications could not be received
+// are then read back by the streams
+func (h *AccountEventHub) NotifyAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, subscribers := range h.subscribers {
+		for ch := range subscribers {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
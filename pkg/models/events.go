@@ -0,0 +1,166 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type EventType string
+
+const (
+	AccountCreatedEvent     EventType = "AccountCreated"
+	TransactionCreatedEvent EventType = "TransactionCreated"
+	// TransactionSettledEvent is written when the balance of a transaction is fully settled
+	TransactionSettledEvent EventType = "TransactionSettled"
+)
+
+// Event is a domain event written to the outbox in the same database transaction as the change
+// it describes, Payload is the account or transaction as of the change
+type Event struct {
+	bun.BaseModel `bun:"table:outbox_event,alias:oe"`
+
+	ID          int64           `json:"id" bun:"id,pk,autoincrement"`
+	AccountID   int64           `json:"account_id" bun:"account_id"`
+	Type        EventType       `json:"type" bun:"type"`
+	Payload     json.RawMessage `json:"payload" bun:"payload,type:jsonb"`
+	CreatedAt   time.Time       `json:"created_at" bun:"created_at"`
+	PublishedAt *time.Time      `json:"published_at,omitempty" bun:"published_at"`
+	Attempts    int             `json:"attempts" bun:"attempts"`
+	LastError   string          `json:"last_error,omitempty" bun:"last_error,nullzero"`
+
+	// NextAttemptAt is when an event that failed to be published is retried, and ParkedAt when
+	// it ran out of attempts and was set aside
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" bun:"next_attempt_at"`
+	ParkedAt      *time.Time `json:"parked_at,omitempty" bun:"parked_at"`
+}
+
+// NewAccountEvent returns an event of the account with the account as its payload
+func NewAccountEvent(eventType EventType, account Account, createdAt time.Time) (Event, error) {
+
+	payload, err := json.Marshal(account)
+	if err != nil {
+		return Event{}, fmt.Errorf("unable to marshal account [%s]", err.Error())
+	}
+
+	return Event{
+		AccountID: account.AccountID,
+		Type:      eventType,
+		Payload:   payload,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// NewTransactionEvent returns an event of the transaction with the transaction as its payload
+func NewTransactionEvent(eventType EventType, transaction Transaction, createdAt time.Time) (Event, error) {
+
+	transaction.OperationType = nil
+
+	payload, err := json.Marshal(transaction)
+	if err != nil {
+		return Event{}, fmt.Errorf("unable to marshal transaction [%s]", err.Error())
+	}
+
+	return Event{
+		AccountID: transaction.AccountID,
+		Type:      eventType,
+		Payload:   payload,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+type OutboxService interface {
+	// LockRelay keeps other relays from publishing until the returned func is called, so that the
+	// events of an account are published in order, it returns false when another relay holds the lock
+	LockRelay(ctx context.Context) (func(), bool, error)
+	// ListPending returns up to limit events that are neither published nor parked with an id greater than afterID, by id
+	ListPending(ctx context.Context, afterID int64, limit int) ([]Event, error)
+	MarkPublished(ctx context.Context, eventID int64, publishedAt time.Time) error
+	// MarkFailed records a failed attempt to publish the event, it stays pending until its retry at nextAttemptAt
+	MarkFailed(ctx context.Context, eventID int64, cause string, nextAttemptAt time.Time) error
+	// Park records the last failed attempt to publish the event and sets it aside, it is no longer pending
+	Park(ctx context.Context, eventID int64, cause string, parkedAt time.Time) error
+}
+
+// EventPublisher delivers the events of the outbox to the systems downstream
+type EventPublisher interface {
+	// Publish delivers the event, an event whose delivery was not confirmed is published again
+	// so consumers must expect duplicates and can drop them by id
+	Publish(ctx context.Context, event Event) error
+}
+
+// EventHandler consumes the events of an InProcessPublisher
+type EventHandler func(ctx context.Context, event Event) error
+
+// InProcessPublisher publishes the events to the handlers subscribed within the app
+type InProcessPublisher struct {
+	mu       sync.RWMutex
+	handlers []EventHandler
+}
+
+func NewInProcessPublisher() *InProcessPublisher {
+	return &InProcessPublisher{}
+}
+
+// Subscribe adds a handler that is called with every event published from then on
+func (p *InProcessPublisher) Subscribe(handler EventHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handlers = append(p.handlers, handler)
+}
+
+// Publish calls the handlers in the order they subscribed and stops at the first that fails,
+// the event is then published again to all of them
+func (p *InProcessPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, handler := range p.handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FilePublisher appends the events to a file, one json object per line
+type FilePublisher struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFilePublisher(path string) *FilePublisher {
+	return &FilePublisher{
+		path: path,
+	}
+}
+
+func (p *FilePublisher) Publish(_ context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ba, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("unable to marshal event [%s]", err.Error())
+	}
+
+	file, err := os.OpenFile(p.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(ba, '\n')); err != nil {
+		return err
+	}
+
+	// the event is only marked as published once it is on disk
+	return file.Sync()
+}
@@ -0,0 +1,226 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// ExchangeRateScale is the number of decimal places exchange rates are tracked with
+const ExchangeRateScale = 8
+
+const exchangeRateUnits = 100_000_000
+
+// ExchangeRate is how much of a currency one unit of another buys, in hundred millionths
+type ExchangeRate int64
+
+// ParseExchangeRate parses a positive decimal string such as "5.1234" without going through a float,
+// rates with more than ExchangeRateScale decimal places are rejected
+func ParseExchangeRate(s string) (ExchangeRate, error) {
+
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+
+	if err := checkDecimalSize(s); err != nil {
+		return 0, fmt.Errorf("invalid rate, %s", err.Error())
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+
+	r.Mul(r, big.NewRat(exchangeRateUnits, 1))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("rate must be capped to %d decimal places", ExchangeRateScale)
+	}
+
+	units := r.Num()
+	if !units.IsInt64() {
+		return 0, fmt.Errorf("rate out of range")
+	}
+
+	if units.Sign() <= 0 {
+		return 0, fmt.Errorf("rate must be greater than 0")
+	}
+
+	return ExchangeRate(units.Int64()), nil
+}
+
+// MustParseExchangeRate is like ParseExchangeRate but panics if the rate is invalid
+func MustParseExchangeRate(s string) ExchangeRate {
+	r, err := ParseExchangeRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// String formats the rate as a decimal without trailing zeros
+func (r ExchangeRate) String() string {
+
+	s := fmt.Sprintf("%d.%08d", r/exchangeRateUnits, r%exchangeRateUnits)
+	s = strings.TrimRight(s, "0")
+
+	return strings.TrimSuffix(s, ".")
+}
+
+func (r ExchangeRate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON accepts a json number or a numeric string
+func (r *ExchangeRate) UnmarshalJSON(data []byte) error {
+
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return fmt.Errorf("invalid rate %s", s)
+		}
+		s = unquoted
+	}
+
+	parsed, err := ParseExchangeRate(s)
+	if err != nil {
+		return err
+	}
+
+	*r = parsed
+	return nil
+}
+
+// Convert returns the amount at the rate, rounded half away from zero to the minor unit of the to currency
+func (r ExchangeRate) Convert(amount Money, to Currency) Money {
+
+	// the product is in hundred millionths of the minor units of Money, it is divided by the
+	// size of a minor unit of the currency in those and multiplied back once rounded
+	unit := new(big.Int).Mul(big.NewInt(int64(to.MinorUnit())), big.NewInt(exchangeRateUnits))
+	product := new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(r)))
+
+	negative := product.Sign() < 0
+	product.Abs(product)
+
+	quotient, remainder := new(big.Int).QuoRem(product, unit, new(big.Int))
+	if remainder.Lsh(remainder, 1).Cmp(unit) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+	if negative {
+		quotient.Neg(quotient)
+	}
+
+	return Money(quotient.Int64()) * to.MinorUnit()
+}
+
+// FXRate is the current rate from a currency to another, set through the api or loaded from a file
+type FXRate struct {
+	bun.BaseModel `bun:"table:fx_rate,alias:fxr"`
+
+	From      Currency     `json:"from" bun:"from_currency,pk"`
+	To        Currency     `json:"to" bun:"to_currency,pk"`
+	Rate      ExchangeRate `json:"rate" bun:"rate"`
+	UpdatedAt time.Time    `json:"updated_at" bun:"updated_at"`
+}
+
+// Validate checks that the rate is positive and converts between two different supported currencies
+func (r FXRate) Validate() error {
+
+	switch {
+	case !r.From.IsSupported():
+		return fmt.Errorf("%w %q", UnsupportedCurrencyErr, r.From)
+	case !r.To.IsSupported():
+		return fmt.Errorf("%w %q", UnsupportedCurrencyErr, r.To)
+	case r.From == r.To:
+		return fmt.Errorf("rate must convert between two different currencies")
+	case r.Rate <= 0:
+		return fmt.Errorf("rate must be greater than 0")
+	}
+
+	return nil
+}
+
+// FXQuote locks the rate of a currency pair until it expires, the transaction booked with
+// the quote is converted at its rate whatever the current rate is. A quote books a single
+// transaction, UsedAt is when it did
+type FXQuote struct {
+	bun.BaseModel `bun:"table:fx_quote,alias:fxq"`
+
+	ID        int64        `json:"id" bun:"id,pk,autoincrement"`
+	From      Currency     `json:"from" bun:"from_currency"`
+	To        Currency     `json:"to" bun:"to_currency"`
+	Rate      ExchangeRate `json:"rate" bun:"rate"`
+	CreatedAt time.Time    `json:"created_at" bun:"created_at"`
+	ExpiresAt time.Time    `json:"expires_at" bun:"expires_at"`
+	UsedAt    *time.Time   `json:"used_at,omitempty" bun:"used_at"`
+}
+
+// IsExpired reports whether the rate of the quote is no longer locked
+func (q FXQuote) IsExpired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
+
+// CheckBooks checks that the quote can book the converted transaction at now: it was not used yet,
+// it has not expired and the transaction was converted at its rate between its currencies
+func (q FXQuote) CheckBooks(transaction Transaction, now time.Time) error {
+
+	switch {
+	case q.UsedAt != nil:
+		return fmt.Errorf("%w, quote %d was used at %s", FXQuoteUsedErr, q.ID, q.UsedAt.Format(time.RFC3339))
+	case q.IsExpired(now):
+		return fmt.Errorf("%w at %s", FXQuoteExpiredErr, q.ExpiresAt.Format(time.RFC3339))
+	case q.From != transaction.OriginalCurrency || q.To != transaction.Currency || transaction.FXRate == nil || *transaction.FXRate != q.Rate:
+		return fmt.Errorf("%w, quote %d converts from %s to %s", FXQuoteMismatchErr, q.ID, q.From, q.To)
+	}
+
+	return nil
+}
+
+type FXService interface {
+	// SetRate adds the rate of a currency pair or replaces its current one
+	SetRate(ctx context.Context, rate FXRate) (FXRate, error)
+	// GetRate returns the current rate from a currency to another, NoRecordErr when the pair has none
+	GetRate(ctx context.Context, from Currency, to Currency) (FXRate, error)
+	// ListRates returns the current rates ordered by pair
+	ListRates(ctx context.Context) ([]FXRate, error)
+	// CreateQuote locks the current rate of the pair, it fails with ConversionUnavailableErr when the pair has none
+	CreateQuote(ctx context.Context, from Currency, to Currency) (FXQuote, error)
+	GetQuote(ctx context.Context, quoteID int64) (FXQuote, error)
+}
+
+type rateConverter struct {
+	fx FXService
+}
+
+// NewRateConverter returns a converter that converts amounts at the current rates of fx
+func NewRateConverter(fx FXService) CurrencyConverter {
+	return &rateConverter{
+		fx: fx,
+	}
+}
+
+func (rc *rateConverter) Convert(ctx context.Context, amount Money, from Currency, to Currency) (Money, ExchangeRate, error) {
+
+	rate, err := rc.fx.GetRate(ctx, from, to)
+	if err != nil {
+		if errors.Is(err, NoRecordErr) {
+			err = fmt.Errorf("%w, no rate from %s to %s", ConversionUnavailableErr, from, to)
+		}
+		return amount, 0, err
+	}
+
+	return rate.Rate.Convert(amount, to), rate.Rate, nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// IdempotencyKey identifies a client request that must only be applied once.
+// Scope separates keys of different endpoints and RequestHash is used to
+// detect a key being reused with a different payload.
+type IdempotencyKey struct {
+	Scope       string
+	Key         string
+	RequestHash string
+
+	// Response renders the response served for the result of the request, services store it
+	// in the same database transaction as the claim so that a committed request is never left
+	// in progress. Keys without one stay in progress until they are completed
+	Response IdempotentResponse
+}
+
+// IdempotentResponse renders the status code and body served for the result a service returned
+type IdempotentResponse func(result any) (statusCode int, body []byte, err error)
+
+// IdempotencyRecord is the persisted state of an idempotency key along with
+// the first response that was served for it.
+type IdempotencyRecord struct {
+	bun.BaseModel `bun:"table:idempotency_key,alias:ik"`
+
+	Scope        string    `bun:"scope,pk"`
+	Key          string    `bun:"key,pk"`
+	RequestHash  string    `bun:"request_hash"`
+	StatusCode   int       `bun:"status_code,nullzero"`
+	ResponseBody []byte    `bun:"response_body"`
+	CreatedAt    time.Time `bun:"created_at"`
+}
+
+// IsComplete returns whether a response has been stored for the key
+func (ir IdempotencyRecord) IsComplete() bool {
+	return ir.StatusCode != 0
+}
+
+type IdempotencyService interface {
+	GetForKey(ctx context.Context, scope string, key string) (IdempotencyRecord, error)
+	// Complete stores the response of a key that is still in progress, or claims the key along with
+	// the response when the claim was rolled back with a request that failed
+	Complete(ctx context.Context, record IdempotencyRecord) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type idempotencyKeyCtxKey struct{}
+
+// ContextWithIdempotencyKey attaches an idempotency key to the context.
+// Services that create records claim the key in the same database transaction.
+func ContextWithIdempotencyKey(ctx context.Context, key IdempotencyKey) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key attached to the context if any
+func IdempotencyKeyFromContext(ctx context.Context) (IdempotencyKey, bool) {
+	key, ok := ctx.Value(idempotencyKeyCtxKey{}).(IdempotencyKey)
+	return key, ok
+}
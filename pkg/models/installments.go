@@ -0,0 +1,73 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// MaxInstallments is the largest number of installments a purchase can be split into
+const MaxInstallments = 24
+
+// Installment is a part of a purchase with installments, it only becomes
+// an open debit on the purchase once it is posted on its due date
+type Installment struct {
+	bun.BaseModel `bun:"table:installment,alias:i"`
+
+	ID            int64      `json:"id" bun:"id,pk,autoincrement"`
+	TransactionID int64      `json:"transaction_id" bun:"transaction_id"`
+	AccountID     int64      `json:"account_id" bun:"account_id"`
+	Number        int        `json:"number" bun:"number"`
+	Amount        Money      `json:"amount" bun:"amount"`
+	DueDate       time.Time  `json:"due_date" bun:"due_date"`
+	PostedAt      *time.Time `json:"posted_at,omitempty" bun:"posted_at"`
+}
+
+type InstallmentService interface {
+	ListForTransaction(ctx context.Context, transactionID int64) ([]Installment, error)
+	// PostDue turns the installments due by the given time into open debits and returns how many were posted,
+	// the installments that could not be posted are skipped and returned in a BatchError
+	PostDue(ctx context.Context, until time.Time) (int, error)
+}
+
+// NewInstallmentSchedule splits a total into monthly installments starting at the given date, each a whole
+// number of minor units of the currency, the units that can not be split evenly go to the first installment
+// so they sum exactly to the total
+func NewInstallmentSchedule(total Money, count int, start time.Time, currency Currency) []Installment {
+
+	schedule := make([]Installment, 0, count)
+	unit := currency.MinorUnit()
+	amount := total / unit / Money(count) * unit
+	remainder := total - amount*Money(count)
+
+	for i := 0; i < count; i++ {
+		installment := Installment{
+			Number:  i + 1,
+			Amount:  amount,
+			DueDate: addMonths(start, i),
+		}
+		if i == 0 {
+			installment.Amount += remainder
+		}
+		schedule = append(schedule, installment)
+	}
+
+	return schedule
+}
+
+// addMonths adds months to a date keeping the day of the month, clamped to the last day
+// of shorter months, so that the 31st of january is followed by the end of february
+func addMonths(t time.Time, months int) time.Time {
+
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+
+	firstOfMonth := time.Date(year, month+time.Month(months), 1, hour, min, sec, t.Nanosecond(), t.Location())
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// LedgerAccount is an account of the double-entry ledger, customer ledger accounts
+// are kept per account while the others are shared by the whole system
+type LedgerAccount string
+
+const (
+	CustomerLedgerAccount         LedgerAccount = "customer"
+	MerchantClearingLedgerAccount LedgerAccount = "merchant_clearing"
+	CashLedgerAccount             LedgerAccount = "cash"
+	TransferClearingLedgerAccount LedgerAccount = "transfer_clearing"
+	FeeIncomeLedgerAccount        LedgerAccount = "fee_income"
+)
+
+// JournalEntry records a transaction in the ledger, it is never updated and
+// its postings must sum to zero
+type JournalEntry struct {
+	bun.BaseModel `bun:"table:journal_entry,alias:je"`
+
+	ID            int64     `json:"id" bun:"id,pk,autoincrement"`
+	TransactionID int64     `json:"transaction_id" bun:"transaction_id"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at"`
+
+	Postings []Posting `json:"postings" bun:"rel:has-many,join:id=journal_entry_id"`
+}
+
+// Posting is a leg of a journal entry, debits are positive and credits negative. The shared ledger
+// accounts take postings in every currency, their balances are only meaningful per currency
+type Posting struct {
+	bun.BaseModel `bun:"table:posting,alias:p"`
+
+	ID             int64         `json:"id" bun:"id,pk,autoincrement"`
+	JournalEntryID int64         `json:"journal_entry_id" bun:"journal_entry_id"`
+	LedgerAccount  LedgerAccount `json:"ledger_account" bun:"ledger_account"`
+	AccountID      *int64        `json:"account_id,omitempty" bun:"account_id"`
+	Amount         Money         `json:"amount" bun:"amount"`
+	Currency       Currency      `json:"currency" bun:"currency"`
+}
+
+type LedgerService interface {
+	ListForTransaction(ctx context.Context, transactionID int64) ([]JournalEntry, error)
+	// Verify returns the ids of the journal entries whose postings do not sum to zero
+	Verify(ctx context.Context) ([]int64, error)
+}
+
+// NewJournalEntry returns the entry that records a booked transaction, the customer
+// ledger account of the account is debited by what the customer owes and the
+// counterpart of the operation type takes the other side
+func NewJournalEntry(transaction Transaction) JournalEntry {
+
+	accountID := transaction.AccountID
+
+	currency := transaction.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	return JournalEntry{
+		TransactionID: transaction.ID,
+		CreatedAt:     transaction.EventDate,
+		Postings: []Posting{
+			{LedgerAccount: CustomerLedgerAccount, AccountID: &accountID, Amount: -transaction.Amount, Currency: currency},
+			{LedgerAccount: counterpartLedgerAccount(transaction.OperationTypeID), Amount: transaction.Amount, Currency: currency},
+		},
+	}
+}
+
+// IsBalanced reports whether the postings of the entry sum to zero in each of their currencies
+func (je JournalEntry) IsBalanced() bool {
+
+	if len(je.Postings) < 2 {
+		return false
+	}
+
+	sums := map[Currency]Money{}
+	for _, posting := range je.Postings {
+		sums[posting.Currency] += posting.Amount
+	}
+
+	for _, sum := range sums {
+		if sum != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// counterpartLedgerAccount is where the money of a transaction comes from or goes to,
+// purchases are owed to merchants, transfers net out between customers, interest and fees
+// are income and everything else, including the operation types added through the api, moves cash
+func counterpartLedgerAccount(operationTypeID int64) LedgerAccount {
+	switch OperationTypeID(operationTypeID) {
+	case NormalPurchase, PurchaseWithInstallments, PurchaseReversal:
+		return MerchantClearingLedgerAccount
+	case TransferOut, TransferIn:
+		return TransferClearingLedgerAccount
+	case Interest, LateFee:
+		return FeeIncomeLedgerAccount
+	}
+	return CashLedgerAccount
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MoneyScale is the number of decimal places amounts are tracked with
+const MoneyScale = 2
+
+const moneyMinorUnits = 100
+
+// MaxMoney is the largest amount, either way, that is parsed, it leaves room below the int64 limit
+// for the sums of balances and for interest multiplying them by a rate in basis points
+const MaxMoney Money = 100_000_000_000 * moneyMinorUnits
+
+// maxDecimalLength and maxDecimalExponent bound the decimal strings handed to big.Rat, whose
+// SetString allocates as many digits as an exponent asks for
+const (
+	maxDecimalLength   = 64
+	maxDecimalExponent = 20
+)
+
+// decimalPattern is the only syntax amounts and rates are written in, big.Rat also takes
+// fractions and the base prefixes, underscores and binary exponents of go literals
+var decimalPattern = regexp.MustCompile(`^[+-]?\d+(\.\d+)?([eE][+-]?\d+)?$`)
+
+// checkDecimalSize rejects decimal strings that are too long or whose exponent is too large
+// for any amount or rate, before they are parsed
+func checkDecimalSize(s string) error {
+
+	if len(s) > maxDecimalLength {
+		return fmt.Errorf("must have at most %d characters", maxDecimalLength)
+	}
+
+	i := strings.IndexAny(s, "eE")
+	if i < 0 {
+		return nil
+	}
+
+	exponent, err := strconv.Atoi(s[i+1:])
+	if err != nil || exponent < -maxDecimalExponent || exponent > maxDecimalExponent {
+		return fmt.Errorf("exponent must be between %d and %d", -maxDecimalExponent, maxDecimalExponent)
+	}
+
+	return nil
+}
+
+// Money is an amount in minor units (cents), all arithmetic on it is exact
+type Money int64
+
+// ParseMoney parses a decimal string such as "123.45" into minor units without
+// going through a float, amounts with more than MoneyScale decimal places or beyond MaxMoney are rejected
+func ParseMoney(s string) (Money, error) {
+
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	if err := checkDecimalSize(s); err != nil {
+		return 0, fmt.Errorf("invalid amount, %s", err.Error())
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	r.Mul(r, big.NewRat(moneyMinorUnits, 1))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("amount must be capped to %d decimal places", MoneyScale)
+	}
+
+	minor := r.Num()
+	if minor.CmpAbs(big.NewInt(int64(MaxMoney))) > 0 {
+		return 0, fmt.Errorf("amount out of range, must be at most %s either way", MaxMoney)
+	}
+
+	return Money(minor.Int64()), nil
+}
+
+// MustParseMoney is like ParseMoney but panics if the amount is invalid
+func MustParseMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Abs returns the absolute value of the amount
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// String formats the amount as a decimal with MoneyScale decimal places
+func (m Money) String() string {
+
+	sign := ""
+	minor := uint64(m)
+	if m < 0 {
+		sign = "-"
+		minor = uint64(-m)
+	}
+
+	return fmt.Sprintf("%s%d.%02d", sign, minor/moneyMinorUnits, minor%moneyMinorUnits)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a json number or a numeric string
+func (m *Money) UnmarshalJSON(data []byte) error {
+
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	s := string(data)
+	if strings.HasPrefix(s, `"`) {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return fmt.Errorf("invalid amount %s", s)
+		}
+		s = unquoted
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
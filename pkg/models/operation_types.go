@@ -0,0 +1,19 @@
+package models
+
+import "context"
+
+type OperationTypeService interface {
+	Create(ctx context.Context, operationType OperationType) (OperationType, error)
+	GetForID(ctx context.Context, operationTypeID int64) (OperationType, error)
+	List(ctx context.Context) ([]OperationType, error)
+	Update(ctx context.Context, update OperationTypeUpdate) (OperationType, error)
+}
+
+// OperationTypeUpdate changes the fields that are set, the direction can not be changed
+// once transactions may have been booked with the type
+type OperationTypeUpdate struct {
+	ID             int64
+	Description    *string
+	Active         *bool
+	APRBasisPoints *int64
+}
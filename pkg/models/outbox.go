@@ -0,0 +1,120 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+var outboxRelayBatchSize = 100
+
+// maxOutboxRetryBackoff caps the wait between two attempts of an event
+const maxOutboxRetryBackoff = time.Hour
+
+// OutboxRelayResult is the outcome of a relay
+type OutboxRelayResult struct {
+	Published int
+	// Parked are the events that ran out of attempts, they are no longer published
+	// and no longer hold back the events of their account
+	Parked []int64
+	// Blocked are the accounts whose events are held back behind an event waiting for its retry
+	Blocked []int64
+}
+
+// OutboxRelay publishes the events of the outbox. Events are marked as published only once the
+// publisher confirmed them, so every event is delivered at least once, and the events of an account
+// are published in the order they were written
+type OutboxRelay struct {
+	outbox      OutboxService
+	publisher   EventPublisher
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// NewOutboxRelay returns a relay that retries an event that failed to be published backoff after its first
+// attempt, doubling the wait after every attempt, and parks it after maxAttempts
+func NewOutboxRelay(outbox OutboxService, publisher EventPublisher, maxAttempts int, backoff time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		outbox:      outbox,
+		publisher:   publisher,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+// Relay publishes the pending events that are due by now. An event that fails to be published holds back
+// the later events of its account until a later relay publishes it or it runs out of attempts and is parked
+func (r *OutboxRelay) Relay(ctx context.Context, now time.Time) (OutboxRelayResult, error) {
+
+	result := OutboxRelayResult{}
+
+	release, ok, err := r.outbox.LockRelay(ctx)
+	if err != nil || !ok {
+		return result, err
+	}
+	defer release()
+
+	blocked := map[int64]bool{}
+	afterID := int64(0)
+
+	for {
+		pending, err := r.outbox.ListPending(ctx, afterID, outboxRelayBatchSize)
+		if err != nil || len(pending) == 0 {
+			return result, err
+		}
+
+		for _, event := range pending {
+			afterID = event.ID
+
+			if blocked[event.AccountID] {
+				continue
+			}
+
+			if event.NextAttemptAt != nil && event.NextAttemptAt.After(now) {
+				r.block(&result, blocked, event.AccountID)
+				continue
+			}
+
+			err := r.publisher.Publish(ctx, event)
+			switch {
+			case err != nil && event.Attempts+1 >= r.maxAttempts:
+				if err := r.outbox.Park(ctx, event.ID, err.Error(), now); err != nil {
+					return result, err
+				}
+				result.Parked = append(result.Parked, event.ID)
+			case err != nil:
+				if err := r.outbox.MarkFailed(ctx, event.ID, err.Error(), now.Add(OutboxRetryBackoff(r.backoff, event.Attempts+1))); err != nil {
+					return result, err
+				}
+				r.block(&result, blocked, event.AccountID)
+			default:
+				// an event that is published but not marked is published again by the next relay
+				if err := r.outbox.MarkPublished(ctx, event.ID, time.Now()); err != nil {
+					return result, err
+				}
+				result.Published++
+			}
+		}
+
+		if len(pending) < outboxRelayBatchSize {
+			return result, nil
+		}
+	}
+}
+
+// block holds back the later events of the account for the rest of the relay
+func (r *OutboxRelay) block(result *OutboxRelayResult, blocked map[int64]bool, accountID int64) {
+	blocked[accountID] = true
+	result.Blocked = append(result.Blocked, accountID)
+}
+
+// OutboxRetryBackoff returns the wait after the given number of attempts of an event, backoff after the first
+// one doubled after every other attempt, up to maxOutboxRetryBackoff
+func OutboxRetryBackoff(backoff time.Duration, attempts int) time.Duration {
+
+	wait := backoff
+	for i := 1; i < attempts && wait < maxOutboxRetryBackoff; i++ {
+		wait *= 2
+	}
+
+	return min(wait, maxOutboxRetryBackoff)
+}
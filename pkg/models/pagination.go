@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// TransactionCursor is the keyset position of the last transaction of a page,
+// transactions are listed newest first ordered by event date and id
+type TransactionCursor struct {
+	EventDate time.Time
+	ID        int64
+}
+
+// Encode returns an opaque representation of the cursor to hand out to clients
+func (tc TransactionCursor) Encode() string {
+	raw := tc.EventDate.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatInt(tc.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeTransactionCursor parses a cursor returned by Encode
+func DecodeTransactionCursor(s string) (TransactionCursor, error) {
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return TransactionCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	eventDateS, idS, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return TransactionCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	eventDate, err := time.Parse(time.RFC3339Nano, eventDateS)
+	if err != nil {
+		return TransactionCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	id, err := strconv.ParseInt(idS, 10, 64)
+	if err != nil {
+		return TransactionCursor{}, fmt.Errorf("invalid cursor")
+	}
+
+	return TransactionCursor{EventDate: eventDate, ID: id}, nil
+}
+
+// TransactionFilter selects the transactions of an account to list
+type TransactionFilter struct {
+	AccountID        int64
+	OperationTypeIDs []int64
+	// From is inclusive and To is exclusive
+	From *time.Time
+	To   *time.Time
+	// Settled selects transactions with no remaining balance when true
+	// and transactions with an open balance when false
+	Settled *bool
+	After   *TransactionCursor
+	Limit   int
+}
+
+type TransactionPage struct {
+	Transactions []Transaction
+	NextCursor   *TransactionCursor
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ScheduleIdempotencyScope is the idempotency scope of the transactions booked by schedules,
+// unlike the scopes of the api it is not a path so that client keys can not collide with it
+const ScheduleIdempotencyScope = "schedule-run"
+
+var scheduleLeaseBatchSize = 100
+
+// Scheduler books the transactions of the due schedules through the transaction service. Every
+// occurrence is booked with its own idempotency key, so a run that is repeated after a crash or
+// after its lease expired finds the transaction of the first one instead of booking it again
+type Scheduler struct {
+	schedules      ScheduleService
+	transactions   TransactionService
+	operationTypes OperationTypeService
+	idempotency    IdempotencyService
+	owner          string
+	leaseTTL       time.Duration
+}
+
+// NewScheduler returns a scheduler that holds the schedules it runs for leaseTTL, it must be
+// longer than booking a transaction takes
+func NewScheduler(schedules ScheduleService, transactions TransactionService, operationTypes OperationTypeService, idempotency IdempotencyService, leaseTTL time.Duration) *Scheduler {
+
+	id := make([]byte, 8)
+	rand.Read(id)
+
+	return &Scheduler{
+		schedules:      schedules,
+		transactions:   transactions,
+		operationTypes: operationTypes,
+		idempotency:    idempotency,
+		owner:          hex.EncodeToString(id),
+		leaseTTL:       leaseTTL,
+	}
+}
+
+// RunDue executes the schedules due by now and returns how many runs were logged, failed ones included
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) (int, error) {
+
+	runs := 0
+
+	for {
+		// schedules catching up on missed occurrences are due again right after their run,
+		// failed runs are not as their retry is always later than now
+		leased, err := s.schedules.Lease(ctx, s.owner, now, s.leaseTTL, scheduleLeaseBatchSize)
+		if err != nil || len(leased) == 0 {
+			return runs, err
+		}
+
+		for _, schedule := range leased {
+			_, err := s.schedules.RecordRun(ctx, s.owner, s.run(ctx, schedule, now))
+			switch {
+			case errors.Is(err, ScheduleLeaseLostErr):
+				continue
+			case err != nil:
+				return runs, err
+			}
+			runs++
+		}
+	}
+}
+
+// run books the transaction of the leased occurrence and returns the log entry of the attempt
+func (s *Scheduler) run(ctx context.Context, schedule Schedule, now time.Time) ScheduleRun {
+
+	run := ScheduleRun{
+		ScheduleID:   schedule.ID,
+		ScheduledFor: schedule.NextRunAt,
+		Attempt:      schedule.Attempts + 1,
+		Status:       ScheduleRunSucceeded,
+		CreatedAt:    now,
+	}
+
+	// the operation type may have been deactivated since the schedule was created, the run
+	// fails like the api rejects new transactions of the type, and is retried in case it is reactivated
+	operationType, err := s.operationTypes.GetForID(ctx, schedule.OperationTypeID)
+	switch {
+	case err != nil:
+		run.Status = ScheduleRunFailed
+		run.Error = err.Error()
+		return run
+	case !operationType.Active:
+		run.Status = ScheduleRunFailed
+		run.Error = fmt.Errorf("%w, operation type %d", OperationTypeNotActiveErr, operationType.ID).Error()
+		return run
+	}
+
+	key := ScheduleIdempotencyKey(schedule)
+
+	transactionStatus, err := s.transactions.Create(ContextWithIdempotencyKey(ctx, key), Transaction{
+		AccountID:       schedule.AccountID,
+		OperationTypeID: schedule.OperationTypeID,
+		Amount:          schedule.Amount,
+	})
+
+	switch {
+	case err == nil:
+		run.TransactionID = &transactionStatus.TransactionID
+	case errors.Is(err, IdempotencyKeyReplayErr):
+		run.TransactionID = s.replayedTransactionID(ctx, key)
+	default:
+		// IdempotencyKeyInProgressErr included: whether the concurrent attempt books the occurrence
+		// is not known yet, the retry replays its transaction or books it again if it was rolled back
+		run.Status = ScheduleRunFailed
+		run.Error = err.Error()
+	}
+
+	return run
+}
+
+// ScheduleIdempotencyKey returns the key the transaction of the next occurrence of a schedule is booked with,
+// the booked transaction is stored along with it so that a repeated run can log its id
+func ScheduleIdempotencyKey(schedule Schedule) IdempotencyKey {
+	return IdempotencyKey{
+		Scope: ScheduleIdempotencyScope,
+		Key:   fmt.Sprintf("%d/%d", schedule.ID, schedule.Occurrences),
+		Response: func(result any) (int, []byte, error) {
+			ba, err := json.Marshal(result)
+			return http.StatusCreated, ba, err
+		},
+	}
+}
+
+func (s *Scheduler) replayedTransactionID(ctx context.Context, key IdempotencyKey) *int64 {
+
+	record, err := s.idempotency.GetForKey(ctx, key.Scope, key.Key)
+	if err != nil {
+		return nil
+	}
+
+	transactionStatus := TransactionStatus{}
+	if err := json.Unmarshal(record.ResponseBody, &transactionStatus); err != nil {
+		return nil
+	}
+
+	return &transactionStatus.TransactionID
+}
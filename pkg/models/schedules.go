@@ -0,0 +1,204 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type ScheduleFrequency string
+
+const (
+	OnceSchedule    ScheduleFrequency = "once"
+	DailySchedule   ScheduleFrequency = "daily"
+	WeeklySchedule  ScheduleFrequency = "weekly"
+	MonthlySchedule ScheduleFrequency = "monthly"
+)
+
+// IsValid reports whether the frequency is one of the supported ones
+func (sf ScheduleFrequency) IsValid() bool {
+	switch sf {
+	case OnceSchedule, DailySchedule, WeeklySchedule, MonthlySchedule:
+		return true
+	}
+	return false
+}
+
+// MaxInterval returns the largest interval of a schedule of the frequency, recurring schedules run at least once a year
+func (sf ScheduleFrequency) MaxInterval() int {
+	switch sf {
+	case DailySchedule:
+		return 365
+	case WeeklySchedule:
+		return 52
+	case MonthlySchedule:
+		return 12
+	}
+	return 1
+}
+
+type ScheduleStatus string
+
+const (
+	ScheduleActive    ScheduleStatus = "active"
+	ScheduleCompleted ScheduleStatus = "completed"
+	ScheduleCancelled ScheduleStatus = "cancelled"
+)
+
+type ScheduleRunStatus string
+
+const (
+	ScheduleRunSucceeded ScheduleRunStatus = "succeeded"
+	ScheduleRunFailed    ScheduleRunStatus = "failed"
+)
+
+const (
+	// MaxScheduleAttempts is how many times a run is tried before its occurrence is skipped
+	MaxScheduleAttempts = 5
+	// ScheduleRetryDelay is the wait before the first retry of a failed run, it doubles on every attempt
+	ScheduleRetryDelay = time.Minute
+)
+
+// Schedule is a transaction booked once at StartAt or every Interval days, weeks or months from it
+// until EndAt, a failed run is retried for the same occurrence before moving on to the next one.
+// While a runner holds the lease no other runner executes the schedule
+type Schedule struct {
+	bun.BaseModel `bun:"table:schedule,alias:sc"`
+
+	ID              int64             `json:"id" bun:"id,pk,autoincrement"`
+	AccountID       int64             `json:"account_id" bun:"account_id"`
+	OperationTypeID int64             `json:"operation_type_id" bun:"operation_type_id"`
+	Amount          Money             `json:"amount" bun:"amount"`
+	Frequency       ScheduleFrequency `json:"frequency" bun:"frequency"`
+	Interval        int               `json:"interval" bun:"interval_count"`
+	StartAt         time.Time         `json:"start_at" bun:"start_at"`
+	EndAt           *time.Time        `json:"end_at,omitempty" bun:"end_at"`
+	Status          ScheduleStatus    `json:"status" bun:"status"`
+	NextRunAt       time.Time         `json:"next_run_at" bun:"next_run_at"`
+	Occurrences     int               `json:"occurrences" bun:"occurrences"`
+	Attempts        int               `json:"attempts" bun:"attempts"`
+	RetryAt         *time.Time        `json:"retry_at,omitempty" bun:"retry_at"`
+	LeaseOwner      *string           `json:"-" bun:"lease_owner"`
+	LeasedUntil     *time.Time        `json:"-" bun:"leased_until"`
+	CreatedAt       time.Time         `json:"created_at" bun:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at" bun:"updated_at"`
+}
+
+// ScheduleRun is an entry of the execution log of a schedule, one per attempt
+type ScheduleRun struct {
+	bun.BaseModel `bun:"table:schedule_run,alias:sr"`
+
+	ID            int64             `json:"id" bun:"id,pk,autoincrement"`
+	ScheduleID    int64             `json:"schedule_id" bun:"schedule_id"`
+	ScheduledFor  time.Time         `json:"scheduled_for" bun:"scheduled_for"`
+	Attempt       int               `json:"attempt" bun:"attempt"`
+	Status        ScheduleRunStatus `json:"status" bun:"status"`
+	TransactionID *int64            `json:"transaction_id,omitempty" bun:"transaction_id"`
+	Error         string            `json:"error,omitempty" bun:"error,nullzero"`
+	CreatedAt     time.Time         `json:"created_at" bun:"created_at"`
+}
+
+type ScheduleService interface {
+	Create(ctx context.Context, schedule Schedule) (Schedule, error)
+	GetForID(ctx context.Context, scheduleID int64) (Schedule, error)
+	// Cancel stops an active schedule, a run in progress is still logged
+	Cancel(ctx context.Context, scheduleID int64) (Schedule, error)
+	// ListRuns returns the execution log of a schedule, newest first
+	ListRuns(ctx context.Context, scheduleID int64) ([]ScheduleRun, error)
+	// Lease hands owner up to limit due schedules that no other runner holds until now plus ttl,
+	// a runner that stops before recording its run loses the lease once it expires
+	Lease(ctx context.Context, owner string, now time.Time, ttl time.Duration, limit int) ([]Schedule, error)
+	// RecordRun logs a run of the occurrence owner leased and moves the schedule on as of the time
+	// of the run, it fails with ScheduleLeaseLostErr if the lease expired and the occurrence was
+	// taken by another runner
+	RecordRun(ctx context.Context, owner string, run ScheduleRun) (Schedule, error)
+}
+
+// NewSchedule sets up a schedule for its first run at StartAt
+func NewSchedule(schedule Schedule, now time.Time) Schedule {
+
+	if schedule.Interval == 0 {
+		schedule.Interval = 1
+	}
+
+	schedule.Status = ScheduleActive
+	schedule.NextRunAt = schedule.StartAt
+	schedule.Occurrences = 0
+	schedule.Attempts = 0
+	schedule.RetryAt = nil
+	schedule.LeaseOwner = nil
+	schedule.LeasedUntil = nil
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+
+	return schedule
+}
+
+// Occurrence returns the time of the nth run of the schedule counting from 0, monthly schedules
+// keep the day of StartAt clamped to the end of shorter months like installments do
+func (s Schedule) Occurrence(n int) time.Time {
+	switch s.Frequency {
+	case DailySchedule:
+		return s.StartAt.AddDate(0, 0, n*s.Interval)
+	case WeeklySchedule:
+		return s.StartAt.AddDate(0, 0, 7*n*s.Interval)
+	case MonthlySchedule:
+		return addMonths(s.StartAt, n*s.Interval)
+	}
+	return s.StartAt
+}
+
+// DueAt returns when the schedule runs next, either its next occurrence or the retry of a failed run
+func (s Schedule) DueAt() time.Time {
+	if s.RetryAt != nil {
+		return *s.RetryAt
+	}
+	return s.NextRunAt
+}
+
+// IsDue reports whether an active schedule should run at now
+func (s Schedule) IsDue(now time.Time) bool {
+	return s.Status == ScheduleActive && !now.Before(s.DueAt())
+}
+
+// IsLeased reports whether a runner holds the schedule at now
+func (s Schedule) IsLeased(now time.Time) bool {
+	return s.LeasedUntil != nil && now.Before(*s.LeasedUntil)
+}
+
+// IsLeasedBy reports whether owner holds the lease of the occurrence the run was scheduled for
+func (s Schedule) IsLeasedBy(owner string, run ScheduleRun) bool {
+	return s.LeaseOwner != nil && *s.LeaseOwner == owner && s.NextRunAt.Equal(run.ScheduledFor)
+}
+
+// Record moves the schedule on after a run, a success or the last failed attempt moves to the next
+// occurrence while the other failures are retried with an exponential backoff. The lease is released
+func (s *Schedule) Record(run ScheduleRun, now time.Time) {
+
+	s.LeaseOwner = nil
+	s.LeasedUntil = nil
+	s.UpdatedAt = now
+
+	if s.Status != ScheduleActive {
+		return
+	}
+
+	if run.Status == ScheduleRunFailed && run.Attempt < MaxScheduleAttempts {
+		retryAt := now.Add(ScheduleRetryDelay << (run.Attempt - 1))
+		s.Attempts = run.Attempt
+		s.RetryAt = &retryAt
+		return
+	}
+
+	s.Occurrences++
+	s.Attempts = 0
+	s.RetryAt = nil
+
+	next := s.Occurrence(s.Occurrences)
+	if s.Frequency == OnceSchedule || (s.EndAt != nil && next.After(*s.EndAt)) {
+		s.Status = ScheduleCompleted
+		return
+	}
+	s.NextRunAt = next
+}
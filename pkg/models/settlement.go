@@ -0,0 +1,105 @@
+package models
+
+import (
+	"sort"
+)
+
+// SettlementStrategy decides the order in which settlement pays off or consumes
+// the open balances of the previous transactions of an account
+type SettlementStrategy interface {
+	// Order sorts the transactions of an account, given oldest first, in the order they are settled
+	Order(transactions []Transaction)
+}
+
+// Names accounts use to select one of the settlement strategies shipped with the app
+const (
+	FIFOSettlementStrategy     = "fifo"
+	LIFOSettlementStrategy     = "lifo"
+	PrioritySettlementStrategy = "priority"
+)
+
+// FIFOSettlement settles the oldest transactions first
+type FIFOSettlement struct{}
+
+func (FIFOSettlement) Order(transactions []Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return isOlder(transactions[i], transactions[j])
+	})
+}
+
+// LIFOSettlement settles the newest transactions first
+type LIFOSettlement struct{}
+
+func (LIFOSettlement) Order(transactions []Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return isOlder(transactions[j], transactions[i])
+	})
+}
+
+// PrioritySettlement settles transactions by the priority of their operation type, lower first,
+// operation types without a priority have 0 and transactions with the same priority are settled oldest first
+type PrioritySettlement struct {
+	Priorities map[int64]int
+}
+
+// DefaultPrioritySettlement pays withdrawals before purchases and purchases with installments last
+func DefaultPrioritySettlement() PrioritySettlement {
+	return PrioritySettlement{
+		Priorities: map[int64]int{
+			int64(Withdrawal):               -2,
+			int64(NormalPurchase):           -1,
+			int64(PurchaseWithInstallments): 1,
+		},
+	}
+}
+
+func (ps PrioritySettlement) Order(transactions []Transaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		pi, pj := ps.Priorities[transactions[i].OperationTypeID], ps.Priorities[transactions[j].OperationTypeID]
+		if pi != pj {
+			return pi < pj
+		}
+		return isOlder(transactions[i], transactions[j])
+	})
+}
+
+// SettlementStrategies holds the strategies accounts can select by name
+// and the default one used for accounts without a strategy
+type SettlementStrategies struct {
+	Default SettlementStrategy
+	ByName  map[string]SettlementStrategy
+}
+
+// NewSettlementStrategies returns the strategies shipped with the app, settling oldest first by default
+func NewSettlementStrategies() SettlementStrategies {
+	return SettlementStrategies{
+		Default: FIFOSettlement{},
+		ByName: map[string]SettlementStrategy{
+			FIFOSettlementStrategy:     FIFOSettlement{},
+			LIFOSettlementStrategy:     LIFOSettlement{},
+			PrioritySettlementStrategy: DefaultPrioritySettlement(),
+		},
+	}
+}
+
+// ForAccount returns the strategy selected by the account, or the default one
+// when the account has none or selected one that is no longer available
+func (ss SettlementStrategies) ForAccount(account Account) SettlementStrategy {
+	if account.SettlementStrategy != nil {
+		if strategy, ok := ss.ByName[*account.SettlementStrategy]; ok {
+			return strategy
+		}
+	}
+	if ss.Default == nil {
+		return FIFOSettlement{}
+	}
+	return ss.Default
+}
+
+// isOlder compares transactions by (event_date, id)
+func isOlder(a, b Transaction) bool {
+	if a.EventDate.Equal(b.EventDate) {
+		return a.ID < b.ID
+	}
+	return a.EventDate.Before(b.EventDate)
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	// DefaultClosingDay is the day of the month the cycle of an account closes when it did not select one
+	DefaultClosingDay = 1
+	// DefaultDueDay is the day of the month the statement of an account is due when it did not select one
+	DefaultDueDay = 10
+	// MaxBillingDay keeps the closing and due days in every month
+	MaxBillingDay = 28
+
+	// MinimumPaymentPercent is the part of the debt of a statement that must be paid by its due date
+	MinimumPaymentPercent = 15
+)
+
+// MinimumPaymentFloor is the smallest minimum payment, unless the whole debt is smaller
+var MinimumPaymentFloor = MustParseMoney("10.00")
+
+// Statement freezes a billing cycle of an account, the lines keep the transactions
+// of the cycle as they were when it closed
+type Statement struct {
+	bun.BaseModel `bun:"table:statement,alias:st"`
+
+	ID             int64           `json:"id" bun:"id,pk,autoincrement"`
+	AccountID      int64           `json:"account_id" bun:"account_id"`
+	PeriodStart    time.Time       `json:"period_start" bun:"period_start"`
+	PeriodEnd      time.Time       `json:"period_end" bun:"period_end"`
+	OpeningBalance Money           `json:"opening_balance" bun:"opening_balance"`
+	ClosingBalance Money           `json:"closing_balance" bun:"closing_balance"`
+	MinimumPayment Money           `json:"minimum_payment" bun:"minimum_payment"`
+	DueDate        time.Time       `json:"due_date" bun:"due_date"`
+	CreatedAt      time.Time       `json:"created_at" bun:"created_at"`
+	Lines          []StatementLine `json:"lines,omitempty" bun:"rel:has-many,join:id=statement_id"`
+}
+
+// StatementLine is a copy of a transaction of the cycle of a statement
+type StatementLine struct {
+	bun.BaseModel `bun:"table:statement_line,alias:sl"`
+
+	ID              int64     `json:"id" bun:"id,pk,autoincrement"`
+	StatementID     int64     `json:"-" bun:"statement_id"`
+	TransactionID   int64     `json:"transaction_id" bun:"transaction_id"`
+	OperationTypeID int64     `json:"operation_type_id" bun:"operation_type_id"`
+	Amount          Money     `json:"amount" bun:"amount"`
+	Balance         Money     `json:"balance" bun:"balance"`
+	EventDate       time.Time `json:"event_date" bun:"event_date"`
+}
+
+type StatementService interface {
+	// ListForAccount returns the statements of an account without their lines, newest first
+	ListForAccount(ctx context.Context, accountID int64) ([]Statement, error)
+	// GetForID returns a statement with its lines
+	GetForID(ctx context.Context, statementID int64) (Statement, error)
+	// GenerateDue closes the cycles of every account that closed by the given time and have no
+	// statement yet, one statement per cycle, it returns how many statements were generated. The
+	// accounts that failed are skipped and returned in a BatchError
+	GenerateDue(ctx context.Context, now time.Time) (int, error)
+}
+
+// BillingCycle returns the closing and due days of the account, falling back to the defaults
+func (a Account) BillingCycle() (closingDay int, dueDay int) {
+
+	closingDay, dueDay = DefaultClosingDay, DefaultDueDay
+	if a.ClosingDay != nil {
+		closingDay = *a.ClosingDay
+	}
+	if a.DueDay != nil {
+		dueDay = *a.DueDay
+	}
+
+	return closingDay, dueDay
+}
+
+// IsBillingDay reports whether day can be used as a closing or due day
+func IsBillingDay(day int) bool {
+	return day >= 1 && day <= MaxBillingDay
+}
+
+// LastClosingDate returns the start of the last closing day that is not after now, billing days
+// are UTC days like the days accruals are keyed by so that a statement is due on the day it is accrued
+func LastClosingDate(closingDay int, now time.Time) time.Time {
+
+	year, month, _ := now.UTC().Date()
+
+	closing := time.Date(year, month, closingDay, 0, 0, 0, 0, time.UTC)
+	if closing.After(now) {
+		closing = closing.AddDate(0, -1, 0)
+	}
+
+	return closing
+}
+
+// StatementDueDate returns the first due day after the closing date
+func StatementDueDate(closing time.Time, dueDay int) time.Time {
+
+	year, month, _ := closing.Date()
+
+	due := time.Date(year, month, dueDay, 0, 0, 0, 0, closing.Location())
+	if !due.After(closing) {
+		due = due.AddDate(0, 1, 0)
+	}
+
+	return due
+}
+
+// MinimumPayment returns the part of a closing balance that must be paid by the due date,
+// a percentage of the debt rounded up to the cent but never less than the floor
+func MinimumPayment(closingBalance Money) Money {
+
+	if closingBalance >= 0 {
+		return 0
+	}
+
+	debt := -closingBalance
+	payment := (debt*MinimumPaymentPercent + 99) / 100
+
+	return min(max(payment, MinimumPaymentFloor), debt)
+}
+
+// NewStatement opens the statement of the cycle that follows the previous statement, or of the first cycle
+// of the account which starts when it was opened, and returns false if that cycle did not close by now.
+// Generating statements until it returns false closes every missed cycle with a statement of its own
+func NewStatement(account Account, previous *Statement, now time.Time) (Statement, bool) {
+
+	closingDay, dueDay := account.BillingCycle()
+
+	statement := Statement{
+		AccountID:   account.AccountID,
+		PeriodStart: account.CreatedAt,
+	}
+
+	switch {
+	case previous != nil:
+		statement.PeriodStart = previous.PeriodEnd
+		statement.OpeningBalance = previous.ClosingBalance
+	case account.CreatedAt.IsZero():
+		// without an opening date the first cycle is the last one that closed
+		statement.PeriodStart = LastClosingDate(closingDay, now).AddDate(0, -1, 0)
+	}
+
+	// the cycle closes on the first closing day after it started, the closing day may have changed since
+	statement.PeriodEnd = LastClosingDate(closingDay, statement.PeriodStart).AddDate(0, 1, 0)
+	statement.DueDate = StatementDueDate(statement.PeriodEnd, dueDay)
+
+	return statement, !statement.PeriodEnd.After(now)
+}
+
+// Close freezes the transactions of the cycle into lines and sets the closing balance, which is what was still
+// open on the transactions made before the end of the cycle. The balances of the transactions must be the ones
+// they had at the end of the cycle, not their current ones
+func (s *Statement) Close(transactions []Transaction, closingBalance Money) {
+
+	s.Lines = make([]StatementLine, 0, len(transactions))
+	for _, transaction := range transactions {
+		s.Lines = append(s.Lines, StatementLine{
+			TransactionID:   transaction.ID,
+			OperationTypeID: transaction.OperationTypeID,
+			Amount:          transaction.Amount,
+			Balance:         transaction.Balance,
+			EventDate:       transaction.EventDate,
+		})
+	}
+
+	s.ClosingBalance = closingBalance
+	s.MinimumPayment = MinimumPayment(closingBalance)
+}
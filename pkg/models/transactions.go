@@ -1,8 +1,51 @@
 package models
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type TransactionService interface {
 	Create(ctx context.Context, transaction Transaction) (TransactionStatus, error)
 	GetForID(ctx context.Context, transactionID int64) (Transaction, error)
+	ListForAccount(ctx context.Context, filter TransactionFilter) (TransactionPage, error)
+	// Reverse voids a debit fully when amount is 0 or partially otherwise
+	Reverse(ctx context.Context, transactionID int64, amount Money) (TransactionStatus, error)
+}
+
+// NewReversal builds the compensating credit for a debit of which reversed was already reversed, a zero
+// amount reverses the rest of it. Only debits booked directly are reversible, a leg of a transfer or a charge
+// booked by the app is not
+func NewReversal(original Transaction, reversed Money, amount Money) (Transaction, error) {
+
+	if original.Amount >= 0 || original.ReversesTransactionID != nil || original.TransferID != nil ||
+		IsInternalOperationType(original.OperationTypeID) {
+		return Transaction{}, NotReversibleErr
+	}
+
+	remaining := -original.Amount - reversed
+	if remaining <= 0 {
+		return Transaction{}, AlreadyReversedErr
+	}
+
+	if amount == 0 {
+		amount = remaining
+	}
+
+	if amount < 0 || amount > remaining {
+		return Transaction{}, ReversalAmountExceededErr
+	}
+
+	if err := original.Currency.CheckAmount(amount); err != nil {
+		return Transaction{}, err
+	}
+
+	return Transaction{
+		AccountID:             original.AccountID,
+		OperationTypeID:       int64(PurchaseReversal),
+		Amount:                amount,
+		Currency:              original.Currency,
+		EventDate:             time.Now(),
+		ReversesTransactionID: &original.ID,
+	}, nil
 }
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Transfer moves money between two accounts, it is booked as a debit on the
+// source and a credit on the destination that both carry the transfer id
+type Transfer struct {
+	bun.BaseModel `bun:"table:transfer,alias:tr"`
+
+	ID                   int64     `json:"id" bun:"id,pk,autoincrement"`
+	SourceAccountID      int64     `json:"source_account_id" bun:"source_account_id"`
+	DestinationAccountID int64     `json:"destination_account_id" bun:"destination_account_id"`
+	Amount               Money     `json:"amount" bun:"amount"`
+	DebitTransactionID   *int64    `json:"debit_transaction_id,omitempty" bun:"debit_transaction_id"`
+	CreditTransactionID  *int64    `json:"credit_transaction_id,omitempty" bun:"credit_transaction_id"`
+	CreatedAt            time.Time `json:"created_at" bun:"created_at"`
+}
+
+type TransferService interface {
+	Create(ctx context.Context, transfer Transfer) (Transfer, error)
+	GetForID(ctx context.Context, transferID int64) (Transfer, error)
+}
@@ -11,38 +11,127 @@ type Account struct {
 
 	AccountID      int64  `json:"account_id" bun:"id,autoincrement"`
 	DocumentNumber string `json:"document_number" bun:"document_number"`
+
+	// DocumentType is the type DocumentNumber was validated as, accounts opened before the
+	// validation have none
+	DocumentType DocumentType `json:"document_type,omitempty" bun:"document_type,nullzero"`
+
+	// CreditLimit is the limit the account was granted and AvailableCreditLimit is what is left of it to spend,
+	// debits consume it and credits restore it
+	CreditLimit          *Money `json:"credit_limit,omitempty" bun:"credit_limit"`
+	AvailableCreditLimit *Money `json:"available_credit_limit,omitempty" bun:"available_credit_limit"`
+
+	// SettlementStrategy is the name of the strategy used to settle the transactions of the account,
+	// the default one of the app is used when it is not set
+	SettlementStrategy *string `json:"settlement_strategy,omitempty" bun:"settlement_strategy"`
+
+	// ClosingDay and DueDay are the days of the month the billing cycle of the account closes
+	// and its statement is due, the defaults are used when they are not set
+	ClosingDay *int `json:"closing_day,omitempty" bun:"closing_day"`
+	DueDay     *int `json:"due_day,omitempty" bun:"due_day"`
+
+	// Status decides whether the account takes new transactions, see AccountStatus
+	Status AccountStatus `json:"status" bun:"status"`
+
+	// Currency is the currency the account is kept in, all its transactions and balances are in it
+	Currency Currency `json:"currency" bun:"currency"`
+
+	// CreatedAt is when the account was opened, its first billing cycle starts then
+	CreatedAt time.Time `json:"created_at" bun:"created_at"`
 }
 
+// OperationTypeBalance is the open position of an account for one operation type
+type OperationTypeBalance struct {
+	OperationTypeID int64  `bun:"operation_type_id"`
+	Description     string `bun:"description"`
+	AvailableCredit Money  `bun:"available_credit"`
+	OutstandingDebt Money  `bun:"outstanding_debt"`
+}
+
+// AccountBalance summarizes the remaining balances of the transactions of an account,
+// credit not yet consumed by debits and debt not yet paid off by credits,
+// the pending authorization holds are taken out of the available credit
+type AccountBalance struct {
+	AccountID       int64
+	AvailableCredit Money
+	OutstandingDebt Money
+	Held            Money
+	ByOperationType []OperationTypeBalance
+}
+
+type OperationTypeDirection string
+
+const (
+	DebitDirection  OperationTypeDirection = "debit"
+	CreditDirection OperationTypeDirection = "credit"
+)
+
 type OperationType struct {
-	ID          int64  `json:"id"`
-	Description string `json:"description"`
+	bun.BaseModel `bun:"table:operation_type,alias:ot"`
+
+	ID          int64                  `json:"id" bun:"id,pk,autoincrement"`
+	Description string                 `json:"description" bun:"description"`
+	Direction   OperationTypeDirection `json:"direction" bun:"direction"`
+	Active      bool                   `json:"active" bun:"active"`
+
+	// APRBasisPoints is the yearly interest rate charged on the open debits of the type,
+	// in hundredths of a percent, zero debits do not accrue interest
+	APRBasisPoints int64 `json:"apr_bps" bun:"apr_bps"`
+}
+
+// IsCredit returns whether transactions of this type add to the balance of the account
+func (ot OperationType) IsCredit() bool {
+	return ot.Direction == CreditDirection
 }
 
 type OperationTypeID int
 
+// Operation types shipped with the migrations, types added through the api get ids from 100
 const (
 	NormalPurchase OperationTypeID = iota + 1
 	PurchaseWithInstallments
 	Withdrawal
 	CreditVoucher
+	PurchaseReversal
+	TransferOut
+	TransferIn
+	Interest
+	LateFee
 )
 
-func IsSupportedType(operationType int) bool {
-	switch {
-	case operationType > 4 || operationType < 1:
-		return false
-	default:
+// DefaultOperationTypes returns the operation types seeded by the migrations
+func DefaultOperationTypes() []OperationType {
+	return []OperationType{
+		{ID: int64(NormalPurchase), Description: "Normal Purchase", Direction: DebitDirection, Active: true},
+		{ID: int64(PurchaseWithInstallments), Description: "Purchase with installments", Direction: DebitDirection, Active: true},
+		{ID: int64(Withdrawal), Description: "Withdrawal", Direction: DebitDirection, Active: true},
+		{ID: int64(CreditVoucher), Description: "Credit Voucher", Direction: CreditDirection, Active: true},
+		{ID: int64(PurchaseReversal), Description: "Purchase Reversal", Direction: CreditDirection, Active: true},
+		{ID: int64(TransferOut), Description: "Transfer Out", Direction: DebitDirection, Active: true},
+		{ID: int64(TransferIn), Description: "Transfer In", Direction: CreditDirection, Active: true},
+		{ID: int64(Interest), Description: "Interest", Direction: DebitDirection, Active: true},
+		{ID: int64(LateFee), Description: "Late Fee", Direction: DebitDirection, Active: true},
+	}
+}
+
+// IsInternalOperationType reports whether transactions of the operation type are only
+// booked by other operations, such as reversals and transfers, and not created directly
+func IsInternalOperationType(operationTypeID int64) bool {
+	switch OperationTypeID(operationTypeID) {
+	case PurchaseReversal, TransferOut, TransferIn, Interest, LateFee:
 		return true
 	}
+	return false
 }
 
-func IsCredit(operationType int) bool {
-	switch {
-	case operationType == int(CreditVoucher):
+// IsChargeOperationType reports whether transactions of the operation type are charges
+// posted by the app, such as interest, which the credit limit of the account can not decline
+func IsChargeOperationType(operationTypeID int64) bool {
+	switch OperationTypeID(operationTypeID) {
+	case Interest, LateFee:
 		return true
-	default:
-		return false
 	}
+	return false
 }
 
 type Transaction struct {
@@ -51,9 +140,28 @@ type Transaction struct {
 	ID              int64     `json:"id" bun:"id,autoincrement"`
 	AccountID       int64     `json:"account_id" bun:"account_id"`
 	OperationTypeID int64     `json:"operation_type_id" bun:"operation_type_id"`
-	Amount          float64   `json:"amount" bun:"amount"`
+	Amount          Money     `json:"amount" bun:"amount"`
+	Currency        Currency  `json:"currency" bun:"currency"`
 	EventDate       time.Time `json:"event_date" bun:"event_date"`
-	Balance         float64   `json:"balance" bun:"balance"`
+	Balance         Money     `json:"balance" bun:"balance"`
+
+	// Installments is the number of installments of a purchase with installments
+	Installments int `json:"installments,omitempty" bun:"installments,nullzero"`
+
+	// ReversesTransactionID links a reversal to the debit it voids
+	ReversesTransactionID *int64 `json:"reverses_transaction_id,omitempty" bun:"reverses_transaction_id"`
+
+	// TransferID links both sides of a transfer
+	TransferID *int64 `json:"transfer_id,omitempty" bun:"transfer_id"`
+
+	// OriginalAmount and OriginalCurrency are what a converted transaction was requested in, Amount is
+	// them converted into the currency of the account at FXRate, locked by FXQuoteID when a quote was used
+	OriginalAmount   *Money        `json:"original_amount,omitempty" bun:"original_amount"`
+	OriginalCurrency Currency      `json:"original_currency,omitempty" bun:"original_currency,nullzero"`
+	FXRate           *ExchangeRate `json:"fx_rate,omitempty" bun:"fx_rate"`
+	FXQuoteID        *int64        `json:"fx_quote_id,omitempty" bun:"fx_quote_id"`
+
+	OperationType *OperationType `json:"operation_type,omitempty" bun:"rel:belongs-to,join:operation_type_id=id"`
 }
 
 type TransactionStatus struct {
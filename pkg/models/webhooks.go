@@ -0,0 +1,338 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Headers of the webhook deliveries, WebhookSignatureHeader carries SignWebhookPayload of the body
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Webhook subscribes a url to the events of the given types, of every account or of AccountID only.
+// Secret signs the deliveries, it is only returned when the webhook is created
+type Webhook struct {
+	bun.BaseModel `bun:"table:webhook,alias:wh"`
+
+	ID         int64       `json:"id" bun:"id,pk,autoincrement"`
+	URL        string      `json:"url" bun:"url"`
+	EventTypes []EventType `json:"event_types" bun:"event_types,array"`
+	AccountID  *int64      `json:"account_id,omitempty" bun:"account_id"`
+	Secret     string      `json:"secret,omitempty" bun:"secret"`
+	CreatedAt  time.Time   `json:"created_at" bun:"created_at"`
+}
+
+// Subscribes reports whether the event is delivered to the webhook
+func (w Webhook) Subscribes(event Event) bool {
+
+	if w.AccountID != nil && *w.AccountID != event.AccountID {
+		return false
+	}
+
+	for _, eventType := range w.EventTypes {
+		if eventType == event.Type {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookHostCheck checks the host of the url of a webhook before the webhook is created
+type WebhookHostCheck func(ctx context.Context, host string) error
+
+// CheckPublicWebhookHost fails with InvalidWebhookURLErr when the host, or any address it resolves to, is loopback,
+// private, link local or unspecified, so that deliveries can not be pointed at the network the app runs in
+func CheckPublicWebhookHost(ctx context.Context, host string) error {
+
+	addrs := []netip.Addr{}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		addrs = append(addrs, addr)
+	} else if addrs, err = net.DefaultResolver.LookupNetIP(ctx, "ip", host); err != nil {
+		return fmt.Errorf("%w, %s can not be resolved", InvalidWebhookURLErr, host)
+	}
+
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return fmt.Errorf("%w, %s is not a public address", InvalidWebhookURLErr, host)
+		}
+	}
+
+	return nil
+}
+
+// IsPublicAddr reports whether the address can be reached from outside the network the app runs in
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsUnspecified() &&
+		!addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast()
+}
+
+// NewWebhookClient returns the client deliveries are posted with. It checks the address it connects to with
+// IsPublicAddr, as the host of a webhook may resolve to another address than when the webhook was created,
+// and it does not follow redirects, the redirect is the response of the attempt
+func NewWebhookClient(timeout time.Duration) *http.Client {
+
+	dialer := &net.Dialer{Timeout: timeout, Control: checkPublicDialAddr}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be the address dialed instead of the one of the webhook
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkPublicDialAddr fails with InvalidWebhookURLErr before a connection to a non public address is made
+func checkPublicDialAddr(network string, address string, _ syscall.RawConn) error {
+
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w, %s is not an address", InvalidWebhookURLErr, address)
+	}
+
+	if !IsPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w, %s is not a public address", InvalidWebhookURLErr, addrPort.Addr())
+	}
+
+	return nil
+}
+
+// IsEventType reports whether events of the type are written to the outbox
+func IsEventType(eventType EventType) bool {
+	switch eventType {
+	case AccountCreatedEvent, TransactionCreatedEvent, TransactionSettledEvent:
+		return true
+	}
+	return false
+}
+
+// NewWebhookSecret returns a random secret to sign the deliveries of a webhook with
+func NewWebhookSecret() string {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return "whsec_" + hex.EncodeToString(secret)
+}
+
+// SignWebhookPayload returns the hex HMAC-SHA256 of the timestamp and body of a delivery, joined by
+// a dot, keyed with the secret of the webhook. Receivers compute it again to check a delivery
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryDeadLetter deliveries ran out of attempts and are no longer retried
+	WebhookDeliveryDeadLetter WebhookDeliveryStatus = "dead_letter"
+)
+
+// WebhookDelivery is an event to deliver to a webhook, Payload is the body it is posted with
+type WebhookDelivery struct {
+	bun.BaseModel `bun:"table:webhook_delivery,alias:whd"`
+
+	ID             int64                 `json:"id" bun:"id,pk,autoincrement"`
+	WebhookID      int64                 `json:"webhook_id" bun:"webhook_id"`
+	EventID        int64                 `json:"event_id" bun:"event_id"`
+	EventType      EventType             `json:"event_type" bun:"event_type"`
+	Payload        json.RawMessage       `json:"payload" bun:"payload,type:jsonb"`
+	Status         WebhookDeliveryStatus `json:"status" bun:"status"`
+	Attempts       int                   `json:"attempts" bun:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at" bun:"next_attempt_at"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty" bun:"last_attempt_at"`
+	ResponseStatus *int                  `json:"response_status,omitempty" bun:"response_status"`
+	LastError      string                `json:"last_error,omitempty" bun:"last_error,nullzero"`
+	CreatedAt      time.Time             `json:"created_at" bun:"created_at"`
+}
+
+// webhookPayload is the body deliveries are posted with
+type webhookPayload struct {
+	ID        int64           `json:"id"`
+	Type      EventType       `json:"type"`
+	AccountID int64           `json:"account_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewWebhookDelivery returns the pending delivery of a written event to a webhook that subscribes it
+func NewWebhookDelivery(webhook Webhook, event Event) (WebhookDelivery, error) {
+
+	payload, err := json.Marshal(webhookPayload{
+		ID:        event.ID,
+		Type:      event.Type,
+		AccountID: event.AccountID,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return WebhookDelivery{}, fmt.Errorf("unable to marshal webhook payload [%s]", err.Error())
+	}
+
+	return WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        WebhookDeliveryPending,
+		NextAttemptAt: event.CreatedAt,
+		CreatedAt:     event.CreatedAt,
+	}, nil
+}
+
+type WebhookService interface {
+	Create(ctx context.Context, webhook Webhook) (Webhook, error)
+	// GetForID returns the webhook without its secret
+	GetForID(ctx context.Context, webhookID int64) (Webhook, error)
+	// ListDeliveries returns the deliveries of a webhook, newest first
+	ListDeliveries(ctx context.Context, webhookID int64) ([]WebhookDelivery, error)
+	// LeaseDue returns up to limit pending deliveries due by now along with their webhooks, secrets
+	// included, and moves their next attempt to now plus ttl so that no other dispatcher takes them
+	LeaseDue(ctx context.Context, now time.Time, ttl time.Duration, limit int) ([]WebhookDelivery, map[int64]Webhook, error)
+	// RecordAttempt stores the outcome of an attempt of a leased delivery
+	RecordAttempt(ctx context.Context, delivery WebhookDelivery) (WebhookDelivery, error)
+}
+
+// maxWebhookRetryBackoff caps the wait between two attempts of a delivery
+const maxWebhookRetryBackoff = 24 * time.Hour
+
+// WebhookRetryBackoff returns the wait after the given number of attempts of a delivery, backoff after the first
+// one doubled after every other attempt, up to maxWebhookRetryBackoff
+func WebhookRetryBackoff(backoff time.Duration, attempts int) time.Duration {
+
+	wait := backoff
+	for i := 1; i < attempts && wait < maxWebhookRetryBackoff; i++ {
+		wait *= 2
+	}
+
+	return min(wait, maxWebhookRetryBackoff)
+}
+
+// WebhookDispatcher posts the due deliveries to their webhooks, a delivery that is not answered
+// with a 2xx is retried with exponential backoff until it runs out of attempts
+type WebhookDispatcher struct {
+	webhooks    WebhookService
+	client      *http.Client
+	maxAttempts int
+	backoff     time.Duration
+}
+
+// NewWebhookDispatcher returns a dispatcher that retries a delivery backoff after its first attempt,
+// doubling the wait after every attempt, and dead letters it after maxAttempts
+func NewWebhookDispatcher(webhooks WebhookService, client *http.Client, maxAttempts int, backoff time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhooks:    webhooks,
+		client:      client,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+	}
+}
+
+// DeliverDue attempts the deliveries due by now and returns how many were attempted
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+
+	attempted := 0
+	started := time.Now()
+
+	for {
+		// deliveries are leased one at a time so that each lease outlasts its own attempt, which its client
+		// times out first, no matter how long the attempts before it took
+		leased, webhooks, err := d.webhooks.LeaseDue(ctx, now, time.Since(started)+d.client.Timeout+time.Minute, 1)
+		if err != nil || len(leased) == 0 {
+			return attempted, err
+		}
+
+		delivery := leased[0]
+		if _, err := d.webhooks.RecordAttempt(ctx, d.attempt(ctx, webhooks[delivery.WebhookID], delivery, now)); err != nil {
+			return attempted, err
+		}
+		attempted++
+	}
+}
+
+// attempt posts the delivery and returns it updated with the outcome
+func (d *WebhookDispatcher) attempt(ctx context.Context, webhook Webhook, delivery WebhookDelivery, now time.Time) WebhookDelivery {
+
+	attemptedAt := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &attemptedAt
+	delivery.ResponseStatus = nil
+	delivery.LastError = ""
+
+	statusCode, err := d.post(ctx, webhook, delivery, attemptedAt)
+	switch {
+	case err != nil:
+		delivery.LastError = err.Error()
+	case statusCode < 200 || statusCode > 299:
+		delivery.ResponseStatus = &statusCode
+		delivery.LastError = fmt.Sprintf("webhook responded with status %d", statusCode)
+	default:
+		delivery.ResponseStatus = &statusCode
+		delivery.Status = WebhookDeliverySucceeded
+		return delivery
+	}
+
+	if delivery.Attempts >= d.maxAttempts {
+		delivery.Status = WebhookDeliveryDeadLetter
+		return delivery
+	}
+
+	delivery.NextAttemptAt = now.Add(WebhookRetryBackoff(d.backoff, delivery.Attempts))
+	return delivery
+}
+
+func (d *WebhookDispatcher) post(ctx context.Context, webhook Webhook, delivery WebhookDelivery, attemptedAt time.Time) (int, error) {
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := attemptedAt.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// the body is drained so that the connection is reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	StreamAccountEventsExtension = "/accounts/:accountId/events"
+)
+
+var (
+	// accountEventsPollInterval is how often an idle stream is kept alive and reads back
+	// the events whose notification it missed
+	accountEventsPollInterval = 15 * time.Second
+	accountEventsBatchSize    = 100
+)
+
+// WithAccountEventService enables the event streams of the accounts
+func WithAccountEventService(accountEventService models.AccountEventService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.accountEvents = accountEventService
+	}
+}
+
+// CloseStreams ends the open event streams, the server waits for them to end when it shuts down
+func (pah *paymentsAppHandler) CloseStreams() {
+	pah.closeStreams.Do(func() {
+		close(pah.streamsDone)
+	})
+}
+
+// StreamAccountEvents streams the events of an account as server-sent events, starting after the
+// Last-Event-ID header when it is provided and with the events written from now on otherwise
+func (pah *paymentsAppHandler) StreamAccountEvents(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	accountIdS := params.ByName("accountId")
+
+	accountId, err := strconv.Atoi(accountIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse account id", "accountIdS", accountIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	lastEventID := int64(-1)
+	if lastEventIDS := r.Header.Get("Last-Event-ID"); lastEventIDS != "" {
+		lastEventID, err = strconv.ParseInt(lastEventIDS, 10, 64)
+		if err != nil || lastEventID < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			ba, _ := json.Marshal(map[string]string{"msg": "Last-Event-ID must be the id of an event"})
+			fmt.Fprintf(w, "%s", string(ba))
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		pah.logger.ErrorContext(ctx, "unable to stream account events, the response writer does not flush")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := pah.accountsService.GetForID(ctx, int64(accountId)); err != nil {
+		pah.writeAccountEventsErr(ctx, w, err)
+		return
+	}
+
+	// the subscription is taken before the events are read so that none is written in between unnoticed
+	notified, unsubscribe := pah.accountEvents.Subscribe(int64(accountId))
+	defer unsubscribe()
+
+	if lastEventID < 0 {
+		lastEventID, err = pah.accountEvents.LatestID(ctx, int64(accountId))
+		if err != nil {
+			pah.writeAccountEventsErr(ctx, w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(accountEventsPollInterval)
+	defer ticker.Stop()
+
+	for {
+		lastEventID, err = pah.writeAccountEvents(ctx, w, int64(accountId), lastEventID)
+		if err != nil {
+			pah.logger.ErrorContext(ctx, "unable to stream account events", "accountID", accountId, "err", err)
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-pah.streamsDone:
+			return
+		case <-notified:
+		case <-ticker.C:
+			// a comment keeps proxies from closing the idle connection
+			fmt.Fprintf(w, ": keepalive\n\n")
+		}
+	}
+}
+
+// writeAccountEvents writes the events of the account after lastEventID and returns the id of the last one written
+func (pah *paymentsAppHandler) writeAccountEvents(ctx context.Context, w http.ResponseWriter, accountID int64, lastEventID int64) (int64, error) {
+
+	for {
+		events, err := pah.accountEvents.ListForAccount(ctx, accountID, lastEventID, accountEventsBatchSize)
+		if err != nil {
+			return lastEventID, err
+		}
+
+		for _, event := range events {
+			// the data of an event must fit in a single line
+			data := bytes.Buffer{}
+			if err := json.Compact(&data, event.Payload); err != nil {
+				return lastEventID, err
+			}
+
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data.String()); err != nil {
+				return lastEventID, err
+			}
+			lastEventID = event.ID
+		}
+
+		if len(events) < accountEventsBatchSize {
+			return lastEventID, nil
+		}
+	}
+}
+
+func (pah *paymentsAppHandler) writeAccountEventsErr(ctx context.Context, w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, models.NoRecordErr):
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		pah.logger.ErrorContext(ctx, "unable to process account events request", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+	fmt.Fprintf(w, "%s", string(ba))
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	FreezeAccountExtension            = "/accounts/:accountId/freeze"
+	UnfreezeAccountExtension          = "/accounts/:accountId/unfreeze"
+	CloseAccountExtension             = "/accounts/:accountId/close"
+	ListAccountStatusHistoryExtension = "/accounts/:accountId/status-history"
+)
+
+// FreezeAccount stops an active account from taking transactions until it is unfrozen
+func (pah *paymentsAppHandler) FreezeAccount(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	pah.updateAccountStatus(w, r, params, models.AccountFrozen)
+}
+
+// UnfreezeAccount lets a frozen account take transactions again
+func (pah *paymentsAppHandler) UnfreezeAccount(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	pah.updateAccountStatus(w, r, params, models.AccountActive)
+}
+
+// CloseAccount closes an account with no outstanding balance for good
+func (pah *paymentsAppHandler) CloseAccount(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	pah.updateAccountStatus(w, r, params, models.AccountClosed)
+}
+
+// ListAccountStatusHistory lists the status changes of an account, newest first
+func (pah *paymentsAppHandler) ListAccountStatusHistory(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	accountId, ok := pah.accountID(ctx, w, params)
+	if !ok {
+		return
+	}
+
+	history, err := pah.accountsService.ListStatusHistory(ctx, accountId)
+	if err != nil {
+		pah.writeAccountStatusErr(ctx, w, err)
+		return
+	}
+
+	ba, err := json.Marshal(ListAccountStatusHistoryResponse{
+		AccountID: accountId,
+		History:   history,
+	})
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal status history", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+func (pah *paymentsAppHandler) updateAccountStatus(w http.ResponseWriter, r *http.Request, params httprouter.Params, status models.AccountStatus) {
+	ctx := context.Background()
+
+	accountId, ok := pah.accountID(ctx, w, params)
+	if !ok {
+		return
+	}
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	// the body is optional, it only carries the reason of the change
+	req := UpdateAccountStatusRequest{}
+	if len(ba) > 0 {
+		if err := json.Unmarshal(ba, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+			return
+		}
+	}
+
+	account, err := pah.accountsService.UpdateStatus(ctx, accountId, status, req.Reason)
+	if err != nil {
+		pah.writeAccountStatusErr(ctx, w, err)
+		return
+	}
+
+	resp := GetAccountResponse{
+		AccountID:            account.AccountID,
+		DocumentNumber:       account.DocumentNumber,
+		DocumentType:         account.DocumentType,
+		CreditLimit:          account.CreditLimit,
+		AvailableCreditLimit: account.AvailableCreditLimit,
+		SettlementStrategy:   account.SettlementStrategy,
+		ClosingDay:           account.ClosingDay,
+		DueDay:               account.DueDay,
+		Status:               account.Status,
+		Currency:             account.Currency,
+	}
+
+	ba, err = json.Marshal(resp)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal account", "account", account, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+func (pah *paymentsAppHandler) accountID(ctx context.Context, w http.ResponseWriter, params httprouter.Params) (int64, bool) {
+	accountIdS := params.ByName("accountId")
+
+	accountId, err := strconv.Atoi(accountIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse account id", "accountIdS", accountIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, false
+	}
+
+	return int64(accountId), true
+}
+
+func (pah *paymentsAppHandler) writeAccountStatusErr(ctx context.Context, w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, models.NoRecordErr):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.InvalidAccountStatusTransitionErr):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, models.OutstandingBalanceErr):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		pah.logger.ErrorContext(ctx, "unable to process account status", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+	fmt.Fprintf(w, "%s", string(ba))
+}
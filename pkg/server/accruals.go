@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	AccrueExtension              = "/accruals"
+	ListAccountAccrualsExtension = "/accounts/:accountId/accruals"
+)
+
+// WithAccrualService enables the interest and late fee endpoints
+func WithAccrualService(accrualService models.AccrualService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.accruals = accrualService
+	}
+}
+
+// Accrue posts the interest and late fees of a past day, the days and statements
+// that were already charged are skipped so it is safe to run again
+func (pah *paymentsAppHandler) Accrue(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := AccrueRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	accrued, err := pah.accruals.Accrue(ctx, req.day)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to accrue", "date", req.Date, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, err = json.Marshal(AccrueResponse{
+		Date:    req.Date,
+		Accrued: accrued,
+	})
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal accruals", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+// ListAccountAccruals lists the interest and late fees charged to an account, newest first
+func (pah *paymentsAppHandler) ListAccountAccruals(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	accountIdS := params.ByName("accountId")
+
+	accountId, err := strconv.Atoi(accountIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse account id", "accountIdS", accountIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	accruals, err := pah.accruals.ListForAccount(ctx, int64(accountId))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to list accruals", "accountID", accountId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ba, err := json.Marshal(ListAccrualsResponse{
+		AccountID: int64(accountId),
+		Accruals:  accruals,
+	})
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal accruals", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
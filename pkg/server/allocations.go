@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	ListTransactionAllocationsExtension = "/transactions/:transactionId/allocations"
+)
+
+// WithAllocationService enables the settlement allocations endpoint
+func WithAllocationService(allocationService models.AllocationService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.allocations = allocationService
+	}
+}
+
+// ListTransactionAllocations lists how a transaction was settled, the debits a credit paid
+// or the credits that paid a debit
+func (pah *paymentsAppHandler) ListTransactionAllocations(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	transactionIdS := params.ByName("transactionId")
+
+	transactionId, err := strconv.Atoi(transactionIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse transaction id", "transactionIdS", transactionIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	allocations, err := pah.allocations.ListForTransaction(ctx, int64(transactionId))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to list allocations", "transactionID", transactionId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ba, err := json.Marshal(ListAllocationsResponse{
+		TransactionID: int64(transactionId),
+		Allocations:   allocations,
+	})
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal allocations", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	CreateAuthorizationExtension  = "/authorizations"
+	GetAuthorizationExtension     = "/authorizations/:authorizationId"
+	CaptureAuthorizationExtension = "/authorizations/:authorizationId/capture"
+	ReleaseAuthorizationExtension = "/authorizations/:authorizationId/release"
+)
+
+// WithAuthorizationService enables the two-phase purchase endpoints
+func WithAuthorizationService(authorizationService models.AuthorizationService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.authorizations = authorizationService
+	}
+}
+
+// CreateAuthorization places a hold on an account for a debit that is settled later
+func (pah *paymentsAppHandler) CreateAuthorization(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := CreateAuthorizationRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	operationType, ok := pah.activeOperationType(ctx, w, req.OperationTypeID)
+	if !ok {
+		return
+	}
+
+	if operationType.IsCredit() || models.IsInternalOperationType(operationType.ID) {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": "only debits can be authorized"})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	response := jsonResponse[models.Authorization](http.StatusCreated)
+	ctx, idempotencyKey, err := pah.idempotencyKeyContext(ctx, r, CreateAuthorizationExtension, ba, response)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	authorization, err := pah.authorizations.Create(ctx, models.Authorization{
+		AccountID:       req.AccountID,
+		OperationTypeID: req.OperationTypeID,
+		Amount:          req.Amount,
+	})
+	if err != nil {
+		if pah.handleIdempotencyErr(ctx, w, idempotencyKey, err) {
+			return
+		}
+		pah.writeIdempotentErr(ctx, w, idempotencyKey, func(w http.ResponseWriter) {
+			pah.writeAuthorizationErr(ctx, w, err)
+		})
+		return
+	}
+
+	pah.writeResponse(ctx, w, response, authorization)
+}
+
+// GetAuthorization fetches an authorization hold for the provided id
+func (pah *paymentsAppHandler) GetAuthorization(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	authorizationId, ok := pah.authorizationID(ctx, w, params)
+	if !ok {
+		return
+	}
+
+	authorization, err := pah.authorizations.GetForID(ctx, authorizationId)
+	if err != nil {
+		pah.writeAuthorizationErr(ctx, w, err)
+		return
+	}
+
+	pah.writeAuthorization(ctx, w, http.StatusOK, authorization)
+}
+
+// CaptureAuthorization books a debit for the whole hold, or part of it when an amount is provided
+func (pah *paymentsAppHandler) CaptureAuthorization(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	authorizationId, ok := pah.authorizationID(ctx, w, params)
+	if !ok {
+		return
+	}
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := CaptureAuthorizationRequest{}
+	if len(ba) > 0 {
+		if err := json.Unmarshal(ba, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+			return
+		}
+	}
+
+	// the scope includes the authorization id so a key can not be replayed for another hold
+	response := jsonResponse[models.Authorization](http.StatusOK)
+	ctx, idempotencyKey, err := pah.idempotencyKeyContext(ctx, r, r.URL.Path, ba, response)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	authorization, err := pah.authorizations.Capture(ctx, authorizationId, req.Amount)
+	if err != nil {
+		if pah.handleIdempotencyErr(ctx, w, idempotencyKey, err) {
+			return
+		}
+		pah.writeIdempotentErr(ctx, w, idempotencyKey, func(w http.ResponseWriter) {
+			pah.writeAuthorizationErr(ctx, w, err)
+		})
+		return
+	}
+
+	pah.writeResponse(ctx, w, response, authorization)
+}
+
+// ReleaseAuthorization drops a pending hold without booking anything
+func (pah *paymentsAppHandler) ReleaseAuthorization(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	authorizationId, ok := pah.authorizationID(ctx, w, params)
+	if !ok {
+		return
+	}
+
+	authorization, err := pah.authorizations.Release(ctx, authorizationId)
+	if err != nil {
+		pah.writeAuthorizationErr(ctx, w, err)
+		return
+	}
+
+	pah.writeAuthorization(ctx, w, http.StatusOK, authorization)
+}
+
+func (pah *paymentsAppHandler) authorizationID(ctx context.Context, w http.ResponseWriter, params httprouter.Params) (int64, bool) {
+	authorizationIdS := params.ByName("authorizationId")
+
+	authorizationId, err := strconv.Atoi(authorizationIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse authorization id", "authorizationIdS", authorizationIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, false
+	}
+
+	return int64(authorizationId), true
+}
+
+func (pah *paymentsAppHandler) writeAuthorization(ctx context.Context, w http.ResponseWriter, statusCode int, authorization models.Authorization) {
+
+	ba, err := json.Marshal(authorization)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal authorization", "authorization", authorization, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+func (pah *paymentsAppHandler) writeAuthorizationErr(ctx context.Context, w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, models.NoRecordErr):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.AuthorizationNotPendingErr):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, models.InsufficientLimitErr), errors.Is(err, models.CaptureAmountExceededErr),
+		errors.Is(err, models.AccountNotActiveErr), errors.Is(err, models.AmountPrecisionErr):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		pah.logger.ErrorContext(ctx, "unable to process authorization", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+	fmt.Fprintf(w, "%s", string(ba))
+}
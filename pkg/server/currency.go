@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+)
+
+// WithCurrencyConverter sets the converter of the transactions that request a conversion,
+// without one they are rejected
+func WithCurrencyConverter(converter models.CurrencyConverter) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.converter = converter
+	}
+}
+
+// convertTransaction converts the amount of a transaction that requested a conversion into the currency
+// of its account, at the rate locked by the quote when one is given and at the rate of the converter
+// otherwise, keeping the amount and currency it was requested in. It writes the error response when the
+// transaction can not be converted
+func (pah *paymentsAppHandler) convertTransaction(ctx context.Context, w http.ResponseWriter, transaction models.Transaction, quoteID *int64) (models.Transaction, bool) {
+
+	account, err := pah.accountsService.GetForID(ctx, transaction.AccountID)
+	if err != nil {
+		pah.writeCurrencyErr(ctx, w, err)
+		return transaction, false
+	}
+
+	var (
+		converted models.Money
+		rate      models.ExchangeRate
+	)
+
+	switch {
+	case quoteID != nil:
+		if pah.fx == nil {
+			pah.writeCurrencyErr(ctx, w, models.ConversionUnavailableErr)
+			return transaction, false
+		}
+
+		quote, err := pah.fx.GetQuote(ctx, *quoteID)
+		if err != nil {
+			pah.writeCurrencyErr(ctx, w, err)
+			return transaction, false
+		}
+
+		if transaction.Currency == "" {
+			transaction.Currency = quote.From
+		}
+
+		// the transaction service checks again that the quote is unused and unexpired when it books the
+		// transaction, and marks it used within the same database transaction
+		if quote.From != transaction.Currency || quote.To != account.Currency {
+			pah.writeCurrencyErr(ctx, w, fmt.Errorf("%w, quote %d converts from %s to %s", models.FXQuoteMismatchErr, quote.ID, quote.From, quote.To))
+			return transaction, false
+		}
+
+		if err := quote.From.CheckAmount(transaction.Amount); err != nil {
+			pah.writeCurrencyErr(ctx, w, err)
+			return transaction, false
+		}
+
+		converted, rate = quote.Rate.Convert(transaction.Amount, account.Currency), quote.Rate
+	case transaction.Currency == account.Currency:
+		return transaction, true
+	case pah.converter == nil:
+		pah.writeCurrencyErr(ctx, w, models.ConversionUnavailableErr)
+		return transaction, false
+	default:
+		if converted, rate, err = pah.converter.Convert(ctx, transaction.Amount, transaction.Currency, account.Currency); err != nil {
+			pah.writeCurrencyErr(ctx, w, err)
+			return transaction, false
+		}
+	}
+
+	if converted == 0 {
+		pah.writeCurrencyErr(ctx, w, fmt.Errorf("%w, the amount is too small to be converted to %s", models.AmountPrecisionErr, account.Currency))
+		return transaction, false
+	}
+
+	originalAmount := transaction.Amount
+	transaction.OriginalAmount = &originalAmount
+	transaction.OriginalCurrency = transaction.Currency
+	transaction.FXRate = &rate
+	transaction.FXQuoteID = quoteID
+	transaction.Amount = converted
+	transaction.Currency = account.Currency
+
+	return transaction, true
+}
+
+func (pah *paymentsAppHandler) writeCurrencyErr(ctx context.Context, w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, models.NoRecordErr):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.UnsupportedCurrencyErr):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, models.ConversionUnavailableErr), errors.Is(err, models.AmountPrecisionErr),
+		errors.Is(err, models.FXQuoteMismatchErr):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		pah.logger.ErrorContext(ctx, "unable to convert amount", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+	fmt.Fprintf(w, "%s", string(ba))
+}
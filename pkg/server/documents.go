@@ -0,0 +1,32 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+)
+
+// WithDocumentValidators sets the document types accounts can be opened with, defaults to the ones shipped with the app
+func WithDocumentValidators(documents models.DocumentValidators) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.documents = documents
+	}
+}
+
+// writeDocumentErr writes a document error with the field and code clients can match on
+func (pah *paymentsAppHandler) writeDocumentErr(ctx context.Context, w http.ResponseWriter, err error) {
+
+	documentErr := &models.DocumentError{}
+	if !errors.As(err, &documentErr) {
+		pah.logger.ErrorContext(ctx, "unable to validate document", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	ba, _ := json.Marshal(documentErr)
+	fmt.Fprintf(w, "%s", string(ba))
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	SetFXRateExtension     = "/fx/rates"
+	ListFXRatesExtension   = "/fx/rates"
+	CreateFXQuoteExtension = "/fx/quotes"
+	GetFXQuoteExtension    = "/fx/quotes/:quoteId"
+)
+
+// WithFXService enables the fx rate and quote endpoints and the transactions booked with a quote
+func WithFXService(fxService models.FXService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.fx = fxService
+	}
+}
+
+// SetFXRate adds the rate of a currency pair or replaces its current one
+func (pah *paymentsAppHandler) SetFXRate(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := SetFXRateRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	rate, err := pah.fx.SetRate(ctx, models.FXRate{
+		From: req.From,
+		To:   req.To,
+		Rate: req.Rate,
+	})
+	if err != nil {
+		pah.writeFXErr(ctx, w, err)
+		return
+	}
+
+	pah.writeFX(ctx, w, http.StatusOK, rate)
+}
+
+// ListFXRates lists the current rates of all the currency pairs
+func (pah *paymentsAppHandler) ListFXRates(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	rates, err := pah.fx.ListRates(ctx)
+	if err != nil {
+		pah.writeFXErr(ctx, w, err)
+		return
+	}
+
+	pah.writeFX(ctx, w, http.StatusOK, ListFXRatesResponse{Rates: rates})
+}
+
+// CreateFXQuote locks the current rate of a currency pair for the transactions booked with the quote
+func (pah *paymentsAppHandler) CreateFXQuote(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := CreateFXQuoteRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	quote, err := pah.fx.CreateQuote(ctx, req.From, req.To)
+	if err != nil {
+		pah.writeFXErr(ctx, w, err)
+		return
+	}
+
+	pah.writeFX(ctx, w, http.StatusCreated, quote)
+}
+
+// GetFXQuote fetches a quote for the provided id, expired quotes included
+func (pah *paymentsAppHandler) GetFXQuote(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	quoteIdS := params.ByName("quoteId")
+
+	quoteId, err := strconv.Atoi(quoteIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse quote id", "quoteIdS", quoteIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	quote, err := pah.fx.GetQuote(ctx, int64(quoteId))
+	if err != nil {
+		pah.writeFXErr(ctx, w, err)
+		return
+	}
+
+	pah.writeFX(ctx, w, http.StatusOK, quote)
+}
+
+func (pah *paymentsAppHandler) writeFX(ctx context.Context, w http.ResponseWriter, statusCode int, resp any) {
+
+	ba, err := json.Marshal(resp)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal fx response", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+func (pah *paymentsAppHandler) writeFXErr(ctx context.Context, w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, models.NoRecordErr):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.ConversionUnavailableErr):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		pah.logger.ErrorContext(ctx, "unable to process fx request", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+	fmt.Fprintf(w, "%s", string(ba))
+}
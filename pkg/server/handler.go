@@ -11,6 +11,7 @@ import (
 	"os"
 	"payments-backend-app/pkg/models"
 	"strconv"
+	"sync"
 	"sync/atomic"
 
 	"github.com/julienschmidt/httprouter"
@@ -22,18 +23,44 @@ type ExtensionDetail struct {
 }
 
 var (
-	LivenessExtension          = "/liveness"
-	ReadinessExtension         = "/readiness"
-	CreateAccountExtension     = "/accounts"
-	GetAccountExtension        = "/accounts/:accountId"
-	CreateTransactionExtension = "/transactions"
+	LivenessExtension           = "/liveness"
+	ReadinessExtension          = "/readiness"
+	CreateAccountExtension      = "/accounts"
+	GetAccountExtension         = "/accounts/:accountId"
+	GetAccountBalanceExtension  = "/accounts/:accountId/balance"
+	UpdateCreditLimitExtension  = "/accounts/:accountId/limit"
+	CreateTransactionExtension  = "/transactions"
+	GetTransactionExtension     = "/transactions/:transactionId"
+	ReverseTransactionExtension = "/transactions/:transactionId/reverse"
+
+	ListAccountTransactionsExtension = "/accounts/:accountId/transactions"
 )
 
 type paymentsAppHandler struct {
 	panicCount         atomic.Int64
 	accountsService    models.AccountsService
 	transactionService models.TransactionService
+	idempotencyService models.IdempotencyService
+	operationTypes     models.OperationTypeService
+	installmentService models.InstallmentService
+	authorizations     models.AuthorizationService
+	transfers          models.TransferService
+	allocations        models.AllocationService
+	statements         models.StatementService
+	accruals           models.AccrualService
+	schedules          models.ScheduleService
+	settlement         models.SettlementStrategies
+	documents          models.DocumentValidators
+	converter          models.CurrencyConverter
+	fx                 models.FXService
+	webhooks           models.WebhookService
+	webhookHosts       models.WebhookHostCheck
+	accountEvents      models.AccountEventService
 	logger             *slog.Logger
+
+	// streamsDone is closed to end the open event streams when the server shuts down
+	streamsDone  chan struct{}
+	closeStreams sync.Once
 }
 
 // Option for the payments app server
@@ -49,6 +76,10 @@ func NewPaymentsAppHandler(
 		panicCount:         atomic.Int64{},
 		accountsService:    accountsService,
 		transactionService: transactionService,
+		settlement:         models.NewSettlementStrategies(),
+		documents:          models.NewDocumentValidators(),
+		webhookHosts:       models.CheckPublicWebhookHost,
+		streamsDone:        make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -72,6 +103,20 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithOperationTypeService sets the operation types used to validate transactions
+func WithOperationTypeService(operationTypeService models.OperationTypeService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.operationTypes = operationTypeService
+	}
+}
+
+// WithIdempotencyService enables support for the Idempotency-Key header on create requests
+func WithIdempotencyService(idempotencyService models.IdempotencyService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.idempotencyService = idempotencyService
+	}
+}
+
 // PanicHandler is used to recover when there is a crash serving a request
 func (pah *paymentsAppHandler) PanicHandler(w http.ResponseWriter, r *http.Request, i interface{}) {
 	pah.panicCount.Add(1)
@@ -112,32 +157,110 @@ func (pah *paymentsAppHandler) CreateAccount(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	account, err := pah.accountsService.Create(ctx, models.Account{DocumentNumber: req.DocumentNumber})
+	documentType, documentNumber, err := pah.documents.Validate(req.DocumentType, req.DocumentNumber)
+	if err != nil {
+		pah.writeDocumentErr(ctx, w, err)
+		return
+	}
+
+	response := jsonResponse[models.Account](http.StatusCreated)
+	ctx, idempotencyKey, err := pah.idempotencyKeyContext(ctx, r, CreateAccountExtension, ba, response)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	if req.SettlementStrategy != nil && !pah.isSettlementStrategy(*req.SettlementStrategy) {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": fmt.Sprintf("unknown settlement strategy %s", *req.SettlementStrategy)})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	account, err := pah.accountsService.Create(ctx, models.Account{
+		DocumentNumber:       documentNumber,
+		DocumentType:         documentType,
+		AvailableCreditLimit: req.AvailableCreditLimit,
+		SettlementStrategy:   req.SettlementStrategy,
+		ClosingDay:           req.ClosingDay,
+		DueDay:               req.DueDay,
+		Currency:             req.Currency,
+	})
+	if err != nil {
+		if pah.handleIdempotencyErr(ctx, w, idempotencyKey, err) {
+			return
+		}
+
+		pah.writeIdempotentErr(ctx, w, idempotencyKey, func(w http.ResponseWriter) {
+			switch {
+			case errors.Is(err, models.DuplicateRecordErr):
+				w.WriteHeader(http.StatusConflict)
+				ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+				fmt.Fprintf(w, "%s", string(ba))
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+		return
+	}
+
+	pah.writeResponse(ctx, w, response, account)
+}
+
+// GetAccount fetches an account for the provided account id
+func (pah *paymentsAppHandler) GetAccount(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	accountIdS := params.ByName("accountId")
+
+	accountId, err := strconv.Atoi(accountIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse account id", "accountIdS", accountIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	account, err := pah.accountsService.GetForID(ctx, int64(accountId))
 	if err != nil {
 		switch {
-		case errors.Is(err, models.DuplicateRecordErr):
-			w.WriteHeader(http.StatusConflict)
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
 			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
 			fmt.Fprintf(w, "%s", string(ba))
 		default:
+			pah.logger.ErrorContext(ctx, "unable to fetch account for id", "accountID", accountId, "err", err)
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 		return
 	}
 
-	ba, err = json.Marshal(account)
+	resp := GetAccountResponse{
+		AccountID:            account.AccountID,
+		DocumentNumber:       account.DocumentNumber,
+		DocumentType:         account.DocumentType,
+		CreditLimit:          account.CreditLimit,
+		AvailableCreditLimit: account.AvailableCreditLimit,
+		SettlementStrategy:   account.SettlementStrategy,
+		ClosingDay:           account.ClosingDay,
+		DueDay:               account.DueDay,
+		Status:               account.Status,
+		Currency:             account.Currency,
+	}
+
+	ba, err := json.Marshal(resp)
 	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal account", "account", account, "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		pah.logger.ErrorContext(ctx, "marshalling error", "err", err.Error())
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "%s", string(ba))
 }
 
-// GetAccount fetches an account for the provided account id
-func (pah *paymentsAppHandler) GetAccount(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+// UpdateCreditLimit sets the credit limit of an account, moving what is available by the change
+func (pah *paymentsAppHandler) UpdateCreditLimit(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	ctx := context.Background()
 	accountIdS := params.ByName("accountId")
 
@@ -148,7 +271,21 @@ func (pah *paymentsAppHandler) GetAccount(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	account, err := pah.accountsService.GetForID(ctx, int64(accountId))
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := UpdateCreditLimitRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	account, err := pah.accountsService.UpdateCreditLimit(ctx, int64(accountId), &req.AvailableCreditLimit)
 	if err != nil {
 		switch {
 		case errors.Is(err, models.NoRecordErr):
@@ -156,18 +293,26 @@ func (pah *paymentsAppHandler) GetAccount(w http.ResponseWriter, r *http.Request
 			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
 			fmt.Fprintf(w, "%s", string(ba))
 		default:
-			pah.logger.ErrorContext(ctx, "unable to fetch account for id", "accountID", accountId, "err", err)
+			pah.logger.ErrorContext(ctx, "unable to update credit limit", "accountID", accountId, "err", err)
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 		return
 	}
 
 	resp := GetAccountResponse{
-		AccountID:      account.AccountID,
-		DocumentNumber: account.DocumentNumber,
+		AccountID:            account.AccountID,
+		DocumentNumber:       account.DocumentNumber,
+		DocumentType:         account.DocumentType,
+		CreditLimit:          account.CreditLimit,
+		AvailableCreditLimit: account.AvailableCreditLimit,
+		SettlementStrategy:   account.SettlementStrategy,
+		ClosingDay:           account.ClosingDay,
+		DueDay:               account.DueDay,
+		Status:               account.Status,
+		Currency:             account.Currency,
 	}
 
-	ba, err := json.Marshal(resp)
+	ba, err = json.Marshal(resp)
 	if err != nil {
 		pah.logger.ErrorContext(ctx, "unable to marshal account", "account", account, "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
@@ -178,6 +323,60 @@ func (pah *paymentsAppHandler) GetAccount(w http.ResponseWriter, r *http.Request
 	fmt.Fprintf(w, "%s", string(ba))
 }
 
+// GetAccountBalance returns the available credit and outstanding debt of an account
+func (pah *paymentsAppHandler) GetAccountBalance(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	accountIdS := params.ByName("accountId")
+
+	accountId, err := strconv.Atoi(accountIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse account id", "accountIdS", accountIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	balance, err := pah.accountsService.GetBalance(ctx, int64(accountId))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to fetch balance for account", "accountID", accountId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := GetAccountBalanceResponse{
+		AccountID:       balance.AccountID,
+		AvailableCredit: balance.AvailableCredit,
+		OutstandingDebt: balance.OutstandingDebt,
+		Held:            balance.Held,
+		OperationTypes:  make([]OperationTypeBalanceResponse, 0, len(balance.ByOperationType)),
+	}
+
+	for _, operationTypeBalance := range balance.ByOperationType {
+		resp.OperationTypes = append(resp.OperationTypes, OperationTypeBalanceResponse{
+			OperationTypeID: operationTypeBalance.OperationTypeID,
+			Description:     operationTypeBalance.Description,
+			AvailableCredit: operationTypeBalance.AvailableCredit,
+			OutstandingDebt: operationTypeBalance.OutstandingDebt,
+		})
+	}
+
+	ba, err := json.Marshal(resp)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal balance", "balance", balance, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
 // CreateTransaction creates a transaction given an account id, operation type and amount
 func (pah *paymentsAppHandler) CreateTransaction(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	ctx := context.Background()
@@ -196,11 +395,134 @@ func (pah *paymentsAppHandler) CreateTransaction(w http.ResponseWriter, r *http.
 		return
 	}
 
-	transactionStatus, err := pah.transactionService.Create(ctx, models.Transaction{
+	operationType, ok := pah.activeOperationType(ctx, w, req.OperationTypeID)
+	if !ok {
+		return
+	}
+
+	if req.Installments > 0 && operationType.ID != int64(models.PurchaseWithInstallments) {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": "installments are only supported for purchases with installments"})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	// reversals and transfers must be linked to the operation that books them
+	if models.IsInternalOperationType(operationType.ID) {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": "operation type is reserved for reversals and transfers"})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	amount := req.Amount
+	if !operationType.IsCredit() {
+		amount = -amount
+	}
+
+	transaction := models.Transaction{
 		AccountID:       req.AccountID,
 		OperationTypeID: req.OperationTypeID,
-		Amount:          req.Amount,
-	})
+		Amount:          amount,
+		Currency:        req.Currency,
+		Installments:    req.Installments,
+	}
+
+	response := renderedJSONResponse(http.StatusCreated, newCreateTransactionResponse)
+	ctx, idempotencyKey, err := pah.idempotencyKeyContext(ctx, r, CreateTransactionExtension, ba, response)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	// the amount is converted into the currency of the account only when the client asked for it
+	// or booked it with a quote, otherwise a transaction in another currency is rejected by the service.
+	// A retry is replayed before converting, since the quote or rate it was booked with may be gone by then
+	if req.Convert || req.QuoteID != nil {
+		if pah.replayIdempotentRequest(ctx, w, idempotencyKey) {
+			return
+		}
+
+		converted := false
+		pah.writeIdempotentErr(ctx, w, idempotencyKey, func(w http.ResponseWriter) {
+			transaction, converted = pah.convertTransaction(ctx, w, transaction, req.QuoteID)
+		})
+		if !converted {
+			return
+		}
+	}
+
+	transactionStatus, err := pah.transactionService.Create(ctx, transaction)
+	if err != nil {
+		if pah.handleIdempotencyErr(ctx, w, idempotencyKey, err) {
+			return
+		}
+
+		pah.writeIdempotentErr(ctx, w, idempotencyKey, func(w http.ResponseWriter) {
+			switch {
+			case errors.Is(err, models.NoRecordErr):
+				w.WriteHeader(http.StatusNotFound)
+				ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+				fmt.Fprintf(w, "%s", string(ba))
+			case errors.Is(err, models.InsufficientLimitErr), errors.Is(err, models.AccountNotActiveErr),
+				errors.Is(err, models.CurrencyMismatchErr), errors.Is(err, models.AmountPrecisionErr),
+				errors.Is(err, models.FXQuoteUsedErr), errors.Is(err, models.FXQuoteExpiredErr), errors.Is(err, models.FXQuoteMismatchErr):
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+				fmt.Fprintf(w, "%s", string(ba))
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+		return
+	}
+
+	pah.writeResponse(ctx, w, response, transactionStatus)
+}
+
+// activeOperationType fetches the operation type of a request and writes a bad request
+// response if it does not exist or is inactive
+func (pah *paymentsAppHandler) activeOperationType(ctx context.Context, w http.ResponseWriter, operationTypeID int64) (models.OperationType, bool) {
+
+	operationType, err := pah.operationTypes.GetForID(ctx, operationTypeID)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusBadRequest)
+			ba, _ := json.Marshal(map[string]string{"msg": "unsupported operation type"})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to fetch operation type", "operationTypeID", operationTypeID, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return operationType, false
+	}
+
+	if !operationType.Active {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": "inactive operation type"})
+		fmt.Fprintf(w, "%s", string(ba))
+		return operationType, false
+	}
+
+	return operationType, true
+}
+
+// GetTransaction fetches a transaction for the provided transaction id
+func (pah *paymentsAppHandler) GetTransaction(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	transactionIdS := params.ByName("transactionId")
+
+	transactionId, err := strconv.Atoi(transactionIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse transaction id", "transactionIdS", transactionIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	transaction, err := pah.transactionService.GetForID(ctx, int64(transactionId))
 	if err != nil {
 		switch {
 		case errors.Is(err, models.NoRecordErr):
@@ -208,23 +530,147 @@ func (pah *paymentsAppHandler) CreateTransaction(w http.ResponseWriter, r *http.
 			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
 			fmt.Fprintf(w, "%s", string(ba))
 		default:
+			pah.logger.ErrorContext(ctx, "unable to fetch transaction for id", "transactionID", transactionId, "err", err)
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 		return
 	}
 
-	resp := CreateTransactionResponse{
-		TransactionID: transactionStatus.TransactionID,
-		AccountID:     transactionStatus.AccountID,
+	ba, err := json.Marshal(NewGetTransactionResponse(transaction))
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal transaction", "transaction", transaction, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
-	ba, err = json.Marshal(resp)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+// ReverseTransaction voids a debit fully, or partially when an amount is provided,
+// by creating a linked credit
+func (pah *paymentsAppHandler) ReverseTransaction(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	transactionIdS := params.ByName("transactionId")
+
+	transactionId, err := strconv.Atoi(transactionIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse transaction id", "transactionIdS", transactionIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ba, err := io.ReadAll(r.Body)
 	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := ReverseTransactionRequest{}
+	if len(ba) > 0 {
+		if err := json.Unmarshal(ba, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+			return
+		}
+	}
+
+	// the scope includes the transaction id so a key can not be replayed for another transaction
+	response := renderedJSONResponse(http.StatusCreated, newCreateTransactionResponse)
+	ctx, idempotencyKey, err := pah.idempotencyKeyContext(ctx, r, r.URL.Path, ba, response)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	transactionStatus, err := pah.transactionService.Reverse(ctx, int64(transactionId), req.Amount)
+	if err != nil {
+		if pah.handleIdempotencyErr(ctx, w, idempotencyKey, err) {
+			return
+		}
+
+		pah.writeIdempotentErr(ctx, w, idempotencyKey, func(w http.ResponseWriter) {
+			switch {
+			case errors.Is(err, models.NoRecordErr):
+				w.WriteHeader(http.StatusNotFound)
+				ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+				fmt.Fprintf(w, "%s", string(ba))
+			case errors.Is(err, models.AlreadyReversedErr):
+				w.WriteHeader(http.StatusConflict)
+				ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+				fmt.Fprintf(w, "%s", string(ba))
+			case errors.Is(err, models.NotReversibleErr), errors.Is(err, models.ReversalAmountExceededErr),
+				errors.Is(err, models.AccountNotActiveErr), errors.Is(err, models.AmountPrecisionErr):
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+				fmt.Fprintf(w, "%s", string(ba))
+			default:
+				pah.logger.ErrorContext(ctx, "unable to reverse transaction", "transactionID", transactionId, "err", err)
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		})
+		return
+	}
+
+	pah.writeResponse(ctx, w, response, transactionStatus)
+}
+
+// ListAccountTransactions lists the transactions of an account, newest first, one page at a time
+func (pah *paymentsAppHandler) ListAccountTransactions(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	accountIdS := params.ByName("accountId")
+
+	accountId, err := strconv.Atoi(accountIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse account id", "accountIdS", accountIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	filter, err := ParseListTransactionsQuery(int64(accountId), r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	page, err := pah.transactionService.ListForAccount(ctx, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to list transactions for account", "accountID", accountId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := ListTransactionsResponse{
+		Transactions: make([]GetTransactionResponse, 0, len(page.Transactions)),
+	}
+
+	for _, transaction := range page.Transactions {
+		resp.Transactions = append(resp.Transactions, NewGetTransactionResponse(transaction))
+	}
+
+	if page.NextCursor != nil {
+		resp.NextCursor = page.NextCursor.Encode()
+	}
+
+	ba, err := json.Marshal(resp)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal transactions", "accountID", accountId, "err", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		pah.logger.ErrorContext(ctx, "marshalling error", "err", err.Error())
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, "%s", string(ba))
 }
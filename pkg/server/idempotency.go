@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+)
+
+var (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	maxIdempotencyKeyLen = 255
+)
+
+// idempotencyKeyContext attaches the idempotency key sent by the client to the context so that the service
+// can claim it and store the response rendered for its result within the same database transaction as the create
+func (pah *paymentsAppHandler) idempotencyKeyContext(ctx context.Context, r *http.Request, scope string, body []byte, response models.IdempotentResponse) (context.Context, *models.IdempotencyKey, error) {
+
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" || pah.idempotencyService == nil {
+		return ctx, nil, nil
+	}
+
+	if len(key) > maxIdempotencyKeyLen {
+		return ctx, nil, fmt.Errorf("idempotency key length must be no greater than %d", maxIdempotencyKeyLen)
+	}
+
+	hash := sha256.Sum256(body)
+
+	idempotencyKey := models.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: hex.EncodeToString(hash[:]),
+		Response:    response,
+	}
+
+	return models.ContextWithIdempotencyKey(ctx, idempotencyKey), &idempotencyKey, nil
+}
+
+// jsonResponse renders the result of type T a service returned as the json body served with statusCode
+func jsonResponse[T any](statusCode int) models.IdempotentResponse {
+	return renderedJSONResponse(statusCode, func(result T) T { return result })
+}
+
+// renderedJSONResponse renders the result of type T a service returned as the json body served with statusCode,
+// after render maps it to the body
+func renderedJSONResponse[T any, R any](statusCode int, render func(T) R) models.IdempotentResponse {
+	return func(result any) (int, []byte, error) {
+
+		typed, ok := result.(T)
+		if !ok {
+			return 0, nil, fmt.Errorf("unexpected result of type %T", result)
+		}
+
+		ba, err := json.Marshal(render(typed))
+		return statusCode, ba, err
+	}
+}
+
+// writeResponse writes the response rendered for the result of a service, which is the one
+// stored for the idempotency key of the request
+func (pah *paymentsAppHandler) writeResponse(ctx context.Context, w http.ResponseWriter, response models.IdempotentResponse, result any) {
+
+	statusCode, ba, err := response(result)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "marshalling error", "err", err.Error())
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+// writeIdempotentErr writes the response for an error of the service with write and stores it for the key
+// when it is a validation error, which the same request always gets, so that retries replay it. Business rule
+// errors such as an insufficient limit or a missing account may not hold anymore on a retry and server errors
+// are transient, their key is left unclaimed so that a retry runs again
+func (pah *paymentsAppHandler) writeIdempotentErr(ctx context.Context, w http.ResponseWriter, idempotencyKey *models.IdempotencyKey, write func(w http.ResponseWriter)) {
+
+	if idempotencyKey == nil {
+		write(w)
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: w}
+	write(recorder)
+
+	if recorder.statusCode != http.StatusBadRequest {
+		return
+	}
+
+	err := pah.idempotencyService.Complete(ctx, models.IdempotencyRecord{
+		Scope:        idempotencyKey.Scope,
+		Key:          idempotencyKey.Key,
+		RequestHash:  idempotencyKey.RequestHash,
+		StatusCode:   recorder.statusCode,
+		ResponseBody: recorder.body.Bytes(),
+	})
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to store idempotent response", "key", idempotencyKey.Key, "err", err)
+	}
+}
+
+// responseRecorder keeps the status code and body written through it
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	rr.statusCode = statusCode
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+// replayIdempotentRequest writes the response for a request whose idempotency key was already claimed, for handlers
+// that do work before the service claims the key, and returns false if the key was not used yet
+func (pah *paymentsAppHandler) replayIdempotentRequest(ctx context.Context, w http.ResponseWriter, idempotencyKey *models.IdempotencyKey) bool {
+
+	if idempotencyKey == nil {
+		return false
+	}
+
+	record, err := pah.idempotencyService.GetForKey(ctx, idempotencyKey.Scope, idempotencyKey.Key)
+	switch {
+	case errors.Is(err, models.NoRecordErr):
+		return false
+	case err != nil:
+		pah.logger.ErrorContext(ctx, "unable to fetch idempotency key", "key", idempotencyKey.Key, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return true
+	case record.RequestHash != idempotencyKey.RequestHash:
+		err = models.IdempotencyKeyMismatchErr
+	case !record.IsComplete():
+		err = models.IdempotencyKeyInProgressErr
+	default:
+		err = models.IdempotencyKeyReplayErr
+	}
+
+	return pah.handleIdempotencyErr(ctx, w, idempotencyKey, err)
+}
+
+// handleIdempotencyErr writes the response for errors raised while claiming an idempotency key
+// and returns false if the error is not related to idempotency
+func (pah *paymentsAppHandler) handleIdempotencyErr(ctx context.Context, w http.ResponseWriter, idempotencyKey *models.IdempotencyKey, err error) bool {
+
+	switch {
+	case errors.Is(err, models.IdempotencyKeyReplayErr):
+		record, err := pah.idempotencyService.GetForKey(ctx, idempotencyKey.Scope, idempotencyKey.Key)
+		if err != nil {
+			pah.logger.ErrorContext(ctx, "unable to fetch idempotent response", "key", idempotencyKey.Key, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return true
+		}
+		w.WriteHeader(record.StatusCode)
+		fmt.Fprintf(w, "%s", string(record.ResponseBody))
+	case errors.Is(err, models.IdempotencyKeyMismatchErr):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+	case errors.Is(err, models.IdempotencyKeyInProgressErr):
+		w.WriteHeader(http.StatusConflict)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+	default:
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	ListTransactionInstallmentsExtension = "/transactions/:transactionId/installments"
+)
+
+// WithInstallmentService enables the installment schedule endpoint
+func WithInstallmentService(installmentService models.InstallmentService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.installmentService = installmentService
+	}
+}
+
+// ListTransactionInstallments lists the installment schedule of a purchase with installments
+func (pah *paymentsAppHandler) ListTransactionInstallments(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	transactionIdS := params.ByName("transactionId")
+
+	transactionId, err := strconv.Atoi(transactionIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse transaction id", "transactionIdS", transactionIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	installments, err := pah.installmentService.ListForTransaction(ctx, int64(transactionId))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to list installments", "transactionID", transactionId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := ListInstallmentsResponse{
+		TransactionID: int64(transactionId),
+		Installments:  make([]InstallmentResponse, 0, len(installments)),
+	}
+	for _, installment := range installments {
+		resp.Installments = append(resp.Installments, InstallmentResponse{
+			Number:   installment.Number,
+			Amount:   installment.Amount,
+			DueDate:  installment.DueDate,
+			Posted:   installment.PostedAt != nil,
+			PostedAt: installment.PostedAt,
+		})
+	}
+
+	ba, err := json.Marshal(resp)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal installments", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	ListOperationTypesExtension  = "/operation-types"
+	CreateOperationTypeExtension = "/operation-types"
+	UpdateOperationTypeExtension = "/operation-types/:operationTypeId"
+)
+
+// ListOperationTypes lists every operation type, active or not
+func (pah *paymentsAppHandler) ListOperationTypes(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	operationTypes, err := pah.operationTypes.List(ctx)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to list operation types", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, err := json.Marshal(ListOperationTypesResponse{OperationTypes: operationTypes})
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal operation types", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+// CreateOperationType registers a new operation type that transactions can be booked with
+func (pah *paymentsAppHandler) CreateOperationType(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := CreateOperationTypeRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	operationType, err := pah.operationTypes.Create(ctx, models.OperationType{
+		Description:    req.Description,
+		Direction:      req.Direction,
+		Active:         active,
+		APRBasisPoints: req.APRBasisPoints,
+	})
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to create operation type", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, err = json.Marshal(operationType)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		pah.logger.ErrorContext(ctx, "marshalling error", "err", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+// UpdateOperationType changes the description or the interest rate of an operation type or (de)activates it
+func (pah *paymentsAppHandler) UpdateOperationType(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	operationTypeIdS := params.ByName("operationTypeId")
+
+	operationTypeId, err := strconv.Atoi(operationTypeIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse operation type id", "operationTypeIdS", operationTypeIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := UpdateOperationTypeRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	operationType, err := pah.operationTypes.Update(ctx, models.OperationTypeUpdate{
+		ID:             int64(operationTypeId),
+		Description:    req.Description,
+		Active:         req.Active,
+		APRBasisPoints: req.APRBasisPoints,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to update operation type", "operationTypeID", operationTypeId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ba, err = json.Marshal(operationType)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		pah.logger.ErrorContext(ctx, "marshalling error", "err", err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
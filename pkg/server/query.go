@@ -0,0 +1,86 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"payments-backend-app/pkg/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	SettlementStatusSettled = "settled"
+	SettlementStatusOpen    = "open"
+)
+
+// ParseListTransactionsQuery builds the filter for listing the transactions of an account
+// from the query parameters operation_type_id, from, to, status, cursor and limit
+func ParseListTransactionsQuery(accountID int64, query url.Values) (models.TransactionFilter, error) {
+
+	filter := models.TransactionFilter{
+		AccountID: accountID,
+		Limit:     models.DefaultPageLimit,
+	}
+
+	// operation types can be repeated or comma separated
+	for _, value := range query["operation_type_id"] {
+		for _, operationTypeIDS := range strings.Split(value, ",") {
+			operationTypeID, err := strconv.ParseInt(strings.TrimSpace(operationTypeIDS), 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid operation_type_id %q", operationTypeIDS)
+			}
+			filter.OperationTypeIDs = append(filter.OperationTypeIDs, operationTypeID)
+		}
+	}
+
+	if fromS := query.Get("from"); fromS != "" {
+		from, err := time.Parse(time.RFC3339, fromS)
+		if err != nil {
+			return filter, fmt.Errorf("from must be an RFC 3339 date time")
+		}
+		filter.From = &from
+	}
+
+	if toS := query.Get("to"); toS != "" {
+		to, err := time.Parse(time.RFC3339, toS)
+		if err != nil {
+			return filter, fmt.Errorf("to must be an RFC 3339 date time")
+		}
+		filter.To = &to
+	}
+
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return filter, fmt.Errorf("from must be before to")
+	}
+
+	switch status := query.Get("status"); status {
+	case "":
+	case SettlementStatusSettled:
+		settled := true
+		filter.Settled = &settled
+	case SettlementStatusOpen:
+		settled := false
+		filter.Settled = &settled
+	default:
+		return filter, fmt.Errorf("status must be one of %s, %s", SettlementStatusSettled, SettlementStatusOpen)
+	}
+
+	if cursorS := query.Get("cursor"); cursorS != "" {
+		cursor, err := models.DecodeTransactionCursor(cursorS)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &cursor
+	}
+
+	if limitS := query.Get("limit"); limitS != "" {
+		limit, err := strconv.Atoi(limitS)
+		if err != nil || limit < 1 || limit > models.MaxPageLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", models.MaxPageLimit)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
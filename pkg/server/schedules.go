@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	CreateScheduleExtension   = "/schedules"
+	GetScheduleExtension      = "/schedules/:scheduleId"
+	CancelScheduleExtension   = "/schedules/:scheduleId/cancel"
+	ListScheduleRunsExtension = "/schedules/:scheduleId/runs"
+)
+
+// WithScheduleService enables the scheduled transaction endpoints
+func WithScheduleService(scheduleService models.ScheduleService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.schedules = scheduleService
+	}
+}
+
+// CreateSchedule registers a transaction booked at a later time, once or on a recurring basis
+func (pah *paymentsAppHandler) CreateSchedule(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := CreateScheduleRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	operationType, ok := pah.activeOperationType(ctx, w, req.OperationTypeID)
+	if !ok {
+		return
+	}
+
+	// schedules book plain transactions, the rest must be linked to the operation that books them
+	if models.IsInternalOperationType(operationType.ID) || operationType.ID == int64(models.PurchaseWithInstallments) {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": "operation type can not be scheduled"})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	amount := req.Amount
+	if !operationType.IsCredit() {
+		amount = -amount
+	}
+
+	response := jsonResponse[models.Schedule](http.StatusCreated)
+	ctx, idempotencyKey, err := pah.idempotencyKeyContext(ctx, r, CreateScheduleExtension, ba, response)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	schedule, err := pah.schedules.Create(ctx, models.Schedule{
+		AccountID:       req.AccountID,
+		OperationTypeID: req.OperationTypeID,
+		Amount:          amount,
+		Frequency:       req.Frequency,
+		Interval:        req.Interval,
+		StartAt:         req.StartAt,
+		EndAt:           req.EndAt,
+	})
+	if err != nil {
+		if pah.handleIdempotencyErr(ctx, w, idempotencyKey, err) {
+			return
+		}
+		pah.writeIdempotentErr(ctx, w, idempotencyKey, func(w http.ResponseWriter) {
+			pah.writeScheduleErr(ctx, w, err)
+		})
+		return
+	}
+
+	pah.writeResponse(ctx, w, response, schedule)
+}
+
+// GetSchedule fetches a schedule for the provided id
+func (pah *paymentsAppHandler) GetSchedule(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	scheduleId, ok := pah.scheduleID(ctx, w, params)
+	if !ok {
+		return
+	}
+
+	schedule, err := pah.schedules.GetForID(ctx, scheduleId)
+	if err != nil {
+		pah.writeScheduleErr(ctx, w, err)
+		return
+	}
+
+	pah.writeSchedule(ctx, w, http.StatusOK, schedule)
+}
+
+// CancelSchedule stops an active schedule from booking any more transactions
+func (pah *paymentsAppHandler) CancelSchedule(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	scheduleId, ok := pah.scheduleID(ctx, w, params)
+	if !ok {
+		return
+	}
+
+	schedule, err := pah.schedules.Cancel(ctx, scheduleId)
+	if err != nil {
+		pah.writeScheduleErr(ctx, w, err)
+		return
+	}
+
+	pah.writeSchedule(ctx, w, http.StatusOK, schedule)
+}
+
+// ListScheduleRuns lists the execution log of a schedule, newest first
+func (pah *paymentsAppHandler) ListScheduleRuns(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	scheduleId, ok := pah.scheduleID(ctx, w, params)
+	if !ok {
+		return
+	}
+
+	runs, err := pah.schedules.ListRuns(ctx, scheduleId)
+	if err != nil {
+		pah.writeScheduleErr(ctx, w, err)
+		return
+	}
+
+	ba, err := json.Marshal(ListScheduleRunsResponse{
+		ScheduleID: scheduleId,
+		Runs:       runs,
+	})
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal schedule runs", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+func (pah *paymentsAppHandler) scheduleID(ctx context.Context, w http.ResponseWriter, params httprouter.Params) (int64, bool) {
+	scheduleIdS := params.ByName("scheduleId")
+
+	scheduleId, err := strconv.Atoi(scheduleIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse schedule id", "scheduleIdS", scheduleIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return 0, false
+	}
+
+	return int64(scheduleId), true
+}
+
+func (pah *paymentsAppHandler) writeSchedule(ctx context.Context, w http.ResponseWriter, statusCode int, schedule models.Schedule) {
+
+	ba, err := json.Marshal(schedule)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal schedule", "schedule", schedule, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+func (pah *paymentsAppHandler) writeScheduleErr(ctx context.Context, w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, models.NoRecordErr):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.ScheduleNotActiveErr):
+		w.WriteHeader(http.StatusConflict)
+	case errors.Is(err, models.AccountNotActiveErr), errors.Is(err, models.AmountPrecisionErr):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		pah.logger.ErrorContext(ctx, "unable to process schedule", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+	fmt.Fprintf(w, "%s", string(ba))
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	UpdateSettlementStrategyExtension = "/accounts/:accountId/settlement-strategy"
+)
+
+// WithSettlementStrategies sets the strategies accounts can select, defaults to the ones shipped with the app
+func WithSettlementStrategies(settlement models.SettlementStrategies) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.settlement = settlement
+	}
+}
+
+// UpdateSettlementStrategy selects the strategy used to settle the transactions of an account
+func (pah *paymentsAppHandler) UpdateSettlementStrategy(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	accountIdS := params.ByName("accountId")
+
+	accountId, err := strconv.Atoi(accountIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse account id", "accountIdS", accountIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := UpdateSettlementStrategyRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	if req.SettlementStrategy != nil && !pah.isSettlementStrategy(*req.SettlementStrategy) {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": fmt.Sprintf("unknown settlement strategy %s", *req.SettlementStrategy)})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	account, err := pah.accountsService.UpdateSettlementStrategy(ctx, int64(accountId), req.SettlementStrategy)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to update settlement strategy", "accountID", accountId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := GetAccountResponse{
+		AccountID:            account.AccountID,
+		DocumentNumber:       account.DocumentNumber,
+		DocumentType:         account.DocumentType,
+		CreditLimit:          account.CreditLimit,
+		AvailableCreditLimit: account.AvailableCreditLimit,
+		SettlementStrategy:   account.SettlementStrategy,
+		ClosingDay:           account.ClosingDay,
+		DueDay:               account.DueDay,
+		Status:               account.Status,
+		Currency:             account.Currency,
+	}
+
+	ba, err = json.Marshal(resp)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal account", "account", account, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+func (pah *paymentsAppHandler) isSettlementStrategy(name string) bool {
+	_, ok := pah.settlement.ByName[name]
+	return ok
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	UpdateBillingCycleExtension    = "/accounts/:accountId/billing-cycle"
+	ListAccountStatementsExtension = "/accounts/:accountId/statements"
+	GetStatementExtension          = "/statements/:statementId"
+)
+
+// WithStatementService enables the statement endpoints
+func WithStatementService(statementService models.StatementService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.statements = statementService
+	}
+}
+
+// UpdateBillingCycle sets the closing and due days of an account, they apply from the next statement on
+func (pah *paymentsAppHandler) UpdateBillingCycle(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	accountIdS := params.ByName("accountId")
+
+	accountId, err := strconv.Atoi(accountIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse account id", "accountIdS", accountIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := UpdateBillingCycleRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	account, err := pah.accountsService.UpdateBillingCycle(ctx, int64(accountId), req.ClosingDay, req.DueDay)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to update billing cycle", "accountID", accountId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := GetAccountResponse{
+		AccountID:            account.AccountID,
+		DocumentNumber:       account.DocumentNumber,
+		DocumentType:         account.DocumentType,
+		CreditLimit:          account.CreditLimit,
+		AvailableCreditLimit: account.AvailableCreditLimit,
+		SettlementStrategy:   account.SettlementStrategy,
+		ClosingDay:           account.ClosingDay,
+		DueDay:               account.DueDay,
+		Status:               account.Status,
+		Currency:             account.Currency,
+	}
+
+	ba, err = json.Marshal(resp)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal account", "account", account, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+// ListAccountStatements lists the statements of an account newest first, without their lines
+func (pah *paymentsAppHandler) ListAccountStatements(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	accountIdS := params.ByName("accountId")
+
+	accountId, err := strconv.Atoi(accountIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse account id", "accountIdS", accountIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	statements, err := pah.statements.ListForAccount(ctx, int64(accountId))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to list statements", "accountID", accountId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ba, err := json.Marshal(ListStatementsResponse{
+		AccountID:  int64(accountId),
+		Statements: statements,
+	})
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal statements", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+// GetStatement fetches a statement with the transactions of its cycle
+func (pah *paymentsAppHandler) GetStatement(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+	statementIdS := params.ByName("statementId")
+
+	statementId, err := strconv.Atoi(statementIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse statement id", "statementIdS", statementIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	statement, err := pah.statements.GetForID(ctx, int64(statementId))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.NoRecordErr):
+			w.WriteHeader(http.StatusNotFound)
+			ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+			fmt.Fprintf(w, "%s", string(ba))
+		default:
+			pah.logger.ErrorContext(ctx, "unable to fetch statement for id", "statementID", statementId, "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	ba, err := json.Marshal(statement)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal statement", "statement", statement, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "%s", string(ba))
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	CreateTransferExtension = "/transfers"
+	GetTransferExtension    = "/transfers/:transferId"
+)
+
+// WithTransferService enables the account-to-account transfer endpoints
+func WithTransferService(transferService models.TransferService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.transfers = transferService
+	}
+}
+
+// CreateTransfer moves money from one account to another in a single operation
+func (pah *paymentsAppHandler) CreateTransfer(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := CreateTransferRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	response := jsonResponse[models.Transfer](http.StatusCreated)
+	ctx, idempotencyKey, err := pah.idempotencyKeyContext(ctx, r, CreateTransferExtension, ba, response)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	transfer, err := pah.transfers.Create(ctx, models.Transfer{
+		SourceAccountID:      req.SourceAccountID,
+		DestinationAccountID: req.DestinationAccountID,
+		Amount:               req.Amount,
+	})
+	if err != nil {
+		if pah.handleIdempotencyErr(ctx, w, idempotencyKey, err) {
+			return
+		}
+		pah.writeIdempotentErr(ctx, w, idempotencyKey, func(w http.ResponseWriter) {
+			pah.writeTransferErr(ctx, w, err)
+		})
+		return
+	}
+
+	pah.writeResponse(ctx, w, response, transfer)
+}
+
+// GetTransfer fetches a transfer for the provided id
+func (pah *paymentsAppHandler) GetTransfer(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	transferIdS := params.ByName("transferId")
+
+	transferId, err := strconv.Atoi(transferIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse transfer id", "transferIdS", transferIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	transfer, err := pah.transfers.GetForID(ctx, int64(transferId))
+	if err != nil {
+		pah.writeTransferErr(ctx, w, err)
+		return
+	}
+
+	pah.writeTransfer(ctx, w, http.StatusOK, transfer)
+}
+
+func (pah *paymentsAppHandler) writeTransfer(ctx context.Context, w http.ResponseWriter, statusCode int, transfer models.Transfer) {
+
+	ba, err := json.Marshal(transfer)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal transfer", "transfer", transfer, "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+func (pah *paymentsAppHandler) writeTransferErr(ctx context.Context, w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, models.SameAccountTransferErr):
+		w.WriteHeader(http.StatusBadRequest)
+	case errors.Is(err, models.NoRecordErr):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.InsufficientLimitErr), errors.Is(err, models.AccountNotActiveErr),
+		errors.Is(err, models.CurrencyMismatchErr), errors.Is(err, models.AmountPrecisionErr):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		pah.logger.ErrorContext(ctx, "unable to process transfer", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+	fmt.Fprintf(w, "%s", string(ba))
+}
@@ -3,90 +3,237 @@ package server
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"payments-backend-app/pkg/models"
-	"strconv"
 	"strings"
+	"time"
 )
 
+// CreateAccountRequest opens an account, the document is checked by the document validators of the handler
 type CreateAccountRequest struct {
-	DocumentNumber string `json:"document_number"`
+	DocumentNumber       string              `json:"document_number"`
+	DocumentType         models.DocumentType `json:"document_type,omitempty"`
+	AvailableCreditLimit *models.Money       `json:"available_credit_limit,omitempty"`
+	SettlementStrategy   *string             `json:"settlement_strategy,omitempty"`
+	ClosingDay           *int                `json:"closing_day,omitempty"`
+	DueDay               *int                `json:"due_day,omitempty"`
+	Currency             models.Currency     `json:"currency,omitempty"`
 }
 
 func (c *CreateAccountRequest) UnmarshalJSON(data []byte) error {
 
 	var createAccountRequest struct {
-		DocumentNumber string `json:"document_number"`
+		DocumentNumber       string              `json:"document_number"`
+		DocumentType         models.DocumentType `json:"document_type"`
+		AvailableCreditLimit *models.Money       `json:"available_credit_limit"`
+		SettlementStrategy   *string             `json:"settlement_strategy"`
+		ClosingDay           *int                `json:"closing_day"`
+		DueDay               *int                `json:"due_day"`
+		Currency             string              `json:"currency"`
 	}
 
 	if err := json.Unmarshal(data, &createAccountRequest); err != nil {
 		return err
 	}
 
-	documentNumber := createAccountRequest.DocumentNumber
+	// without a currency the account is opened in the default one
+	var currency models.Currency
+	if createAccountRequest.Currency != "" {
+		var err error
+		if currency, err = models.ParseCurrency(createAccountRequest.Currency); err != nil {
+			return err
+		}
+	}
 
-	switch {
-	case documentNumber != strings.TrimSpace(documentNumber):
-		return fmt.Errorf("document number has trailing spaces")
-	case documentNumber != strings.TrimLeft(documentNumber, "0"):
-		return fmt.Errorf("document number has 0's in the beginning")
-	case len(documentNumber) > 15:
-		return fmt.Errorf("document number length must be no greater than 15")
-	case len(documentNumber) == 0:
-		return fmt.Errorf("empty document number not allowed")
+	if createAccountRequest.AvailableCreditLimit != nil && *createAccountRequest.AvailableCreditLimit < 0 {
+		return fmt.Errorf("available credit limit must not be negative")
 	}
 
-	_, err := strconv.Atoi(documentNumber)
-	if err != nil {
-		return fmt.Errorf("invalid number")
+	if err := validateBillingCycle(createAccountRequest.ClosingDay, createAccountRequest.DueDay); err != nil {
+		return err
 	}
 
-	c.DocumentNumber = documentNumber
+	c.DocumentNumber = createAccountRequest.DocumentNumber
+	c.DocumentType = createAccountRequest.DocumentType
+	c.AvailableCreditLimit = createAccountRequest.AvailableCreditLimit
+	c.SettlementStrategy = createAccountRequest.SettlementStrategy
+	c.ClosingDay = createAccountRequest.ClosingDay
+	c.DueDay = createAccountRequest.DueDay
+	c.Currency = currency
 	return nil
 }
 
 type GetAccountResponse struct {
-	AccountID      int64  `json:"account_id"`
-	DocumentNumber string `json:"document_number"`
+	AccountID            int64                `json:"account_id"`
+	DocumentNumber       string               `json:"document_number"`
+	DocumentType         models.DocumentType  `json:"document_type,omitempty"`
+	CreditLimit          *models.Money        `json:"credit_limit,omitempty"`
+	AvailableCreditLimit *models.Money        `json:"available_credit_limit,omitempty"`
+	SettlementStrategy   *string              `json:"settlement_strategy,omitempty"`
+	ClosingDay           *int                 `json:"closing_day,omitempty"`
+	DueDay               *int                 `json:"due_day,omitempty"`
+	Status               models.AccountStatus `json:"status"`
+	Currency             models.Currency      `json:"currency"`
+}
+
+// UpdateAccountStatusRequest carries the optional reason of a freeze, unfreeze or close
+type UpdateAccountStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ListAccountStatusHistoryResponse struct {
+	AccountID int64                        `json:"account_id"`
+	History   []models.AccountStatusChange `json:"history"`
+}
+
+// UpdateSettlementStrategyRequest selects the settlement strategy of an account, null restores the default
+type UpdateSettlementStrategyRequest struct {
+	SettlementStrategy *string `json:"settlement_strategy"`
+}
+
+// UpdateBillingCycleRequest sets the closing and due days of an account, null restores the default
+type UpdateBillingCycleRequest struct {
+	ClosingDay *int `json:"closing_day"`
+	DueDay     *int `json:"due_day"`
+}
+
+func (u *UpdateBillingCycleRequest) UnmarshalJSON(data []byte) error {
+
+	var updateBillingCycleRequest struct {
+		ClosingDay *int `json:"closing_day"`
+		DueDay     *int `json:"due_day"`
+	}
+
+	if err := json.Unmarshal(data, &updateBillingCycleRequest); err != nil {
+		return err
+	}
+
+	if err := validateBillingCycle(updateBillingCycleRequest.ClosingDay, updateBillingCycleRequest.DueDay); err != nil {
+		return err
+	}
+
+	u.ClosingDay = updateBillingCycleRequest.ClosingDay
+	u.DueDay = updateBillingCycleRequest.DueDay
+	return nil
+}
+
+func validateBillingCycle(closingDay *int, dueDay *int) error {
+
+	switch {
+	case closingDay != nil && !models.IsBillingDay(*closingDay):
+		return fmt.Errorf("closing day must be between 1 and %d", models.MaxBillingDay)
+	case dueDay != nil && !models.IsBillingDay(*dueDay):
+		return fmt.Errorf("due day must be between 1 and %d", models.MaxBillingDay)
+	}
+
+	return nil
+}
+
+type UpdateCreditLimitRequest struct {
+	AvailableCreditLimit models.Money `json:"available_credit_limit"`
+}
+
+func (u *UpdateCreditLimitRequest) UnmarshalJSON(data []byte) error {
+
+	var updateCreditLimitRequest struct {
+		AvailableCreditLimit *models.Money `json:"available_credit_limit"`
+	}
+
+	if err := json.Unmarshal(data, &updateCreditLimitRequest); err != nil {
+		return err
+	}
+
+	switch {
+	case updateCreditLimitRequest.AvailableCreditLimit == nil:
+		return fmt.Errorf("available credit limit is required")
+	case *updateCreditLimitRequest.AvailableCreditLimit < 0:
+		return fmt.Errorf("available credit limit must not be negative")
+	}
+
+	u.AvailableCreditLimit = *updateCreditLimitRequest.AvailableCreditLimit
+	return nil
 }
 
+type OperationTypeBalanceResponse struct {
+	OperationTypeID int64        `json:"operation_type_id"`
+	Description     string       `json:"description"`
+	AvailableCredit models.Money `json:"available_credit"`
+	OutstandingDebt models.Money `json:"outstanding_debt"`
+}
+
+type GetAccountBalanceResponse struct {
+	AccountID       int64                          `json:"account_id"`
+	AvailableCredit models.Money                   `json:"available_credit"`
+	OutstandingDebt models.Money                   `json:"outstanding_debt"`
+	Held            models.Money                   `json:"held"`
+	OperationTypes  []OperationTypeBalanceResponse `json:"operation_types"`
+}
+
+// CreateTransactionRequest books a transaction in the currency of the account, a transaction in another
+// currency is only booked when Convert asks for its amount to be converted into the currency of the account,
+// or when QuoteID books it at the rate locked by an fx quote
 type CreateTransactionRequest struct {
-	AccountID       int64   `json:"account_id"`
-	OperationTypeID int64   `json:"operation_type_id"`
-	Amount          float64 `json:"amount"`
+	AccountID       int64           `json:"account_id"`
+	OperationTypeID int64           `json:"operation_type_id"`
+	Amount          models.Money    `json:"amount"`
+	Currency        models.Currency `json:"currency,omitempty"`
+	Convert         bool            `json:"convert,omitempty"`
+	QuoteID         *int64          `json:"quote_id,omitempty"`
+	Installments    int             `json:"installments,omitempty"`
 }
 
 func (c *CreateTransactionRequest) UnmarshalJSON(data []byte) error {
 
 	var createTransactionRequest struct {
-		AccountID       int64   `json:"account_id"`
-		OperationTypeID int64   `json:"operation_type_id"`
-		Amount          float64 `json:"amount"`
+		AccountID       int64        `json:"account_id"`
+		OperationTypeID int64        `json:"operation_type_id"`
+		Amount          models.Money `json:"amount"`
+		Currency        string       `json:"currency"`
+		Convert         bool         `json:"convert"`
+		QuoteID         *int64       `json:"quote_id"`
+		Installments    int          `json:"installments"`
 	}
 
 	if err := json.Unmarshal(data, &createTransactionRequest); err != nil {
 		return err
 	}
 
-	if !models.IsSupportedType(int(createTransactionRequest.OperationTypeID)) {
+	switch {
+	case createTransactionRequest.OperationTypeID <= 0:
 		return fmt.Errorf("unsupported operation type")
+	case createTransactionRequest.Amount <= 0:
+		return fmt.Errorf("amount must be greater than 0")
+	case createTransactionRequest.Installments < 0 || createTransactionRequest.Installments > models.MaxInstallments:
+		return fmt.Errorf("installments must be between 0 and %d", models.MaxInstallments)
+	case createTransactionRequest.Amount < models.Money(createTransactionRequest.Installments):
+		return fmt.Errorf("amount is too small to be split into %d installments", createTransactionRequest.Installments)
+	case createTransactionRequest.Convert && createTransactionRequest.Currency == "":
+		return fmt.Errorf("currency is required to convert the amount")
+	case createTransactionRequest.QuoteID != nil && *createTransactionRequest.QuoteID <= 0:
+		return fmt.Errorf("invalid quote id")
 	}
 
-	amountS := fmt.Sprintf("%f", createTransactionRequest.Amount)
-	decimal := strings.Trim(strings.Split(amountS, ".")[1], "0")
-
-	switch {
-	case len(decimal) > 2:
-		return fmt.Errorf("amount must be capped to 2 decimal places")
+	// the amount is parsed exactly by models.Money, its precision is checked against the minor unit
+	// of the currency here when it is given and against the one of the account when it is booked
+	var currency models.Currency
+	if createTransactionRequest.Currency != "" {
+		var err error
+		if currency, err = models.ParseCurrency(createTransactionRequest.Currency); err != nil {
+			return err
+		}
+		if err := currency.CheckAmount(createTransactionRequest.Amount); err != nil {
+			return err
+		}
 	}
 
+	// the sign of the amount is set from the direction of the operation type by the handler
 	c.AccountID = createTransactionRequest.AccountID
 	c.OperationTypeID = createTransactionRequest.OperationTypeID
-
-	if models.IsCredit(int(c.OperationTypeID)) {
-		c.Amount = createTransactionRequest.Amount
-	} else {
-		c.Amount = -createTransactionRequest.Amount
-	}
+	c.Amount = createTransactionRequest.Amount
+	c.Currency = currency
+	c.Convert = createTransactionRequest.Convert
+	c.QuoteID = createTransactionRequest.QuoteID
+	c.Installments = createTransactionRequest.Installments
 
 	return nil
 }
@@ -95,3 +242,547 @@ type CreateTransactionResponse struct {
 	TransactionID int64 `json:"transaction_id"`
 	AccountID     int64 `json:"account_id"`
 }
+
+func newCreateTransactionResponse(transactionStatus models.TransactionStatus) CreateTransactionResponse {
+	return CreateTransactionResponse{
+		TransactionID: transactionStatus.TransactionID,
+		AccountID:     transactionStatus.AccountID,
+	}
+}
+
+type OperationTypeResponse struct {
+	OperationTypeID int64  `json:"operation_type_id"`
+	Description     string `json:"description"`
+}
+
+// ReverseTransactionRequest is optional, without an amount the transaction is reversed fully
+type ReverseTransactionRequest struct {
+	Amount models.Money `json:"amount"`
+}
+
+func (r *ReverseTransactionRequest) UnmarshalJSON(data []byte) error {
+
+	var reverseTransactionRequest struct {
+		Amount *models.Money `json:"amount"`
+	}
+
+	if err := json.Unmarshal(data, &reverseTransactionRequest); err != nil {
+		return err
+	}
+
+	if reverseTransactionRequest.Amount != nil {
+		if *reverseTransactionRequest.Amount <= 0 {
+			return fmt.Errorf("amount must be greater than 0")
+		}
+		r.Amount = *reverseTransactionRequest.Amount
+	}
+
+	return nil
+}
+
+type CreateAuthorizationRequest struct {
+	AccountID       int64        `json:"account_id"`
+	OperationTypeID int64        `json:"operation_type_id"`
+	Amount          models.Money `json:"amount"`
+}
+
+func (c *CreateAuthorizationRequest) UnmarshalJSON(data []byte) error {
+
+	var createAuthorizationRequest struct {
+		AccountID       int64        `json:"account_id"`
+		OperationTypeID int64        `json:"operation_type_id"`
+		Amount          models.Money `json:"amount"`
+	}
+
+	if err := json.Unmarshal(data, &createAuthorizationRequest); err != nil {
+		return err
+	}
+
+	switch {
+	case createAuthorizationRequest.OperationTypeID <= 0:
+		return fmt.Errorf("unsupported operation type")
+	case createAuthorizationRequest.Amount <= 0:
+		return fmt.Errorf("amount must be greater than 0")
+	}
+
+	c.AccountID = createAuthorizationRequest.AccountID
+	c.OperationTypeID = createAuthorizationRequest.OperationTypeID
+	c.Amount = createAuthorizationRequest.Amount
+
+	return nil
+}
+
+// CaptureAuthorizationRequest is optional, without an amount the whole hold is captured
+type CaptureAuthorizationRequest struct {
+	Amount models.Money `json:"amount"`
+}
+
+func (c *CaptureAuthorizationRequest) UnmarshalJSON(data []byte) error {
+
+	var captureAuthorizationRequest struct {
+		Amount *models.Money `json:"amount"`
+	}
+
+	if err := json.Unmarshal(data, &captureAuthorizationRequest); err != nil {
+		return err
+	}
+
+	if captureAuthorizationRequest.Amount != nil {
+		if *captureAuthorizationRequest.Amount <= 0 {
+			return fmt.Errorf("amount must be greater than 0")
+		}
+		c.Amount = *captureAuthorizationRequest.Amount
+	}
+
+	return nil
+}
+
+type CreateTransferRequest struct {
+	SourceAccountID      int64        `json:"source_account_id"`
+	DestinationAccountID int64        `json:"destination_account_id"`
+	Amount               models.Money `json:"amount"`
+}
+
+func (c *CreateTransferRequest) UnmarshalJSON(data []byte) error {
+
+	var createTransferRequest struct {
+		SourceAccountID      int64        `json:"source_account_id"`
+		DestinationAccountID int64        `json:"destination_account_id"`
+		Amount               models.Money `json:"amount"`
+	}
+
+	if err := json.Unmarshal(data, &createTransferRequest); err != nil {
+		return err
+	}
+
+	switch {
+	case createTransferRequest.SourceAccountID == createTransferRequest.DestinationAccountID:
+		return models.SameAccountTransferErr
+	case createTransferRequest.Amount <= 0:
+		return fmt.Errorf("amount must be greater than 0")
+	}
+
+	c.SourceAccountID = createTransferRequest.SourceAccountID
+	c.DestinationAccountID = createTransferRequest.DestinationAccountID
+	c.Amount = createTransferRequest.Amount
+
+	return nil
+}
+
+type GetTransactionResponse struct {
+	TransactionID int64                 `json:"transaction_id"`
+	AccountID     int64                 `json:"account_id"`
+	OperationType OperationTypeResponse `json:"operation_type"`
+	Amount        models.Money          `json:"amount"`
+	Currency      models.Currency       `json:"currency"`
+	Balance       models.Money          `json:"balance"`
+	EventDate     time.Time             `json:"event_date"`
+	Installments  int                   `json:"installments,omitempty"`
+
+	ReversesTransactionID *int64 `json:"reverses_transaction_id,omitempty"`
+	TransferID            *int64 `json:"transfer_id,omitempty"`
+
+	OriginalAmount   *models.Money        `json:"original_amount,omitempty"`
+	OriginalCurrency models.Currency      `json:"original_currency,omitempty"`
+	FXRate           *models.ExchangeRate `json:"fx_rate,omitempty"`
+	FXQuoteID        *int64               `json:"fx_quote_id,omitempty"`
+}
+
+func NewGetTransactionResponse(transaction models.Transaction) GetTransactionResponse {
+
+	resp := GetTransactionResponse{
+		TransactionID: transaction.ID,
+		AccountID:     transaction.AccountID,
+		OperationType: OperationTypeResponse{
+			OperationTypeID: transaction.OperationTypeID,
+		},
+		Amount:    transaction.Amount,
+		Currency:  transaction.Currency,
+		Balance:   transaction.Balance,
+		EventDate: transaction.EventDate,
+
+		Installments:          transaction.Installments,
+		ReversesTransactionID: transaction.ReversesTransactionID,
+		TransferID:            transaction.TransferID,
+
+		OriginalAmount:   transaction.OriginalAmount,
+		OriginalCurrency: transaction.OriginalCurrency,
+		FXRate:           transaction.FXRate,
+		FXQuoteID:        transaction.FXQuoteID,
+	}
+
+	if transaction.OperationType != nil {
+		resp.OperationType.Description = transaction.OperationType.Description
+	}
+
+	return resp
+}
+
+type InstallmentResponse struct {
+	Number   int          `json:"number"`
+	Amount   models.Money `json:"amount"`
+	DueDate  time.Time    `json:"due_date"`
+	Posted   bool         `json:"posted"`
+	PostedAt *time.Time   `json:"posted_at,omitempty"`
+}
+
+type ListInstallmentsResponse struct {
+	TransactionID int64                 `json:"transaction_id"`
+	Installments  []InstallmentResponse `json:"installments"`
+}
+
+type ListStatementsResponse struct {
+	AccountID  int64              `json:"account_id"`
+	Statements []models.Statement `json:"statements"`
+}
+
+// AccrueRequest runs the accruals of a day that already ended, formatted as 2006-01-02
+type AccrueRequest struct {
+	Date string `json:"date"`
+
+	day time.Time
+}
+
+func (a *AccrueRequest) UnmarshalJSON(data []byte) error {
+
+	var accrueRequest struct {
+		Date string `json:"date"`
+	}
+
+	if err := json.Unmarshal(data, &accrueRequest); err != nil {
+		return err
+	}
+
+	day, err := time.Parse(time.DateOnly, accrueRequest.Date)
+	if err != nil {
+		return fmt.Errorf("date must be formatted as %s", time.DateOnly)
+	}
+
+	if !day.Before(models.AccrualDay(time.Now())) {
+		return fmt.Errorf("only days that already ended can be accrued")
+	}
+
+	a.Date = accrueRequest.Date
+	a.day = day
+	return nil
+}
+
+type AccrueResponse struct {
+	Date    string `json:"date"`
+	Accrued int    `json:"accrued"`
+}
+
+type ListAccrualsResponse struct {
+	AccountID int64            `json:"account_id"`
+	Accruals  []models.Accrual `json:"accruals"`
+}
+
+type CreateScheduleRequest struct {
+	AccountID       int64                    `json:"account_id"`
+	OperationTypeID int64                    `json:"operation_type_id"`
+	Amount          models.Money             `json:"amount"`
+	Frequency       models.ScheduleFrequency `json:"frequency"`
+	Interval        int                      `json:"interval"`
+	StartAt         time.Time                `json:"start_at"`
+	EndAt           *time.Time               `json:"end_at,omitempty"`
+}
+
+func (c *CreateScheduleRequest) UnmarshalJSON(data []byte) error {
+
+	var createScheduleRequest struct {
+		AccountID       int64                    `json:"account_id"`
+		OperationTypeID int64                    `json:"operation_type_id"`
+		Amount          models.Money             `json:"amount"`
+		Frequency       models.ScheduleFrequency `json:"frequency"`
+		Interval        *int                     `json:"interval"`
+		StartAt         *time.Time               `json:"start_at"`
+		EndAt           *time.Time               `json:"end_at"`
+	}
+
+	if err := json.Unmarshal(data, &createScheduleRequest); err != nil {
+		return err
+	}
+
+	// without a frequency the transaction is booked once and without a start right away
+	if createScheduleRequest.Frequency == "" {
+		createScheduleRequest.Frequency = models.OnceSchedule
+	}
+	interval := 1
+	if createScheduleRequest.Interval != nil {
+		interval = *createScheduleRequest.Interval
+	}
+	startAt := time.Now()
+	if createScheduleRequest.StartAt != nil {
+		startAt = *createScheduleRequest.StartAt
+	}
+
+	switch {
+	case createScheduleRequest.OperationTypeID <= 0:
+		return fmt.Errorf("unsupported operation type")
+	case createScheduleRequest.Amount <= 0:
+		return fmt.Errorf("amount must be greater than 0")
+	case !createScheduleRequest.Frequency.IsValid():
+		return fmt.Errorf("frequency must be one of once, daily, weekly or monthly")
+	case interval < 1:
+		return fmt.Errorf("interval must be greater than 0")
+	case createScheduleRequest.Frequency == models.OnceSchedule && (interval != 1 || createScheduleRequest.EndAt != nil):
+		return fmt.Errorf("interval and end_at are only supported for recurring schedules")
+	case interval > createScheduleRequest.Frequency.MaxInterval():
+		return fmt.Errorf("interval of %s schedules must not be greater than %d", createScheduleRequest.Frequency, createScheduleRequest.Frequency.MaxInterval())
+	case createScheduleRequest.StartAt != nil && startAt.Before(time.Now()):
+		return fmt.Errorf("start_at must not be in the past")
+	case createScheduleRequest.EndAt != nil && createScheduleRequest.EndAt.Before(startAt):
+		return fmt.Errorf("end_at must not be before start_at")
+	}
+
+	// the sign of the amount is set from the direction of the operation type by the handler
+	c.AccountID = createScheduleRequest.AccountID
+	c.OperationTypeID = createScheduleRequest.OperationTypeID
+	c.Amount = createScheduleRequest.Amount
+	c.Frequency = createScheduleRequest.Frequency
+	c.Interval = interval
+	c.StartAt = startAt
+	c.EndAt = createScheduleRequest.EndAt
+
+	return nil
+}
+
+type ListScheduleRunsResponse struct {
+	ScheduleID int64                `json:"schedule_id"`
+	Runs       []models.ScheduleRun `json:"runs"`
+}
+
+type ListAllocationsResponse struct {
+	TransactionID int64               `json:"transaction_id"`
+	Allocations   []models.Allocation `json:"allocations"`
+}
+
+type ListTransactionsResponse struct {
+	Transactions []GetTransactionResponse `json:"transactions"`
+	NextCursor   string                   `json:"next_cursor,omitempty"`
+}
+
+type CreateOperationTypeRequest struct {
+	Description    string                        `json:"description"`
+	Direction      models.OperationTypeDirection `json:"direction"`
+	Active         *bool                         `json:"active,omitempty"`
+	APRBasisPoints int64                         `json:"apr_bps,omitempty"`
+}
+
+func (c *CreateOperationTypeRequest) UnmarshalJSON(data []byte) error {
+
+	var createOperationTypeRequest struct {
+		Description    string                        `json:"description"`
+		Direction      models.OperationTypeDirection `json:"direction"`
+		Active         *bool                         `json:"active"`
+		APRBasisPoints int64                         `json:"apr_bps"`
+	}
+
+	if err := json.Unmarshal(data, &createOperationTypeRequest); err != nil {
+		return err
+	}
+
+	description := strings.TrimSpace(createOperationTypeRequest.Description)
+
+	switch {
+	case len(description) == 0:
+		return fmt.Errorf("empty description not allowed")
+	case createOperationTypeRequest.Direction != models.DebitDirection && createOperationTypeRequest.Direction != models.CreditDirection:
+		return fmt.Errorf("direction must be one of %s, %s", models.DebitDirection, models.CreditDirection)
+	case createOperationTypeRequest.APRBasisPoints < 0:
+		return fmt.Errorf("apr must not be negative")
+	}
+
+	c.Description = description
+	c.Direction = createOperationTypeRequest.Direction
+	c.Active = createOperationTypeRequest.Active
+	c.APRBasisPoints = createOperationTypeRequest.APRBasisPoints
+
+	return nil
+}
+
+type UpdateOperationTypeRequest struct {
+	Description    *string `json:"description,omitempty"`
+	Active         *bool   `json:"active,omitempty"`
+	APRBasisPoints *int64  `json:"apr_bps,omitempty"`
+}
+
+func (u *UpdateOperationTypeRequest) UnmarshalJSON(data []byte) error {
+
+	var updateOperationTypeRequest struct {
+		Description    *string `json:"description"`
+		Active         *bool   `json:"active"`
+		APRBasisPoints *int64  `json:"apr_bps"`
+	}
+
+	if err := json.Unmarshal(data, &updateOperationTypeRequest); err != nil {
+		return err
+	}
+
+	if updateOperationTypeRequest.Description != nil {
+		description := strings.TrimSpace(*updateOperationTypeRequest.Description)
+		if len(description) == 0 {
+			return fmt.Errorf("empty description not allowed")
+		}
+		updateOperationTypeRequest.Description = &description
+	}
+
+	if updateOperationTypeRequest.APRBasisPoints != nil && *updateOperationTypeRequest.APRBasisPoints < 0 {
+		return fmt.Errorf("apr must not be negative")
+	}
+
+	if updateOperationTypeRequest.Description == nil && updateOperationTypeRequest.Active == nil && updateOperationTypeRequest.APRBasisPoints == nil {
+		return fmt.Errorf("nothing to update")
+	}
+
+	u.Description = updateOperationTypeRequest.Description
+	u.Active = updateOperationTypeRequest.Active
+	u.APRBasisPoints = updateOperationTypeRequest.APRBasisPoints
+
+	return nil
+}
+
+type ListOperationTypesResponse struct {
+	OperationTypes []models.OperationType `json:"operation_types"`
+}
+
+// SetFXRateRequest sets the rate from a currency to another, one unit of From buys Rate of To
+type SetFXRateRequest struct {
+	From models.Currency     `json:"from"`
+	To   models.Currency     `json:"to"`
+	Rate models.ExchangeRate `json:"rate"`
+}
+
+func (s *SetFXRateRequest) UnmarshalJSON(data []byte) error {
+
+	var setFXRateRequest struct {
+		From string              `json:"from"`
+		To   string              `json:"to"`
+		Rate models.ExchangeRate `json:"rate"`
+	}
+
+	if err := json.Unmarshal(data, &setFXRateRequest); err != nil {
+		return err
+	}
+
+	from, err := models.ParseCurrency(setFXRateRequest.From)
+	if err != nil {
+		return err
+	}
+
+	to, err := models.ParseCurrency(setFXRateRequest.To)
+	if err != nil {
+		return err
+	}
+
+	rate := models.FXRate{From: from, To: to, Rate: setFXRateRequest.Rate}
+	if err := rate.Validate(); err != nil {
+		return err
+	}
+
+	s.From = rate.From
+	s.To = rate.To
+	s.Rate = rate.Rate
+
+	return nil
+}
+
+type ListFXRatesResponse struct {
+	Rates []models.FXRate `json:"rates"`
+}
+
+// CreateFXQuoteRequest asks for the current rate from a currency to another to be locked
+type CreateFXQuoteRequest struct {
+	From models.Currency `json:"from"`
+	To   models.Currency `json:"to"`
+}
+
+func (c *CreateFXQuoteRequest) UnmarshalJSON(data []byte) error {
+
+	var createFXQuoteRequest struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	if err := json.Unmarshal(data, &createFXQuoteRequest); err != nil {
+		return err
+	}
+
+	from, err := models.ParseCurrency(createFXQuoteRequest.From)
+	if err != nil {
+		return err
+	}
+
+	to, err := models.ParseCurrency(createFXQuoteRequest.To)
+	if err != nil {
+		return err
+	}
+
+	if from == to {
+		return fmt.Errorf("quote must convert between two different currencies")
+	}
+
+	c.From = from
+	c.To = to
+
+	return nil
+}
+
+// CreateWebhookRequest subscribes a url to the events of the given types, of every account
+// unless AccountID is provided
+type CreateWebhookRequest struct {
+	URL        string             `json:"url"`
+	EventTypes []models.EventType `json:"event_types"`
+	AccountID  *int64             `json:"account_id,omitempty"`
+}
+
+func (c *CreateWebhookRequest) UnmarshalJSON(data []byte) error {
+
+	var createWebhookRequest struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+		AccountID  *int64   `json:"account_id"`
+	}
+
+	if err := json.Unmarshal(data, &createWebhookRequest); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(createWebhookRequest.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https url")
+	}
+
+	if len(createWebhookRequest.EventTypes) == 0 {
+		return fmt.Errorf("event_types must not be empty")
+	}
+
+	eventTypes := []models.EventType{}
+	seen := map[models.EventType]bool{}
+	for _, et := range createWebhookRequest.EventTypes {
+		eventType := models.EventType(et)
+		if !models.IsEventType(eventType) {
+			return fmt.Errorf("unknown event type %q", et)
+		}
+
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	if createWebhookRequest.AccountID != nil && *createWebhookRequest.AccountID <= 0 {
+		return fmt.Errorf("account_id must be greater than 0")
+	}
+
+	c.URL = createWebhookRequest.URL
+	c.EventTypes = eventTypes
+	c.AccountID = createWebhookRequest.AccountID
+
+	return nil
+}
+
+type ListWebhookDeliveriesResponse struct {
+	WebhookID  int64                    `json:"webhook_id"`
+	Deliveries []models.WebhookDelivery `json:"deliveries"`
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"payments-backend-app/pkg/models"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	CreateWebhookExtension         = "/webhooks"
+	ListWebhookDeliveriesExtension = "/webhooks/:webhookId/deliveries"
+)
+
+// WithWebhookService enables the webhook endpoints
+func WithWebhookService(webhookService models.WebhookService) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.webhooks = webhookService
+	}
+}
+
+// WithWebhookHostCheck sets the check of the hosts webhooks are created for, defaults to models.CheckPublicWebhookHost
+func WithWebhookHostCheck(check models.WebhookHostCheck) Option {
+	return func(pas *paymentsAppHandler) {
+		pas.webhookHosts = check
+	}
+}
+
+// CreateWebhook subscribes a url to events, the secret that signs its deliveries is only returned here
+func (pah *paymentsAppHandler) CreateWebhook(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	ctx := context.Background()
+
+	ba, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	req := CreateWebhookRequest{}
+	if err := json.Unmarshal(ba, &req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+		fmt.Fprintf(w, "%s", string(ba))
+		return
+	}
+
+	// the url was parsed when the request was decoded
+	u, _ := url.Parse(req.URL)
+	if err := pah.webhookHosts(ctx, u.Hostname()); err != nil {
+		pah.writeWebhookErr(ctx, w, err)
+		return
+	}
+
+	webhook, err := pah.webhooks.Create(ctx, models.Webhook{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		AccountID:  req.AccountID,
+		Secret:     models.NewWebhookSecret(),
+	})
+	if err != nil {
+		pah.writeWebhookErr(ctx, w, err)
+		return
+	}
+
+	pah.writeWebhook(ctx, w, http.StatusCreated, webhook)
+}
+
+// ListWebhookDeliveries lists the deliveries of a webhook, newest first
+func (pah *paymentsAppHandler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	ctx := context.Background()
+
+	webhookIdS := params.ByName("webhookId")
+
+	webhookId, err := strconv.Atoi(webhookIdS)
+	if err != nil {
+		pah.logger.DebugContext(ctx, "unable to parse webhook id", "webhookIdS", webhookIdS, "err", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := pah.webhooks.ListDeliveries(ctx, int64(webhookId))
+	if err != nil {
+		pah.writeWebhookErr(ctx, w, err)
+		return
+	}
+
+	pah.writeWebhook(ctx, w, http.StatusOK, ListWebhookDeliveriesResponse{
+		WebhookID:  int64(webhookId),
+		Deliveries: deliveries,
+	})
+}
+
+func (pah *paymentsAppHandler) writeWebhook(ctx context.Context, w http.ResponseWriter, statusCode int, resp any) {
+
+	ba, err := json.Marshal(resp)
+	if err != nil {
+		pah.logger.ErrorContext(ctx, "unable to marshal webhook response", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(statusCode)
+	fmt.Fprintf(w, "%s", string(ba))
+}
+
+func (pah *paymentsAppHandler) writeWebhookErr(ctx context.Context, w http.ResponseWriter, err error) {
+
+	switch {
+	case errors.Is(err, models.NoRecordErr):
+		w.WriteHeader(http.StatusNotFound)
+	case errors.Is(err, models.InvalidWebhookURLErr):
+		w.WriteHeader(http.StatusBadRequest)
+	default:
+		pah.logger.ErrorContext(ctx, "unable to process webhook request", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	ba, _ := json.Marshal(map[string]string{"msg": err.Error()})
+	fmt.Fprintf(w, "%s", string(ba))
+}
@@ -0,0 +1,92 @@
+package conformance
+
+import (
+	"context"
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func testAccountEvents(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	t.Run("List and latest", func(t *testing.T) {
+		account := createAccount(t, services)
+		other := createAccount(t, services)
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "10")
+		createTransaction(t, services, other.AccountID, models.NormalPurchase, "10")
+
+		events, err := services.AccountEventService.ListForAccount(ctx, account.AccountID, 0, 100)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to list account events [%s]", err)
+		case len(events) != 2 || events[0].Type != models.AccountCreatedEvent || events[1].Type != models.TransactionCreatedEvent:
+			t.Fatalf("expected the AccountCreated and TransactionCreated events got %+v", events)
+		}
+
+		latestID, err := services.AccountEventService.LatestID(ctx, account.AccountID)
+		if err != nil || latestID != events[1].ID {
+			t.Errorf("expected latest id %d got %d err %v", events[1].ID, latestID, err)
+		}
+
+		after, err := services.AccountEventService.ListForAccount(ctx, account.AccountID, events[0].ID, 100)
+		if err != nil || len(after) != 1 || after[0].ID != events[1].ID {
+			t.Errorf("expected only the TransactionCreated event of transaction %d got %+v err %v", purchase.TransactionID, after, err)
+		}
+
+		if events, err := services.AccountEventService.ListForAccount(ctx, account.AccountID, 0, 1); err != nil || len(events) != 1 {
+			t.Errorf("expected the events limited to 1 got %d err %v", len(events), err)
+		}
+
+		if latestID, err := services.AccountEventService.LatestID(ctx, 1_000_000_000); err != nil || latestID != 0 {
+			t.Errorf("expected latest id 0 for an account without events got %d err %v", latestID, err)
+		}
+	})
+
+	t.Run("Subscriptions are signalled", func(t *testing.T) {
+		listenCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go services.AccountEventService.Listen(listenCtx)
+
+		account := createAccount(t, services)
+		other := createAccount(t, services)
+
+		notified, unsubscribe := services.AccountEventService.Subscribe(account.AccountID)
+		defer unsubscribe()
+		otherNotified, otherUnsubscribe := services.AccountEventService.Subscribe(other.AccountID)
+		defer otherUnsubscribe()
+
+		// the listener may take a moment to start, a write signals the subscription once it listens
+		signalled := false
+		for i := 0; i < 50 && !signalled; i++ {
+			createTransaction(t, services, account.AccountID, models.NormalPurchase, "1")
+			select {
+			case <-notified:
+				signalled = true
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		if !signalled {
+			t.Fatalf("expected the subscription of the account signalled")
+		}
+
+		// the listener signals every subscription once it starts listening
+		select {
+		case <-otherNotified:
+		default:
+		}
+
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "1")
+		select {
+		case <-notified:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected the subscription of the account signalled again")
+		}
+
+		select {
+		case <-otherNotified:
+			t.Errorf("expected the subscription of the other account not signalled")
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func testAccountStatus(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	updateStatus := func(t *testing.T, accountID int64, status models.AccountStatus) models.Account {
+		account, err := services.AccountsService.UpdateStatus(ctx, accountID, status, "")
+		if err != nil {
+			t.Fatalf("unable to move account to %s [%s]", status, err)
+		}
+		if account.Status != status {
+			t.Errorf("expected status %s got %s", status, account.Status)
+		}
+		return account
+	}
+
+	t.Run("Frozen accounts only take charges", func(t *testing.T) {
+		account := createAccount(t, services)
+		if account.Status != models.AccountActive {
+			t.Errorf("expected status %s got %s", models.AccountActive, account.Status)
+		}
+
+		updateStatus(t, account.AccountID, models.AccountFrozen)
+
+		_, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          -models.MustParseMoney("10"),
+		})
+		if !errors.Is(err, models.AccountNotActiveErr) {
+			t.Errorf("expected %s got %v", models.AccountNotActiveErr, err)
+		}
+
+		_, err = services.AuthorizationService.Create(ctx, models.Authorization{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10"),
+		})
+		if !errors.Is(err, models.AccountNotActiveErr) {
+			t.Errorf("expected %s got %v", models.AccountNotActiveErr, err)
+		}
+
+		if _, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.Interest),
+			Amount:          -models.MustParseMoney("0.10"),
+		}); err != nil {
+			t.Errorf("unable to charge interest on a frozen account [%s]", err)
+		}
+
+		// the debit of the source must not be booked without the credit of the destination
+		source := createAccount(t, services)
+		_, err = services.TransferService.Create(ctx, models.Transfer{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: account.AccountID,
+			Amount:               models.MustParseMoney("5"),
+		})
+		if !errors.Is(err, models.AccountNotActiveErr) {
+			t.Errorf("expected %s got %v", models.AccountNotActiveErr, err)
+		}
+
+		balance, err := services.AccountsService.GetBalance(ctx, source.AccountID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch balance [%s]", err)
+		case balance.OutstandingDebt != 0:
+			t.Errorf("expected no debt on the source got %s", balance.OutstandingDebt)
+		}
+
+		updateStatus(t, account.AccountID, models.AccountActive)
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "10")
+	})
+
+	t.Run("Closing requires settled balances", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "20")
+		if _, err := services.AccountsService.UpdateStatus(ctx, account.AccountID, models.AccountClosed, ""); !errors.Is(err, models.OutstandingBalanceErr) {
+			t.Errorf("expected %s with an open debit got %v", models.OutstandingBalanceErr, err)
+		}
+
+		createTransaction(t, services, account.AccountID, models.CreditVoucher, "20")
+
+		authorization, err := services.AuthorizationService.Create(ctx, models.Authorization{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("5"),
+		})
+		if err != nil {
+			t.Fatalf("unable to create authorization [%s]", err)
+		}
+
+		if _, err := services.AccountsService.UpdateStatus(ctx, account.AccountID, models.AccountClosed, ""); !errors.Is(err, models.OutstandingBalanceErr) {
+			t.Errorf("expected %s with a pending hold got %v", models.OutstandingBalanceErr, err)
+		}
+
+		if _, err := services.AuthorizationService.Release(ctx, authorization.ID); err != nil {
+			t.Fatalf("unable to release authorization [%s]", err)
+		}
+
+		schedule, err := services.ScheduleService.Create(ctx, models.Schedule{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          -models.MustParseMoney("5"),
+			Frequency:       models.MonthlySchedule,
+			StartAt:         time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("unable to create schedule [%s]", err)
+		}
+
+		updateStatus(t, account.AccountID, models.AccountClosed)
+
+		rschedule, err := services.ScheduleService.GetForID(ctx, schedule.ID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch schedule [%s]", err)
+		case rschedule.Status != models.ScheduleCancelled:
+			t.Errorf("expected the schedule of the closed account to be %s got %s", models.ScheduleCancelled, rschedule.Status)
+		}
+
+		_, err = services.ScheduleService.Create(ctx, models.Schedule{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          -models.MustParseMoney("5"),
+			Frequency:       models.OnceSchedule,
+			StartAt:         time.Now().Add(time.Hour),
+		})
+		if !errors.Is(err, models.AccountNotActiveErr) {
+			t.Errorf("expected %s got %v", models.AccountNotActiveErr, err)
+		}
+
+		_, err = services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.CreditVoucher),
+			Amount:          models.MustParseMoney("1"),
+		})
+		if !errors.Is(err, models.AccountNotActiveErr) {
+			t.Errorf("expected %s got %v", models.AccountNotActiveErr, err)
+		}
+		// reversing the paid purchase would leave credit on the closed account
+		_, err = services.TransactionService.Reverse(ctx, purchase.TransactionID, 0)
+		if !errors.Is(err, models.AccountNotActiveErr) {
+			t.Errorf("expected %s got %v", models.AccountNotActiveErr, err)
+		}
+	})
+
+	t.Run("Status history", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		if _, err := services.AccountsService.UpdateStatus(ctx, account.AccountID, models.AccountActive, ""); !errors.Is(err, models.InvalidAccountStatusTransitionErr) {
+			t.Errorf("expected %s got %v", models.InvalidAccountStatusTransitionErr, err)
+		}
+
+		if _, err := services.AccountsService.UpdateStatus(ctx, account.AccountID, models.AccountFrozen, "chargeback review"); err != nil {
+			t.Fatalf("unable to freeze account [%s]", err)
+		}
+		updateStatus(t, account.AccountID, models.AccountClosed)
+
+		if _, err := services.AccountsService.UpdateStatus(ctx, account.AccountID, models.AccountActive, ""); !errors.Is(err, models.InvalidAccountStatusTransitionErr) {
+			t.Errorf("expected %s got %v", models.InvalidAccountStatusTransitionErr, err)
+		}
+
+		history, err := services.AccountsService.ListStatusHistory(ctx, account.AccountID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to list status history [%s]", err)
+		case len(history) != 2:
+			t.Fatalf("expected 2 status changes got %+v", history)
+		case history[0].FromStatus != models.AccountFrozen || history[0].ToStatus != models.AccountClosed || history[0].Reason != "":
+			t.Errorf("expected the close first got %+v", history[0])
+		case history[1].FromStatus != models.AccountActive || history[1].ToStatus != models.AccountFrozen || history[1].Reason != "chargeback review":
+			t.Errorf("expected the freeze last got %+v", history[1])
+		}
+
+		if _, err := services.AccountsService.ListStatusHistory(ctx, int64(testutils.GenerateRandomNumberInt(10))); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
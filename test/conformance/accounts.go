@@ -0,0 +1,55 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testAccounts(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	account := createAccount(t, services)
+
+	t.Run("Get created account", func(t *testing.T) {
+		raccount, err := services.AccountsService.GetForID(ctx, account.AccountID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch account [%s]", err)
+		case raccount.AccountID != account.AccountID || raccount.DocumentNumber != account.DocumentNumber:
+			t.Errorf("expected %v got %v", account, raccount)
+		}
+	})
+
+	t.Run("Duplicate document number", func(t *testing.T) {
+		_, err := services.AccountsService.Create(ctx, models.Account{DocumentNumber: account.DocumentNumber})
+		if !errors.Is(err, models.DuplicateRecordErr) {
+			t.Errorf("expected %s got %v", models.DuplicateRecordErr, err)
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+		missingID := int64(testutils.GenerateRandomNumberInt(10))
+		if err := services.AccountsService.DeleteForID(ctx, missingID); err != nil {
+			t.Errorf("unable to delete missing account [%s]", err)
+		}
+
+		_, err := services.AccountsService.GetForID(ctx, missingID)
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+
+	t.Run("Transaction for a missing account", func(t *testing.T) {
+		_, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       int64(testutils.GenerateRandomNumberInt(10)),
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          -1,
+		})
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
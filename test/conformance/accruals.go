@@ -0,0 +1,233 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func testAccruals(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	// 36.5% a year is 0.1% a day
+	operationType, err := services.OperationTypeService.Create(ctx, models.OperationType{
+		Description:    "Revolving Purchase",
+		Direction:      models.DebitDirection,
+		Active:         true,
+		APRBasisPoints: 3650,
+	})
+	if err != nil {
+		t.Fatalf("unable to create operation type [%s]", err)
+	}
+
+	expectAccruals := func(t *testing.T, accountID int64, expected map[models.AccrualKind]string) []models.Accrual {
+		accruals, err := services.AccrualService.ListForAccount(ctx, accountID)
+		if err != nil {
+			t.Fatalf("unable to list accruals [%s]", err)
+		}
+		if len(accruals) != len(expected) {
+			t.Fatalf("expected %d accruals got %+v", len(expected), accruals)
+		}
+		for _, accrual := range accruals {
+			amount, ok := expected[accrual.Kind]
+			switch {
+			case !ok:
+				t.Errorf("unexpected accrual %+v", accrual)
+			case accrual.Amount != models.MustParseMoney(amount):
+				t.Errorf("expected %s of %s got %s", amount, accrual.Kind, accrual.Amount)
+			}
+		}
+		return accruals
+	}
+
+	t.Run("Interest is charged once a day", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		if _, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: operationType.ID,
+			Amount:          models.MustParseMoney("-1000"),
+		}); err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+		// purchases do not accrue until a rate is set for them
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "500")
+
+		for i := 0; i < 2; i++ {
+			if _, err := services.AccrualService.Accrue(ctx, time.Now()); err != nil {
+				t.Fatalf("unable to accrue [%s]", err)
+			}
+		}
+
+		accruals := expectAccruals(t, account.AccountID, map[models.AccrualKind]string{
+			models.InterestAccrual: "1",
+		})
+
+		transaction, err := services.TransactionService.GetForID(ctx, accruals[0].TransactionID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch transaction [%s]", err)
+		case transaction.OperationTypeID != int64(models.Interest) || transaction.Amount != models.MustParseMoney("-1"):
+			t.Errorf("expected an interest debit of 1.00 got %+v", transaction)
+		}
+
+		last, err := services.AccrualService.LastAccrualDay(ctx)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch the last accrual day [%s]", err)
+		case last.Before(accruals[0].AccrualDate):
+			t.Errorf("expected the last accrual day to be at least %s got %s", accruals[0].AccrualDate, last)
+		}
+	})
+
+	t.Run("Days before the debits accrue nothing", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		if _, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: operationType.ID,
+			Amount:          models.MustParseMoney("-1000"),
+		}); err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+
+		if _, err := services.AccrualService.Accrue(ctx, time.Now().AddDate(0, 0, -1)); err != nil {
+			t.Fatalf("unable to accrue [%s]", err)
+		}
+
+		expectAccruals(t, account.AccountID, map[models.AccrualKind]string{})
+	})
+
+	t.Run("Late fee for a missed minimum payment", func(t *testing.T) {
+		account := createAccount(t, services)
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "100")
+
+		if _, err := services.StatementService.GenerateDue(ctx, time.Now().AddDate(0, 1, 0)); err != nil {
+			t.Fatalf("unable to generate statements [%s]", err)
+		}
+
+		statements, err := services.StatementService.ListForAccount(ctx, account.AccountID)
+		if err != nil || len(statements) != 1 {
+			t.Fatalf("expected 1 statement got %d err %v", len(statements), err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err := services.AccrualService.Accrue(ctx, statements[0].DueDate); err != nil {
+				t.Fatalf("unable to accrue [%s]", err)
+			}
+		}
+
+		accruals := expectAccruals(t, account.AccountID, map[models.AccrualKind]string{
+			models.LateFeeAccrual: "10",
+		})
+		if accruals[0].StatementID == nil || *accruals[0].StatementID != statements[0].ID {
+			t.Errorf("expected the late fee of statement %d got %v", statements[0].ID, accruals[0].StatementID)
+		}
+	})
+
+	t.Run("Past days accrue on what was open then", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		debit, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: operationType.ID,
+			Amount:          models.MustParseMoney("-1000"),
+		})
+		if err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+		if err := services.Backdate(debit.TransactionID, time.Now().AddDate(0, 0, -3)); err != nil {
+			t.Fatalf("unable to backdate transaction [%s]", err)
+		}
+
+		// the debit is paid off today, two days ago it was still open
+		createTransaction(t, services, account.AccountID, models.CreditVoucher, "1000")
+
+		for _, day := range []time.Time{time.Now().AddDate(0, 0, -2), time.Now()} {
+			if _, err := services.AccrualService.Accrue(ctx, day); err != nil {
+				t.Fatalf("unable to accrue [%s]", err)
+			}
+		}
+
+		accruals := expectAccruals(t, account.AccountID, map[models.AccrualKind]string{
+			models.InterestAccrual: "1",
+		})
+		if day := models.AccrualDay(time.Now().AddDate(0, 0, -2)); !accruals[0].AccrualDate.Equal(day) {
+			t.Errorf("expected the interest of %s got %s", day, accruals[0].AccrualDate)
+		}
+	})
+
+	t.Run("Reversals pay the statement", func(t *testing.T) {
+		account := createAccount(t, services)
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "100")
+
+		if _, err := services.StatementService.GenerateDue(ctx, time.Now().AddDate(0, 1, 0)); err != nil {
+			t.Fatalf("unable to generate statements [%s]", err)
+		}
+
+		statements, err := services.StatementService.ListForAccount(ctx, account.AccountID)
+		if err != nil || len(statements) != 1 {
+			t.Fatalf("expected 1 statement got %d err %v", len(statements), err)
+		}
+
+		// the reversal is booked after the cycle closed, it settles the balance of the statement like a payment
+		reversal, err := services.TransactionService.Reverse(ctx, purchase.TransactionID, 0)
+		if err != nil {
+			t.Fatalf("unable to reverse transaction [%s]", err)
+		}
+		if err := services.Backdate(reversal.TransactionID, statements[0].PeriodEnd); err != nil {
+			t.Fatalf("unable to backdate transaction [%s]", err)
+		}
+
+		if _, err := services.AccrualService.Accrue(ctx, statements[0].DueDate); err != nil {
+			t.Fatalf("unable to accrue [%s]", err)
+		}
+
+		expectAccruals(t, account.AccountID, map[models.AccrualKind]string{})
+	})
+
+	t.Run("Charges exhaust the credit limit", func(t *testing.T) {
+
+		limit := models.MustParseMoney("1000")
+		account, err := services.AccountsService.Create(ctx, models.Account{
+			DocumentNumber:       testutils.GenerateRandomNumber(10),
+			AvailableCreditLimit: &limit,
+		})
+		if err != nil {
+			t.Fatalf("unable to create account [%s]", err)
+		}
+
+		if _, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: operationType.ID,
+			Amount:          -limit,
+		}); err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+
+		if _, err := services.AccrualService.Accrue(ctx, time.Now()); err != nil {
+			t.Fatalf("unable to accrue [%s]", err)
+		}
+
+		expectAccruals(t, account.AccountID, map[models.AccrualKind]string{
+			models.InterestAccrual: "1",
+		})
+
+		raccount, err := services.AccountsService.GetForID(ctx, account.AccountID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch account [%s]", err)
+		case raccount.AvailableCreditLimit == nil || *raccount.AvailableCreditLimit != 0:
+			t.Errorf("expected the credit limit to be exhausted got %v", raccount.AvailableCreditLimit)
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+		if _, err := services.AccrualService.ListForAccount(ctx, int64(testutils.GenerateRandomNumberInt(10))); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %v got %v", models.NoRecordErr, err)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func testAllocations(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	type allocation struct {
+		creditTransactionID int64
+		debitTransactionID  int64
+		amount              string
+	}
+
+	expectAllocations := func(t *testing.T, transactionID int64, expected []allocation) {
+		rallocations, err := services.AllocationService.ListForTransaction(ctx, transactionID)
+		if err != nil {
+			t.Fatalf("unable to list allocations [%s]", err)
+		}
+		if len(rallocations) != len(expected) {
+			t.Fatalf("expected %d allocations for transaction %d got %+v", len(expected), transactionID, rallocations)
+		}
+		for i, e := range expected {
+			switch {
+			case rallocations[i].CreditTransactionID != e.creditTransactionID || rallocations[i].DebitTransactionID != e.debitTransactionID:
+				t.Errorf("expected allocation from %d to %d got %+v", e.creditTransactionID, e.debitTransactionID, rallocations[i])
+			case rallocations[i].Amount != models.MustParseMoney(e.amount):
+				t.Errorf("expected amount %s got %s", e.amount, rallocations[i].Amount)
+			}
+		}
+	}
+
+	t.Run("Credit pays debits oldest first", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "50")
+		withdrawal := createTransaction(t, services, account.AccountID, models.Withdrawal, "30")
+		voucher := createTransaction(t, services, account.AccountID, models.CreditVoucher, "60")
+
+		expectAllocations(t, voucher.TransactionID, []allocation{
+			{voucher.TransactionID, purchase.TransactionID, "50"},
+			{voucher.TransactionID, withdrawal.TransactionID, "10"},
+		})
+		expectAllocations(t, withdrawal.TransactionID, []allocation{
+			{voucher.TransactionID, withdrawal.TransactionID, "10"},
+		})
+	})
+
+	t.Run("Debit consumes open credits", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		voucher := createTransaction(t, services, account.AccountID, models.CreditVoucher, "20")
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "35")
+
+		expectAllocations(t, purchase.TransactionID, []allocation{
+			{voucher.TransactionID, purchase.TransactionID, "20"},
+		})
+	})
+
+	t.Run("Credits keep their own balances", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		first := createTransaction(t, services, account.AccountID, models.CreditVoucher, "100")
+		second := createTransaction(t, services, account.AccountID, models.CreditVoucher, "50")
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "30")
+
+		expectAllocations(t, second.TransactionID, []allocation{})
+		expectAllocations(t, purchase.TransactionID, []allocation{
+			{first.TransactionID, purchase.TransactionID, "30"},
+		})
+
+		for id, balance := range map[int64]string{first.TransactionID: "70", second.TransactionID: "50"} {
+			transaction, err := services.TransactionService.GetForID(ctx, id)
+			switch {
+			case err != nil:
+				t.Errorf("unable to fetch transaction [%s]", err)
+			case transaction.Balance != models.MustParseMoney(balance):
+				t.Errorf("transaction %d expected balance %s got %s", id, balance, transaction.Balance)
+			}
+		}
+	})
+
+	t.Run("Reversal and posted installments", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		purchase, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.PurchaseWithInstallments),
+			Amount:          -models.MustParseMoney("90"),
+			Installments:    3,
+		})
+		if err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+
+		voucher := createTransaction(t, services, account.AccountID, models.CreditVoucher, "50")
+
+		// the second installment consumes what is left of the voucher once posted
+		if _, err := services.InstallmentService.PostDue(ctx, time.Now().AddDate(0, 1, 1)); err != nil {
+			t.Fatalf("unable to post installments [%s]", err)
+		}
+
+		expectAllocations(t, voucher.TransactionID, []allocation{
+			{voucher.TransactionID, purchase.TransactionID, "30"},
+			{voucher.TransactionID, purchase.TransactionID, "20"},
+		})
+
+		// the last installment is cancelled and what is still open of the purchase is paid by the reversal
+		reversal, err := services.TransactionService.Reverse(ctx, purchase.TransactionID, models.MustParseMoney("40"))
+		if err != nil {
+			t.Fatalf("unable to reverse transaction [%s]", err)
+		}
+
+		expectAllocations(t, reversal.TransactionID, []allocation{
+			{reversal.TransactionID, purchase.TransactionID, "10"},
+		})
+	})
+
+	t.Run("Missing transaction", func(t *testing.T) {
+		_, err := services.AllocationService.ListForTransaction(ctx, int64(testutils.GenerateRandomNumberInt(10)))
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func testAuthorizations(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	limit := models.MustParseMoney("100")
+	account, err := services.AccountsService.Create(ctx, models.Account{
+		DocumentNumber:       testutils.GenerateRandomNumber(10),
+		AvailableCreditLimit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	hold := func(t *testing.T, amount string) models.Authorization {
+		authorization, err := services.AuthorizationService.Create(ctx, models.Authorization{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney(amount),
+		})
+		if err != nil {
+			t.Fatalf("unable to create authorization [%s]", err)
+		}
+		if authorization.Status != models.AuthorizationPending {
+			t.Errorf("expected status %s got %s", models.AuthorizationPending, authorization.Status)
+		}
+		return authorization
+	}
+
+	expectLimitAndHeld := func(t *testing.T, expectedLimit string, expectedHeld string) {
+		raccount, err := services.AccountsService.GetForID(ctx, account.AccountID)
+		if err != nil {
+			t.Fatalf("unable to fetch account [%s]", err)
+		}
+		if raccount.AvailableCreditLimit == nil || *raccount.AvailableCreditLimit != models.MustParseMoney(expectedLimit) {
+			t.Errorf("expected limit %s got %v", expectedLimit, raccount.AvailableCreditLimit)
+		}
+
+		balance, err := services.AccountsService.GetBalance(ctx, account.AccountID)
+		if err != nil {
+			t.Fatalf("unable to fetch balance [%s]", err)
+		}
+		if balance.Held != models.MustParseMoney(expectedHeld) {
+			t.Errorf("expected held %s got %s", expectedHeld, balance.Held)
+		}
+	}
+
+	t.Run("Capture part of a hold", func(t *testing.T) {
+		authorization := hold(t, "60")
+		expectLimitAndHeld(t, "40", "60")
+
+		_, err := services.AuthorizationService.Capture(ctx, authorization.ID, models.MustParseMoney("60.01"))
+		if !errors.Is(err, models.CaptureAmountExceededErr) {
+			t.Errorf("expected %s got %v", models.CaptureAmountExceededErr, err)
+		}
+
+		captured, err := services.AuthorizationService.Capture(ctx, authorization.ID, models.MustParseMoney("50"))
+		switch {
+		case err != nil:
+			t.Fatalf("unable to capture authorization [%s]", err)
+		case captured.Status != models.AuthorizationCaptured:
+			t.Errorf("expected status %s got %s", models.AuthorizationCaptured, captured.Status)
+		case captured.CapturedAmount != models.MustParseMoney("50"):
+			t.Errorf("expected captured amount 50.00 got %s", captured.CapturedAmount)
+		case captured.TransactionID == nil:
+			t.Fatalf("expected a captured transaction")
+		}
+
+		transaction, err := services.TransactionService.GetForID(ctx, *captured.TransactionID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch transaction [%s]", err)
+		case transaction.Amount != -models.MustParseMoney("50"):
+			t.Errorf("expected amount -50.00 got %s", transaction.Amount)
+		}
+
+		expectLimitAndHeld(t, "50", "0")
+
+		_, err = services.AuthorizationService.Capture(ctx, authorization.ID, 0)
+		if !errors.Is(err, models.AuthorizationNotPendingErr) {
+			t.Errorf("expected %s got %v", models.AuthorizationNotPendingErr, err)
+		}
+	})
+
+	t.Run("Release a hold", func(t *testing.T) {
+		authorization := hold(t, "30")
+		expectLimitAndHeld(t, "20", "30")
+
+		released, err := services.AuthorizationService.Release(ctx, authorization.ID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to release authorization [%s]", err)
+		case released.Status != models.AuthorizationReleased:
+			t.Errorf("expected status %s got %s", models.AuthorizationReleased, released.Status)
+		}
+
+		expectLimitAndHeld(t, "50", "0")
+
+		_, err = services.AuthorizationService.Release(ctx, authorization.ID)
+		if !errors.Is(err, models.AuthorizationNotPendingErr) {
+			t.Errorf("expected %s got %v", models.AuthorizationNotPendingErr, err)
+		}
+	})
+
+	t.Run("Hold over the limit", func(t *testing.T) {
+		_, err := services.AuthorizationService.Create(ctx, models.Authorization{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("50.01"),
+		})
+		if !errors.Is(err, models.InsufficientLimitErr) {
+			t.Errorf("expected %s got %v", models.InsufficientLimitErr, err)
+		}
+	})
+
+	t.Run("Expire stale holds", func(t *testing.T) {
+		authorization := hold(t, "10")
+		expectLimitAndHeld(t, "40", "10")
+
+		expired, err := services.AuthorizationService.ExpireStale(ctx, time.Now().Add(2*time.Hour))
+		switch {
+		case err != nil:
+			t.Fatalf("unable to expire authorizations [%s]", err)
+		case expired < 1:
+			t.Errorf("expected at least one expired authorization got %d", expired)
+		}
+
+		rauthorization, err := services.AuthorizationService.GetForID(ctx, authorization.ID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch authorization [%s]", err)
+		case rauthorization.Status != models.AuthorizationExpired:
+			t.Errorf("expected status %s got %s", models.AuthorizationExpired, rauthorization.Status)
+		}
+
+		expectLimitAndHeld(t, "50", "0")
+	})
+
+	t.Run("Missing authorization", func(t *testing.T) {
+		_, err := services.AuthorizationService.Capture(ctx, int64(testutils.GenerateRandomNumberInt(10)), 0)
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
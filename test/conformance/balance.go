@@ -0,0 +1,41 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testBalance(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	account := createAccount(t, services)
+	createTransaction(t, services, account.AccountID, models.NormalPurchase, "50")
+	createTransaction(t, services, account.AccountID, models.Withdrawal, "20")
+	createTransaction(t, services, account.AccountID, models.CreditVoucher, "60")
+
+	balance, err := services.AccountsService.GetBalance(ctx, account.AccountID)
+	switch {
+	case err != nil:
+		t.Fatalf("unable to fetch balance [%s]", err)
+	case balance.AvailableCredit != 0:
+		t.Errorf("expected no available credit got %s", balance.AvailableCredit)
+	case balance.OutstandingDebt != models.MustParseMoney("10"):
+		t.Errorf("expected outstanding debt 10.00 got %s", balance.OutstandingDebt)
+	case len(balance.ByOperationType) != 3:
+		t.Fatalf("expected 3 operation types got %d", len(balance.ByOperationType))
+	case balance.ByOperationType[1].OperationTypeID != int64(models.Withdrawal):
+		t.Errorf("expected operation types ordered by id got %v", balance.ByOperationType)
+	case balance.ByOperationType[1].Description != "Withdrawal":
+		t.Errorf("unexpected description %s", balance.ByOperationType[1].Description)
+	case balance.ByOperationType[1].OutstandingDebt != models.MustParseMoney("10"):
+		t.Errorf("expected withdrawal debt 10.00 got %s", balance.ByOperationType[1].OutstandingDebt)
+	}
+
+	_, err = services.AccountsService.GetBalance(ctx, int64(testutils.GenerateRandomNumberInt(10)))
+	if !errors.Is(err, models.NoRecordErr) {
+		t.Errorf("expected %s got %v", models.NoRecordErr, err)
+	}
+}
@@ -0,0 +1,108 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testCreditLimit(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	limit := models.MustParseMoney("100")
+	account, err := services.AccountsService.Create(ctx, models.Account{
+		DocumentNumber:       testutils.GenerateRandomNumber(10),
+		AvailableCreditLimit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	expectLimit := func(t *testing.T, expected string) {
+		raccount, err := services.AccountsService.GetForID(ctx, account.AccountID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch account [%s]", err)
+		case raccount.AvailableCreditLimit == nil:
+			t.Errorf("expected limit %s got none", expected)
+		case *raccount.AvailableCreditLimit != models.MustParseMoney(expected):
+			t.Errorf("expected limit %s got %s", expected, *raccount.AvailableCreditLimit)
+		}
+	}
+
+	purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "60")
+	expectLimit(t, "40")
+
+	t.Run("Debit over the limit", func(t *testing.T) {
+		_, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.Withdrawal),
+			Amount:          -models.MustParseMoney("40.01"),
+		})
+		if !errors.Is(err, models.InsufficientLimitErr) {
+			t.Errorf("expected %s got %v", models.InsufficientLimitErr, err)
+		}
+
+		page, err := services.TransactionService.ListForAccount(ctx, models.TransactionFilter{AccountID: account.AccountID})
+		switch {
+		case err != nil:
+			t.Errorf("unable to list transactions [%s]", err)
+		case len(page.Transactions) != 1:
+			t.Errorf("expected the rejected debit not to be recorded got %d transactions", len(page.Transactions))
+		}
+
+		expectLimit(t, "40")
+	})
+
+	t.Run("Credits restore the limit", func(t *testing.T) {
+		createTransaction(t, services, account.AccountID, models.CreditVoucher, "30")
+		expectLimit(t, "70")
+
+		createTransaction(t, services, account.AccountID, models.Withdrawal, "70")
+		expectLimit(t, "0")
+
+		if _, err := services.TransactionService.Reverse(ctx, purchase.TransactionID, 0); err != nil {
+			t.Fatalf("unable to reverse transaction [%s]", err)
+		}
+		expectLimit(t, "60")
+	})
+
+	t.Run("Update the limit", func(t *testing.T) {
+		updated := models.MustParseMoney("500")
+		raccount, err := services.AccountsService.UpdateCreditLimit(ctx, account.AccountID, &updated)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to update limit [%s]", err)
+		case raccount.CreditLimit == nil || *raccount.CreditLimit != updated:
+			t.Errorf("expected limit %s got %v", updated, raccount.CreditLimit)
+		}
+
+		// the 40 still spent out of the previous limit is spent out of the new one
+		expectLimit(t, "460")
+
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "459.99")
+		expectLimit(t, "0.01")
+
+		// lowering the limit below what is spent leaves nothing available
+		lowered := models.MustParseMoney("400")
+		if _, err := services.AccountsService.UpdateCreditLimit(ctx, account.AccountID, &lowered); err != nil {
+			t.Fatalf("unable to update limit [%s]", err)
+		}
+		expectLimit(t, "0")
+
+		if _, err := services.AccountsService.UpdateCreditLimit(ctx, account.AccountID, nil); err != nil {
+			t.Fatalf("unable to remove limit [%s]", err)
+		}
+
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "10000")
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+		_, err := services.AccountsService.UpdateCreditLimit(ctx, int64(testutils.GenerateRandomNumberInt(10)), &limit)
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testCurrencies(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	createAccountIn := func(t *testing.T, currency models.Currency) models.Account {
+		account, err := services.AccountsService.Create(ctx, models.Account{
+			DocumentNumber: testutils.GenerateRandomNumber(10),
+			Currency:       currency,
+		})
+		if err != nil {
+			t.Fatalf("unable to create account [%s]", err)
+		}
+		if account.Currency != currency {
+			t.Errorf("expected currency %s got %s", currency, account.Currency)
+		}
+		return account
+	}
+
+	t.Run("Default currency", func(t *testing.T) {
+		account := createAccount(t, services)
+		if account.Currency != models.DefaultCurrency {
+			t.Errorf("expected currency %s got %s", models.DefaultCurrency, account.Currency)
+		}
+	})
+
+	t.Run("Transactions are booked in the currency of the account", func(t *testing.T) {
+		account := createAccountIn(t, "USD")
+
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "10")
+
+		transaction, err := services.TransactionService.GetForID(ctx, purchase.TransactionID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch transaction [%s]", err)
+		case transaction.Currency != "USD":
+			t.Errorf("expected currency USD got %s", transaction.Currency)
+		}
+
+		// the postings carry the currency so that the shared ledger accounts are summed per currency
+		entries, err := services.LedgerService.ListForTransaction(ctx, purchase.TransactionID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to list journal entries [%s]", err)
+		case len(entries) != 1 || entries[0].Postings[0].Currency != "USD" || entries[0].Postings[1].Currency != "USD":
+			t.Errorf("expected the postings in USD got %+v", entries)
+		}
+
+		_, err = services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.CreditVoucher),
+			Amount:          models.MustParseMoney("10"),
+			Currency:        "BRL",
+		})
+		if !errors.Is(err, models.CurrencyMismatchErr) {
+			t.Errorf("expected %s got %v", models.CurrencyMismatchErr, err)
+		}
+
+		// the rejected credit did not settle anything
+		balance, err := services.AccountsService.GetBalance(ctx, account.AccountID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch balance [%s]", err)
+		case balance.OutstandingDebt != models.MustParseMoney("10"):
+			t.Errorf("expected outstanding debt 10.00 got %s", balance.OutstandingDebt)
+		}
+	})
+
+	t.Run("Minor unit precision", func(t *testing.T) {
+		account := createAccountIn(t, "JPY")
+
+		_, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          -models.MustParseMoney("10.50"),
+		})
+		if !errors.Is(err, models.AmountPrecisionErr) {
+			t.Errorf("expected %s got %v", models.AmountPrecisionErr, err)
+		}
+
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "100")
+
+		_, err = services.TransactionService.Reverse(ctx, purchase.TransactionID, models.MustParseMoney("0.50"))
+		if !errors.Is(err, models.AmountPrecisionErr) {
+			t.Errorf("expected %s got %v", models.AmountPrecisionErr, err)
+		}
+
+		_, err = services.AuthorizationService.Create(ctx, models.Authorization{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("0.01"),
+		})
+		if !errors.Is(err, models.AmountPrecisionErr) {
+			t.Errorf("expected %s got %v", models.AmountPrecisionErr, err)
+		}
+	})
+
+	t.Run("Transfers between currencies", func(t *testing.T) {
+		source := createAccountIn(t, "BRL")
+		destination := createAccountIn(t, "USD")
+
+		_, err := services.TransferService.Create(ctx, models.Transfer{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: destination.AccountID,
+			Amount:               models.MustParseMoney("5"),
+		})
+		if !errors.Is(err, models.CurrencyMismatchErr) {
+			t.Errorf("expected %s got %v", models.CurrencyMismatchErr, err)
+		}
+
+		balance, err := services.AccountsService.GetBalance(ctx, source.AccountID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch balance [%s]", err)
+		case balance.OutstandingDebt != 0:
+			t.Errorf("expected no debit on the source got %s", balance.OutstandingDebt)
+		}
+	})
+}
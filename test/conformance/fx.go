@@ -0,0 +1,197 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func testFX(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	t.Run("Rates", func(t *testing.T) {
+		set, err := services.FXService.SetRate(ctx, models.FXRate{From: "CHF", To: "GBP", Rate: models.MustParseExchangeRate("0.9")})
+		if err != nil {
+			t.Fatalf("unable to set rate [%s]", err)
+		}
+
+		// setting the rate of a pair again replaces it
+		updated, err := services.FXService.SetRate(ctx, models.FXRate{From: "CHF", To: "GBP", Rate: models.MustParseExchangeRate("0.87654321")})
+		switch {
+		case err != nil:
+			t.Fatalf("unable to update rate [%s]", err)
+		case updated.UpdatedAt.Before(set.UpdatedAt):
+			t.Errorf("expected the update time to move forward got %s before %s", updated.UpdatedAt, set.UpdatedAt)
+		}
+
+		rate, err := services.FXService.GetRate(ctx, "CHF", "GBP")
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch rate [%s]", err)
+		case rate.Rate != models.MustParseExchangeRate("0.87654321"):
+			t.Errorf("expected rate 0.87654321 got %s", rate.Rate)
+		}
+
+		// rates are not inverted, the opposite pair has its own
+		if _, err := services.FXService.GetRate(ctx, "GBP", "CHF"); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected error %v got %v", models.NoRecordErr, err)
+		}
+
+		rates, err := services.FXService.ListRates(ctx)
+		if err != nil {
+			t.Fatalf("unable to list rates [%s]", err)
+		}
+
+		found := 0
+		for i, rate := range rates {
+			if rate.From == "CHF" && rate.To == "GBP" {
+				found++
+			}
+			if i > 0 && (rates[i-1].From > rate.From || (rates[i-1].From == rate.From && rates[i-1].To >= rate.To)) {
+				t.Errorf("expected rates ordered by pair got %s%s before %s%s", rates[i-1].From, rates[i-1].To, rate.From, rate.To)
+			}
+		}
+		if found != 1 {
+			t.Errorf("expected the CHF to GBP rate listed once got %d times", found)
+		}
+	})
+
+	t.Run("Quotes", func(t *testing.T) {
+		if _, err := services.FXService.SetRate(ctx, models.FXRate{From: "EUR", To: "CAD", Rate: models.MustParseExchangeRate("1.5")}); err != nil {
+			t.Fatalf("unable to set rate [%s]", err)
+		}
+
+		quote, err := services.FXService.CreateQuote(ctx, "EUR", "CAD")
+		switch {
+		case err != nil:
+			t.Fatalf("unable to create quote [%s]", err)
+		case quote.From != "EUR" || quote.To != "CAD" || quote.Rate != models.MustParseExchangeRate("1.5"):
+			t.Errorf("expected EUR to CAD at 1.5 got %+v", quote)
+		case quote.ExpiresAt.Sub(quote.CreatedAt) != time.Minute:
+			t.Errorf("expected the quote to expire after a minute got %s", quote.ExpiresAt.Sub(quote.CreatedAt))
+		case quote.IsExpired(time.Now()):
+			t.Errorf("expected a fresh quote got one expired at %s", quote.ExpiresAt)
+		}
+
+		// the quote keeps its rate when the rate of the pair changes
+		if _, err := services.FXService.SetRate(ctx, models.FXRate{From: "EUR", To: "CAD", Rate: models.MustParseExchangeRate("1.6")}); err != nil {
+			t.Fatalf("unable to update rate [%s]", err)
+		}
+
+		fetched, err := services.FXService.GetQuote(ctx, quote.ID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch quote [%s]", err)
+		case fetched.ID != quote.ID || fetched.Rate != quote.Rate:
+			t.Errorf("expected quote %d at %s got %+v", quote.ID, quote.Rate, fetched)
+		}
+
+		if _, err := services.FXService.GetQuote(ctx, quote.ID+1000); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected error %v got %v", models.NoRecordErr, err)
+		}
+
+		if _, err := services.FXService.CreateQuote(ctx, "PYG", "KRW"); !errors.Is(err, models.ConversionUnavailableErr) {
+			t.Errorf("expected error %v got %v", models.ConversionUnavailableErr, err)
+		}
+	})
+
+	t.Run("Converted transactions keep their original amount", func(t *testing.T) {
+		if _, err := services.FXService.SetRate(ctx, models.FXRate{From: "USD", To: "BRL", Rate: models.MustParseExchangeRate("5.25")}); err != nil {
+			t.Fatalf("unable to set rate [%s]", err)
+		}
+
+		quote, err := services.FXService.CreateQuote(ctx, "USD", "BRL")
+		if err != nil {
+			t.Fatalf("unable to create quote [%s]", err)
+		}
+
+		account := createAccount(t, services)
+
+		originalAmount := models.MustParseMoney("-10")
+		transactionStatus, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:        account.AccountID,
+			OperationTypeID:  int64(models.NormalPurchase),
+			Amount:           quote.Rate.Convert(originalAmount, "BRL"),
+			Currency:         "BRL",
+			OriginalAmount:   &originalAmount,
+			OriginalCurrency: "USD",
+			FXRate:           &quote.Rate,
+			FXQuoteID:        &quote.ID,
+		})
+		if err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+
+		transaction, err := services.TransactionService.GetForID(ctx, transactionStatus.TransactionID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch transaction [%s]", err)
+		case transaction.Amount != models.MustParseMoney("-52.50") || transaction.Currency != "BRL":
+			t.Errorf("expected -52.50 BRL got %s %s", transaction.Amount, transaction.Currency)
+		case transaction.OriginalAmount == nil || *transaction.OriginalAmount != originalAmount || transaction.OriginalCurrency != "USD":
+			t.Errorf("expected the original -10.00 USD got %v %s", transaction.OriginalAmount, transaction.OriginalCurrency)
+		case transaction.FXRate == nil || *transaction.FXRate != quote.Rate:
+			t.Errorf("expected rate %s got %v", quote.Rate, transaction.FXRate)
+		case transaction.FXQuoteID == nil || *transaction.FXQuoteID != quote.ID:
+			t.Errorf("expected quote %d got %v", quote.ID, transaction.FXQuoteID)
+		}
+
+		fetched, err := services.FXService.GetQuote(ctx, quote.ID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch quote [%s]", err)
+		case fetched.UsedAt == nil:
+			t.Errorf("expected the quote used by the transaction")
+		}
+
+		// a quote books a single transaction
+		if _, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:        account.AccountID,
+			OperationTypeID:  int64(models.NormalPurchase),
+			Amount:           quote.Rate.Convert(originalAmount, "BRL"),
+			Currency:         "BRL",
+			OriginalAmount:   &originalAmount,
+			OriginalCurrency: "USD",
+			FXRate:           &quote.Rate,
+			FXQuoteID:        &quote.ID,
+		}); !errors.Is(err, models.FXQuoteUsedErr) {
+			t.Errorf("expected error %v got %v", models.FXQuoteUsedErr, err)
+		}
+
+		// a transaction that is not booked leaves its quote unused
+		unused, err := services.FXService.CreateQuote(ctx, "USD", "BRL")
+		if err != nil {
+			t.Fatalf("unable to create quote [%s]", err)
+		}
+
+		otherRate := models.MustParseExchangeRate("6")
+		if _, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:        account.AccountID,
+			OperationTypeID:  int64(models.NormalPurchase),
+			Amount:           otherRate.Convert(originalAmount, "BRL"),
+			Currency:         "BRL",
+			OriginalAmount:   &originalAmount,
+			OriginalCurrency: "USD",
+			FXRate:           &otherRate,
+			FXQuoteID:        &unused.ID,
+		}); !errors.Is(err, models.FXQuoteMismatchErr) {
+			t.Errorf("expected error %v got %v", models.FXQuoteMismatchErr, err)
+		}
+
+		if fetched, err := services.FXService.GetQuote(ctx, unused.ID); err != nil || fetched.UsedAt != nil {
+			t.Errorf("expected the quote unused got %v err %v", fetched.UsedAt, err)
+		}
+
+		// transactions booked in the currency of their account have no original amount
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "5")
+		transaction, err = services.TransactionService.GetForID(ctx, purchase.TransactionID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch transaction [%s]", err)
+		case transaction.OriginalAmount != nil || transaction.OriginalCurrency != "" || transaction.FXRate != nil || transaction.FXQuoteID != nil:
+			t.Errorf("expected no conversion got %+v", transaction)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"strconv"
+	"testing"
+)
+
+func testIdempotency(t *testing.T, services Services) {
+
+	account := createAccount(t, services)
+
+	key := models.IdempotencyKey{
+		Scope:       "conformance",
+		Key:         testutils.GenerateRandomNumber(12),
+		RequestHash: "hash",
+	}
+	ctx := models.ContextWithIdempotencyKey(context.Background(), key)
+
+	transaction := models.Transaction{
+		AccountID:       account.AccountID,
+		OperationTypeID: int64(models.NormalPurchase),
+		Amount:          -100,
+	}
+
+	if _, err := services.TransactionService.Create(ctx, transaction); err != nil {
+		t.Fatalf("unable to create transaction [%s]", err)
+	}
+
+	_, err := services.TransactionService.Create(ctx, transaction)
+	if !errors.Is(err, models.IdempotencyKeyInProgressErr) {
+		t.Errorf("expected %s got %v", models.IdempotencyKeyInProgressErr, err)
+	}
+
+	key.RequestHash = "other"
+	_, err = services.TransactionService.Create(models.ContextWithIdempotencyKey(context.Background(), key), transaction)
+	if !errors.Is(err, models.IdempotencyKeyMismatchErr) {
+		t.Errorf("expected %s got %v", models.IdempotencyKeyMismatchErr, err)
+	}
+
+	t.Run("Response is stored with the claim", func(t *testing.T) {
+
+		key := models.IdempotencyKey{
+			Scope:       "conformance",
+			Key:         testutils.GenerateRandomNumber(12),
+			RequestHash: "hash",
+			Response: func(result any) (int, []byte, error) {
+				return 201, []byte(strconv.FormatInt(result.(models.TransactionStatus).TransactionID, 10)), nil
+			},
+		}
+		ctx := models.ContextWithIdempotencyKey(context.Background(), key)
+
+		transactionStatus, err := services.TransactionService.Create(ctx, transaction)
+		if err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+
+		_, err = services.TransactionService.Create(ctx, transaction)
+		if !errors.Is(err, models.IdempotencyKeyReplayErr) {
+			t.Errorf("expected %s got %v", models.IdempotencyKeyReplayErr, err)
+		}
+
+		record, err := services.IdempotencyService.GetForKey(ctx, key.Scope, key.Key)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch idempotency record [%s]", err)
+		case record.StatusCode != 201 || string(record.ResponseBody) != strconv.FormatInt(transactionStatus.TransactionID, 10):
+			t.Errorf("expected the response of transaction %d got %+v", transactionStatus.TransactionID, record)
+		}
+	})
+
+	t.Run("Failed create releases the key", func(t *testing.T) {
+
+		key := models.IdempotencyKey{Scope: "conformance", Key: testutils.GenerateRandomNumber(12), RequestHash: "hash"}
+		ctx := models.ContextWithIdempotencyKey(context.Background(), key)
+
+		_, err := services.AccountsService.Create(ctx, models.Account{DocumentNumber: account.DocumentNumber})
+		if !errors.Is(err, models.DuplicateRecordErr) {
+			t.Errorf("expected %s got %v", models.DuplicateRecordErr, err)
+		}
+
+		if _, err := services.AccountsService.Create(ctx, models.Account{DocumentNumber: testutils.GenerateRandomNumber(10)}); err != nil {
+			t.Errorf("unable to create account with released key [%s]", err)
+		}
+	})
+
+	t.Run("Response of a failed create claims the key", func(t *testing.T) {
+
+		key := models.IdempotencyKey{Scope: "conformance", Key: testutils.GenerateRandomNumber(12), RequestHash: "hash"}
+		ctx := models.ContextWithIdempotencyKey(context.Background(), key)
+
+		if _, err := services.AccountsService.Create(ctx, models.Account{DocumentNumber: account.DocumentNumber}); !errors.Is(err, models.DuplicateRecordErr) {
+			t.Errorf("expected %s got %v", models.DuplicateRecordErr, err)
+		}
+
+		if err := services.IdempotencyService.Complete(ctx, models.IdempotencyRecord{
+			Scope:        key.Scope,
+			Key:          key.Key,
+			RequestHash:  key.RequestHash,
+			StatusCode:   409,
+			ResponseBody: []byte("{}"),
+		}); err != nil {
+			t.Fatalf("unable to complete key [%s]", err)
+		}
+
+		_, err := services.AccountsService.Create(ctx, models.Account{DocumentNumber: testutils.GenerateRandomNumber(10)})
+		if !errors.Is(err, models.IdempotencyKeyReplayErr) {
+			t.Errorf("expected %s got %v", models.IdempotencyKeyReplayErr, err)
+		}
+	})
+	t.Run("Failed response rolls back the transfer", func(t *testing.T) {
+
+		limit := models.MustParseMoney("100")
+		source, err := services.AccountsService.Create(context.Background(), models.Account{
+			DocumentNumber:       testutils.GenerateRandomNumber(10),
+			AvailableCreditLimit: &limit,
+		})
+		if err != nil {
+			t.Fatalf("unable to create account [%s]", err)
+		}
+		destination := createAccount(t, services)
+
+		key := models.IdempotencyKey{
+			Scope:       "conformance",
+			Key:         testutils.GenerateRandomNumber(12),
+			RequestHash: "hash",
+			Response: func(any) (int, []byte, error) {
+				return 0, nil, errors.New("unable to render response")
+			},
+		}
+		ctx := models.ContextWithIdempotencyKey(context.Background(), key)
+
+		if _, err := services.TransferService.Create(ctx, models.Transfer{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: destination.AccountID,
+			Amount:               models.MustParseMoney("40"),
+		}); err == nil {
+			t.Fatalf("expected the transfer to fail")
+		}
+
+		raccount, err := services.AccountsService.GetForID(context.Background(), source.AccountID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch account [%s]", err)
+		case raccount.AvailableCreditLimit == nil || *raccount.AvailableCreditLimit != limit:
+			t.Errorf("expected the credit limit of %s got %v", limit, raccount.AvailableCreditLimit)
+		}
+
+		for _, accountID := range []int64{source.AccountID, destination.AccountID} {
+			page, err := services.TransactionService.ListForAccount(context.Background(), models.TransactionFilter{AccountID: accountID})
+			switch {
+			case err != nil:
+				t.Fatalf("unable to list transactions [%s]", err)
+			case len(page.Transactions) != 0:
+				t.Errorf("expected no transactions of account %d got %+v", accountID, page.Transactions)
+			}
+		}
+
+		if _, err := services.IdempotencyService.GetForKey(context.Background(), key.Scope, key.Key); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected the key to be released got %v", err)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func testInstallments(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	createPurchase := func(t *testing.T, accountID int64, amount string, installments int) models.Transaction {
+		transactionStatus, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       accountID,
+			OperationTypeID: int64(models.PurchaseWithInstallments),
+			Amount:          -models.MustParseMoney(amount),
+			Installments:    installments,
+		})
+		if err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+		transaction, err := services.TransactionService.GetForID(ctx, transactionStatus.TransactionID)
+		if err != nil {
+			t.Fatalf("unable to fetch transaction [%s]", err)
+		}
+		return transaction
+	}
+
+	expectBalance := func(t *testing.T, transactionID int64, expected string) {
+		transaction, err := services.TransactionService.GetForID(ctx, transactionID)
+		if err != nil {
+			t.Fatalf("unable to fetch transaction [%s]", err)
+		}
+		if transaction.Balance != models.MustParseMoney(expected) {
+			t.Errorf("transaction %d expected balance %s got %s", transactionID, expected, transaction.Balance)
+		}
+	}
+
+	t.Run("Schedule", func(t *testing.T) {
+		account := createAccount(t, services)
+		purchase := createPurchase(t, account.AccountID, "100", 3)
+
+		if purchase.Installments != 3 {
+			t.Errorf("expected 3 installments got %d", purchase.Installments)
+		}
+
+		installments, err := services.InstallmentService.ListForTransaction(ctx, purchase.ID)
+		if err != nil {
+			t.Fatalf("unable to list installments [%s]", err)
+		}
+		if len(installments) != 3 {
+			t.Fatalf("expected 3 installments got %d", len(installments))
+		}
+
+		for i, expected := range []string{"33.34", "33.33", "33.33"} {
+			if installments[i].Number != i+1 || installments[i].Amount != models.MustParseMoney(expected) {
+				t.Errorf("installment %d expected amount %s got %v", i+1, expected, installments[i])
+			}
+		}
+
+		if installments[0].PostedAt == nil || installments[1].PostedAt != nil {
+			t.Errorf("expected only the first installment to be posted")
+		}
+
+		if !installments[1].DueDate.After(installments[0].DueDate) {
+			t.Errorf("expected monthly due dates got %s and %s", installments[0].DueDate, installments[1].DueDate)
+		}
+
+		// only the first installment is open
+		expectBalance(t, purchase.ID, "-33.34")
+	})
+
+	t.Run("Posting due installments", func(t *testing.T) {
+		account := createAccount(t, services)
+		purchase := createPurchase(t, account.AccountID, "90", 3)
+		credit := createTransaction(t, services, account.AccountID, models.CreditVoucher, "40")
+
+		// the credit pays the first installment and is kept for the next one
+		expectBalance(t, purchase.ID, "0")
+		expectBalance(t, credit.TransactionID, "10")
+
+		if _, err := services.InstallmentService.PostDue(ctx, time.Now().AddDate(0, 1, 1)); err != nil {
+			t.Fatalf("unable to post due installments [%s]", err)
+		}
+
+		expectBalance(t, purchase.ID, "-20")
+		expectBalance(t, credit.TransactionID, "0")
+
+		installments, err := services.InstallmentService.ListForTransaction(ctx, purchase.ID)
+		if err != nil {
+			t.Fatalf("unable to list installments [%s]", err)
+		}
+		if installments[1].PostedAt == nil || installments[2].PostedAt != nil {
+			t.Errorf("expected only the first two installments to be posted")
+		}
+	})
+
+	t.Run("Reversal cancels pending installments first", func(t *testing.T) {
+		account := createAccount(t, services)
+		purchase := createPurchase(t, account.AccountID, "90", 3)
+
+		reversal, err := services.TransactionService.Reverse(ctx, purchase.ID, models.MustParseMoney("70"))
+		if err != nil {
+			t.Fatalf("unable to reverse transaction [%s]", err)
+		}
+
+		installments, err := services.InstallmentService.ListForTransaction(ctx, purchase.ID)
+		if err != nil {
+			t.Fatalf("unable to list installments [%s]", err)
+		}
+		for i, expected := range []string{"30", "0", "0"} {
+			if installments[i].Amount != models.MustParseMoney(expected) {
+				t.Errorf("installment %d expected amount %s got %s", i+1, expected, installments[i].Amount)
+			}
+		}
+
+		expectBalance(t, purchase.ID, "-20")
+		expectBalance(t, reversal.TransactionID, "0")
+	})
+
+	t.Run("Missing transaction", func(t *testing.T) {
+		_, err := services.InstallmentService.ListForTransaction(ctx, int64(testutils.GenerateRandomNumberInt(10)))
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
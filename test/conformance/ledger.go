@@ -0,0 +1,108 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testLedger(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	account := createAccount(t, services)
+	other := createAccount(t, services)
+
+	purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "50")
+	voucher := createTransaction(t, services, account.AccountID, models.CreditVoucher, "20")
+
+	reversal, err := services.TransactionService.Reverse(ctx, purchase.TransactionID, 0)
+	if err != nil {
+		t.Fatalf("unable to reverse transaction [%s]", err)
+	}
+
+	transfer, err := services.TransferService.Create(ctx, models.Transfer{
+		SourceAccountID:      account.AccountID,
+		DestinationAccountID: other.AccountID,
+		Amount:               models.MustParseMoney("10"),
+	})
+	if err != nil {
+		t.Fatalf("unable to create transfer [%s]", err)
+	}
+
+	t.Run("Every transaction is journaled", func(t *testing.T) {
+
+		expected := []struct {
+			transactionID int64
+			accountID     int64
+			customer      string
+			counterpart   models.LedgerAccount
+		}{
+			{purchase.TransactionID, account.AccountID, "50", models.MerchantClearingLedgerAccount},
+			{voucher.TransactionID, account.AccountID, "-20", models.CashLedgerAccount},
+			{reversal.TransactionID, account.AccountID, "-50", models.MerchantClearingLedgerAccount},
+			{*transfer.DebitTransactionID, account.AccountID, "10", models.TransferClearingLedgerAccount},
+			{*transfer.CreditTransactionID, other.AccountID, "-10", models.TransferClearingLedgerAccount},
+		}
+
+		for _, e := range expected {
+			entries, err := services.LedgerService.ListForTransaction(ctx, e.transactionID)
+			switch {
+			case err != nil:
+				t.Fatalf("unable to list journal entries [%s]", err)
+			case len(entries) != 1:
+				t.Fatalf("expected one journal entry for transaction %d got %d", e.transactionID, len(entries))
+			case !entries[0].IsBalanced():
+				t.Errorf("expected a balanced entry for transaction %d got %+v", e.transactionID, entries[0].Postings)
+			case entries[0].Postings[0].LedgerAccount != models.CustomerLedgerAccount || *entries[0].Postings[0].AccountID != e.accountID:
+				t.Errorf("expected the customer leg of account %d got %+v", e.accountID, entries[0].Postings[0])
+			case entries[0].Postings[0].Amount != models.MustParseMoney(e.customer):
+				t.Errorf("expected the customer leg to be %s got %s", e.customer, entries[0].Postings[0].Amount)
+			case entries[0].Postings[1].LedgerAccount != e.counterpart:
+				t.Errorf("expected counterpart %s got %s", e.counterpart, entries[0].Postings[1].LedgerAccount)
+			}
+		}
+	})
+
+	t.Run("Settlement does not touch the ledger", func(t *testing.T) {
+
+		// the reversal restored credit that the next debit consumes, only its own entry is added
+		debit := createTransaction(t, services, account.AccountID, models.Withdrawal, "5")
+
+		entries, err := services.LedgerService.ListForTransaction(ctx, purchase.TransactionID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to list journal entries [%s]", err)
+		case len(entries) != 1 || entries[0].Postings[0].Amount != models.MustParseMoney("50"):
+			t.Errorf("expected the purchase entry to be unchanged got %+v", entries)
+		}
+
+		entries, err = services.LedgerService.ListForTransaction(ctx, debit.TransactionID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to list journal entries [%s]", err)
+		case len(entries) != 1 || entries[0].Postings[0].Amount != models.MustParseMoney("5"):
+			t.Errorf("expected an entry of 5.00 for the withdrawal got %+v", entries)
+		}
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+
+		unbalanced, err := services.LedgerService.Verify(ctx)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to verify ledger [%s]", err)
+		case len(unbalanced) != 0:
+			t.Errorf("expected no unbalanced journal entries got %v", unbalanced)
+		}
+	})
+
+	t.Run("Missing transaction", func(t *testing.T) {
+
+		_, err := services.LedgerService.ListForTransaction(ctx, int64(testutils.GenerateRandomNumberInt(10)))
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testListTransactions(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	account := createAccount(t, services)
+	createTransaction(t, services, account.AccountID, models.NormalPurchase, "10")
+	createTransaction(t, services, account.AccountID, models.NormalPurchase, "20")
+	createTransaction(t, services, account.AccountID, models.Withdrawal, "30")
+	createTransaction(t, services, account.AccountID, models.CreditVoucher, "10")
+
+	t.Run("Pages cover every transaction newest first", func(t *testing.T) {
+
+		filter := models.TransactionFilter{AccountID: account.AccountID, Limit: 3}
+		ids := make([]int64, 0)
+
+		for {
+			page, err := services.TransactionService.ListForAccount(ctx, filter)
+			if err != nil {
+				t.Fatalf("unable to list transactions [%s]", err)
+			}
+
+			for _, transaction := range page.Transactions {
+				if transaction.OperationType == nil {
+					t.Errorf("transaction %d without operation type", transaction.ID)
+				}
+				ids = append(ids, transaction.ID)
+			}
+
+			if page.NextCursor == nil {
+				break
+			}
+			filter.After = page.NextCursor
+		}
+
+		if len(ids) != 4 {
+			t.Fatalf("expected 4 transactions got %d", len(ids))
+		}
+
+		for i := 1; i < len(ids); i++ {
+			if ids[i] >= ids[i-1] {
+				t.Errorf("transactions not ordered newest first %v", ids)
+			}
+		}
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+
+		settled := false
+		page, err := services.TransactionService.ListForAccount(ctx, models.TransactionFilter{
+			AccountID:        account.AccountID,
+			OperationTypeIDs: []int64{int64(models.NormalPurchase)},
+			Settled:          &settled,
+		})
+		switch {
+		case err != nil:
+			t.Errorf("unable to list transactions [%s]", err)
+		case len(page.Transactions) != 1:
+			t.Errorf("expected 1 open purchase got %d", len(page.Transactions))
+		case page.Transactions[0].Balance != models.MustParseMoney("-20"):
+			t.Errorf("expected balance -20.00 got %s", page.Transactions[0].Balance)
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+		_, err := services.TransactionService.ListForAccount(ctx, models.TransactionFilter{
+			AccountID: int64(testutils.GenerateRandomNumberInt(10)),
+		})
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
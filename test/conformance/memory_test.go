@@ -0,0 +1,34 @@
+package conformance
+
+import (
+	"payments-backend-app/internal/memory"
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestMemoryServices(t *testing.T) {
+	Run(t, func(t *testing.T) Services {
+		store := memory.NewStore(models.NewSettlementStrategies())
+		return Services{
+			AccountsService:      memory.NewAccountsService(store),
+			TransactionService:   memory.NewTransactionService(store),
+			OperationTypeService: memory.NewOperationTypeService(store),
+			InstallmentService:   memory.NewInstallmentService(store),
+			AuthorizationService: memory.NewAuthorizationService(store, time.Hour),
+			TransferService:      memory.NewTransferService(store),
+			LedgerService:        memory.NewLedgerService(store),
+			AllocationService:    memory.NewAllocationService(store),
+			StatementService:     memory.NewStatementService(store),
+			AccrualService:       memory.NewAccrualService(store, models.MustParseMoney("10")),
+			ScheduleService:      memory.NewScheduleService(store),
+			IdempotencyService:   memory.NewIdempotencyService(store),
+			FXService:            memory.NewFXService(store, time.Minute),
+			OutboxService:        memory.NewOutboxService(store),
+			WebhookService:       memory.NewWebhookService(store),
+			AccountEventService:  memory.NewAccountEventService(store),
+			Backdate:             store.Backdate,
+			BackdateAccount:      store.BackdateAccount,
+		}
+	})
+}
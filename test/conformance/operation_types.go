@@ -0,0 +1,95 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testOperationTypes(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	t.Run("Seeded operation types", func(t *testing.T) {
+		for _, expected := range models.DefaultOperationTypes() {
+			operationType, err := services.OperationTypeService.GetForID(ctx, expected.ID)
+			switch {
+			case err != nil:
+				t.Errorf("unable to fetch operation type %d [%s]", expected.ID, err)
+			case operationType != expected:
+				t.Errorf("expected %v got %v", expected, operationType)
+			}
+		}
+	})
+
+	t.Run("Create and update", func(t *testing.T) {
+
+		operationType, err := services.OperationTypeService.Create(ctx, models.OperationType{
+			Description: "Chargeback " + testutils.GenerateRandomNumber(6),
+			Direction:   models.CreditDirection,
+			Active:      true,
+		})
+		switch {
+		case err != nil:
+			t.Fatalf("unable to create operation type [%s]", err)
+		case operationType.ID < 100:
+			t.Errorf("expected an id from 100 got %d", operationType.ID)
+		case !operationType.IsCredit():
+			t.Errorf("expected a credit operation type got %s", operationType.Direction)
+		}
+
+		active := false
+		updated, err := services.OperationTypeService.Update(ctx, models.OperationTypeUpdate{ID: operationType.ID, Active: &active})
+		switch {
+		case err != nil:
+			t.Fatalf("unable to update operation type [%s]", err)
+		case updated.Active:
+			t.Errorf("expected an inactive operation type")
+		case updated.Description != operationType.Description || updated.Direction != operationType.Direction:
+			t.Errorf("expected only active to change got %v", updated)
+		}
+
+		list, err := services.OperationTypeService.List(ctx)
+		if err != nil {
+			t.Fatalf("unable to list operation types [%s]", err)
+		}
+
+		found := false
+		for i, listed := range list {
+			if i > 0 && list[i-1].ID >= listed.ID {
+				t.Errorf("operation types not ordered by id")
+			}
+			found = found || listed == updated
+		}
+
+		if !found {
+			t.Errorf("updated operation type %d not listed", updated.ID)
+		}
+	})
+
+	t.Run("Missing operation type", func(t *testing.T) {
+		_, err := services.OperationTypeService.GetForID(ctx, int64(testutils.GenerateRandomNumberInt(9)))
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+
+		active := true
+		_, err = services.OperationTypeService.Update(ctx, models.OperationTypeUpdate{ID: int64(testutils.GenerateRandomNumberInt(9)), Active: &active})
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+	t.Run("Transaction of a missing operation type", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		_, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(testutils.GenerateRandomNumberInt(9)),
+			Amount:          -100,
+		})
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
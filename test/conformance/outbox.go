@@ -0,0 +1,226 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+// recordingPublisher records the events it publishes and fails the ones listed in fail as many times as listed
+type recordingPublisher struct {
+	published []models.Event
+	fail      map[int64]int
+}
+
+func testOutbox(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	// pendingFor returns the pending events of the account, by id
+	pendingFor := func(t *testing.T, accountID int64) []models.Event {
+		pending, err := services.OutboxService.ListPending(ctx, 0, 1_000_000)
+		if err != nil {
+			t.Fatalf("unable to list pending events [%s]", err)
+		}
+
+		events := []models.Event{}
+		for _, event := range pending {
+			if event.AccountID == accountID {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	eventTypes := func(events []models.Event) []models.EventType {
+		types := []models.EventType{}
+		for _, event := range events {
+			types = append(types, event.Type)
+		}
+		return types
+	}
+
+	t.Run("Events are written with the change", func(t *testing.T) {
+		account := createAccount(t, services)
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "10")
+		payment := createTransaction(t, services, account.AccountID, models.CreditVoucher, "10")
+
+		events := pendingFor(t, account.AccountID)
+		expected := []models.EventType{
+			models.AccountCreatedEvent,
+			models.TransactionCreatedEvent,
+			models.TransactionCreatedEvent,
+			models.TransactionSettledEvent,
+			models.TransactionSettledEvent,
+		}
+		if fmt.Sprint(eventTypes(events)) != fmt.Sprint(expected) {
+			t.Fatalf("expected events %v got %v", expected, eventTypes(events))
+		}
+
+		// the payload is the account or transaction as of the event
+		for i, transactionID := range []int64{0, purchase.TransactionID, payment.TransactionID, purchase.TransactionID, payment.TransactionID} {
+			var payload struct {
+				ID        int64        `json:"id"`
+				AccountID int64        `json:"account_id"`
+				Balance   models.Money `json:"balance"`
+			}
+			if err := json.Unmarshal(events[i].Payload, &payload); err != nil {
+				t.Fatalf("unable to unmarshal payload of %s [%s]", events[i].Type, err)
+			}
+
+			switch {
+			case i == 0 && payload.AccountID != account.AccountID:
+				t.Errorf("expected account %d got %s", account.AccountID, string(events[i].Payload))
+			case i > 0 && payload.ID != transactionID:
+				t.Errorf("expected transaction %d in event %d got %s", transactionID, i, string(events[i].Payload))
+			case i > 2 && payload.Balance != 0:
+				t.Errorf("expected a settled balance got %s", payload.Balance)
+			}
+		}
+
+		// a debit left open is not settled
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "5")
+		if events := pendingFor(t, account.AccountID); len(events) != 6 || events[5].Type != models.TransactionCreatedEvent {
+			t.Errorf("expected a single TransactionCreated event got %v", eventTypes(events))
+		}
+	})
+
+	t.Run("Events of failed changes are not written", func(t *testing.T) {
+		account := createAccount(t, services)
+		limit := models.MustParseMoney("10")
+		if _, err := services.AccountsService.UpdateCreditLimit(ctx, account.AccountID, &limit); err != nil {
+			t.Fatalf("unable to update credit limit [%s]", err)
+		}
+
+		_, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("-20"),
+		})
+		if !errors.Is(err, models.InsufficientLimitErr) {
+			t.Fatalf("expected error %v got %v", models.InsufficientLimitErr, err)
+		}
+
+		if events := pendingFor(t, account.AccountID); len(events) != 1 {
+			t.Errorf("expected only the AccountCreated event got %v", eventTypes(events))
+		}
+	})
+
+	t.Run("Relay", func(t *testing.T) {
+		first := createAccount(t, services)
+		createTransaction(t, services, first.AccountID, models.NormalPurchase, "10")
+		createTransaction(t, services, first.AccountID, models.NormalPurchase, "20")
+
+		second := createAccount(t, services)
+		createTransaction(t, services, second.AccountID, models.NormalPurchase, "10")
+
+		firstEvents := pendingFor(t, first.AccountID)
+		if len(firstEvents) != 3 {
+			t.Fatalf("expected 3 events got %v", eventTypes(firstEvents))
+		}
+
+		// the second event of the first account fails, the third is held back behind it
+		publisher := &recordingPublisher{fail: map[int64]int{firstEvents[1].ID: 1}}
+		relay := models.NewOutboxRelay(services.OutboxService, publisher, 3, time.Minute)
+		now := time.Now()
+
+		result, err := relay.Relay(ctx, now)
+		if err != nil {
+			t.Fatalf("unable to relay [%s]", err)
+		}
+
+		pending := pendingFor(t, first.AccountID)
+		switch {
+		case len(pending) != 2 || pending[0].ID != firstEvents[1].ID:
+			t.Fatalf("expected the failed event and the one after it pending got %v", pending)
+		case pending[0].Attempts != 1 || pending[0].LastError == "":
+			t.Errorf("expected the failed attempt recorded got %d attempts and error %q", pending[0].Attempts, pending[0].LastError)
+		case pending[0].NextAttemptAt == nil || pending[0].NextAttemptAt.Before(now.Add(time.Minute)):
+			t.Errorf("expected the retry a minute later got %v", pending[0].NextAttemptAt)
+		case fmt.Sprint(result.Blocked) != fmt.Sprint([]int64{first.AccountID}):
+			t.Errorf("expected the first account blocked got %v", result.Blocked)
+		case len(pendingFor(t, second.AccountID)) != 0:
+			t.Errorf("expected the events of the second account published")
+		}
+
+		// the failed event is not retried before its backoff, it still holds back the account
+		if result, err := relay.Relay(ctx, now); err != nil || result.Published != 0 {
+			t.Fatalf("expected nothing published before the retry got %d err %v", result.Published, err)
+		}
+
+		if _, err := relay.Relay(ctx, now.Add(time.Minute)); err != nil {
+			t.Fatalf("unable to relay [%s]", err)
+		}
+
+		if pending := pendingFor(t, first.AccountID); len(pending) != 0 {
+			t.Errorf("expected every event published got %v", eventTypes(pending))
+		}
+
+		published := []int64{}
+		for _, event := range publisher.published {
+			if event.AccountID == first.AccountID {
+				published = append(published, event.ID)
+			}
+		}
+		expected := []int64{firstEvents[0].ID, firstEvents[1].ID, firstEvents[2].ID}
+		if fmt.Sprint(published) != fmt.Sprint(expected) {
+			t.Errorf("expected the events of the account published in order %v got %v", expected, published)
+		}
+	})
+
+	t.Run("Events that run out of attempts are parked", func(t *testing.T) {
+		account := createAccount(t, services)
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "10")
+
+		events := pendingFor(t, account.AccountID)
+		if len(events) != 2 {
+			t.Fatalf("expected 2 events got %v", eventTypes(events))
+		}
+
+		// the first event never gets through, once parked it no longer holds back the second
+		publisher := &recordingPublisher{fail: map[int64]int{events[0].ID: 100}}
+		relay := models.NewOutboxRelay(services.OutboxService, publisher, 2, time.Minute)
+		now := time.Now()
+
+		for _, at := range []time.Time{now, now.Add(time.Minute)} {
+			if _, err := relay.Relay(ctx, at); err != nil {
+				t.Fatalf("unable to relay [%s]", err)
+			}
+		}
+
+		if pending := pendingFor(t, account.AccountID); len(pending) != 0 {
+			t.Errorf("expected no pending events got %v", eventTypes(pending))
+		}
+
+		if len(publisher.published) != 1 || publisher.published[0].ID != events[1].ID {
+			t.Errorf("expected only the second event published got %v", publisher.published)
+		}
+	})
+
+	t.Run("A single relay publishes at a time", func(t *testing.T) {
+		release, ok, err := services.OutboxService.LockRelay(ctx)
+		if err != nil || !ok {
+			t.Fatalf("unable to lock relay ok %t err %v", ok, err)
+		}
+
+		account := createAccount(t, services)
+
+		publisher := &recordingPublisher{}
+		relay := models.NewOutboxRelay(services.OutboxService, publisher, 3, time.Minute)
+		if result, err := relay.Relay(ctx, time.Now()); err != nil || result.Published != 0 {
+			t.Errorf("expected nothing published while locked got %d err %v", result.Published, err)
+		}
+
+		release()
+
+		if _, err := relay.Relay(ctx, time.Now()); err != nil {
+			t.Fatalf("unable to relay [%s]", err)
+		}
+		if pending := pendingFor(t, account.AccountID); len(pending) != 0 {
+			t.Errorf("expected the events published once unlocked got %v", eventTypes(pending))
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package conformance
+
+import (
+	"context"
+	"payments-backend-app/builder"
+	imodels "payments-backend-app/internal/models"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresServices(t *testing.T) {
+
+	if testutils.IsWithoutDatabase() {
+		t.Skip("running without a database")
+	}
+
+	envConfig := builder.GetEnvConfig()
+
+	db, err := builder.NewDatabase(
+		envConfig.DatabaseAddr,
+		envConfig.DatabaseName,
+		envConfig.DatabaseUser,
+		envConfig.DatabasePassword,
+		envConfig.UseInsecureDatabase)
+	require.NoError(t, err)
+	defer db.Close()
+
+	settlement := models.NewSettlementStrategies()
+
+	Run(t, func(t *testing.T) Services {
+		return Services{
+			AccountsService:      imodels.NewAccountsService(db),
+			TransactionService:   imodels.NewTransactionService(db, settlement),
+			OperationTypeService: imodels.NewOperationTypeService(db),
+			InstallmentService:   imodels.NewInstallmentService(db, settlement),
+			AuthorizationService: imodels.NewAuthorizationService(db, time.Hour, settlement),
+			TransferService:      imodels.NewTransferService(db, settlement),
+			LedgerService:        imodels.NewLedgerService(db),
+			AllocationService:    imodels.NewAllocationService(db),
+			StatementService:     imodels.NewStatementService(db),
+			AccrualService:       imodels.NewAccrualService(db, models.MustParseMoney("10"), settlement),
+			ScheduleService:      imodels.NewScheduleService(db),
+			IdempotencyService:   imodels.NewIdempotencyService(db),
+			FXService:            imodels.NewFXService(db, time.Minute),
+			OutboxService:        imodels.NewOutboxService(db),
+			WebhookService:       imodels.NewWebhookService(db),
+			AccountEventService:  imodels.NewAccountEventService(db),
+			Backdate: func(transactionID int64, eventDate time.Time) error {
+				_, err := db.NewUpdate().
+					Model((*models.Transaction)(nil)).
+					Set("event_date = ?", eventDate).
+					Where("id = ?", transactionID).
+					Exec(context.Background())
+				return err
+			},
+			BackdateAccount: func(accountID int64, createdAt time.Time) error {
+				_, err := db.NewUpdate().
+					Model((*models.Account)(nil)).
+					Set("created_at = ?", createdAt).
+					Where("id = ?", accountID).
+					Exec(context.Background())
+				return err
+			},
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testReversal(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	expectBalances := func(t *testing.T, expected map[int64]string) {
+		for id, balance := range expected {
+			transaction, err := services.TransactionService.GetForID(ctx, id)
+			if err != nil {
+				t.Fatalf("unable to fetch transaction [%s]", err)
+			}
+			if transaction.Balance != models.MustParseMoney(balance) {
+				t.Errorf("transaction %d expected balance %s got %s", id, balance, transaction.Balance)
+			}
+		}
+	}
+
+	reverse := func(t *testing.T, transactionID int64, amount string) models.Transaction {
+		transactionStatus, err := services.TransactionService.Reverse(ctx, transactionID, models.MustParseMoney(amount))
+		if err != nil {
+			t.Fatalf("unable to reverse transaction [%s]", err)
+		}
+		reversal, err := services.TransactionService.GetForID(ctx, transactionStatus.TransactionID)
+		if err != nil {
+			t.Fatalf("unable to fetch reversal [%s]", err)
+		}
+		if reversal.ReversesTransactionID == nil || *reversal.ReversesTransactionID != transactionID {
+			t.Errorf("expected reversal linked to %d got %v", transactionID, reversal.ReversesTransactionID)
+		}
+		if reversal.OperationTypeID != int64(models.PurchaseReversal) {
+			t.Errorf("expected operation type %d got %d", models.PurchaseReversal, reversal.OperationTypeID)
+		}
+		return reversal
+	}
+
+	t.Run("Open debit", func(t *testing.T) {
+		account := createAccount(t, services)
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "50")
+
+		reversal := reverse(t, purchase.TransactionID, "0")
+		if reversal.Amount != models.MustParseMoney("50") {
+			t.Errorf("expected amount 50.00 got %s", reversal.Amount)
+		}
+
+		expectBalances(t, map[int64]string{purchase.TransactionID: "0", reversal.ID: "0"})
+	})
+
+	t.Run("Paid debit restores the consumed credit", func(t *testing.T) {
+		account := createAccount(t, services)
+		credit := createTransaction(t, services, account.AccountID, models.CreditVoucher, "50")
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "30")
+
+		reversal := reverse(t, purchase.TransactionID, "0")
+
+		// the reversal keeps what it gives back, the rest of the voucher stays on it
+		expectBalances(t, map[int64]string{credit.TransactionID: "20", purchase.TransactionID: "0", reversal.ID: "30"})
+	})
+
+	t.Run("Partial reversal of a partly paid debit", func(t *testing.T) {
+		account := createAccount(t, services)
+		credit := createTransaction(t, services, account.AccountID, models.CreditVoucher, "20")
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "50")
+
+		reversal := reverse(t, purchase.TransactionID, "40")
+		if reversal.Amount != models.MustParseMoney("40") {
+			t.Errorf("expected amount 40.00 got %s", reversal.Amount)
+		}
+
+		expectBalances(t, map[int64]string{credit.TransactionID: "0", purchase.TransactionID: "0", reversal.ID: "10"})
+	})
+
+	t.Run("Partial reversals up to the amount of the debit", func(t *testing.T) {
+		account := createAccount(t, services)
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "50")
+
+		first := reverse(t, purchase.TransactionID, "20")
+		expectBalances(t, map[int64]string{purchase.TransactionID: "-30", first.ID: "0"})
+
+		// a zero amount reverses what is left
+		second := reverse(t, purchase.TransactionID, "0")
+		if second.Amount != models.MustParseMoney("30") {
+			t.Errorf("expected amount 30.00 got %s", second.Amount)
+		}
+		expectBalances(t, map[int64]string{purchase.TransactionID: "0", second.ID: "0"})
+
+		if _, err := services.TransactionService.Reverse(ctx, purchase.TransactionID, models.MustParseMoney("0.01")); !errors.Is(err, models.AlreadyReversedErr) {
+			t.Errorf("expected %s got %v", models.AlreadyReversedErr, err)
+		}
+	})
+
+	t.Run("Restored credit pays other debts", func(t *testing.T) {
+		account := createAccount(t, services)
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "50")
+		createTransaction(t, services, account.AccountID, models.CreditVoucher, "50")
+		other := createTransaction(t, services, account.AccountID, models.Withdrawal, "20")
+
+		reversal := reverse(t, purchase.TransactionID, "0")
+
+		expectBalances(t, map[int64]string{purchase.TransactionID: "0", other.TransactionID: "0", reversal.ID: "30"})
+	})
+
+	t.Run("Rejected reversals", func(t *testing.T) {
+		account := createAccount(t, services)
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "50")
+		credit := createTransaction(t, services, account.AccountID, models.CreditVoucher, "10")
+
+		_, err := services.TransactionService.Reverse(ctx, purchase.TransactionID, models.MustParseMoney("50.01"))
+		if !errors.Is(err, models.ReversalAmountExceededErr) {
+			t.Errorf("expected %s got %v", models.ReversalAmountExceededErr, err)
+		}
+
+		_, err = services.TransactionService.Reverse(ctx, credit.TransactionID, 0)
+		if !errors.Is(err, models.NotReversibleErr) {
+			t.Errorf("expected %s got %v", models.NotReversibleErr, err)
+		}
+
+		reversal := reverse(t, purchase.TransactionID, "10")
+
+		_, err = services.TransactionService.Reverse(ctx, purchase.TransactionID, models.MustParseMoney("40.01"))
+		if !errors.Is(err, models.ReversalAmountExceededErr) {
+			t.Errorf("expected %s got %v", models.ReversalAmountExceededErr, err)
+		}
+
+		reverse(t, purchase.TransactionID, "0")
+
+		_, err = services.TransactionService.Reverse(ctx, purchase.TransactionID, 0)
+		if !errors.Is(err, models.AlreadyReversedErr) {
+			t.Errorf("expected %s got %v", models.AlreadyReversedErr, err)
+		}
+
+		_, err = services.TransactionService.Reverse(ctx, reversal.ID, 0)
+		if !errors.Is(err, models.NotReversibleErr) {
+			t.Errorf("expected %s got %v", models.NotReversibleErr, err)
+		}
+
+		_, err = services.TransactionService.Reverse(ctx, int64(testutils.GenerateRandomNumberInt(10)), 0)
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
@@ -0,0 +1,319 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSchedules(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	// the schedules of other runs sharing the database may be due as well, so the
+	// tests look at their own schedules instead of counting runs
+	createSchedule := func(t *testing.T, accountID int64, frequency models.ScheduleFrequency, amount string, startAt time.Time) models.Schedule {
+		schedule, err := services.ScheduleService.Create(ctx, models.Schedule{
+			AccountID:       accountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          -models.MustParseMoney(amount),
+			Frequency:       frequency,
+			StartAt:         startAt,
+		})
+		if err != nil {
+			t.Fatalf("unable to create schedule [%s]", err)
+		}
+		return schedule
+	}
+
+	isLeased := func(leased []models.Schedule, scheduleID int64) bool {
+		for _, schedule := range leased {
+			if schedule.ID == scheduleID {
+				return true
+			}
+		}
+		return false
+	}
+
+	listRuns := func(t *testing.T, scheduleID int64) []models.ScheduleRun {
+		runs, err := services.ScheduleService.ListRuns(ctx, scheduleID)
+		if err != nil {
+			t.Fatalf("unable to list runs [%s]", err)
+		}
+		return runs
+	}
+
+	listTransactions := func(t *testing.T, accountID int64) []models.Transaction {
+		page, err := services.TransactionService.ListForAccount(ctx, models.TransactionFilter{AccountID: accountID})
+		if err != nil {
+			t.Fatalf("unable to list transactions [%s]", err)
+		}
+		return page.Transactions
+	}
+
+	t.Run("Leases are exclusive until they expire", func(t *testing.T) {
+		account := createAccount(t, services)
+		startAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		schedule := createSchedule(t, account.AccountID, models.OnceSchedule, "10", startAt)
+
+		leased, err := services.ScheduleService.Lease(ctx, "first", startAt.Add(-time.Second), time.Minute, 100)
+		if err != nil || isLeased(leased, schedule.ID) {
+			t.Fatalf("expected the schedule not to be due before its start err %v", err)
+		}
+
+		leased, err = services.ScheduleService.Lease(ctx, "first", startAt, time.Minute, 100)
+		if err != nil || !isLeased(leased, schedule.ID) {
+			t.Fatalf("expected the schedule to be leased err %v", err)
+		}
+
+		leased, err = services.ScheduleService.Lease(ctx, "second", startAt.Add(time.Second), time.Minute, 100)
+		if err != nil || isLeased(leased, schedule.ID) {
+			t.Fatalf("expected the schedule to be held by the first runner err %v", err)
+		}
+
+		leased, err = services.ScheduleService.Lease(ctx, "second", startAt.Add(time.Minute), time.Minute, 100)
+		if err != nil || !isLeased(leased, schedule.ID) {
+			t.Fatalf("expected the expired lease to be taken err %v", err)
+		}
+
+		run := models.ScheduleRun{
+			ScheduleID:   schedule.ID,
+			ScheduledFor: startAt,
+			Attempt:      1,
+			Status:       models.ScheduleRunSucceeded,
+			CreatedAt:    startAt.Add(time.Minute),
+		}
+
+		if _, err := services.ScheduleService.RecordRun(ctx, "first", run); !errors.Is(err, models.ScheduleLeaseLostErr) {
+			t.Fatalf("expected %v got %v", models.ScheduleLeaseLostErr, err)
+		}
+
+		recorded, err := services.ScheduleService.RecordRun(ctx, "second", run)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to record run [%s]", err)
+		case recorded.Status != models.ScheduleCompleted || recorded.Occurrences != 1:
+			t.Errorf("expected the schedule to be completed got %+v", recorded)
+		}
+
+		if runs := listRuns(t, schedule.ID); len(runs) != 1 || runs[0].Status != models.ScheduleRunSucceeded {
+			t.Errorf("expected 1 succeeded run got %+v", runs)
+		}
+	})
+
+	t.Run("Occurrences are booked once", func(t *testing.T) {
+		account := createAccount(t, services)
+		startAt := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+		schedule := createSchedule(t, account.AccountID, models.DailySchedule, "10", startAt)
+
+		// a runner that stopped right after booking the first occurrence
+		if _, err := services.TransactionService.Create(
+			models.ContextWithIdempotencyKey(ctx, models.ScheduleIdempotencyKey(schedule)),
+			models.Transaction{
+				AccountID:       account.AccountID,
+				OperationTypeID: int64(models.NormalPurchase),
+				Amount:          schedule.Amount,
+			}); err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+
+		scheduler := models.NewScheduler(services.ScheduleService, services.TransactionService, services.OperationTypeService, services.IdempotencyService, time.Minute)
+
+		if _, err := scheduler.RunDue(ctx, startAt); err != nil {
+			t.Fatalf("unable to run schedules [%s]", err)
+		}
+		if transactions := listTransactions(t, account.AccountID); len(transactions) != 1 {
+			t.Fatalf("expected the first occurrence to be booked once got %d transactions", len(transactions))
+		}
+
+		// the missed occurrences are caught up in order
+		if _, err := scheduler.RunDue(ctx, startAt.AddDate(0, 0, 2)); err != nil {
+			t.Fatalf("unable to run schedules [%s]", err)
+		}
+
+		transactions := listTransactions(t, account.AccountID)
+		if len(transactions) != 3 {
+			t.Fatalf("expected 3 transactions got %d", len(transactions))
+		}
+
+		runs := listRuns(t, schedule.ID)
+		if len(runs) != 3 {
+			t.Fatalf("expected 3 runs got %+v", runs)
+		}
+		for i, run := range runs {
+			expected := startAt.AddDate(0, 0, len(runs)-1-i)
+			switch {
+			case run.Status != models.ScheduleRunSucceeded || !run.ScheduledFor.Equal(expected):
+				t.Errorf("expected a succeeded run for %s got %+v", expected, run)
+			case run.TransactionID == nil:
+				t.Errorf("expected the transaction of the run for %s", expected)
+			}
+		}
+
+		schedule, err := services.ScheduleService.GetForID(ctx, schedule.ID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch schedule [%s]", err)
+		case schedule.Occurrences != 3 || !schedule.NextRunAt.Equal(startAt.AddDate(0, 0, 3)):
+			t.Errorf("expected the next run on the fourth day got %+v", schedule)
+		}
+	})
+
+	t.Run("Failed runs are retried", func(t *testing.T) {
+
+		limit := models.MustParseMoney("10")
+		account, err := services.AccountsService.Create(ctx, models.Account{
+			DocumentNumber:       testutils.GenerateRandomNumber(10),
+			AvailableCreditLimit: &limit,
+		})
+		if err != nil {
+			t.Fatalf("unable to create account [%s]", err)
+		}
+
+		startAt := time.Now().Add(3 * time.Hour).Truncate(time.Second)
+		schedule := createSchedule(t, account.AccountID, models.OnceSchedule, "50", startAt)
+		scheduler := models.NewScheduler(services.ScheduleService, services.TransactionService, services.OperationTypeService, services.IdempotencyService, time.Minute)
+
+		now := startAt
+		for attempt := 1; attempt <= models.MaxScheduleAttempts; attempt++ {
+			if _, err := scheduler.RunDue(ctx, now); err != nil {
+				t.Fatalf("unable to run schedules [%s]", err)
+			}
+
+			schedule, err = services.ScheduleService.GetForID(ctx, schedule.ID)
+			if err != nil {
+				t.Fatalf("unable to fetch schedule [%s]", err)
+			}
+			if attempt == models.MaxScheduleAttempts {
+				break
+			}
+
+			expected := now.Add(models.ScheduleRetryDelay << (attempt - 1))
+			if schedule.Attempts != attempt || schedule.RetryAt == nil || !schedule.RetryAt.Equal(expected) {
+				t.Fatalf("expected attempt %d to be retried at %s got %+v", attempt, expected, schedule)
+			}
+			now = *schedule.RetryAt
+		}
+
+		if schedule.Status != models.ScheduleCompleted {
+			t.Errorf("expected the schedule to give up after %d attempts got %+v", models.MaxScheduleAttempts, schedule)
+		}
+
+		runs := listRuns(t, schedule.ID)
+		switch {
+		case len(runs) != models.MaxScheduleAttempts:
+			t.Fatalf("expected %d runs got %+v", models.MaxScheduleAttempts, runs)
+		case runs[0].Attempt != models.MaxScheduleAttempts || runs[0].Status != models.ScheduleRunFailed:
+			t.Errorf("expected the last attempt first got %+v", runs[0])
+		case runs[0].Error != models.InsufficientLimitErr.Error() || runs[0].TransactionID != nil:
+			t.Errorf("expected the run to fail with %v got %+v", models.InsufficientLimitErr, runs[0])
+		}
+
+		if transactions := listTransactions(t, account.AccountID); len(transactions) != 0 {
+			t.Errorf("expected no transactions got %d", len(transactions))
+		}
+	})
+
+	t.Run("Runs of inactive operation types fail", func(t *testing.T) {
+		operationType, err := services.OperationTypeService.Create(ctx, models.OperationType{
+			Description: "Subscription " + testutils.GenerateRandomNumber(6),
+			Direction:   models.DebitDirection,
+			Active:      true,
+		})
+		if err != nil {
+			t.Fatalf("unable to create operation type [%s]", err)
+		}
+
+		account := createAccount(t, services)
+		startAt := time.Now().Add(5 * time.Hour).Truncate(time.Second)
+		schedule, err := services.ScheduleService.Create(ctx, models.Schedule{
+			AccountID:       account.AccountID,
+			OperationTypeID: operationType.ID,
+			Amount:          -models.MustParseMoney("10"),
+			Frequency:       models.OnceSchedule,
+			StartAt:         startAt,
+		})
+		if err != nil {
+			t.Fatalf("unable to create schedule [%s]", err)
+		}
+
+		active := false
+		if _, err := services.OperationTypeService.Update(ctx, models.OperationTypeUpdate{ID: operationType.ID, Active: &active}); err != nil {
+			t.Fatalf("unable to update operation type [%s]", err)
+		}
+
+		scheduler := models.NewScheduler(services.ScheduleService, services.TransactionService, services.OperationTypeService, services.IdempotencyService, time.Minute)
+		if _, err := scheduler.RunDue(ctx, startAt); err != nil {
+			t.Fatalf("unable to run schedules [%s]", err)
+		}
+
+		runs := listRuns(t, schedule.ID)
+		switch {
+		case len(runs) != 1:
+			t.Fatalf("expected 1 run got %+v", runs)
+		case runs[0].Status != models.ScheduleRunFailed || !strings.HasPrefix(runs[0].Error, models.OperationTypeNotActiveErr.Error()):
+			t.Errorf("expected the run to fail with %v got %+v", models.OperationTypeNotActiveErr, runs[0])
+		}
+		if transactions := listTransactions(t, account.AccountID); len(transactions) != 0 {
+			t.Errorf("expected no transactions got %d", len(transactions))
+		}
+
+		// the retry books the occurrence once the type is active again
+		active = true
+		if _, err := services.OperationTypeService.Update(ctx, models.OperationTypeUpdate{ID: operationType.ID, Active: &active}); err != nil {
+			t.Fatalf("unable to update operation type [%s]", err)
+		}
+
+		if _, err := scheduler.RunDue(ctx, startAt.Add(models.ScheduleRetryDelay)); err != nil {
+			t.Fatalf("unable to run schedules [%s]", err)
+		}
+		if transactions := listTransactions(t, account.AccountID); len(transactions) != 1 {
+			t.Errorf("expected the retry to book the occurrence got %d transactions", len(transactions))
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		account := createAccount(t, services)
+		startAt := time.Now().Add(4 * time.Hour).Truncate(time.Second)
+		schedule := createSchedule(t, account.AccountID, models.MonthlySchedule, "10", startAt)
+
+		cancelled, err := services.ScheduleService.Cancel(ctx, schedule.ID)
+		if err != nil || cancelled.Status != models.ScheduleCancelled {
+			t.Fatalf("expected the schedule to be cancelled got %+v err %v", cancelled, err)
+		}
+
+		if _, err := services.ScheduleService.Cancel(ctx, schedule.ID); !errors.Is(err, models.ScheduleNotActiveErr) {
+			t.Errorf("expected %v got %v", models.ScheduleNotActiveErr, err)
+		}
+
+		leased, err := services.ScheduleService.Lease(ctx, "runner", startAt, time.Minute, 100)
+		if err != nil || isLeased(leased, schedule.ID) {
+			t.Errorf("expected cancelled schedules not to be leased err %v", err)
+		}
+	})
+
+	t.Run("Missing records", func(t *testing.T) {
+		missingID := int64(testutils.GenerateRandomNumberInt(10))
+
+		if _, err := services.ScheduleService.Create(ctx, models.Schedule{
+			AccountID:       missingID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          -models.MustParseMoney("10"),
+			Frequency:       models.OnceSchedule,
+			StartAt:         time.Now(),
+		}); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %v got %v", models.NoRecordErr, err)
+		}
+
+		if _, err := services.ScheduleService.GetForID(ctx, missingID); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %v got %v", models.NoRecordErr, err)
+		}
+
+		if _, err := services.ScheduleService.ListRuns(ctx, missingID); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %v got %v", models.NoRecordErr, err)
+		}
+	})
+}
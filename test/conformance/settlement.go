@@ -0,0 +1,79 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"payments-backend-app/pkg/models"
+	"testing"
+)
+
+func testSettlement(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	type step struct {
+		operationTypeID models.OperationTypeID
+		amount          string
+		expectedBalance string
+	}
+
+	scenarios := [][]step{
+		{
+			{models.CreditVoucher, "50", "10"},
+			{models.NormalPurchase, "30", "0"},
+			{models.NormalPurchase, "10", "0"},
+		},
+		{
+			{models.CreditVoucher, "50", "0"},
+			{models.NormalPurchase, "30", "0"},
+			{models.NormalPurchase, "10", "0"},
+			{models.NormalPurchase, "20", "-10"},
+		},
+		{
+			{models.NormalPurchase, "50", "-50"},
+			{models.Withdrawal, "23.5", "-23.5"},
+			{models.PurchaseWithInstallments, "18.7", "-18.7"},
+		},
+		{
+			{models.NormalPurchase, "50", "0"},
+			{models.NormalPurchase, "23.5", "-13.5"},
+			{models.NormalPurchase, "18.7", "-18.7"},
+			{models.CreditVoucher, "60", "0"},
+		},
+		{
+			{models.NormalPurchase, "50", "0"},
+			{models.NormalPurchase, "23.5", "0"},
+			{models.NormalPurchase, "18.7", "0"},
+			{models.CreditVoucher, "60", "0"},
+			{models.CreditVoucher, "100", "67.8"},
+		},
+		{
+			{models.CreditVoucher, "0.1", "0"},
+			{models.CreditVoucher, "0.2", "0"},
+			{models.NormalPurchase, "0.3", "0"},
+		},
+	}
+
+	for i, scenario := range scenarios {
+		t.Run(fmt.Sprintf("scenario %d", i+1), func(t *testing.T) {
+
+			account := createAccount(t, services)
+
+			ids := make([]int64, 0, len(scenario))
+			for _, s := range scenario {
+				ids = append(ids, createTransaction(t, services, account.AccountID, s.operationTypeID, s.amount).TransactionID)
+			}
+
+			for j, id := range ids {
+				transaction, err := services.TransactionService.GetForID(ctx, id)
+				if err != nil {
+					t.Fatalf("unable to fetch transaction [%s]", err)
+				}
+
+				expected := models.MustParseMoney(scenario[j].expectedBalance)
+				if transaction.Balance != expected {
+					t.Errorf("step %d expected balance %s got %s", j+1, expected, transaction.Balance)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testSettlementStrategies(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	createAccountWithStrategy := func(t *testing.T, strategy string) models.Account {
+		account, err := services.AccountsService.Create(ctx, models.Account{
+			DocumentNumber:     testutils.GenerateRandomNumber(10),
+			SettlementStrategy: &strategy,
+		})
+		if err != nil {
+			t.Fatalf("unable to create account [%s]", err)
+		}
+		return account
+	}
+
+	expectBalances := func(t *testing.T, transactions []models.TransactionStatus, expected []string) {
+		for i, transactionStatus := range transactions {
+			transaction, err := services.TransactionService.GetForID(ctx, transactionStatus.TransactionID)
+			switch {
+			case err != nil:
+				t.Errorf("unable to fetch transaction [%s]", err)
+			case transaction.Balance != models.MustParseMoney(expected[i]):
+				t.Errorf("transaction %d expected balance %s got %s", i+1, expected[i], transaction.Balance)
+			}
+		}
+	}
+
+	t.Run("FIFO by default", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		transactions := []models.TransactionStatus{
+			createTransaction(t, services, account.AccountID, models.NormalPurchase, "10"),
+			createTransaction(t, services, account.AccountID, models.NormalPurchase, "20"),
+			createTransaction(t, services, account.AccountID, models.CreditVoucher, "25"),
+		}
+
+		expectBalances(t, transactions, []string{"0", "-5", "0"})
+	})
+
+	t.Run("LIFO pays the newest debits first", func(t *testing.T) {
+		account := createAccountWithStrategy(t, models.LIFOSettlementStrategy)
+
+		transactions := []models.TransactionStatus{
+			createTransaction(t, services, account.AccountID, models.NormalPurchase, "10"),
+			createTransaction(t, services, account.AccountID, models.NormalPurchase, "20"),
+			createTransaction(t, services, account.AccountID, models.CreditVoucher, "25"),
+		}
+
+		expectBalances(t, transactions, []string{"-5", "0", "0"})
+	})
+
+	t.Run("Priority pays withdrawals first and installments last", func(t *testing.T) {
+		account := createAccountWithStrategy(t, models.PrioritySettlementStrategy)
+
+		installments, err := services.TransactionService.Create(ctx, models.Transaction{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.PurchaseWithInstallments),
+			Amount:          -models.MustParseMoney("30"),
+			Installments:    2,
+		})
+		if err != nil {
+			t.Fatalf("unable to create transaction [%s]", err)
+		}
+
+		transactions := []models.TransactionStatus{
+			installments,
+			createTransaction(t, services, account.AccountID, models.NormalPurchase, "10"),
+			createTransaction(t, services, account.AccountID, models.Withdrawal, "20"),
+			createTransaction(t, services, account.AccountID, models.CreditVoucher, "40"),
+		}
+
+		expectBalances(t, transactions, []string{"-5", "0", "0", "0"})
+	})
+
+	t.Run("Update the strategy of an account", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		lifo := models.LIFOSettlementStrategy
+		raccount, err := services.AccountsService.UpdateSettlementStrategy(ctx, account.AccountID, &lifo)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to update settlement strategy [%s]", err)
+		case raccount.SettlementStrategy == nil || *raccount.SettlementStrategy != lifo:
+			t.Errorf("expected settlement strategy %s got %v", lifo, raccount.SettlementStrategy)
+		}
+
+		transactions := []models.TransactionStatus{
+			createTransaction(t, services, account.AccountID, models.NormalPurchase, "10"),
+			createTransaction(t, services, account.AccountID, models.NormalPurchase, "20"),
+			createTransaction(t, services, account.AccountID, models.CreditVoucher, "25"),
+		}
+
+		expectBalances(t, transactions, []string{"-5", "0", "0"})
+
+		raccount, err = services.AccountsService.UpdateSettlementStrategy(ctx, account.AccountID, nil)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to update settlement strategy [%s]", err)
+		case raccount.SettlementStrategy != nil:
+			t.Errorf("expected no settlement strategy got %s", *raccount.SettlementStrategy)
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+		_, err := services.AccountsService.UpdateSettlementStrategy(ctx, int64(testutils.GenerateRandomNumberInt(10)), nil)
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
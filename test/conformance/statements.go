@@ -0,0 +1,205 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func testStatements(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	account := createAccount(t, services)
+	closingDay, dueDay := 10, 20
+	if _, err := services.AccountsService.UpdateBillingCycle(ctx, account.AccountID, &closingDay, &dueDay); err != nil {
+		t.Fatalf("unable to update billing cycle [%s]", err)
+	}
+
+	purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "100")
+	createTransaction(t, services, account.AccountID, models.Withdrawal, "20")
+
+	// the cycle that closes within the next month holds the transactions created now
+	now := time.Now()
+	if _, err := services.StatementService.GenerateDue(ctx, now.AddDate(0, 1, 0)); err != nil {
+		t.Fatalf("unable to generate statements [%s]", err)
+	}
+
+	statements, err := services.StatementService.ListForAccount(ctx, account.AccountID)
+	switch {
+	case err != nil:
+		t.Fatalf("unable to list statements [%s]", err)
+	case len(statements) != 1:
+		t.Fatalf("expected 1 statement got %d", len(statements))
+	}
+	first := statements[0]
+
+	t.Run("Closed cycle", func(t *testing.T) {
+
+		statement, err := services.StatementService.GetForID(ctx, first.ID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch statement [%s]", err)
+		case len(statement.Lines) != 2:
+			t.Fatalf("expected 2 lines got %d", len(statement.Lines))
+		case statement.Lines[0].TransactionID != purchase.TransactionID:
+			t.Errorf("expected the purchase to be the first line got %+v", statement.Lines[0])
+		case statement.ClosingBalance != models.MustParseMoney("-120"):
+			t.Errorf("expected closing balance -120.00 got %s", statement.ClosingBalance)
+		case statement.MinimumPayment != models.MustParseMoney("18"):
+			t.Errorf("expected minimum payment 18.00 got %s", statement.MinimumPayment)
+		case statement.PeriodEnd.Day() != closingDay || !statement.DueDate.After(statement.PeriodEnd) || statement.DueDate.Day() != dueDay:
+			t.Errorf("expected the cycle to close on day %d and be due on day %d got %s and %s", closingDay, dueDay, statement.PeriodEnd, statement.DueDate)
+		}
+	})
+
+	t.Run("Cycle is generated once", func(t *testing.T) {
+
+		if _, err := services.StatementService.GenerateDue(ctx, now.AddDate(0, 1, 0)); err != nil {
+			t.Fatalf("unable to generate statements [%s]", err)
+		}
+
+		statements, err := services.StatementService.ListForAccount(ctx, account.AccountID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to list statements [%s]", err)
+		case len(statements) != 1:
+			t.Errorf("expected 1 statement got %d", len(statements))
+		}
+	})
+
+	t.Run("Lines are frozen", func(t *testing.T) {
+
+		createTransaction(t, services, account.AccountID, models.CreditVoucher, "100")
+
+		statement, err := services.StatementService.GetForID(ctx, first.ID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch statement [%s]", err)
+		case statement.Lines[0].Balance != models.MustParseMoney("-100"):
+			t.Errorf("expected the purchase balance to stay at -100.00 got %s", statement.Lines[0].Balance)
+		}
+	})
+
+	t.Run("Next cycle opens with the previous closing balance", func(t *testing.T) {
+
+		if _, err := services.StatementService.GenerateDue(ctx, now.AddDate(0, 2, 0)); err != nil {
+			t.Fatalf("unable to generate statements [%s]", err)
+		}
+
+		statements, err := services.StatementService.ListForAccount(ctx, account.AccountID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to list statements [%s]", err)
+		case len(statements) != 2:
+			t.Fatalf("expected 2 statements got %d", len(statements))
+		}
+
+		next := statements[0]
+		switch {
+		case !next.PeriodStart.Equal(first.PeriodEnd):
+			t.Errorf("expected the cycle to start at %s got %s", first.PeriodEnd, next.PeriodStart)
+		case next.OpeningBalance != first.ClosingBalance:
+			t.Errorf("expected opening balance %s got %s", first.ClosingBalance, next.OpeningBalance)
+		case next.ClosingBalance != models.MustParseMoney("-20"):
+			t.Errorf("expected closing balance -20.00 got %s", next.ClosingBalance)
+		case next.MinimumPayment != models.MustParseMoney("10"):
+			t.Errorf("expected minimum payment 10.00 got %s", next.MinimumPayment)
+		}
+	})
+
+	t.Run("Closing balance ignores what happened after the cycle", func(t *testing.T) {
+		account := createAccount(t, services)
+		if err := services.BackdateAccount(account.AccountID, now.AddDate(0, 0, -45)); err != nil {
+			t.Fatalf("unable to backdate account [%s]", err)
+		}
+
+		credit := createTransaction(t, services, account.AccountID, models.CreditVoucher, "100")
+		if err := services.Backdate(credit.TransactionID, now.AddDate(0, 0, -40)); err != nil {
+			t.Fatalf("unable to backdate transaction [%s]", err)
+		}
+
+		// neither the later credit nor the purchase it pays may touch the closed cycle
+		createTransaction(t, services, account.AccountID, models.CreditVoucher, "50")
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "30")
+
+		if _, err := services.StatementService.GenerateDue(ctx, now); err != nil {
+			t.Fatalf("unable to generate statements [%s]", err)
+		}
+
+		statements, err := services.StatementService.ListForAccount(ctx, account.AccountID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to list statements [%s]", err)
+		case len(statements) == 0:
+			t.Fatalf("expected a statement")
+		case statements[0].ClosingBalance != models.MustParseMoney("100"):
+			t.Errorf("expected closing balance 100.00 got %s", statements[0].ClosingBalance)
+		}
+
+		// the purchase was settled with the credit after the cycle closed, its line keeps the whole credit
+		lines := 0
+		for _, listed := range statements {
+			statement, err := services.StatementService.GetForID(ctx, listed.ID)
+			if err != nil {
+				t.Fatalf("unable to fetch statement [%s]", err)
+			}
+			for _, line := range statement.Lines {
+				lines++
+				if line.TransactionID != credit.TransactionID || line.Balance != models.MustParseMoney("100") {
+					t.Errorf("expected the credit with balance 100.00 got %+v", line)
+				}
+			}
+		}
+		if lines != 1 {
+			t.Errorf("expected 1 line got %d", lines)
+		}
+	})
+
+	t.Run("Every missed cycle has a statement", func(t *testing.T) {
+		account := createAccount(t, services)
+		if _, err := services.AccountsService.UpdateBillingCycle(ctx, account.AccountID, &closingDay, &dueDay); err != nil {
+			t.Fatalf("unable to update billing cycle [%s]", err)
+		}
+
+		createdAt := now.AddDate(0, -3, 0).Truncate(time.Second)
+		if err := services.BackdateAccount(account.AccountID, createdAt); err != nil {
+			t.Fatalf("unable to backdate account [%s]", err)
+		}
+
+		if _, err := services.StatementService.GenerateDue(ctx, now); err != nil {
+			t.Fatalf("unable to generate statements [%s]", err)
+		}
+
+		statements, err := services.StatementService.ListForAccount(ctx, account.AccountID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to list statements [%s]", err)
+		case len(statements) < 3:
+			t.Fatalf("expected a statement for each of the last 3 cycles got %d", len(statements))
+		case !statements[0].PeriodEnd.Equal(models.LastClosingDate(closingDay, now)):
+			t.Errorf("expected the last cycle to close at %s got %s", models.LastClosingDate(closingDay, now), statements[0].PeriodEnd)
+		case !statements[len(statements)-1].PeriodStart.Equal(createdAt):
+			t.Errorf("expected the first cycle to start at %s got %s", createdAt, statements[len(statements)-1].PeriodStart)
+		}
+
+		for i := 1; i < len(statements); i++ {
+			if !statements[i-1].PeriodStart.Equal(statements[i].PeriodEnd) {
+				t.Errorf("expected the cycle to start at %s got %s", statements[i].PeriodEnd, statements[i-1].PeriodStart)
+			}
+		}
+	})
+
+	t.Run("Missing records", func(t *testing.T) {
+
+		if _, err := services.StatementService.GetForID(ctx, int64(testutils.GenerateRandomNumberInt(10))); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %v got %v", models.NoRecordErr, err)
+		}
+
+		if _, err := services.StatementService.ListForAccount(ctx, int64(testutils.GenerateRandomNumberInt(10))); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %v got %v", models.NoRecordErr, err)
+		}
+	})
+}
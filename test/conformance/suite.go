@@ -0,0 +1,107 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+// Services are the implementations under test, they must share the same backing store
+// and settle with the strategies of models.NewSettlementStrategies
+type Services struct {
+	AccountsService      models.AccountsService
+	TransactionService   models.TransactionService
+	OperationTypeService models.OperationTypeService
+	InstallmentService   models.InstallmentService
+	// AuthorizationService holds must expire after an hour
+	AuthorizationService models.AuthorizationService
+	TransferService      models.TransferService
+	LedgerService        models.LedgerService
+	AllocationService    models.AllocationService
+	StatementService     models.StatementService
+	// AccrualService must charge late fees of 10.00
+	AccrualService     models.AccrualService
+	ScheduleService    models.ScheduleService
+	IdempotencyService models.IdempotencyService
+	// FXService quotes must lock their rate for a minute
+	FXService      models.FXService
+	OutboxService  models.OutboxService
+	WebhookService models.WebhookService
+	// AccountEventService must signal the events written through the other services once it listens
+	AccountEventService models.AccountEventService
+
+	// Backdate moves the event date of a transaction, to book it in a cycle or day that already closed
+	Backdate func(transactionID int64, eventDate time.Time) error
+	// BackdateAccount moves the opening date of an account, to open it before cycles that already closed
+	BackdateAccount func(accountID int64, createdAt time.Time) error
+}
+
+// NewServicesFunc returns fresh services for a test run
+type NewServicesFunc func(t *testing.T) Services
+
+// Run checks that an implementation of the services behaves like the reference postgres one
+func Run(t *testing.T, newServices NewServicesFunc) {
+	t.Run("Accounts", func(t *testing.T) { testAccounts(t, newServices(t)) })
+	t.Run("Settlement", func(t *testing.T) { testSettlement(t, newServices(t)) })
+	t.Run("List transactions", func(t *testing.T) { testListTransactions(t, newServices(t)) })
+	t.Run("Balance", func(t *testing.T) { testBalance(t, newServices(t)) })
+	t.Run("Idempotency", func(t *testing.T) { testIdempotency(t, newServices(t)) })
+	t.Run("Operation types", func(t *testing.T) { testOperationTypes(t, newServices(t)) })
+	t.Run("Reversal", func(t *testing.T) { testReversal(t, newServices(t)) })
+	t.Run("Installments", func(t *testing.T) { testInstallments(t, newServices(t)) })
+	t.Run("Credit limit", func(t *testing.T) { testCreditLimit(t, newServices(t)) })
+	t.Run("Authorizations", func(t *testing.T) { testAuthorizations(t, newServices(t)) })
+	t.Run("Transfers", func(t *testing.T) { testTransfers(t, newServices(t)) })
+	t.Run("Ledger", func(t *testing.T) { testLedger(t, newServices(t)) })
+	t.Run("Allocations", func(t *testing.T) { testAllocations(t, newServices(t)) })
+	t.Run("Settlement strategies", func(t *testing.T) { testSettlementStrategies(t, newServices(t)) })
+	t.Run("Statements", func(t *testing.T) { testStatements(t, newServices(t)) })
+	t.Run("Accruals", func(t *testing.T) { testAccruals(t, newServices(t)) })
+	t.Run("Schedules", func(t *testing.T) { testSchedules(t, newServices(t)) })
+	t.Run("Account status", func(t *testing.T) { testAccountStatus(t, newServices(t)) })
+	t.Run("Currencies", func(t *testing.T) { testCurrencies(t, newServices(t)) })
+	t.Run("FX", func(t *testing.T) { testFX(t, newServices(t)) })
+	t.Run("Outbox", func(t *testing.T) { testOutbox(t, newServices(t)) })
+	t.Run("Webhooks", func(t *testing.T) { testWebhooks(t, newServices(t)) })
+	t.Run("Account events", func(t *testing.T) { testAccountEvents(t, newServices(t)) })
+}
+
+func createAccount(t *testing.T, services Services) models.Account {
+	account, err := services.AccountsService.Create(context.Background(), models.Account{
+		DocumentNumber: testutils.GenerateRandomNumber(10),
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+	return account
+}
+
+func createTransaction(t *testing.T, services Services, accountID int64, operationTypeID models.OperationTypeID, amount string) models.TransactionStatus {
+
+	money := models.MustParseMoney(amount)
+	if operationTypeID != models.CreditVoucher {
+		money = -money
+	}
+
+	transactionStatus, err := services.TransactionService.Create(context.Background(), models.Transaction{
+		AccountID:       accountID,
+		OperationTypeID: int64(operationTypeID),
+		Amount:          money,
+	})
+	if err != nil {
+		t.Fatalf("unable to create transaction [%s]", err)
+	}
+	return transactionStatus
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event models.Event) error {
+	if p.fail[event.ID] > 0 {
+		p.fail[event.ID]--
+		return fmt.Errorf("unable to publish event %d", event.ID)
+	}
+	p.published = append(p.published, event)
+	return nil
+}
@@ -0,0 +1,144 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func testTransfers(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	limit := models.MustParseMoney("100")
+	source, err := services.AccountsService.Create(ctx, models.Account{
+		DocumentNumber:       testutils.GenerateRandomNumber(10),
+		AvailableCreditLimit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	destination := createAccount(t, services)
+	debt := createTransaction(t, services, destination.AccountID, models.NormalPurchase, "30")
+
+	t.Run("Transfer settles the debts of the destination", func(t *testing.T) {
+		transfer, err := services.TransferService.Create(ctx, models.Transfer{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: destination.AccountID,
+			Amount:               models.MustParseMoney("50"),
+		})
+		switch {
+		case err != nil:
+			t.Fatalf("unable to create transfer [%s]", err)
+		case transfer.DebitTransactionID == nil || transfer.CreditTransactionID == nil:
+			t.Fatalf("expected both transactions of the transfer")
+		}
+
+		expected := []struct {
+			transactionID int64
+			accountID     int64
+			amount        string
+			balance       string
+		}{
+			{*transfer.DebitTransactionID, source.AccountID, "-50", "-50"},
+			{*transfer.CreditTransactionID, destination.AccountID, "50", "20"},
+			{debt.TransactionID, destination.AccountID, "-30", "0"},
+		}
+
+		for _, e := range expected {
+			transaction, err := services.TransactionService.GetForID(ctx, e.transactionID)
+			switch {
+			case err != nil:
+				t.Errorf("unable to fetch transaction [%s]", err)
+			case transaction.AccountID != e.accountID:
+				t.Errorf("expected account %d got %d", e.accountID, transaction.AccountID)
+			case transaction.Amount != models.MustParseMoney(e.amount):
+				t.Errorf("expected amount %s got %s", e.amount, transaction.Amount)
+			case transaction.Balance != models.MustParseMoney(e.balance):
+				t.Errorf("expected balance %s got %s", e.balance, transaction.Balance)
+			case e.transactionID != debt.TransactionID && (transaction.TransferID == nil || *transaction.TransferID != transfer.ID):
+				t.Errorf("expected transfer id %d got %v", transfer.ID, transaction.TransferID)
+			}
+		}
+
+		rtransfer, err := services.TransferService.GetForID(ctx, transfer.ID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch transfer [%s]", err)
+		case rtransfer.Amount != transfer.Amount || rtransfer.SourceAccountID != source.AccountID:
+			t.Errorf("expected transfer %+v got %+v", transfer, rtransfer)
+		}
+	})
+
+	t.Run("Legs of a transfer are not reversible", func(t *testing.T) {
+		transfer, err := services.TransferService.Create(ctx, models.Transfer{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: destination.AccountID,
+			Amount:               models.MustParseMoney("10"),
+		})
+		if err != nil {
+			t.Fatalf("unable to create transfer [%s]", err)
+		}
+
+		for _, transactionID := range []int64{*transfer.DebitTransactionID, *transfer.CreditTransactionID} {
+			_, err := services.TransactionService.Reverse(ctx, transactionID, 0)
+			if !errors.Is(err, models.NotReversibleErr) {
+				t.Errorf("expected %s got %v", models.NotReversibleErr, err)
+			}
+		}
+
+		transaction, err := services.TransactionService.GetForID(ctx, *transfer.DebitTransactionID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch transaction [%s]", err)
+		case transaction.Balance != models.MustParseMoney("-10"):
+			t.Errorf("expected balance -10 got %s", transaction.Balance)
+		}
+	})
+
+	t.Run("Transfer over the limit", func(t *testing.T) {
+		_, err := services.TransferService.Create(ctx, models.Transfer{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: destination.AccountID,
+			Amount:               models.MustParseMoney("50.01"),
+		})
+		if !errors.Is(err, models.InsufficientLimitErr) {
+			t.Errorf("expected %s got %v", models.InsufficientLimitErr, err)
+		}
+
+		page, err := services.TransactionService.ListForAccount(ctx, models.TransactionFilter{AccountID: destination.AccountID})
+		switch {
+		case err != nil:
+			t.Errorf("unable to list transactions [%s]", err)
+		case len(page.Transactions) != 3:
+			t.Errorf("expected the rejected transfer not to be recorded got %d transactions", len(page.Transactions))
+		}
+	})
+
+	t.Run("Rejected transfers", func(t *testing.T) {
+		_, err := services.TransferService.Create(ctx, models.Transfer{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: source.AccountID,
+			Amount:               models.MustParseMoney("1"),
+		})
+		if !errors.Is(err, models.SameAccountTransferErr) {
+			t.Errorf("expected %s got %v", models.SameAccountTransferErr, err)
+		}
+
+		_, err = services.TransferService.Create(ctx, models.Transfer{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: int64(testutils.GenerateRandomNumberInt(10)),
+			Amount:               models.MustParseMoney("1"),
+		})
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+
+		_, err = services.TransferService.GetForID(ctx, int64(testutils.GenerateRandomNumberInt(10)))
+		if !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected %s got %v", models.NoRecordErr, err)
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"payments-backend-app/pkg/models"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func testWebhooks(t *testing.T, services Services) {
+	ctx := context.Background()
+
+	// webhooks are scoped to an account so that the tests ignore the events of the others
+	createWebhook := func(t *testing.T, accountID int64, url string, eventTypes ...models.EventType) models.Webhook {
+		webhook, err := services.WebhookService.Create(ctx, models.Webhook{
+			URL:        url,
+			EventTypes: eventTypes,
+			AccountID:  &accountID,
+			Secret:     models.NewWebhookSecret(),
+		})
+		if err != nil {
+			t.Fatalf("unable to create webhook [%s]", err)
+		}
+		return webhook
+	}
+
+	listDeliveries := func(t *testing.T, webhookID int64) []models.WebhookDelivery {
+		deliveries, err := services.WebhookService.ListDeliveries(ctx, webhookID)
+		if err != nil {
+			t.Fatalf("unable to list deliveries [%s]", err)
+		}
+		return deliveries
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		account := createAccount(t, services)
+		webhook := createWebhook(t, account.AccountID, "http://localhost/hook", models.TransactionCreatedEvent)
+		if webhook.ID == 0 || webhook.Secret == "" {
+			t.Fatalf("expected an id and a secret got %+v", webhook)
+		}
+
+		rwebhook, err := services.WebhookService.GetForID(ctx, webhook.ID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to get webhook [%s]", err)
+		case rwebhook.Secret != "":
+			t.Errorf("expected the secret to be hidden")
+		case rwebhook.URL != webhook.URL || fmt.Sprint(rwebhook.EventTypes) != fmt.Sprint(webhook.EventTypes):
+			t.Errorf("expected webhook %+v got %+v", webhook, rwebhook)
+		}
+
+		missing := int64(1_000_000_000)
+		if _, err := services.WebhookService.Create(ctx, models.Webhook{URL: "http://localhost/hook", EventTypes: []models.EventType{models.TransactionCreatedEvent}, AccountID: &missing, Secret: "secret"}); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected error %v for a missing account got %v", models.NoRecordErr, err)
+		}
+
+		if _, err := services.WebhookService.ListDeliveries(ctx, missing); !errors.Is(err, models.NoRecordErr) {
+			t.Errorf("expected error %v for a missing webhook got %v", models.NoRecordErr, err)
+		}
+	})
+
+	t.Run("Deliveries are enqueued with the event", func(t *testing.T) {
+		account := createAccount(t, services)
+		other := createAccount(t, services)
+
+		created := createWebhook(t, account.AccountID, "http://localhost/created", models.TransactionCreatedEvent)
+		settled := createWebhook(t, account.AccountID, "http://localhost/settled", models.TransactionSettledEvent)
+		unrelated := createWebhook(t, other.AccountID, "http://localhost/other", models.TransactionCreatedEvent)
+
+		purchase := createTransaction(t, services, account.AccountID, models.NormalPurchase, "10")
+		createTransaction(t, services, account.AccountID, models.CreditVoucher, "10")
+
+		deliveries := listDeliveries(t, created.ID)
+		if len(deliveries) != 2 {
+			t.Fatalf("expected 2 deliveries got %d", len(deliveries))
+		}
+
+		// newest first
+		delivery := deliveries[1]
+		var payload struct {
+			ID        int64            `json:"id"`
+			Type      models.EventType `json:"type"`
+			AccountID int64            `json:"account_id"`
+			Data      struct {
+				ID int64 `json:"id"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(delivery.Payload, &payload); err != nil {
+			t.Fatalf("unable to unmarshal payload [%s]", err)
+		}
+
+		switch {
+		case delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 0:
+			t.Errorf("expected a pending delivery got %s after %d attempts", delivery.Status, delivery.Attempts)
+		case payload.ID != delivery.EventID || payload.Type != models.TransactionCreatedEvent || payload.AccountID != account.AccountID:
+			t.Errorf("expected the event %d in the payload got %s", delivery.EventID, string(delivery.Payload))
+		case payload.Data.ID != purchase.TransactionID:
+			t.Errorf("expected transaction %d in the payload got %s", purchase.TransactionID, string(delivery.Payload))
+		}
+
+		if deliveries := listDeliveries(t, settled.ID); len(deliveries) != 2 {
+			t.Errorf("expected 2 settled deliveries got %d", len(deliveries))
+		}
+
+		if deliveries := listDeliveries(t, unrelated.ID); len(deliveries) != 0 {
+			t.Errorf("expected no deliveries for another account got %d", len(deliveries))
+		}
+	})
+
+	t.Run("Deliveries are signed and retried", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		var webhook models.Webhook
+		calls := 0
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			timestamp, _ := strconv.ParseInt(r.Header.Get(models.WebhookTimestampHeader), 10, 64)
+			if r.Header.Get(models.WebhookSignatureHeader) != models.SignWebhookPayload(webhook.Secret, timestamp, body) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			calls++
+			if calls == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		webhook = createWebhook(t, account.AccountID, receiver.URL, models.AccountCreatedEvent, models.TransactionCreatedEvent)
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "10")
+
+		dispatcher := models.NewWebhookDispatcher(services.WebhookService, receiver.Client(), 3, time.Minute)
+		now := time.Now()
+
+		if _, err := dispatcher.DeliverDue(ctx, now); err != nil {
+			t.Fatalf("unable to deliver [%s]", err)
+		}
+
+		deliveries := listDeliveries(t, webhook.ID)
+		switch {
+		case len(deliveries) != 1:
+			t.Fatalf("expected a single delivery got %d", len(deliveries))
+		case deliveries[0].Status != models.WebhookDeliveryPending || deliveries[0].Attempts != 1:
+			t.Fatalf("expected a pending delivery after an attempt got %s after %d", deliveries[0].Status, deliveries[0].Attempts)
+		case deliveries[0].ResponseStatus == nil || *deliveries[0].ResponseStatus != http.StatusInternalServerError || deliveries[0].LastError == "":
+			t.Errorf("expected the failed attempt recorded got %+v", deliveries[0])
+		case deliveries[0].NextAttemptAt.Before(now.Add(time.Minute).Add(-time.Second)):
+			t.Errorf("expected the next attempt a minute later got %s", deliveries[0].NextAttemptAt)
+		}
+
+		// the retry is not due yet
+		if _, err := dispatcher.DeliverDue(ctx, now.Add(30*time.Second)); err != nil {
+			t.Fatalf("unable to deliver [%s]", err)
+		}
+		if calls != 1 {
+			t.Fatalf("expected no retry before the backoff got %d calls", calls)
+		}
+
+		if _, err := dispatcher.DeliverDue(ctx, now.Add(time.Minute)); err != nil {
+			t.Fatalf("unable to deliver [%s]", err)
+		}
+
+		deliveries = listDeliveries(t, webhook.ID)
+		switch {
+		case deliveries[0].Status != models.WebhookDeliverySucceeded || deliveries[0].Attempts != 2:
+			t.Errorf("expected a succeeded delivery after 2 attempts got %s after %d", deliveries[0].Status, deliveries[0].Attempts)
+		case deliveries[0].ResponseStatus == nil || *deliveries[0].ResponseStatus != http.StatusNoContent || deliveries[0].LastError != "":
+			t.Errorf("expected the successful attempt recorded got %+v", deliveries[0])
+		}
+	})
+
+	t.Run("Deliveries out of attempts are dead lettered", func(t *testing.T) {
+		account := createAccount(t, services)
+
+		calls := 0
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer receiver.Close()
+
+		webhook := createWebhook(t, account.AccountID, receiver.URL, models.TransactionCreatedEvent)
+		createTransaction(t, services, account.AccountID, models.NormalPurchase, "10")
+
+		dispatcher := models.NewWebhookDispatcher(services.WebhookService, receiver.Client(), 2, time.Minute)
+		now := time.Now()
+
+		// the second retry waits twice as long as the first
+		for _, at := range []time.Time{now, now.Add(time.Minute), now.Add(3 * time.Minute), now.Add(time.Hour)} {
+			if _, err := dispatcher.DeliverDue(ctx, at); err != nil {
+				t.Fatalf("unable to deliver [%s]", err)
+			}
+		}
+
+		deliveries := listDeliveries(t, webhook.ID)
+		switch {
+		case deliveries[0].Status != models.WebhookDeliveryDeadLetter || deliveries[0].Attempts != 2:
+			t.Errorf("expected a dead lettered delivery after 2 attempts got %s after %d", deliveries[0].Status, deliveries[0].Attempts)
+		case calls != 2:
+			t.Errorf("expected 2 calls got %d", calls)
+		}
+	})
+}
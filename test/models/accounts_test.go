@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestAccountChangeStatus(t *testing.T) {
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	type TestData struct {
+		from     models.AccountStatus
+		to       models.AccountStatus
+		expected error
+	}
+
+	tests := []TestData{
+		{from: models.AccountActive, to: models.AccountFrozen},
+		{from: models.AccountActive, to: models.AccountClosed},
+		{from: models.AccountFrozen, to: models.AccountActive},
+		{from: models.AccountFrozen, to: models.AccountClosed},
+		{from: models.AccountActive, to: models.AccountActive, expected: models.InvalidAccountStatusTransitionErr},
+		{from: models.AccountFrozen, to: models.AccountFrozen, expected: models.InvalidAccountStatusTransitionErr},
+		{from: models.AccountClosed, to: models.AccountActive, expected: models.InvalidAccountStatusTransitionErr},
+		{from: models.AccountClosed, to: models.AccountFrozen, expected: models.InvalidAccountStatusTransitionErr},
+		{from: models.AccountActive, to: "deleted", expected: models.InvalidAccountStatusTransitionErr},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.from)+" to "+string(test.to), func(t *testing.T) {
+			account := models.Account{AccountID: 7, Status: test.from}
+
+			change, err := account.ChangeStatus(test.to, "reason", now)
+			switch {
+			case !errors.Is(err, test.expected):
+				t.Errorf("expected %v got %v", test.expected, err)
+			case err != nil && account.Status != test.from:
+				t.Errorf("expected the status to stay %s got %s", test.from, account.Status)
+			case err == nil && account.Status != test.to:
+				t.Errorf("expected status %s got %s", test.to, account.Status)
+			case err == nil && (change.AccountID != 7 || change.FromStatus != test.from || change.ToStatus != test.to || !change.CreatedAt.Equal(now)):
+				t.Errorf("unexpected status change %+v", change)
+			}
+		})
+	}
+}
+
+func TestAccountAcceptsTransaction(t *testing.T) {
+
+	type TestData struct {
+		status          models.AccountStatus
+		operationTypeID models.OperationTypeID
+		expected        bool
+	}
+
+	tests := []TestData{
+		{status: models.AccountActive, operationTypeID: models.NormalPurchase, expected: true},
+		{status: models.AccountActive, operationTypeID: models.Interest, expected: true},
+		{status: models.AccountFrozen, operationTypeID: models.NormalPurchase, expected: false},
+		{status: models.AccountFrozen, operationTypeID: models.CreditVoucher, expected: false},
+		{status: models.AccountFrozen, operationTypeID: models.Interest, expected: true},
+		{status: models.AccountFrozen, operationTypeID: models.LateFee, expected: true},
+		{status: models.AccountClosed, operationTypeID: models.CreditVoucher, expected: false},
+		{status: models.AccountClosed, operationTypeID: models.Interest, expected: false},
+	}
+
+	for _, test := range tests {
+		account := models.Account{Status: test.status}
+		if accepts := account.AcceptsTransaction(int64(test.operationTypeID)); accepts != test.expected {
+			t.Errorf("expected %t for operation type %d on a %s account got %t", test.expected, test.operationTypeID, test.status, accepts)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestDailyInterest(t *testing.T) {
+
+	balance := func(amount string, aprBasisPoints int64) models.InterestBearingBalance {
+		return models.InterestBearingBalance{Balance: models.MustParseMoney(amount), APRBasisPoints: aprBasisPoints}
+	}
+
+	type TestData struct {
+		description string
+		balances    []models.InterestBearingBalance
+		expected    string
+	}
+
+	tests := []TestData{
+		{
+			description: "No debits",
+			balances:    nil,
+			expected:    "0",
+		},
+		{
+			description: "One debit",
+			balances:    []models.InterestBearingBalance{balance("-1000", 3650)},
+			expected:    "1",
+		},
+		{
+			description: "Rates per operation type",
+			balances:    []models.InterestBearingBalance{balance("-1000", 3650), balance("-500", 7300)},
+			expected:    "2",
+		},
+		{
+			description: "Credits and types without a rate are left out",
+			balances:    []models.InterestBearingBalance{balance("-1000", 3650), balance("200", 3650), balance("-800", 0)},
+			expected:    "1",
+		},
+		{
+			description: "Rounded once after adding up",
+			balances:    []models.InterestBearingBalance{balance("-2.50", 3650), balance("-2.50", 3650)},
+			expected:    "0.01",
+		},
+		{
+			description: "Rounded half up",
+			balances:    []models.InterestBearingBalance{balance("-5", 3650)},
+			expected:    "0.01",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			interest := models.DailyInterest(test.balances)
+			if interest != models.MustParseMoney(test.expected) {
+				t.Errorf("expected interest %s got %s", test.expected, interest)
+			}
+		})
+	}
+}
+
+func TestAccrualDay(t *testing.T) {
+
+	saoPaulo := time.FixedZone("BRT", -3*60*60)
+	day := models.AccrualDay(time.Date(2024, 3, 10, 22, 30, 0, 0, saoPaulo))
+
+	if !day.Equal(time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the day in UTC got %s", day)
+	}
+}
+
+func TestIsLateFeeDue(t *testing.T) {
+
+	statement := models.Statement{MinimumPayment: models.MustParseMoney("15")}
+
+	switch {
+	case !models.IsLateFeeDue(statement, 0):
+		t.Errorf("expected a late fee without payments")
+	case !models.IsLateFeeDue(statement, models.MustParseMoney("14.99")):
+		t.Errorf("expected a late fee below the minimum payment")
+	case models.IsLateFeeDue(statement, models.MustParseMoney("15")):
+		t.Errorf("expected no late fee once the minimum payment is paid")
+	case models.IsLateFeeDue(models.Statement{}, 0):
+		t.Errorf("expected no late fee for statements without debt")
+	}
+}
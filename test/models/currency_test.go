@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"payments-backend-app/pkg/models"
+	"testing"
+)
+
+func TestParseCurrency(t *testing.T) {
+
+	type TestData struct {
+		description string
+		code        string
+		expected    models.Currency
+		err         error
+	}
+
+	tests := []TestData{
+		{description: "Upper case", code: "BRL", expected: "BRL"},
+		{description: "Lower case", code: "usd", expected: "USD"},
+		{description: "Surrounding spaces", code: " JPY ", expected: "JPY"},
+		{description: "Unknown code", code: "XYZ", err: models.UnsupportedCurrencyErr},
+		{description: "Finer minor unit than tracked", code: "KWD", err: models.UnsupportedCurrencyErr},
+		{description: "Empty", code: "", err: models.UnsupportedCurrencyErr},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			currency, err := models.ParseCurrency(test.code)
+			switch {
+			case !errors.Is(err, test.err):
+				t.Errorf("expected error %v got %v", test.err, err)
+			case err == nil && currency != test.expected:
+				t.Errorf("expected %s got %s", test.expected, currency)
+			}
+		})
+	}
+}
+
+func TestCurrencyAmounts(t *testing.T) {
+
+	type TestData struct {
+		description string
+		currency    models.Currency
+		amount      string
+		rounded     string
+		err         error
+	}
+
+	tests := []TestData{
+		{description: "Cents", currency: "BRL", amount: "10.01", rounded: "10.01"},
+		{description: "Whole yen", currency: "JPY", amount: "10", rounded: "10"},
+		{description: "Yen with cents", currency: "JPY", amount: "10.49", rounded: "10", err: models.AmountPrecisionErr},
+		{description: "Half a yen rounds up", currency: "JPY", amount: "10.50", rounded: "11", err: models.AmountPrecisionErr},
+		{description: "Negative half a yen rounds away from zero", currency: "JPY", amount: "-10.50", rounded: "-11", err: models.AmountPrecisionErr},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			amount := models.MustParseMoney(test.amount)
+
+			if err := test.currency.CheckAmount(amount); !errors.Is(err, test.err) {
+				t.Errorf("expected error %v got %v", test.err, err)
+			}
+
+			if rounded := test.currency.Round(amount); rounded != models.MustParseMoney(test.rounded) {
+				t.Errorf("expected %s got %s", test.rounded, rounded)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"payments-backend-app/pkg/models"
+	"testing"
+)
+
+func TestDocumentValidators(t *testing.T) {
+
+	documents := models.NewDocumentValidators()
+
+	type TestData struct {
+		description  string
+		documentType models.DocumentType
+		number       string
+		expectedType models.DocumentType
+		expected     string
+		expectedCode string
+	}
+
+	tests := []TestData{
+		{
+			description:  "CPF",
+			number:       "52998224725",
+			expectedType: models.CPFDocument,
+			expected:     "52998224725",
+		},
+		{
+			description:  "Formatted CPF",
+			number:       "529.982.247-25",
+			expectedType: models.CPFDocument,
+			expected:     "52998224725",
+		},
+		{
+			description:  "CPF that lost its leading zero",
+			number:       "1234567890",
+			expectedType: models.CPFDocument,
+			expected:     "01234567890",
+		},
+		{
+			description:  "CNPJ",
+			number:       "11222333000181",
+			expectedType: models.CNPJDocument,
+			expected:     "11222333000181",
+		},
+		{
+			description:  "Formatted CNPJ",
+			number:       "11.222.333/0001-81",
+			expectedType: models.CNPJDocument,
+			expected:     "11222333000181",
+		},
+		{
+			description:  "Short CNPJ with its type",
+			documentType: models.CNPJDocument,
+			number:       "191",
+			expectedType: models.CNPJDocument,
+			expected:     "00000000000191",
+		},
+		{
+			description:  "Wrong first check digit",
+			number:       "52998224735",
+			expectedType: models.CPFDocument,
+			expectedCode: models.DocumentInvalidCheckDigitsCode,
+		},
+		{
+			description:  "Wrong second check digit",
+			number:       "11222333000182",
+			expectedType: models.CNPJDocument,
+			expectedCode: models.DocumentInvalidCheckDigitsCode,
+		},
+		{
+			description:  "Repeated digits",
+			number:       "00000000000",
+			expectedType: models.CPFDocument,
+			expectedCode: models.DocumentRepeatedDigitsCode,
+		},
+		{
+			description:  "Empty",
+			number:       "",
+			expectedCode: models.DocumentRequiredCode,
+		},
+		{
+			description:  "Spaces",
+			number:       " 52998224725",
+			expectedCode: models.DocumentInvalidCharactersCode,
+		},
+		{
+			description:  "Too many digits",
+			number:       "112223330001810",
+			expectedCode: models.DocumentInvalidLengthCode,
+		},
+		{
+			description:  "Unsupported type",
+			documentType: "rg",
+			number:       "52998224725",
+			expectedType: "rg",
+			expectedCode: models.DocumentUnsupportedTypeCode,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			documentType, number, err := documents.Validate(test.documentType, test.number)
+
+			if test.expectedCode != "" {
+				documentErr := &models.DocumentError{}
+				switch {
+				case !errors.As(err, &documentErr):
+					t.Errorf("expected a document error got %v", err)
+				case documentErr.Code != test.expectedCode:
+					t.Errorf("expected code %s got %s", test.expectedCode, documentErr.Code)
+				case documentType != test.expectedType:
+					t.Errorf("expected type %q got %q", test.expectedType, documentType)
+				}
+				return
+			}
+
+			switch {
+			case err != nil:
+				t.Errorf("unexpected error [%s]", err)
+			case documentType != test.expectedType || number != test.expected:
+				t.Errorf("expected %s %s got %s %s", test.expectedType, test.expected, documentType, number)
+			}
+		})
+	}
+}
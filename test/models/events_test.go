@@ -0,0 +1,117 @@
+package models
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestInProcessPublisher(t *testing.T) {
+	ctx := context.Background()
+
+	publisher := models.NewInProcessPublisher()
+
+	received := []string{}
+	publisher.Subscribe(func(_ context.Context, event models.Event) error {
+		received = append(received, fmt.Sprintf("first %d", event.ID))
+		return nil
+	})
+	publisher.Subscribe(func(_ context.Context, event models.Event) error {
+		if event.ID == 2 {
+			return fmt.Errorf("unable to handle event %d", event.ID)
+		}
+		received = append(received, fmt.Sprintf("second %d", event.ID))
+		return nil
+	})
+
+	if err := publisher.Publish(ctx, models.Event{ID: 1}); err != nil {
+		t.Fatalf("unable to publish [%s]", err)
+	}
+
+	if err := publisher.Publish(ctx, models.Event{ID: 2}); err == nil {
+		t.Errorf("expected the error of the failing handler")
+	}
+
+	if expected := "[first 1 second 1 first 2]"; fmt.Sprint(received) != expected {
+		t.Errorf("expected %s got %v", expected, received)
+	}
+}
+
+func TestFilePublisher(t *testing.T) {
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	publisher := models.NewFilePublisher(path)
+
+	for i := int64(1); i <= 3; i++ {
+		event, err := models.NewTransactionEvent(models.TransactionCreatedEvent, models.Transaction{ID: i, AccountID: 7, Amount: models.MustParseMoney("-10")}, time.Now())
+		if err != nil {
+			t.Fatalf("unable to create event [%s]", err)
+		}
+		event.ID = i
+
+		if err := publisher.Publish(ctx, event); err != nil {
+			t.Fatalf("unable to publish [%s]", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unable to open events file [%s]", err)
+	}
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+
+		event := models.Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("unable to unmarshal line %d [%s]", lines, err)
+		}
+
+		transaction := models.Transaction{}
+		if err := json.Unmarshal(event.Payload, &transaction); err != nil {
+			t.Fatalf("unable to unmarshal payload of line %d [%s]", lines, err)
+		}
+
+		switch {
+		case event.ID != int64(lines) || event.AccountID != 7 || event.Type != models.TransactionCreatedEvent:
+			t.Errorf("expected event %d of account 7 got %+v", lines, event)
+		case transaction.ID != int64(lines) || transaction.Amount != models.MustParseMoney("-10"):
+			t.Errorf("expected transaction %d of -10.00 got %+v", lines, transaction)
+		}
+	}
+
+	if lines != 3 {
+		t.Errorf("expected 3 events got %d", lines)
+	}
+}
+
+func TestOutboxRetryBackoff(t *testing.T) {
+
+	tcs := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 1, expected: 5 * time.Second},
+		{attempts: 2, expected: 10 * time.Second},
+		{attempts: 5, expected: 80 * time.Second},
+		{attempts: 10, expected: 2560 * time.Second},
+		{attempts: 11, expected: time.Hour},
+		{attempts: 100, expected: time.Hour},
+	}
+
+	for _, tc := range tcs {
+		if backoff := models.OutboxRetryBackoff(5*time.Second, tc.attempts); backoff != tc.expected {
+			t.Errorf("expected %s after %d attempts got %s", tc.expected, tc.attempts, backoff)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestParseExchangeRate(t *testing.T) {
+
+	type TestData struct {
+		description string
+		rate        string
+		expected    models.ExchangeRate
+		formatted   string
+		invalid     bool
+	}
+
+	tests := []TestData{
+		{description: "Whole", rate: "5", expected: 500_000_000, formatted: "5"},
+		{description: "Decimal", rate: "5.1234", expected: 512_340_000, formatted: "5.1234"},
+		{description: "Full precision", rate: "0.00000001", expected: 1, formatted: "0.00000001"},
+		{description: "Trailing zeros", rate: "1.50", expected: 150_000_000, formatted: "1.5"},
+		{description: "Too precise", rate: "1.000000001", invalid: true},
+		{description: "Zero", rate: "0", invalid: true},
+		{description: "Negative", rate: "-1", invalid: true},
+		{description: "Fraction", rate: "1/3", invalid: true},
+		{description: "Hexadecimal", rate: "0x10", invalid: true},
+		{description: "Underscores", rate: "1_000", invalid: true},
+		{description: "Not a number", rate: "abc", invalid: true},
+		{description: "Huge exponent", rate: "1e9999999", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			rate, err := models.ParseExchangeRate(test.rate)
+			switch {
+			case test.invalid && err == nil:
+				t.Errorf("expected an error got rate %s", rate)
+			case !test.invalid && err != nil:
+				t.Errorf("unexpected error [%s]", err)
+			case !test.invalid && rate != test.expected:
+				t.Errorf("expected %d got %d", test.expected, rate)
+			case !test.invalid && rate.String() != test.formatted:
+				t.Errorf("expected %s got %s", test.formatted, rate.String())
+			}
+		})
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var rates []models.ExchangeRate
+		if err := json.Unmarshal([]byte(`[5.25, "0.2"]`), &rates); err != nil {
+			t.Fatalf("unable to unmarshal rates [%s]", err)
+		}
+
+		ba, err := json.Marshal(rates)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to marshal rates [%s]", err)
+		case string(ba) != "[5.25,0.2]":
+			t.Errorf("expected [5.25,0.2] got %s", string(ba))
+		}
+	})
+}
+
+func TestExchangeRateConvert(t *testing.T) {
+
+	type TestData struct {
+		description string
+		rate        string
+		amount      string
+		to          models.Currency
+		expected    string
+	}
+
+	tests := []TestData{
+		{description: "Exact", rate: "5", amount: "10", to: "BRL", expected: "50"},
+		{description: "Rounds down", rate: "5.1234", amount: "10", to: "BRL", expected: "51.23"},
+		{description: "Rounds up", rate: "5.1234", amount: "10.01", to: "BRL", expected: "51.29"},
+		{description: "Half a cent rounds up", rate: "0.5", amount: "0.01", to: "USD", expected: "0.01"},
+		{description: "Negative half a cent rounds away from zero", rate: "0.5", amount: "-0.01", to: "USD", expected: "-0.01"},
+		{description: "Whole yen", rate: "149.876", amount: "10.00", to: "JPY", expected: "1499"},
+		{description: "Too small to convert", rate: "0.0001", amount: "0.01", to: "USD", expected: "0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			rate := models.MustParseExchangeRate(test.rate)
+			if converted := rate.Convert(models.MustParseMoney(test.amount), test.to); converted != models.MustParseMoney(test.expected) {
+				t.Errorf("expected %s got %s", test.expected, converted)
+			}
+		})
+	}
+}
+
+func TestFXQuoteExpiry(t *testing.T) {
+
+	now := time.Now()
+	quote := models.FXQuote{CreatedAt: now, ExpiresAt: now.Add(30 * time.Second)}
+
+	switch {
+	case quote.IsExpired(now):
+		t.Errorf("expected the quote locked when created")
+	case !quote.IsExpired(quote.ExpiresAt):
+		t.Errorf("expected the quote expired at its expiry time")
+	}
+}
+
+func TestFXQuoteCheckBooks(t *testing.T) {
+
+	now := time.Now()
+	rate := models.MustParseExchangeRate("5.25")
+	otherRate := models.MustParseExchangeRate("5.5")
+	quote := models.FXQuote{ID: 1, From: "USD", To: "BRL", Rate: rate, CreatedAt: now, ExpiresAt: now.Add(30 * time.Second)}
+	used := quote
+	used.UsedAt = &now
+
+	transaction := models.Transaction{Currency: "BRL", OriginalCurrency: "USD", FXRate: &rate, FXQuoteID: &quote.ID}
+
+	tests := []struct {
+		description string
+		quote       models.FXQuote
+		transaction models.Transaction
+		at          time.Time
+		expected    error
+	}{
+		{description: "Unused quote", quote: quote, transaction: transaction, at: now},
+		{description: "Used quote", quote: used, transaction: transaction, at: now, expected: models.FXQuoteUsedErr},
+		{description: "Expired quote", quote: quote, transaction: transaction, at: quote.ExpiresAt, expected: models.FXQuoteExpiredErr},
+		{description: "Other currency", quote: quote, transaction: models.Transaction{Currency: "MXN", OriginalCurrency: "USD", FXRate: &rate}, at: now, expected: models.FXQuoteMismatchErr},
+		{description: "Other rate", quote: quote, transaction: models.Transaction{Currency: "BRL", OriginalCurrency: "USD", FXRate: &otherRate}, at: now, expected: models.FXQuoteMismatchErr},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if err := test.quote.CheckBooks(test.transaction, test.at); !errors.Is(err, test.expected) {
+				t.Errorf("expected %v got %v", test.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestNewInstallmentSchedule(t *testing.T) {
+
+	type TestData struct {
+		description string
+		total       string
+		currency    models.Currency
+		count       int
+		start       time.Time
+		amounts     []string
+		dueDates    []time.Time
+	}
+
+	tests := []TestData{
+		{
+			description: "Even split",
+			total:       "90",
+			count:       3,
+			start:       time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			amounts:     []string{"30", "30", "30"},
+			dueDates: []time.Time{
+				time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 4, 10, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			description: "Remainder on the first installment",
+			total:       "100",
+			count:       3,
+			start:       time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			amounts:     []string{"33.34", "33.33", "33.33"},
+		},
+		{
+			description: "Whole units of a currency without cents",
+			total:       "1000",
+			currency:    "JPY",
+			count:       3,
+			start:       time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			amounts:     []string{"334", "333", "333"},
+		},
+		{
+			description: "End of month",
+			total:       "0.04",
+			count:       4,
+			start:       time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+			amounts:     []string{"0.01", "0.01", "0.01", "0.01"},
+			dueDates: []time.Time{
+				time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC),
+				time.Date(2024, 4, 30, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			currency := test.currency
+			if currency == "" {
+				currency = models.DefaultCurrency
+			}
+
+			schedule := models.NewInstallmentSchedule(models.MustParseMoney(test.total), test.count, test.start, currency)
+			if len(schedule) != test.count {
+				t.Fatalf("expected %d installments got %d", test.count, len(schedule))
+			}
+
+			var sum models.Money
+			for i, installment := range schedule {
+				sum += installment.Amount
+
+				if installment.Number != i+1 {
+					t.Errorf("expected number %d got %d", i+1, installment.Number)
+				}
+				if installment.Amount != models.MustParseMoney(test.amounts[i]) {
+					t.Errorf("installment %d expected amount %s got %s", i+1, test.amounts[i], installment.Amount)
+				}
+				if test.dueDates != nil && !installment.DueDate.Equal(test.dueDates[i]) {
+					t.Errorf("installment %d expected due date %s got %s", i+1, test.dueDates[i], installment.DueDate)
+				}
+			}
+
+			if sum != models.MustParseMoney(test.total) {
+				t.Errorf("expected installments to sum to %s got %s", test.total, sum)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"payments-backend-app/pkg/models"
+	"testing"
+)
+
+func TestNewJournalEntry(t *testing.T) {
+
+	type TestData struct {
+		description     string
+		operationTypeID models.OperationTypeID
+		amount          string
+		counterpart     models.LedgerAccount
+	}
+
+	tests := []TestData{
+		{
+			description:     "Purchase",
+			operationTypeID: models.NormalPurchase,
+			amount:          "-50",
+			counterpart:     models.MerchantClearingLedgerAccount,
+		},
+		{
+			description:     "Withdrawal",
+			operationTypeID: models.Withdrawal,
+			amount:          "-20",
+			counterpart:     models.CashLedgerAccount,
+		},
+		{
+			description:     "Credit voucher",
+			operationTypeID: models.CreditVoucher,
+			amount:          "60",
+			counterpart:     models.CashLedgerAccount,
+		},
+		{
+			description:     "Reversal",
+			operationTypeID: models.PurchaseReversal,
+			amount:          "50",
+			counterpart:     models.MerchantClearingLedgerAccount,
+		},
+		{
+			description:     "Transfer",
+			operationTypeID: models.TransferIn,
+			amount:          "10",
+			counterpart:     models.TransferClearingLedgerAccount,
+		},
+		{
+			description:     "Interest",
+			operationTypeID: models.Interest,
+			amount:          "-1.23",
+			counterpart:     models.FeeIncomeLedgerAccount,
+		},
+		{
+			description:     "Operation type added through the api",
+			operationTypeID: 100,
+			amount:          "5",
+			counterpart:     models.CashLedgerAccount,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			amount := models.MustParseMoney(test.amount)
+			entry := models.NewJournalEntry(models.Transaction{
+				ID:              1,
+				AccountID:       7,
+				OperationTypeID: int64(test.operationTypeID),
+				Amount:          amount,
+			})
+
+			switch {
+			case !entry.IsBalanced():
+				t.Fatalf("expected a balanced entry got %+v", entry.Postings)
+			case entry.Postings[0].LedgerAccount != models.CustomerLedgerAccount || entry.Postings[0].AccountID == nil || *entry.Postings[0].AccountID != 7:
+				t.Errorf("expected the customer leg of account 7 got %+v", entry.Postings[0])
+			case entry.Postings[0].Amount != -amount:
+				t.Errorf("expected the customer leg to be %s got %s", -amount, entry.Postings[0].Amount)
+			case entry.Postings[1].LedgerAccount != test.counterpart || entry.Postings[1].AccountID != nil:
+				t.Errorf("expected counterpart %s got %+v", test.counterpart, entry.Postings[1])
+			case entry.Postings[0].Currency != models.DefaultCurrency || entry.Postings[1].Currency != models.DefaultCurrency:
+				t.Errorf("expected the postings in the default currency got %+v", entry.Postings)
+			}
+		})
+	}
+
+	t.Run("Unbalanced entries", func(t *testing.T) {
+
+		entry := models.JournalEntry{Postings: []models.Posting{{Amount: 10}, {Amount: -9}}}
+		if entry.IsBalanced() {
+			t.Errorf("expected entry not to be balanced")
+		}
+
+		entry = models.JournalEntry{Postings: []models.Posting{{Amount: 0}}}
+		if entry.IsBalanced() {
+			t.Errorf("expected a single leg entry not to be balanced")
+		}
+
+		entry = models.JournalEntry{Postings: []models.Posting{{Amount: 10, Currency: "BRL"}, {Amount: -10, Currency: "USD"}}}
+		if entry.IsBalanced() {
+			t.Errorf("expected amounts of different currencies not to offset each other")
+		}
+	})
+}
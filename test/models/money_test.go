@@ -0,0 +1,116 @@
+package models
+
+import (
+	"encoding/json"
+	"payments-backend-app/pkg/models"
+	"strings"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+
+	type TestData struct {
+		description string
+		input       string
+		expected    models.Money
+		expectErr   bool
+	}
+
+	tests := []TestData{
+		{description: "Whole amount", input: "50", expected: 5000},
+		{description: "One decimal place", input: "23.5", expected: 2350},
+		{description: "Two decimal places", input: "123.11", expected: 12311},
+		{description: "Trailing zeros", input: "18.700", expected: 1870},
+		{description: "Negative amount", input: "-0.01", expected: -1},
+		{description: "Exponent", input: "1.5e2", expected: 15000},
+		{description: "Three decimal places", input: "123.111", expectErr: true},
+		{description: "Not a number", input: "abc", expectErr: true},
+		{description: "Fraction", input: "1/3", expectErr: true},
+		{description: "Hexadecimal", input: "0x10", expectErr: true},
+		{description: "Hexadecimal with a binary exponent", input: "0x1p4", expectErr: true},
+		{description: "Binary", input: "0b101", expectErr: true},
+		{description: "Octal", input: "0o17", expectErr: true},
+		{description: "Underscores", input: "1_000", expectErr: true},
+		{description: "Binary exponent", input: "1p4", expectErr: true},
+		{description: "Leading dot", input: ".5", expectErr: true},
+		{description: "Trailing dot", input: "5.", expectErr: true},
+		{description: "Out of range", input: "100000000000000000000", expectErr: true},
+		{description: "Largest amount", input: "100000000000", expected: models.MaxMoney},
+		{description: "Largest negative amount", input: "-100000000000", expected: -models.MaxMoney},
+		{description: "Above the largest amount", input: "100000000000.01", expectErr: true},
+		{description: "Below the largest negative amount", input: "-100000000000.01", expectErr: true},
+		{description: "Huge exponent", input: "1e9999999", expectErr: true},
+		{description: "Huge negative exponent", input: "1e-9999999", expectErr: true},
+		{description: "Too many digits", input: "0." + strings.Repeat("0", 100), expectErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			money, err := models.ParseMoney(test.input)
+			switch {
+			case test.expectErr && err == nil:
+				t.Errorf("expected error for %s got %d", test.input, money)
+			case !test.expectErr && err != nil:
+				t.Errorf("unexpected error for %s [%s]", test.input, err)
+			case money != test.expected:
+				t.Errorf("expected %d got %d", test.expected, money)
+			}
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+
+	t.Run("Sum of amounts is exact", func(t *testing.T) {
+
+		var total models.Money
+		for i := 0; i < 10; i++ {
+			total += models.MustParseMoney("0.1")
+		}
+
+		if total != models.MustParseMoney("1") {
+			t.Errorf("expected 1.00 got %s", total)
+		}
+	})
+
+	t.Run("Marshal as a decimal number", func(t *testing.T) {
+
+		ba, err := json.Marshal(map[string]models.Money{"amount": -12305})
+		if err != nil {
+			t.Errorf("unable to marshal [%s]", err)
+		}
+
+		if string(ba) != `{"amount":-123.05}` {
+			t.Errorf("unexpected json %s", string(ba))
+		}
+	})
+
+	t.Run("Unmarshal number and string", func(t *testing.T) {
+
+		var req struct {
+			A models.Money `json:"a"`
+			B models.Money `json:"b"`
+		}
+
+		if err := json.Unmarshal([]byte(`{"a": 0.29, "b": "1.10"}`), &req); err != nil {
+			t.Errorf("unable to unmarshal [%s]", err)
+		}
+
+		if req.A != 29 || req.B != 110 {
+			t.Errorf("expected 29 and 110 got %d and %d", req.A, req.B)
+		}
+	})
+	t.Run("Unmarshal rejects amounts above the largest one", func(t *testing.T) {
+
+		var req struct {
+			A models.Money `json:"a"`
+		}
+
+		for _, data := range []string{`{"a": 100000000000.01}`, `{"a": "-100000000000.01"}`} {
+			if err := json.Unmarshal([]byte(data), &req); err == nil {
+				t.Errorf("expected an error for %s got %s", data, req.A)
+			}
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"context"
+	"payments-backend-app/internal/memory"
+	imodels "payments-backend-app/internal/models"
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+// blockingOperationTypeService holds the next List until it is told to go on
+type blockingOperationTypeService struct {
+	models.OperationTypeService
+	listing chan struct{}
+	proceed chan struct{}
+}
+
+func (s *blockingOperationTypeService) List(ctx context.Context) ([]models.OperationType, error) {
+	list, err := s.OperationTypeService.List(ctx)
+	if s.listing != nil {
+		close(s.listing)
+		<-s.proceed
+		s.listing = nil
+	}
+	return list, err
+}
+
+func TestCachedOperationTypeServiceDiscardsStaleLoads(t *testing.T) {
+	ctx := context.Background()
+
+	store := memory.NewStore(models.NewSettlementStrategies())
+	backing := &blockingOperationTypeService{
+		OperationTypeService: memory.NewOperationTypeService(store),
+		listing:              make(chan struct{}),
+		proceed:              make(chan struct{}),
+	}
+	cached := imodels.NewCachedOperationTypeService(backing, time.Hour)
+
+	// the load reads the operation types before the create and stores them after it
+	loaded := make(chan error)
+	go func() {
+		_, err := cached.List(ctx)
+		loaded <- err
+	}()
+	<-backing.listing
+
+	operationType, err := cached.Create(ctx, models.OperationType{Description: "Subscription", Direction: models.DebitDirection, Active: true})
+	if err != nil {
+		t.Fatalf("unable to create operation type [%s]", err)
+	}
+
+	close(backing.proceed)
+	if err := <-loaded; err != nil {
+		t.Fatalf("unable to list operation types [%s]", err)
+	}
+
+	if _, err := cached.GetForID(ctx, operationType.ID); err != nil {
+		t.Errorf("expected operation type %d after the create got %v", operationType.ID, err)
+	}
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestScheduleOccurrence(t *testing.T) {
+
+	start := time.Date(2024, time.January, 31, 9, 30, 0, 0, time.UTC)
+
+	type TestData struct {
+		description string
+		frequency   models.ScheduleFrequency
+		interval    int
+		n           int
+		expected    time.Time
+	}
+
+	tests := []TestData{
+		{
+			description: "First occurrence is the start",
+			frequency:   models.MonthlySchedule,
+			interval:    1,
+			n:           0,
+			expected:    start,
+		},
+		{
+			description: "Once",
+			frequency:   models.OnceSchedule,
+			interval:    1,
+			n:           3,
+			expected:    start,
+		},
+		{
+			description: "Every other day",
+			frequency:   models.DailySchedule,
+			interval:    2,
+			n:           3,
+			expected:    time.Date(2024, time.February, 6, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			description: "Weekly",
+			frequency:   models.WeeklySchedule,
+			interval:    1,
+			n:           2,
+			expected:    time.Date(2024, time.February, 14, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			description: "Monthly clamped to the end of february",
+			frequency:   models.MonthlySchedule,
+			interval:    1,
+			n:           1,
+			expected:    time.Date(2024, time.February, 29, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			description: "Monthly keeps the day of the start after a short month",
+			frequency:   models.MonthlySchedule,
+			interval:    1,
+			n:           2,
+			expected:    time.Date(2024, time.March, 31, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			description: "Quarterly",
+			frequency:   models.MonthlySchedule,
+			interval:    3,
+			n:           1,
+			expected:    time.Date(2024, time.April, 30, 9, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			schedule := models.Schedule{Frequency: test.frequency, Interval: test.interval, StartAt: start}
+			if occurrence := schedule.Occurrence(test.n); !occurrence.Equal(test.expected) {
+				t.Errorf("expected %s got %s", test.expected, occurrence)
+			}
+		})
+	}
+}
+
+func TestScheduleRecord(t *testing.T) {
+
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	start := now.Add(-time.Hour)
+	owner := "runner"
+
+	leased := func(frequency models.ScheduleFrequency, endAt *time.Time) models.Schedule {
+		schedule := models.NewSchedule(models.Schedule{Frequency: frequency, StartAt: start, EndAt: endAt}, start)
+		leasedUntil := now.Add(time.Minute)
+		schedule.LeaseOwner = &owner
+		schedule.LeasedUntil = &leasedUntil
+		return schedule
+	}
+
+	run := func(schedule models.Schedule, status models.ScheduleRunStatus) models.ScheduleRun {
+		return models.ScheduleRun{ScheduledFor: schedule.NextRunAt, Attempt: schedule.Attempts + 1, Status: status}
+	}
+
+	t.Run("Success moves to the next occurrence", func(t *testing.T) {
+		schedule := leased(models.DailySchedule, nil)
+		schedule.Record(run(schedule, models.ScheduleRunSucceeded), now)
+
+		switch {
+		case schedule.Status != models.ScheduleActive || schedule.Occurrences != 1:
+			t.Errorf("expected an active schedule after 1 occurrence got %+v", schedule)
+		case !schedule.NextRunAt.Equal(start.AddDate(0, 0, 1)):
+			t.Errorf("expected the next run a day after the start got %s", schedule.NextRunAt)
+		case schedule.LeaseOwner != nil || schedule.IsLeased(now):
+			t.Errorf("expected the lease to be released")
+		}
+	})
+
+	t.Run("Failures are retried with a backoff", func(t *testing.T) {
+		schedule := leased(models.DailySchedule, nil)
+
+		for attempt := 1; attempt < models.MaxScheduleAttempts; attempt++ {
+			schedule.Record(run(schedule, models.ScheduleRunFailed), now)
+
+			expected := now.Add(models.ScheduleRetryDelay << (attempt - 1))
+			switch {
+			case schedule.Attempts != attempt || schedule.Occurrences != 0:
+				t.Fatalf("expected attempt %d of the first occurrence got %+v", attempt, schedule)
+			case schedule.RetryAt == nil || !schedule.RetryAt.Equal(expected) || !schedule.DueAt().Equal(expected):
+				t.Fatalf("expected a retry at %s got %v", expected, schedule.RetryAt)
+			case !schedule.NextRunAt.Equal(start):
+				t.Fatalf("expected the occurrence to stay at %s got %s", start, schedule.NextRunAt)
+			}
+		}
+
+		schedule.Record(run(schedule, models.ScheduleRunFailed), now)
+		switch {
+		case schedule.Attempts != 0 || schedule.RetryAt != nil || schedule.Occurrences != 1:
+			t.Errorf("expected the occurrence to be skipped after the last attempt got %+v", schedule)
+		case !schedule.NextRunAt.Equal(start.AddDate(0, 0, 1)):
+			t.Errorf("expected the next run a day after the start got %s", schedule.NextRunAt)
+		}
+	})
+
+	t.Run("Once completes after its run", func(t *testing.T) {
+		schedule := leased(models.OnceSchedule, nil)
+		schedule.Record(run(schedule, models.ScheduleRunSucceeded), now)
+
+		if schedule.Status != models.ScheduleCompleted {
+			t.Errorf("expected status %s got %s", models.ScheduleCompleted, schedule.Status)
+		}
+	})
+
+	t.Run("Completes after the end", func(t *testing.T) {
+		endAt := start.AddDate(0, 0, 1)
+		schedule := leased(models.DailySchedule, &endAt)
+
+		schedule.Record(run(schedule, models.ScheduleRunSucceeded), now)
+		if schedule.Status != models.ScheduleActive {
+			t.Fatalf("expected the run at the end to be kept got %s", schedule.Status)
+		}
+
+		schedule.Record(run(schedule, models.ScheduleRunSucceeded), now)
+		if schedule.Status != models.ScheduleCompleted {
+			t.Errorf("expected status %s got %s", models.ScheduleCompleted, schedule.Status)
+		}
+	})
+
+	t.Run("Cancelled schedules only release the lease", func(t *testing.T) {
+		schedule := leased(models.DailySchedule, nil)
+		schedule.Status = models.ScheduleCancelled
+		schedule.Record(run(schedule, models.ScheduleRunSucceeded), now)
+
+		if schedule.Status != models.ScheduleCancelled || schedule.Occurrences != 0 || schedule.LeaseOwner != nil {
+			t.Errorf("expected a released cancelled schedule got %+v", schedule)
+		}
+	})
+
+	t.Run("Lease of another occurrence", func(t *testing.T) {
+		schedule := leased(models.DailySchedule, nil)
+
+		switch {
+		case !schedule.IsLeasedBy(owner, run(schedule, models.ScheduleRunSucceeded)):
+			t.Errorf("expected the lease to be held by %s", owner)
+		case schedule.IsLeasedBy("other", run(schedule, models.ScheduleRunSucceeded)):
+			t.Errorf("expected the lease not to be held by another runner")
+		case schedule.IsLeasedBy(owner, models.ScheduleRun{ScheduledFor: start.AddDate(0, 0, 1)}):
+			t.Errorf("expected the lease not to cover another occurrence")
+		}
+	})
+}
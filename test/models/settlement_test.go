@@ -0,0 +1,89 @@
+package models
+
+import (
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestSettlementStrategies(t *testing.T) {
+
+	start := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	transaction := func(id int64, operationTypeID models.OperationTypeID, minutes int) models.Transaction {
+		return models.Transaction{
+			ID:              id,
+			OperationTypeID: int64(operationTypeID),
+			EventDate:       start.Add(time.Duration(minutes) * time.Minute),
+		}
+	}
+
+	type TestData struct {
+		description string
+		strategy    models.SettlementStrategy
+		expected    []int64
+	}
+
+	tests := []TestData{
+		{
+			description: "FIFO",
+			strategy:    models.FIFOSettlement{},
+			expected:    []int64{1, 2, 3, 4, 5},
+		},
+		{
+			description: "LIFO",
+			strategy:    models.LIFOSettlement{},
+			expected:    []int64{5, 4, 3, 2, 1},
+		},
+		{
+			description: "Default priority",
+			strategy:    models.DefaultPrioritySettlement(),
+			expected:    []int64{3, 5, 1, 4, 2},
+		},
+		{
+			description: "Custom priority",
+			strategy:    models.PrioritySettlement{Priorities: map[int64]int{int64(models.PurchaseWithInstallments): -1}},
+			expected:    []int64{2, 1, 3, 4, 5},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			// transactions 4 and 5 share the event date so the id breaks the tie
+			transactions := []models.Transaction{
+				transaction(1, models.NormalPurchase, 0),
+				transaction(2, models.PurchaseWithInstallments, 1),
+				transaction(3, models.Withdrawal, 2),
+				transaction(4, models.CreditVoucher, 3),
+				transaction(5, models.Withdrawal, 3),
+			}
+
+			test.strategy.Order(transactions)
+
+			for i, id := range test.expected {
+				if transactions[i].ID != id {
+					t.Errorf("position %d expected transaction %d got %d", i, id, transactions[i].ID)
+				}
+			}
+		})
+	}
+
+	t.Run("Strategy for an account", func(t *testing.T) {
+
+		strategies := models.NewSettlementStrategies()
+
+		lifo := models.LIFOSettlementStrategy
+		if _, ok := strategies.ForAccount(models.Account{SettlementStrategy: &lifo}).(models.LIFOSettlement); !ok {
+			t.Errorf("expected the lifo strategy selected by the account")
+		}
+
+		if _, ok := strategies.ForAccount(models.Account{}).(models.FIFOSettlement); !ok {
+			t.Errorf("expected the default strategy for an account without one")
+		}
+
+		unknown := "unknown"
+		if _, ok := strategies.ForAccount(models.Account{SettlementStrategy: &unknown}).(models.FIFOSettlement); !ok {
+			t.Errorf("expected the default strategy for an account with an unknown one")
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package models
+
+import (
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestStatementCycle(t *testing.T) {
+
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	type TestData struct {
+		description string
+		closingDay  int
+		dueDay      int
+		now         time.Time
+		closing     time.Time
+		due         time.Time
+	}
+
+	tests := []TestData{
+		{
+			description: "Closed this month",
+			closingDay:  5,
+			dueDay:      15,
+			now:         time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			closing:     date(2024, 3, 5),
+			due:         date(2024, 3, 15),
+		},
+		{
+			description: "Closed last month",
+			closingDay:  20,
+			dueDay:      28,
+			now:         time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			closing:     date(2024, 2, 20),
+			due:         date(2024, 2, 28),
+		},
+		{
+			description: "Closes at the start of the day",
+			closingDay:  10,
+			dueDay:      20,
+			now:         date(2024, 3, 10),
+			closing:     date(2024, 3, 10),
+			due:         date(2024, 3, 20),
+		},
+		{
+			description: "Due next month",
+			closingDay:  25,
+			dueDay:      5,
+			now:         time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC),
+			closing:     date(2023, 12, 25),
+			due:         date(2024, 1, 5),
+		},
+		{
+			description: "Due a month after closing",
+			closingDay:  10,
+			dueDay:      10,
+			now:         time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC),
+			closing:     date(2024, 3, 10),
+			due:         date(2024, 4, 10),
+		},
+		{
+			description: "Cycles follow UTC days",
+			closingDay:  10,
+			dueDay:      20,
+			now:         time.Date(2024, 3, 10, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60)),
+			closing:     date(2024, 2, 10),
+			due:         date(2024, 2, 20),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+
+			closing := models.LastClosingDate(test.closingDay, test.now)
+			if !closing.Equal(test.closing) {
+				t.Errorf("expected closing date %s got %s", test.closing, closing)
+			}
+
+			due := models.StatementDueDate(closing, test.dueDay)
+			if !due.Equal(test.due) {
+				t.Errorf("expected due date %s got %s", test.due, due)
+			}
+		})
+	}
+}
+
+func TestMinimumPayment(t *testing.T) {
+
+	type TestData struct {
+		closingBalance string
+		expected       string
+	}
+
+	tests := []TestData{
+		{closingBalance: "50", expected: "0"},
+		{closingBalance: "0", expected: "0"},
+		{closingBalance: "-5", expected: "5"},
+		{closingBalance: "-40", expected: "10"},
+		{closingBalance: "-1000", expected: "150"},
+		{closingBalance: "-100.01", expected: "15.01"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.closingBalance, func(t *testing.T) {
+
+			payment := models.MinimumPayment(models.MustParseMoney(test.closingBalance))
+			if payment != models.MustParseMoney(test.expected) {
+				t.Errorf("expected minimum payment %s got %s", test.expected, payment)
+			}
+		})
+	}
+}
+
+func TestNewStatement(t *testing.T) {
+
+	closingDay, dueDay := 5, 15
+	account := models.Account{
+		AccountID:  1,
+		ClosingDay: &closingDay,
+		DueDay:     &dueDay,
+		CreatedAt:  time.Date(2024, 1, 20, 9, 30, 0, 0, time.UTC),
+	}
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("First statement", func(t *testing.T) {
+
+		statement, ok := models.NewStatement(account, nil, now)
+		switch {
+		case !ok:
+			t.Errorf("expected the first cycle to be closed")
+		case !statement.PeriodStart.Equal(account.CreatedAt):
+			t.Errorf("expected the cycle to start when the account was opened got %s", statement.PeriodStart)
+		case !statement.PeriodEnd.Equal(time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)):
+			t.Errorf("expected the cycle to close on the next closing day got %s", statement.PeriodEnd)
+		case !statement.DueDate.Equal(time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)):
+			t.Errorf("expected the statement to be due on the next due day got %s", statement.DueDate)
+		case statement.OpeningBalance != 0:
+			t.Errorf("expected opening balance 0.00 got %s", statement.OpeningBalance)
+		}
+	})
+
+	t.Run("Following statement", func(t *testing.T) {
+
+		previous := models.Statement{
+			PeriodEnd:      time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC),
+			ClosingBalance: models.MustParseMoney("-20"),
+		}
+
+		statement, ok := models.NewStatement(account, &previous, now)
+		switch {
+		case !ok:
+			t.Errorf("expected the following cycle to be closed")
+		case !statement.PeriodStart.Equal(previous.PeriodEnd):
+			t.Errorf("expected the cycle to start at %s got %s", previous.PeriodEnd, statement.PeriodStart)
+		case !statement.PeriodEnd.Equal(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)):
+			t.Errorf("expected the cycle to close a month later got %s", statement.PeriodEnd)
+		case statement.OpeningBalance != previous.ClosingBalance:
+			t.Errorf("expected opening balance %s got %s", previous.ClosingBalance, statement.OpeningBalance)
+		}
+
+		previous.PeriodEnd = statement.PeriodEnd
+		if _, ok := models.NewStatement(account, &previous, now); ok {
+			t.Errorf("expected the cycle after the last closing not to be closed")
+		}
+	})
+
+	t.Run("Changed closing day", func(t *testing.T) {
+
+		previous := models.Statement{PeriodEnd: time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC)}
+
+		statement, ok := models.NewStatement(account, &previous, now)
+		switch {
+		case !ok:
+			t.Errorf("expected the cycle to be closed")
+		case !statement.PeriodEnd.Equal(time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)):
+			t.Errorf("expected the cycle to close on the first closing day after it started got %s", statement.PeriodEnd)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"payments-backend-app/pkg/models"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+
+	signature := models.SignWebhookPayload("whsec_test", 1700000000, []byte(`{"id":1}`))
+	if expected := "sha256=2f441ba4b3b2d50d28a9ab9d9fd8880376ecd1eb5d0435401553f5d8d0a5dcf8"; signature != expected {
+		t.Errorf("expected signature %s got %s", expected, signature)
+	}
+
+	if models.SignWebhookPayload("whsec_test", 1700000001, []byte(`{"id":1}`)) == signature {
+		t.Errorf("expected the timestamp to be signed")
+	}
+
+	if models.SignWebhookPayload("whsec_other", 1700000000, []byte(`{"id":1}`)) == signature {
+		t.Errorf("expected the secret to key the signature")
+	}
+}
+
+func TestWebhookSubscribes(t *testing.T) {
+
+	accountID := int64(1)
+	otherID := int64(2)
+
+	tcs := []struct {
+		name     string
+		webhook  models.Webhook
+		event    models.Event
+		expected bool
+	}{
+		{
+			name:     "Subscribed type of any account",
+			webhook:  models.Webhook{EventTypes: []models.EventType{models.TransactionCreatedEvent}},
+			event:    models.Event{AccountID: accountID, Type: models.TransactionCreatedEvent},
+			expected: true,
+		},
+		{
+			name:     "Other type",
+			webhook:  models.Webhook{EventTypes: []models.EventType{models.TransactionCreatedEvent}},
+			event:    models.Event{AccountID: accountID, Type: models.TransactionSettledEvent},
+			expected: false,
+		},
+		{
+			name:     "Subscribed account",
+			webhook:  models.Webhook{EventTypes: []models.EventType{models.TransactionCreatedEvent}, AccountID: &accountID},
+			event:    models.Event{AccountID: accountID, Type: models.TransactionCreatedEvent},
+			expected: true,
+		},
+		{
+			name:     "Other account",
+			webhook:  models.Webhook{EventTypes: []models.EventType{models.TransactionCreatedEvent}, AccountID: &otherID},
+			event:    models.Event{AccountID: accountID, Type: models.TransactionCreatedEvent},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if subscribes := tc.webhook.Subscribes(tc.event); subscribes != tc.expected {
+				t.Errorf("expected %t got %t", tc.expected, subscribes)
+			}
+		})
+	}
+}
+
+func TestCheckPublicWebhookHost(t *testing.T) {
+
+	tcs := []struct {
+		host     string
+		expected error
+	}{
+		{host: "93.184.216.34"},
+		{host: "2606:2800:220:1:248:1893:25c8:1946"},
+		{host: "127.0.0.1", expected: models.InvalidWebhookURLErr},
+		{host: "localhost", expected: models.InvalidWebhookURLErr},
+		{host: "10.1.2.3", expected: models.InvalidWebhookURLErr},
+		{host: "192.168.0.10", expected: models.InvalidWebhookURLErr},
+		{host: "169.254.169.254", expected: models.InvalidWebhookURLErr},
+		{host: "0.0.0.0", expected: models.InvalidWebhookURLErr},
+		{host: "::1", expected: models.InvalidWebhookURLErr},
+		{host: "::ffff:127.0.0.1", expected: models.InvalidWebhookURLErr},
+		{host: "fd00::1", expected: models.InvalidWebhookURLErr},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.host, func(t *testing.T) {
+			if err := models.CheckPublicWebhookHost(context.Background(), tc.host); !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestWebhookClient(t *testing.T) {
+
+	client := models.NewWebhookClient(time.Second)
+
+	t.Run("Connections to non public addresses fail", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Errorf("expected no request to reach the loopback receiver")
+		}))
+		defer receiver.Close()
+
+		// the receiver listens on loopback, as a public host that resolves to it after the webhook was created would
+		resp, err := client.Post(receiver.URL, "application/json", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if !errors.Is(err, models.InvalidWebhookURLErr) {
+			t.Errorf("expected %v got %v", models.InvalidWebhookURLErr, err)
+		}
+	})
+
+	t.Run("Redirects are not followed", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodPost, "http://93.184.216.34/hook", nil)
+		if err := client.CheckRedirect(req, []*http.Request{req}); err != http.ErrUseLastResponse {
+			t.Errorf("expected %v got %v", http.ErrUseLastResponse, err)
+		}
+	})
+}
+
+func TestWebhookRetryBackoff(t *testing.T) {
+
+	tcs := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{attempts: 1, expected: 30 * time.Second},
+		{attempts: 2, expected: time.Minute},
+		{attempts: 4, expected: 4 * time.Minute},
+		{attempts: 12, expected: 2048 * 30 * time.Second},
+		{attempts: 13, expected: 24 * time.Hour},
+		{attempts: 100, expected: 24 * time.Hour},
+	}
+
+	for _, tc := range tcs {
+		if backoff := models.WebhookRetryBackoff(30*time.Second, tc.attempts); backoff != tc.expected {
+			t.Errorf("expected %s after %d attempts got %s", tc.expected, tc.attempts, backoff)
+		}
+	}
+}
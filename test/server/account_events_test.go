@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func TestAccountEvents(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	status, account, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+		DocumentNumber: testutils.GenerateCPF(),
+	})
+	if err != nil || status != http.StatusCreated || account == nil {
+		t.Fatalf("unable to create account status %d err %v", status, err)
+	}
+
+	createTransaction := func(t *testing.T, operationTypeID models.OperationTypeID, amount string) {
+		req := server.CreateTransactionRequest{AccountID: account.AccountID, OperationTypeID: int64(operationTypeID), Amount: models.MustParseMoney(amount)}
+		if status, _, err := testServer.CallCreateTransaction(&req); err != nil || status != http.StatusCreated {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+	}
+
+	// readEvents reads count events from the stream, failing the test if they take too long
+	readEvents := func(t *testing.T, reader *bufio.Reader, count int) []testutils.ServerSentEvent {
+		result := make(chan []testutils.ServerSentEvent, 1)
+		go func() {
+			events := []testutils.ServerSentEvent{}
+			for len(events) < count {
+				event, err := testutils.ReadServerSentEvent(reader)
+				if err != nil {
+					break
+				}
+				events = append(events, event)
+			}
+			result <- events
+		}()
+
+		select {
+		case events := <-result:
+			if len(events) != count {
+				t.Fatalf("expected %d events got %+v", count, events)
+			}
+			return events
+		case <-time.After(10 * time.Second):
+			t.Fatalf("expected %d events before the timeout", count)
+			return nil
+		}
+	}
+
+	t.Run("Invalid streams", func(t *testing.T) {
+		for _, tc := range []struct {
+			accountID   int64
+			lastEventID string
+			expected    int
+		}{
+			{accountID: 1_000_000_000, expected: http.StatusNotFound},
+			{accountID: account.AccountID, lastEventID: "abc", expected: http.StatusBadRequest},
+			{accountID: account.AccountID, lastEventID: "-1", expected: http.StatusBadRequest},
+		} {
+			resp, err := testServer.CallStreamAccountEvents(tc.accountID, tc.lastEventID)
+			if err != nil {
+				t.Fatalf("unable to open stream [%s]", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tc.expected {
+				t.Errorf("expected status %d for account %d and last event id %q got %d", tc.expected, tc.accountID, tc.lastEventID, resp.StatusCode)
+			}
+		}
+	})
+
+	t.Run("Stream and resume", func(t *testing.T) {
+		resp, err := testServer.CallStreamAccountEvents(account.AccountID, "")
+		if err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("unable to open stream err %v", err)
+		}
+		defer resp.Body.Close()
+
+		if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+			t.Errorf("expected content type text/event-stream got %s", contentType)
+		}
+
+		// the stream starts with the events written from now on
+		createTransaction(t, models.NormalPurchase, "10")
+		createTransaction(t, models.CreditVoucher, "10")
+
+		events := readEvents(t, bufio.NewReader(resp.Body), 4)
+		expected := []models.EventType{
+			models.TransactionCreatedEvent,
+			models.TransactionCreatedEvent,
+			models.TransactionSettledEvent,
+			models.TransactionSettledEvent,
+		}
+		for i, event := range events {
+			var payload struct {
+				AccountID int64 `json:"account_id"`
+			}
+			switch {
+			case event.Event != string(expected[i]):
+				t.Errorf("expected event %d to be %s got %s", i, expected[i], event.Event)
+			case json.Unmarshal([]byte(event.Data), &payload) != nil || payload.AccountID != account.AccountID:
+				t.Errorf("expected the transaction of account %d got %s", account.AccountID, event.Data)
+			}
+		}
+
+		// a stream resumed after the first event replays the ones after it
+		resumed, err := testServer.CallStreamAccountEvents(account.AccountID, events[0].ID)
+		if err != nil || resumed.StatusCode != http.StatusOK {
+			t.Fatalf("unable to resume stream err %v", err)
+		}
+		defer resumed.Body.Close()
+
+		replayed := readEvents(t, bufio.NewReader(resumed.Body), 3)
+		for i, event := range replayed {
+			if event.ID != events[i+1].ID || event.Event != events[i+1].Event {
+				t.Errorf("expected event %+v replayed got %+v", events[i+1], event)
+			}
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestAccountStatus(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	createAccount := func(t *testing.T) models.Account {
+		account, err := testServer.AccountsService.Create(ctx, models.Account{
+			DocumentNumber: testutils.GenerateRandomNumber(10),
+		})
+		if err != nil {
+			t.Fatalf("unable to create account [%s]", err)
+		}
+		return account
+	}
+
+	purchase := func(accountID int64, amount string) int {
+		status, _, _ := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       accountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney(amount),
+		})
+		return status
+	}
+
+	t.Run("Freeze and unfreeze", func(t *testing.T) {
+		account := createAccount(t)
+
+		status, frozen, err := testServer.CallFreezeAccount(account.AccountID, &server.UpdateAccountStatusRequest{Reason: "suspected fraud"})
+		switch {
+		case err != nil || status != http.StatusOK || frozen == nil:
+			t.Fatalf("unable to freeze account status %d err %v", status, err)
+		case frozen.Status != models.AccountFrozen:
+			t.Errorf("expected status %s got %s", models.AccountFrozen, frozen.Status)
+		}
+
+		if status := purchase(account.AccountID, "10"); status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d for a purchase on a frozen account got %d", http.StatusUnprocessableEntity, status)
+		}
+
+		if status, _, _ := testServer.CallFreezeAccount(account.AccountID, nil); status != http.StatusConflict {
+			t.Errorf("expected status %d got %d", http.StatusConflict, status)
+		}
+
+		status, active, err := testServer.CallUnfreezeAccount(account.AccountID, nil)
+		switch {
+		case err != nil || status != http.StatusOK || active == nil:
+			t.Fatalf("unable to unfreeze account status %d err %v", status, err)
+		case active.Status != models.AccountActive:
+			t.Errorf("expected status %s got %s", models.AccountActive, active.Status)
+		}
+
+		if status := purchase(account.AccountID, "10"); status != http.StatusCreated {
+			t.Errorf("expected status %d for a purchase on an unfrozen account got %d", http.StatusCreated, status)
+		}
+
+		status, history, err := testServer.CallListAccountStatusHistory(account.AccountID)
+		switch {
+		case err != nil || status != http.StatusOK || history == nil:
+			t.Fatalf("unable to list status history status %d err %v", status, err)
+		case len(history.History) != 2:
+			t.Fatalf("expected 2 status changes got %+v", history.History)
+		case history.History[0].FromStatus != models.AccountFrozen || history.History[0].ToStatus != models.AccountActive:
+			t.Errorf("expected the unfreeze first got %+v", history.History[0])
+		case history.History[1].ToStatus != models.AccountFrozen || history.History[1].Reason != "suspected fraud":
+			t.Errorf("expected the freeze with its reason last got %+v", history.History[1])
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		account := createAccount(t)
+
+		if status := purchase(account.AccountID, "25"); status != http.StatusCreated {
+			t.Fatalf("unable to create purchase status %d", status)
+		}
+
+		if status, _, _ := testServer.CallCloseAccount(account.AccountID, nil); status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d with an outstanding balance got %d", http.StatusUnprocessableEntity, status)
+		}
+
+		status, _, _ := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.CreditVoucher),
+			Amount:          models.MustParseMoney("25"),
+		})
+		if status != http.StatusCreated {
+			t.Fatalf("unable to create payment status %d", status)
+		}
+
+		status, closed, err := testServer.CallCloseAccount(account.AccountID, &server.UpdateAccountStatusRequest{Reason: "customer request"})
+		switch {
+		case err != nil || status != http.StatusOK || closed == nil:
+			t.Fatalf("unable to close account status %d err %v", status, err)
+		case closed.Status != models.AccountClosed:
+			t.Errorf("expected status %s got %s", models.AccountClosed, closed.Status)
+		}
+
+		if status := purchase(account.AccountID, "10"); status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d for a purchase on a closed account got %d", http.StatusUnprocessableEntity, status)
+		}
+
+		if status, _, _ := testServer.CallUnfreezeAccount(account.AccountID, nil); status != http.StatusConflict {
+			t.Errorf("expected status %d got %d", http.StatusConflict, status)
+		}
+
+		status, fetched, err := testServer.CallGetAccount(int(account.AccountID))
+		switch {
+		case err != nil || status != http.StatusOK || fetched == nil:
+			t.Fatalf("unable to fetch account status %d err %v", status, err)
+		case fetched.Status != models.AccountClosed:
+			t.Errorf("expected status %s got %s", models.AccountClosed, fetched.Status)
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+		missingID := int64(testutils.GenerateRandomNumberInt(10))
+
+		if status, _, _ := testServer.CallFreezeAccount(missingID, nil); status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+
+		if status, _, _ := testServer.CallListAccountStatusHistory(missingID); status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func TestAccruals(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	// 36.5% a year is 0.1% a day
+	status, operationType, err := testServer.CallCreateOperationType(&server.CreateOperationTypeRequest{
+		Description:    "Revolving Purchase",
+		Direction:      models.DebitDirection,
+		APRBasisPoints: 3650,
+	})
+	switch {
+	case err != nil || status != http.StatusCreated || operationType == nil:
+		t.Fatalf("unable to create operation type status %d err %v", status, err)
+	case operationType.APRBasisPoints != 3650:
+		t.Fatalf("expected apr 3650 got %d", operationType.APRBasisPoints)
+	}
+
+	account, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber: testutils.GenerateRandomNumber(10),
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	status, _, err = testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+		AccountID:       account.AccountID,
+		OperationTypeID: operationType.ID,
+		Amount:          models.MustParseMoney("1000"),
+	})
+	if err != nil || status != http.StatusCreated {
+		t.Fatalf("unable to create transaction status %d err %v", status, err)
+	}
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+
+	t.Run("Interest of a day is charged once", func(t *testing.T) {
+
+		// the api only accrues days that ended, the transaction was created today
+		for i := 0; i < 2; i++ {
+			if _, err := testServer.AccrualService.Accrue(ctx, time.Now()); err != nil {
+				t.Fatalf("unable to accrue [%s]", err)
+			}
+		}
+
+		status, list, err := testServer.CallListAccountAccruals(account.AccountID)
+		switch {
+		case err != nil || status != http.StatusOK || list == nil:
+			t.Fatalf("unable to list accruals status %d err %v", status, err)
+		case len(list.Accruals) != 1:
+			t.Fatalf("expected 1 accrual got %d", len(list.Accruals))
+		case list.Accruals[0].Kind != models.InterestAccrual || list.Accruals[0].Amount != models.MustParseMoney("1"):
+			t.Errorf("expected 1.00 of interest got %+v", list.Accruals[0])
+		}
+
+		status, transaction, err := testServer.CallGetTransaction(list.Accruals[0].TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || transaction == nil:
+			t.Fatalf("unable to fetch transaction status %d err %v", status, err)
+		case transaction.OperationType.OperationTypeID != int64(models.Interest) || transaction.Amount != models.MustParseMoney("-1"):
+			t.Errorf("expected an interest debit of 1.00 got %+v", transaction)
+		}
+	})
+
+	t.Run("Accrue a past day", func(t *testing.T) {
+
+		for i := 0; i < 2; i++ {
+			status, resp, err := testServer.CallAccrue(&server.AccrueRequest{Date: yesterday})
+			switch {
+			case err != nil || status != http.StatusOK || resp == nil:
+				t.Fatalf("unable to accrue status %d err %v", status, err)
+			case resp.Date != yesterday:
+				t.Errorf("expected date %s got %s", yesterday, resp.Date)
+			}
+		}
+
+		// the debit did not exist yet, so there is nothing to charge for that day
+		status, list, err := testServer.CallListAccountAccruals(account.AccountID)
+		switch {
+		case err != nil || status != http.StatusOK || list == nil:
+			t.Fatalf("unable to list accruals status %d err %v", status, err)
+		case len(list.Accruals) != 1:
+			t.Errorf("expected 1 accrual got %d", len(list.Accruals))
+		}
+	})
+
+	t.Run("Invalid dates", func(t *testing.T) {
+
+		for _, date := range []string{"", "10/03/2024", time.Now().UTC().Format(time.DateOnly), time.Now().AddDate(0, 0, 2).Format(time.DateOnly)} {
+			status, _, _ := testServer.CallAccrue(&server.AccrueRequest{Date: date})
+			if status != http.StatusBadRequest {
+				t.Errorf("expected status %d for %q got %d", http.StatusBadRequest, date, status)
+			}
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+
+		status, _, _ := testServer.CallListAccountAccruals(int64(testutils.GenerateRandomNumberInt(10)))
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
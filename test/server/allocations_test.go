@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestTransactionAllocations(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	account, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber: testutils.GenerateRandomNumber(10),
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	create := func(t *testing.T, operationTypeID models.OperationTypeID, amount string) int64 {
+		status, created, err := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(operationTypeID),
+			Amount:          models.MustParseMoney(amount),
+		})
+		if err != nil || status != http.StatusCreated || created == nil {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+		return created.TransactionID
+	}
+
+	purchase := create(t, models.NormalPurchase, "50")
+	withdrawal := create(t, models.Withdrawal, "30")
+	voucher := create(t, models.CreditVoucher, "60")
+
+	t.Run("Credit paying debits", func(t *testing.T) {
+
+		status, resp, err := testServer.CallListTransactionAllocations(voucher)
+		switch {
+		case err != nil || status != http.StatusOK || resp == nil:
+			t.Fatalf("unable to list allocations status %d err %v", status, err)
+		case len(resp.Allocations) != 2:
+			t.Fatalf("expected 2 allocations got %d", len(resp.Allocations))
+		}
+
+		expected := []struct {
+			debitTransactionID int64
+			amount             string
+		}{
+			{purchase, "50"},
+			{withdrawal, "10"},
+		}
+
+		for i, e := range expected {
+			allocation := resp.Allocations[i]
+			switch {
+			case allocation.CreditTransactionID != voucher || allocation.DebitTransactionID != e.debitTransactionID:
+				t.Errorf("expected allocation from %d to %d got %+v", voucher, e.debitTransactionID, allocation)
+			case allocation.Amount != models.MustParseMoney(e.amount):
+				t.Errorf("expected amount %s got %s", e.amount, allocation.Amount)
+			}
+		}
+	})
+
+	t.Run("Debit paid by a credit", func(t *testing.T) {
+
+		status, resp, err := testServer.CallListTransactionAllocations(withdrawal)
+		switch {
+		case err != nil || status != http.StatusOK || resp == nil:
+			t.Fatalf("unable to list allocations status %d err %v", status, err)
+		case len(resp.Allocations) != 1:
+			t.Fatalf("expected 1 allocation got %d", len(resp.Allocations))
+		case resp.Allocations[0].CreditTransactionID != voucher:
+			t.Errorf("expected the withdrawal to be paid by %d got %d", voucher, resp.Allocations[0].CreditTransactionID)
+		}
+	})
+
+	t.Run("Missing transaction", func(t *testing.T) {
+
+		status, _, _ := testServer.CallListTransactionAllocations(int64(testutils.GenerateRandomNumberInt(10)))
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestAuthorizations(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	limit := models.MustParseMoney("100")
+	account, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber:       testutils.GenerateRandomNumber(10),
+		AvailableCreditLimit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	hold := func(t *testing.T, amount string) *models.Authorization {
+		status, authorization, err := testServer.CallCreateAuthorization(&server.CreateAuthorizationRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney(amount),
+		})
+		if err != nil || status != http.StatusCreated || authorization == nil {
+			t.Fatalf("unable to create authorization status %d err %v", status, err)
+		}
+		return authorization
+	}
+
+	t.Run("Capture", func(t *testing.T) {
+
+		authorization := hold(t, "40")
+
+		status, balance, err := testServer.CallGetAccountBalance(account.AccountID)
+		switch {
+		case err != nil || status != http.StatusOK || balance == nil:
+			t.Errorf("unable to fetch balance status %d err %v", status, err)
+		case balance.Held != models.MustParseMoney("40"):
+			t.Errorf("expected held 40.00 got %s", balance.Held)
+		}
+
+		status, captured, err := testServer.CallCaptureAuthorization(authorization.ID, &server.CaptureAuthorizationRequest{
+			Amount: models.MustParseMoney("25.50"),
+		})
+		switch {
+		case err != nil || status != http.StatusOK || captured == nil:
+			t.Fatalf("unable to capture authorization status %d err %v", status, err)
+		case captured.Status != models.AuthorizationCaptured:
+			t.Errorf("expected status %s got %s", models.AuthorizationCaptured, captured.Status)
+		case captured.TransactionID == nil:
+			t.Fatalf("expected a captured transaction")
+		}
+
+		status, transaction, err := testServer.CallGetTransaction(*captured.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || transaction == nil:
+			t.Errorf("unable to fetch transaction status %d err %v", status, err)
+		case transaction.Amount != models.MustParseMoney("-25.50"):
+			t.Errorf("expected amount -25.50 got %s", transaction.Amount)
+		}
+
+		status, _, _ = testServer.CallCaptureAuthorization(authorization.ID, nil)
+		if status != http.StatusConflict {
+			t.Errorf("expected status %d got %d", http.StatusConflict, status)
+		}
+	})
+
+	t.Run("Release", func(t *testing.T) {
+
+		authorization := hold(t, "10")
+
+		status, released, err := testServer.CallReleaseAuthorization(authorization.ID)
+		switch {
+		case err != nil || status != http.StatusOK || released == nil:
+			t.Fatalf("unable to release authorization status %d err %v", status, err)
+		case released.Status != models.AuthorizationReleased:
+			t.Errorf("expected status %s got %s", models.AuthorizationReleased, released.Status)
+		}
+
+		status, fetched, err := testServer.CallGetAuthorization(authorization.ID)
+		switch {
+		case err != nil || status != http.StatusOK || fetched == nil:
+			t.Errorf("unable to fetch authorization status %d err %v", status, err)
+		case fetched.Status != models.AuthorizationReleased:
+			t.Errorf("expected status %s got %s", models.AuthorizationReleased, fetched.Status)
+		}
+	})
+
+	t.Run("Hold over the limit", func(t *testing.T) {
+
+		status, _, _ := testServer.CallCreateAuthorization(&server.CreateAuthorizationRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("1000"),
+		})
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d got %d", http.StatusUnprocessableEntity, status)
+		}
+	})
+
+	t.Run("Credit operation type", func(t *testing.T) {
+
+		status, _, _ := testServer.CallCreateAuthorization(&server.CreateAuthorizationRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.CreditVoucher),
+			Amount:          models.MustParseMoney("10"),
+		})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+		}
+	})
+
+	t.Run("Missing authorization", func(t *testing.T) {
+
+		status, _, _ := testServer.CallReleaseAuthorization(int64(testutils.GenerateRandomNumberInt(10)))
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
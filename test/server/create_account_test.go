@@ -7,6 +7,7 @@ import (
 	"payments-backend-app/pkg/models"
 	"payments-backend-app/pkg/server"
 	"payments-backend-app/test/testutils"
+	"strings"
 	"testing"
 )
 
@@ -27,7 +28,7 @@ func TestCreateAccount(t *testing.T) {
 		{
 			description: "Expect successful account creation",
 			req: &server.CreateAccountRequest{
-				DocumentNumber: testutils.GenerateRandomNumber(10),
+				DocumentNumber: testutils.GenerateCPF(),
 			},
 			expectedStatusCode: http.StatusCreated,
 		},
@@ -91,10 +92,96 @@ func TestCreateAccount(t *testing.T) {
 		})
 	}
 
+	t.Run("Document normalization", func(t *testing.T) {
+
+		cpf := testutils.CPFWithCheckDigits("0" + testutils.GenerateRandomNumber(8))
+		status, resp, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+			DocumentNumber: strings.TrimPrefix(cpf, "0"),
+		})
+		switch {
+		case err != nil || status != http.StatusCreated || resp == nil:
+			t.Fatalf("unable to create account status %d err %v", status, err)
+		case resp.DocumentNumber != cpf || resp.DocumentType != models.CPFDocument:
+			t.Errorf("expected cpf %s got %s %s", cpf, resp.DocumentType, resp.DocumentNumber)
+		}
+
+		cnpj := testutils.GenerateCNPJ()
+		formatted := fmt.Sprintf("%s.%s.%s/%s-%s", cnpj[:2], cnpj[2:5], cnpj[5:8], cnpj[8:12], cnpj[12:])
+		status, resp, err = testServer.CallCreateAccount(&server.CreateAccountRequest{
+			DocumentNumber: formatted,
+		})
+		switch {
+		case err != nil || status != http.StatusCreated || resp == nil:
+			t.Fatalf("unable to create account status %d err %v", status, err)
+		case resp.DocumentNumber != cnpj || resp.DocumentType != models.CNPJDocument:
+			t.Errorf("expected cnpj %s got %s %s", cnpj, resp.DocumentType, resp.DocumentNumber)
+		}
+
+		// the same document with and without its formatting is the same account
+		if status, _, _ := testServer.CallCreateAccount(&server.CreateAccountRequest{DocumentNumber: cnpj}); status != http.StatusConflict {
+			t.Errorf("expected status %d got %d", http.StatusConflict, status)
+		}
+	})
+
+	t.Run("Invalid documents", func(t *testing.T) {
+
+		cpf := testutils.GenerateCPF()
+		invalidCPF := cpf[:10] + fmt.Sprintf("%d", (int(cpf[10]-'0')+1)%10)
+
+		tests := map[string]struct {
+			req           server.CreateAccountRequest
+			expectedField string
+			expectedCode  string
+		}{
+			"Wrong check digits": {
+				req:           server.CreateAccountRequest{DocumentNumber: invalidCPF},
+				expectedField: "document_number",
+				expectedCode:  models.DocumentInvalidCheckDigitsCode,
+			},
+			"Repeated digits": {
+				req:           server.CreateAccountRequest{DocumentNumber: "11111111111"},
+				expectedField: "document_number",
+				expectedCode:  models.DocumentRepeatedDigitsCode,
+			},
+			"Letters": {
+				req:           server.CreateAccountRequest{DocumentNumber: "1234567890a"},
+				expectedField: "document_number",
+				expectedCode:  models.DocumentInvalidCharactersCode,
+			},
+			"Too long": {
+				req:           server.CreateAccountRequest{DocumentNumber: "123456789012345"},
+				expectedField: "document_number",
+				expectedCode:  models.DocumentInvalidLengthCode,
+			},
+			"CNPJ sent as a CPF": {
+				req:           server.CreateAccountRequest{DocumentNumber: testutils.GenerateCNPJ(), DocumentType: models.CPFDocument},
+				expectedField: "document_number",
+				expectedCode:  models.DocumentInvalidLengthCode,
+			},
+			"Unknown type": {
+				req:           server.CreateAccountRequest{DocumentNumber: cpf, DocumentType: "passport"},
+				expectedField: "document_type",
+				expectedCode:  models.DocumentUnsupportedTypeCode,
+			},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				status, documentErr, err := testServer.CallCreateAccountWithDocumentErr(&test.req)
+				switch {
+				case err != nil || status != http.StatusBadRequest || documentErr == nil:
+					t.Fatalf("expected status %d got %d err %v", http.StatusBadRequest, status, err)
+				case documentErr.Field != test.expectedField || documentErr.Code != test.expectedCode || documentErr.Message == "":
+					t.Errorf("expected %s on %s got %+v", test.expectedCode, test.expectedField, documentErr)
+				}
+			})
+		}
+	})
+
 	t.Run("Duplicate account creation should fail", func(t *testing.T) {
 
 		req := &server.CreateAccountRequest{
-			DocumentNumber: testutils.GenerateRandomNumber(10),
+			DocumentNumber: testutils.GenerateCPF(),
 		}
 
 		_, err := testServer.AccountsService.Create(ctx, models.Account{DocumentNumber: req.DocumentNumber})
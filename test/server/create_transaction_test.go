@@ -39,7 +39,7 @@ func TestCreateTransaction(t *testing.T) {
 				req := &server.CreateTransactionRequest{
 					AccountID:       account.AccountID,
 					OperationTypeID: int64(i),
-					Amount:          123.11,
+					Amount:          models.MustParseMoney("123.11"),
 				}
 
 				status, resp, err := testServer.CallCreateTransaction(req)
@@ -67,11 +67,11 @@ func TestCreateTransaction(t *testing.T) {
 						switch transaction.OperationTypeID {
 						case 1, 2, 3:
 							if (-req.Amount) != (transaction.Amount) {
-								t.Errorf("expected -%f got %f ", req.Amount, transaction.Amount)
+								t.Errorf("expected -%s got %s ", req.Amount, transaction.Amount)
 							}
 						case 4:
 							if (req.Amount) != (transaction.Amount) {
-								t.Errorf("expected %f got %f ", req.Amount, transaction.Amount)
+								t.Errorf("expected %s got %s ", req.Amount, transaction.Amount)
 							}
 						}
 					}
@@ -99,7 +99,7 @@ func TestCreateTransaction(t *testing.T) {
 			req := &server.CreateTransactionRequest{
 				AccountID:       account.AccountID,
 				OperationTypeID: 5,
-				Amount:          123.11,
+				Amount:          models.MustParseMoney("123.11"),
 			}
 
 			status, _, err := testServer.CallCreateTransaction(req)
@@ -115,13 +115,9 @@ func TestCreateTransaction(t *testing.T) {
 
 		t.Run("Amount decimal places greater than 2", func(t *testing.T) {
 
-			req := &server.CreateTransactionRequest{
-				AccountID:       account.AccountID,
-				OperationTypeID: 4,
-				Amount:          123.111,
-			}
+			body := fmt.Sprintf(`{"account_id": %d, "operation_type_id": 4, "amount": 123.111}`, account.AccountID)
 
-			status, _, err := testServer.CallCreateTransaction(req)
+			status, _, err := testServer.CallCreateTransactionWithBody([]byte(body))
 			if err != nil {
 				t.Errorf("error creating the transaction [%s]", err)
 			}
@@ -139,7 +135,7 @@ func TestCreateTransaction(t *testing.T) {
 		req := &server.CreateTransactionRequest{
 			AccountID:       int64(testutils.GenerateRandomNumberInt(10)),
 			OperationTypeID: 1,
-			Amount:          123.11,
+			Amount:          models.MustParseMoney("123.11"),
 		}
 
 		status, _, err := testServer.CallCreateTransaction(req)
@@ -159,8 +155,8 @@ func TestCreateTransaction(t *testing.T) {
 
 		type TestData struct {
 			OperationTypeID  int
-			Amount           float64
-			ExpectedBalances float64
+			Amount           models.Money
+			ExpectedBalances models.Money
 		}
 
 		testDatas := make([]TestData, 0)
@@ -231,24 +227,24 @@ func TestCreateTransaction(t *testing.T) {
 
 		testDatas = append(testDatas, TestData{
 			OperationTypeID:  1,
-			Amount:           50,
-			ExpectedBalances: 0.0,
+			Amount:           models.MustParseMoney("50"),
+			ExpectedBalances: models.MustParseMoney("0.0"),
 		}, TestData{
 			OperationTypeID:  1,
-			Amount:           23.5,
-			ExpectedBalances: 0.0,
+			Amount:           models.MustParseMoney("23.5"),
+			ExpectedBalances: models.MustParseMoney("0.0"),
 		}, TestData{
 			OperationTypeID:  1,
-			Amount:           18.7,
-			ExpectedBalances: 0.0,
+			Amount:           models.MustParseMoney("18.7"),
+			ExpectedBalances: models.MustParseMoney("0.0"),
 		}, TestData{
 			OperationTypeID:  4,
-			Amount:           60,
-			ExpectedBalances: 0.0,
+			Amount:           models.MustParseMoney("60"),
+			ExpectedBalances: models.MustParseMoney("0.0"),
 		}, TestData{
 			OperationTypeID:  4,
-			Amount:           100,
-			ExpectedBalances: 67.8,
+			Amount:           models.MustParseMoney("100"),
+			ExpectedBalances: models.MustParseMoney("67.8"),
 		})
 
 		account, err := testServer.AccountsService.Create(ctx, models.Account{
@@ -292,7 +288,7 @@ func TestCreateTransaction(t *testing.T) {
 			}
 
 			if transaction.Balance != testDatas[i].ExpectedBalances {
-				t.Errorf("operation type %d amount %f expected balance %f got %f",
+				t.Errorf("operation type %d amount %s expected balance %s got %s",
 					testDatas[i].OperationTypeID,
 					testDatas[i].Amount,
 					testDatas[i].ExpectedBalances,
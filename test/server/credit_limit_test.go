@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestCreditLimit(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	limit := models.MustParseMoney("100")
+	status, account, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+		DocumentNumber:       testutils.GenerateCPF(),
+		AvailableCreditLimit: &limit,
+	})
+	if err != nil || status != http.StatusCreated || account == nil {
+		t.Fatalf("unable to create account status %d err %v", status, err)
+	}
+
+	t.Run("Debits within the limit", func(t *testing.T) {
+
+		status, _, err := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("60"),
+		})
+		if err != nil || status != http.StatusCreated {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+
+		status, raccount, err := testServer.CallGetAccount(int(account.AccountID))
+		switch {
+		case err != nil || status != http.StatusOK || raccount == nil:
+			t.Errorf("unable to fetch account status %d err %v", status, err)
+		case raccount.AvailableCreditLimit == nil || *raccount.AvailableCreditLimit != models.MustParseMoney("40"):
+			t.Errorf("expected limit 40.00 got %v", raccount.AvailableCreditLimit)
+		}
+	})
+
+	t.Run("Debit over the limit", func(t *testing.T) {
+
+		status, _, _ := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.Withdrawal),
+			Amount:          models.MustParseMoney("40.01"),
+		})
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d got %d", http.StatusUnprocessableEntity, status)
+		}
+	})
+
+	t.Run("Update the limit", func(t *testing.T) {
+
+		status, updated, err := testServer.CallUpdateCreditLimit(account.AccountID, &server.UpdateCreditLimitRequest{
+			AvailableCreditLimit: models.MustParseMoney("250.50"),
+		})
+		switch {
+		case err != nil || status != http.StatusOK || updated == nil:
+			t.Fatalf("unable to update limit status %d err %v", status, err)
+		case updated.CreditLimit == nil || *updated.CreditLimit != models.MustParseMoney("250.50"):
+			t.Errorf("expected limit 250.50 got %v", updated.CreditLimit)
+		case updated.AvailableCreditLimit == nil || *updated.AvailableCreditLimit != models.MustParseMoney("190.50"):
+			t.Errorf("expected available limit 190.50 got %v", updated.AvailableCreditLimit)
+		}
+
+		status, _, err = testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.Withdrawal),
+			Amount:          models.MustParseMoney("190.50"),
+		})
+		if err != nil || status != http.StatusCreated {
+			t.Errorf("unable to create transaction status %d err %v", status, err)
+		}
+	})
+
+	t.Run("Negative limit", func(t *testing.T) {
+
+		status, _, _ := testServer.CallUpdateCreditLimit(account.AccountID, &server.UpdateCreditLimitRequest{
+			AvailableCreditLimit: models.MustParseMoney("-1"),
+		})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+
+		status, _, _ := testServer.CallUpdateCreditLimit(int64(testutils.GenerateRandomNumberInt(10)), &server.UpdateCreditLimitRequest{
+			AvailableCreditLimit: limit,
+		})
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"sync/atomic"
+	"testing"
+)
+
+// fixedRateConverter converts every amount at the same rate
+type fixedRateConverter struct {
+	rate models.ExchangeRate
+}
+
+func (c fixedRateConverter) Convert(_ context.Context, amount models.Money, _ models.Currency, to models.Currency) (models.Money, models.ExchangeRate, error) {
+	return c.rate.Convert(amount, to), c.rate, nil
+}
+
+func TestCurrencies(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t, testutils.WithCurrencyConverter(fixedRateConverter{rate: models.MustParseExchangeRate("5")}))
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	createAccount := func(t *testing.T, currency models.Currency) server.GetAccountResponse {
+		status, account, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+			DocumentNumber: testutils.GenerateCPF(),
+			Currency:       currency,
+		})
+		if err != nil || status != http.StatusCreated || account == nil {
+			t.Fatalf("unable to create account status %d err %v", status, err)
+		}
+		return *account
+	}
+
+	t.Run("Account currency", func(t *testing.T) {
+		if account := createAccount(t, ""); account.Currency != models.DefaultCurrency {
+			t.Errorf("expected currency %s got %s", models.DefaultCurrency, account.Currency)
+		}
+
+		status, account, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+			DocumentNumber: testutils.GenerateCPF(),
+			Currency:       "usd",
+		})
+		switch {
+		case err != nil || status != http.StatusCreated || account == nil:
+			t.Fatalf("unable to create account status %d err %v", status, err)
+		case account.Currency != "USD":
+			t.Errorf("expected currency USD got %s", account.Currency)
+		}
+
+		status, _, _ = testServer.CallCreateAccount(&server.CreateAccountRequest{
+			DocumentNumber: testutils.GenerateCPF(),
+			Currency:       "XYZ",
+		})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+		}
+	})
+
+	t.Run("Minor unit precision", func(t *testing.T) {
+		account := createAccount(t, "JPY")
+
+		status, _, _ := testServer.CallCreateTransactionWithBody([]byte(fmt.Sprintf(`{"account_id": %d, "operation_type_id": 1, "amount": 10.5, "currency": "JPY"}`, account.AccountID)))
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+		}
+
+		status, _, _ = testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10.5"),
+		})
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d got %d", http.StatusUnprocessableEntity, status)
+		}
+
+		status, created, err := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("1000"),
+		})
+		if err != nil || status != http.StatusCreated || created == nil {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+
+		status, transaction, err := testServer.CallGetTransaction(created.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || transaction == nil:
+			t.Fatalf("unable to fetch transaction status %d err %v", status, err)
+		case transaction.Currency != "JPY" || transaction.Amount != models.MustParseMoney("-1000"):
+			t.Errorf("expected -1000.00 JPY got %s %s", transaction.Amount, transaction.Currency)
+		}
+	})
+
+	t.Run("Cross currency", func(t *testing.T) {
+		account := createAccount(t, "BRL")
+
+		status, _, _ := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10"),
+			Currency:        "USD",
+		})
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d without a conversion got %d", http.StatusUnprocessableEntity, status)
+		}
+
+		status, created, err := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10"),
+			Currency:        "USD",
+			Convert:         true,
+		})
+		if err != nil || status != http.StatusCreated || created == nil {
+			t.Fatalf("unable to create converted transaction status %d err %v", status, err)
+		}
+
+		status, transaction, err := testServer.CallGetTransaction(created.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || transaction == nil:
+			t.Fatalf("unable to fetch transaction status %d err %v", status, err)
+		case transaction.Currency != "BRL" || transaction.Amount != models.MustParseMoney("-50"):
+			t.Errorf("expected -50.00 BRL got %s %s", transaction.Amount, transaction.Currency)
+		case transaction.OriginalCurrency != "USD" || transaction.OriginalAmount == nil || *transaction.OriginalAmount != models.MustParseMoney("-10"):
+			t.Errorf("expected the original -10.00 USD got %v %s", transaction.OriginalAmount, transaction.OriginalCurrency)
+		case transaction.FXRate == nil || *transaction.FXRate != models.MustParseExchangeRate("5") || transaction.FXQuoteID != nil:
+			t.Errorf("expected the rate 5 without a quote got %v %v", transaction.FXRate, transaction.FXQuoteID)
+		}
+
+		status, _, _ = testServer.CallCreateTransactionWithBody([]byte(fmt.Sprintf(`{"account_id": %d, "operation_type_id": 1, "amount": 10, "convert": true}`, account.AccountID)))
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d for a conversion without a currency got %d", http.StatusBadRequest, status)
+		}
+	})
+}
+
+func TestCurrencyConversionUnavailable(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	account, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber: testutils.GenerateRandomNumber(10),
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	// no rate is ever set from PYG so the default converter has none to convert at
+	status, _, _ := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+		AccountID:       account.AccountID,
+		OperationTypeID: int64(models.NormalPurchase),
+		Amount:          models.MustParseMoney("10"),
+		Currency:        "PYG",
+		Convert:         true,
+	})
+	if status != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d got %d", http.StatusUnprocessableEntity, status)
+	}
+}
+
+// unavailableConverter converts at rate until it is marked unavailable
+type unavailableConverter struct {
+	rate        models.ExchangeRate
+	unavailable *atomic.Bool
+}
+
+func (c unavailableConverter) Convert(_ context.Context, amount models.Money, _ models.Currency, to models.Currency) (models.Money, models.ExchangeRate, error) {
+	if c.unavailable.Load() {
+		return 0, 0, models.ConversionUnavailableErr
+	}
+	return c.rate.Convert(amount, to), c.rate, nil
+}
+
+func TestCurrencyConversionReplay(t *testing.T) {
+	ctx := context.Background()
+	converter := unavailableConverter{rate: models.MustParseExchangeRate("5"), unavailable: &atomic.Bool{}}
+	testServer := testutils.NewTestServer(t, testutils.WithCurrencyConverter(converter))
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	account, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber: testutils.GenerateRandomNumber(10),
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	key := testutils.GenerateRandomNumber(12)
+	req := &server.CreateTransactionRequest{
+		AccountID:       account.AccountID,
+		OperationTypeID: int64(models.NormalPurchase),
+		Amount:          models.MustParseMoney("10"),
+		Currency:        "USD",
+		Convert:         true,
+	}
+
+	status, first, err := testServer.CallPostWithIdempotencyKey(server.CreateTransactionExtension, req, key)
+	if err != nil || status != http.StatusCreated {
+		t.Fatalf("unable to create converted transaction status %d err %v", status, err)
+	}
+
+	// the retry is replayed without converting again
+	converter.unavailable.Store(true)
+
+	status, second, err := testServer.CallPostWithIdempotencyKey(server.CreateTransactionExtension, req, key)
+	switch {
+	case err != nil:
+		t.Errorf("create request failed [%s]", err)
+	case status != http.StatusCreated:
+		t.Errorf("expected replayed status %d got %d", http.StatusCreated, status)
+	case string(first) != string(second):
+		t.Errorf("expected replayed body %s got %s", string(first), string(second))
+	}
+}
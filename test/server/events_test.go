@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		mu       sync.Mutex
+		received []models.Event
+		failed   bool
+	)
+
+	publisher := models.NewInProcessPublisher()
+	publisher.Subscribe(func(_ context.Context, event models.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// the first TransactionCreated event fails once, it is delivered again by the next relay
+		if event.Type == models.TransactionCreatedEvent && !failed {
+			failed = true
+			return fmt.Errorf("unable to handle event %d", event.ID)
+		}
+
+		received = append(received, event)
+		return nil
+	})
+
+	testServer := testutils.NewTestServer(t, testutils.WithEventPublisher(publisher))
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	status, account, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+		DocumentNumber: testutils.GenerateCPF(),
+	})
+	if err != nil || status != http.StatusCreated || account == nil {
+		t.Fatalf("unable to create account status %d err %v", status, err)
+	}
+
+	for _, req := range []server.CreateTransactionRequest{
+		{AccountID: account.AccountID, OperationTypeID: int64(models.NormalPurchase), Amount: models.MustParseMoney("10")},
+		{AccountID: account.AccountID, OperationTypeID: int64(models.CreditVoucher), Amount: models.MustParseMoney("10")},
+	} {
+		if status, _, err := testServer.CallCreateTransaction(&req); err != nil || status != http.StatusCreated {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+	}
+
+	expected := fmt.Sprint([]models.EventType{
+		models.AccountCreatedEvent,
+		models.TransactionCreatedEvent,
+		models.TransactionCreatedEvent,
+		models.TransactionSettledEvent,
+		models.TransactionSettledEvent,
+	})
+
+	// the relay runs every second
+	var types []models.EventType
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		mu.Lock()
+		types = types[:0]
+		for _, event := range received {
+			if event.AccountID == account.AccountID {
+				types = append(types, event.Type)
+			}
+		}
+		mu.Unlock()
+
+		if fmt.Sprint(types) == expected {
+			return
+		}
+	}
+
+	t.Errorf("expected the events %s of the account in order got %v", expected, types)
+}
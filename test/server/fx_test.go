@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func TestFX(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	createAccount := func(t *testing.T, currency models.Currency) server.GetAccountResponse {
+		status, account, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+			DocumentNumber: testutils.GenerateCPF(),
+			Currency:       currency,
+		})
+		if err != nil || status != http.StatusCreated || account == nil {
+			t.Fatalf("unable to create account status %d err %v", status, err)
+		}
+		return *account
+	}
+
+	t.Run("Rates", func(t *testing.T) {
+		status, rate, err := testServer.CallSetFXRate(&server.SetFXRateRequest{From: "GBP", To: "EUR", Rate: models.MustParseExchangeRate("1.17")})
+		switch {
+		case err != nil || status != http.StatusOK || rate == nil:
+			t.Fatalf("unable to set rate status %d err %v", status, err)
+		case rate.From != "GBP" || rate.To != "EUR" || rate.Rate != models.MustParseExchangeRate("1.17"):
+			t.Errorf("expected GBP to EUR at 1.17 got %+v", rate)
+		}
+
+		status, rates, err := testServer.CallListFXRates()
+		if err != nil || status != http.StatusOK || rates == nil {
+			t.Fatalf("unable to list rates status %d err %v", status, err)
+		}
+
+		found := false
+		for _, rate := range rates.Rates {
+			found = found || (rate.From == "GBP" && rate.To == "EUR")
+		}
+		if !found {
+			t.Errorf("expected the GBP to EUR rate listed got %+v", rates.Rates)
+		}
+
+		for _, body := range []string{
+			`{"from": "GBP", "to": "GBP", "rate": "1"}`,
+			`{"from": "GBP", "to": "XYZ", "rate": "1"}`,
+			`{"from": "GBP", "to": "EUR", "rate": "0"}`,
+			`{"from": "GBP", "to": "EUR", "rate": "1.123456789"}`,
+		} {
+			if status, _, _ := testServer.CallSetFXRate([]byte(body)); status != http.StatusBadRequest {
+				t.Errorf("expected status %d for %s got %d", http.StatusBadRequest, body, status)
+			}
+		}
+	})
+
+	t.Run("Quoted transaction", func(t *testing.T) {
+		if status, _, err := testServer.CallSetFXRate(&server.SetFXRateRequest{From: "USD", To: "MXN", Rate: models.MustParseExchangeRate("17.12345")}); err != nil || status != http.StatusOK {
+			t.Fatalf("unable to set rate status %d err %v", status, err)
+		}
+
+		status, quote, err := testServer.CallCreateFXQuote(&server.CreateFXQuoteRequest{From: "USD", To: "MXN"})
+		switch {
+		case err != nil || status != http.StatusCreated || quote == nil:
+			t.Fatalf("unable to create quote status %d err %v", status, err)
+		case quote.Rate != models.MustParseExchangeRate("17.12345"):
+			t.Errorf("expected rate 17.12345 got %s", quote.Rate)
+		case !quote.ExpiresAt.After(time.Now()):
+			t.Errorf("expected the quote to expire later got %s", quote.ExpiresAt)
+		}
+
+		if status, fetched, err := testServer.CallGetFXQuote(quote.ID); err != nil || status != http.StatusOK || fetched == nil || fetched.ID != quote.ID {
+			t.Errorf("unable to fetch quote status %d err %v", status, err)
+		}
+
+		// the rate moves after the quote was created, the transaction is still booked at the quoted one
+		if status, _, err := testServer.CallSetFXRate(&server.SetFXRateRequest{From: "USD", To: "MXN", Rate: models.MustParseExchangeRate("20")}); err != nil || status != http.StatusOK {
+			t.Fatalf("unable to set rate status %d err %v", status, err)
+		}
+
+		account := createAccount(t, "MXN")
+
+		status, created, err := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10"),
+			QuoteID:         &quote.ID,
+		})
+		if err != nil || status != http.StatusCreated || created == nil {
+			t.Fatalf("unable to create quoted transaction status %d err %v", status, err)
+		}
+
+		status, transaction, err := testServer.CallGetTransaction(created.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || transaction == nil:
+			t.Fatalf("unable to fetch transaction status %d err %v", status, err)
+		case transaction.Amount != models.MustParseMoney("-171.23") || transaction.Currency != "MXN":
+			t.Errorf("expected -171.23 MXN got %s %s", transaction.Amount, transaction.Currency)
+		case transaction.OriginalAmount == nil || *transaction.OriginalAmount != models.MustParseMoney("-10") || transaction.OriginalCurrency != "USD":
+			t.Errorf("expected the original -10.00 USD got %v %s", transaction.OriginalAmount, transaction.OriginalCurrency)
+		case transaction.FXRate == nil || *transaction.FXRate != quote.Rate:
+			t.Errorf("expected rate %s got %v", quote.Rate, transaction.FXRate)
+		case transaction.FXQuoteID == nil || *transaction.FXQuoteID != quote.ID:
+			t.Errorf("expected quote %d got %v", quote.ID, transaction.FXQuoteID)
+		}
+
+		// a quote books a single transaction
+		status, _, _ = testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10"),
+			QuoteID:         &quote.ID,
+		})
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d for a used quote got %d", http.StatusUnprocessableEntity, status)
+		}
+
+		// a quote only converts into the currency it was created for
+		other := createAccount(t, "BRL")
+		status, _, _ = testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       other.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10"),
+			QuoteID:         &quote.ID,
+		})
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d for a quote into another currency got %d", http.StatusUnprocessableEntity, status)
+		}
+
+		status, _, _ = testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10"),
+			Currency:        "EUR",
+			QuoteID:         &quote.ID,
+		})
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d for a quote from another currency got %d", http.StatusUnprocessableEntity, status)
+		}
+
+		missingID := quote.ID + 1000
+		status, _, _ = testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10"),
+			QuoteID:         &missingID,
+		})
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d for a missing quote got %d", http.StatusNotFound, status)
+		}
+	})
+
+	t.Run("Quote errors", func(t *testing.T) {
+		if status, _, _ := testServer.CallCreateFXQuote(&server.CreateFXQuoteRequest{From: "PYG", To: "KRW"}); status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d without a rate got %d", http.StatusUnprocessableEntity, status)
+		}
+
+		if status, _, _ := testServer.CallCreateFXQuote([]byte(`{"from": "USD", "to": "usd"}`)); status != http.StatusBadRequest {
+			t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+		}
+
+		if status, _, _ := testServer.CallGetFXQuote(int64(testutils.GenerateRandomNumberInt(9))); status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+
+		status, _, _ := testServer.CallCreateTransactionWithBody([]byte(fmt.Sprintf(`{"account_id": %d, "operation_type_id": 1, "amount": 10, "quote_id": 0}`, createAccount(t, "MXN").AccountID)))
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d for an invalid quote id got %d", http.StatusBadRequest, status)
+		}
+	})
+}
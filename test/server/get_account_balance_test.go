@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestGetAccountBalance(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	t.Run("Balance of an account with open positions", func(t *testing.T) {
+
+		account, err := testServer.AccountsService.Create(ctx, models.Account{
+			DocumentNumber: testutils.GenerateRandomNumber(10),
+		})
+		if err != nil {
+			t.Errorf("unable to create account [%s]", err)
+		}
+
+		// purchase of 50 and withdrawal of 20 partially paid by a credit of 60
+		for _, req := range []server.CreateTransactionRequest{
+			{AccountID: account.AccountID, OperationTypeID: 1, Amount: models.MustParseMoney("50")},
+			{AccountID: account.AccountID, OperationTypeID: 3, Amount: models.MustParseMoney("20")},
+			{AccountID: account.AccountID, OperationTypeID: 4, Amount: models.MustParseMoney("60")},
+		} {
+			req := req
+			if status, _, err := testServer.CallCreateTransaction(&req); err != nil || status != http.StatusCreated {
+				t.Fatalf("unable to create transaction status %d err %v", status, err)
+			}
+		}
+
+		status, balance, err := testServer.CallGetAccountBalance(account.AccountID)
+		switch {
+		case err != nil || status != http.StatusOK || balance == nil:
+			t.Fatalf("unable to fetch balance status %d err %v", status, err)
+		case balance.AvailableCredit != 0:
+			t.Errorf("expected no available credit got %s", balance.AvailableCredit)
+		case balance.OutstandingDebt != models.MustParseMoney("10"):
+			t.Errorf("expected outstanding debt 10.00 got %s", balance.OutstandingDebt)
+		case len(balance.OperationTypes) != 3:
+			t.Errorf("expected 3 operation types got %d", len(balance.OperationTypes))
+		}
+
+		for _, operationType := range balance.OperationTypes {
+			if operationType.OperationTypeID == 3 && operationType.OutstandingDebt != models.MustParseMoney("10") {
+				t.Errorf("expected withdrawal debt 10.00 got %s", operationType.OutstandingDebt)
+			}
+		}
+
+		req := &server.CreateTransactionRequest{AccountID: account.AccountID, OperationTypeID: 4, Amount: models.MustParseMoney("25")}
+		if status, _, err := testServer.CallCreateTransaction(req); err != nil || status != http.StatusCreated {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+
+		status, balance, err = testServer.CallGetAccountBalance(account.AccountID)
+		switch {
+		case err != nil || status != http.StatusOK || balance == nil:
+			t.Fatalf("unable to fetch balance status %d err %v", status, err)
+		case balance.AvailableCredit != models.MustParseMoney("15"):
+			t.Errorf("expected available credit 15.00 got %s", balance.AvailableCredit)
+		case balance.OutstandingDebt != 0:
+			t.Errorf("expected no outstanding debt got %s", balance.OutstandingDebt)
+		}
+	})
+
+	t.Run("Balance of an account that does not exist", func(t *testing.T) {
+
+		status, balance, _ := testServer.CallGetAccountBalance(int64(testutils.GenerateRandomNumberInt(10)))
+		switch {
+		case status != http.StatusNotFound:
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		case balance != nil:
+			t.Errorf("unexpected response %v", *balance)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestGetTransaction(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	t.Run("Fetch transaction", func(t *testing.T) {
+
+		account, err := testServer.AccountsService.Create(ctx, models.Account{
+			DocumentNumber: testutils.GenerateRandomNumber(10),
+		})
+		if err != nil {
+			t.Errorf("unable to create account [%s]", err)
+		}
+
+		req := &server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: 1,
+			Amount:          models.MustParseMoney("50.25"),
+		}
+
+		status, created, err := testServer.CallCreateTransaction(req)
+		if err != nil || status != http.StatusCreated || created == nil {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+
+		status, transaction, err := testServer.CallGetTransaction(created.TransactionID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch transaction from http request [%s]", err.Error())
+		case status != http.StatusOK:
+			t.Errorf("expected status %d got %d", http.StatusOK, status)
+		case transaction == nil:
+			t.Errorf("empty transaction response")
+		case transaction.TransactionID != created.TransactionID:
+			t.Errorf("expected transaction id %d got %d", created.TransactionID, transaction.TransactionID)
+		case transaction.AccountID != account.AccountID:
+			t.Errorf("expected account id %d got %d", account.AccountID, transaction.AccountID)
+		case transaction.OperationType.OperationTypeID != 1:
+			t.Errorf("expected operation type 1 got %d", transaction.OperationType.OperationTypeID)
+		case transaction.OperationType.Description != "Normal Purchase":
+			t.Errorf("unexpected operation type description %s", transaction.OperationType.Description)
+		case transaction.Amount != -req.Amount:
+			t.Errorf("expected amount -%s got %s", req.Amount, transaction.Amount)
+		case transaction.Balance != -req.Amount:
+			t.Errorf("expected balance -%s got %s", req.Amount, transaction.Balance)
+		case transaction.EventDate.IsZero():
+			t.Errorf("empty event date")
+		}
+	})
+
+	t.Run("Fetch with an ID that does not exist", func(t *testing.T) {
+
+		status, transaction, _ := testServer.CallGetTransaction(int64(testutils.GenerateRandomNumberInt(10)))
+		switch {
+		case status != http.StatusNotFound:
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		case transaction != nil:
+			t.Errorf("unexpected response %v", *transaction)
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	t.Run("Retried account creation is replayed", func(t *testing.T) {
+
+		key := testutils.GenerateRandomNumber(12)
+		req := &server.CreateAccountRequest{
+			DocumentNumber: testutils.GenerateCPF(),
+		}
+
+		status, first, err := testServer.CallPostWithIdempotencyKey(server.CreateAccountExtension, req, key)
+		if err != nil {
+			t.Errorf("create request failed [%s]", err)
+		}
+
+		if status != http.StatusCreated {
+			t.Errorf("expected status %d got %d", http.StatusCreated, status)
+		}
+
+		status, second, err := testServer.CallPostWithIdempotencyKey(server.CreateAccountExtension, req, key)
+		if err != nil {
+			t.Errorf("create request failed [%s]", err)
+		}
+
+		if status != http.StatusCreated {
+			t.Errorf("expected replayed status %d got %d", http.StatusCreated, status)
+		}
+
+		if string(first) != string(second) {
+			t.Errorf("expected replayed body %s got %s", string(first), string(second))
+		}
+	})
+
+	t.Run("Retried transaction creation is replayed", func(t *testing.T) {
+
+		account, err := testServer.AccountsService.Create(ctx, models.Account{
+			DocumentNumber: testutils.GenerateRandomNumber(10),
+		})
+		if err != nil {
+			t.Errorf("unable to create account [%s]", err)
+		}
+
+		key := testutils.GenerateRandomNumber(12)
+		req := &server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: 1,
+			Amount:          models.MustParseMoney("10"),
+		}
+
+		responses := make([]server.CreateTransactionResponse, 0)
+
+		for i := 0; i < 2; i++ {
+			status, body, err := testServer.CallPostWithIdempotencyKey(server.CreateTransactionExtension, req, key)
+			if err != nil {
+				t.Errorf("create request failed [%s]", err)
+			}
+
+			if status != http.StatusCreated {
+				t.Errorf("expected status %d got %d", http.StatusCreated, status)
+			}
+
+			resp := server.CreateTransactionResponse{}
+			if err := json.Unmarshal(body, &resp); err != nil {
+				t.Errorf("unable to unmarshal response [%s]", err)
+			}
+			responses = append(responses, resp)
+		}
+
+		if responses[0].TransactionID != responses[1].TransactionID {
+			t.Errorf("expected the same transaction id got %d and %d", responses[0].TransactionID, responses[1].TransactionID)
+		}
+	})
+
+	t.Run("Same key with a different payload is rejected", func(t *testing.T) {
+
+		account, err := testServer.AccountsService.Create(ctx, models.Account{
+			DocumentNumber: testutils.GenerateRandomNumber(10),
+		})
+		if err != nil {
+			t.Errorf("unable to create account [%s]", err)
+		}
+
+		key := testutils.GenerateRandomNumber(12)
+		req := &server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: 1,
+			Amount:          models.MustParseMoney("10"),
+		}
+
+		status, _, err := testServer.CallPostWithIdempotencyKey(server.CreateTransactionExtension, req, key)
+		if err != nil {
+			t.Errorf("create request failed [%s]", err)
+		}
+
+		if status != http.StatusCreated {
+			t.Errorf("expected status %d got %d", http.StatusCreated, status)
+		}
+
+		req.Amount = models.MustParseMoney("20")
+
+		status, _, err = testServer.CallPostWithIdempotencyKey(server.CreateTransactionExtension, req, key)
+		if err != nil {
+			t.Errorf("create request failed [%s]", err)
+		}
+
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d got %d", http.StatusUnprocessableEntity, status)
+		}
+	})
+
+	t.Run("Business rule errors release the key", func(t *testing.T) {
+
+		limit := models.MustParseMoney("5")
+		account, err := testServer.AccountsService.Create(ctx, models.Account{
+			DocumentNumber:       testutils.GenerateRandomNumber(10),
+			AvailableCreditLimit: &limit,
+		})
+		if err != nil {
+			t.Fatalf("unable to create account [%s]", err)
+		}
+
+		key := testutils.GenerateRandomNumber(12)
+		req := &server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: 1,
+			Amount:          models.MustParseMoney("10"),
+		}
+
+		status, _, err := testServer.CallPostWithIdempotencyKey(server.CreateTransactionExtension, req, key)
+		if err != nil || status != http.StatusUnprocessableEntity {
+			t.Fatalf("expected status %d got %d err %v", http.StatusUnprocessableEntity, status, err)
+		}
+
+		// the failure was not stored, so the retry runs again and succeeds now that the limit allows it
+		limit = models.MustParseMoney("50")
+		if _, err := testServer.AccountsService.UpdateCreditLimit(ctx, account.AccountID, &limit); err != nil {
+			t.Fatalf("unable to update credit limit [%s]", err)
+		}
+
+		status, second, err := testServer.CallPostWithIdempotencyKey(server.CreateTransactionExtension, req, key)
+		switch {
+		case err != nil:
+			t.Errorf("create request failed [%s]", err)
+		case status != http.StatusCreated:
+			t.Errorf("expected status %d got %d", http.StatusCreated, status)
+		}
+
+		// the success is final and replayed from now on
+		status, third, err := testServer.CallPostWithIdempotencyKey(server.CreateTransactionExtension, req, key)
+		switch {
+		case err != nil:
+			t.Errorf("create request failed [%s]", err)
+		case status != http.StatusCreated:
+			t.Errorf("expected replayed status %d got %d", http.StatusCreated, status)
+		case string(second) != string(third):
+			t.Errorf("expected replayed body %s got %s", string(second), string(third))
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestTransactionInstallments(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	account, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber: testutils.GenerateRandomNumber(10),
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	t.Run("Purchase with installments", func(t *testing.T) {
+
+		status, created, err := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.PurchaseWithInstallments),
+			Amount:          models.MustParseMoney("100"),
+			Installments:    3,
+		})
+		if err != nil || status != http.StatusCreated || created == nil {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+
+		status, transaction, err := testServer.CallGetTransaction(created.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || transaction == nil:
+			t.Errorf("unable to fetch transaction status %d err %v", status, err)
+		case transaction.Installments != 3:
+			t.Errorf("expected 3 installments got %d", transaction.Installments)
+		case transaction.Amount != models.MustParseMoney("-100"):
+			t.Errorf("expected amount -100.00 got %s", transaction.Amount)
+		case transaction.Balance != models.MustParseMoney("-33.34"):
+			t.Errorf("expected balance -33.34 got %s", transaction.Balance)
+		}
+
+		status, schedule, err := testServer.CallListTransactionInstallments(created.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || schedule == nil:
+			t.Fatalf("unable to list installments status %d err %v", status, err)
+		case schedule.TransactionID != created.TransactionID:
+			t.Errorf("expected transaction id %d got %d", created.TransactionID, schedule.TransactionID)
+		case len(schedule.Installments) != 3:
+			t.Fatalf("expected 3 installments got %d", len(schedule.Installments))
+		}
+
+		var sum models.Money
+		for _, installment := range schedule.Installments {
+			sum += installment.Amount
+		}
+		if sum != models.MustParseMoney("100") {
+			t.Errorf("expected installments to sum to 100.00 got %s", sum)
+		}
+
+		if !schedule.Installments[0].Posted || schedule.Installments[1].Posted {
+			t.Errorf("expected only the first installment to be posted")
+		}
+	})
+
+	t.Run("Transaction without installments", func(t *testing.T) {
+
+		status, created, err := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney("10"),
+		})
+		if err != nil || status != http.StatusCreated || created == nil {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+
+		status, schedule, err := testServer.CallListTransactionInstallments(created.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || schedule == nil:
+			t.Errorf("unable to list installments status %d err %v", status, err)
+		case len(schedule.Installments) != 0:
+			t.Errorf("expected no installments got %d", len(schedule.Installments))
+		}
+	})
+
+	t.Run("Bad requests", func(t *testing.T) {
+
+		tests := []struct {
+			description string
+			body        string
+		}{
+			{description: "Installments on a normal purchase", body: `{"account_id": %d, "operation_type_id": 1, "amount": 100, "installments": 2}`},
+			{description: "Too many installments", body: `{"account_id": %d, "operation_type_id": 2, "amount": 100, "installments": 25}`},
+			{description: "Negative installments", body: `{"account_id": %d, "operation_type_id": 2, "amount": 100, "installments": -1}`},
+			{description: "Amount smaller than the installments", body: `{"account_id": %d, "operation_type_id": 2, "amount": 0.02, "installments": 3}`},
+		}
+
+		for _, test := range tests {
+			t.Run(test.description, func(t *testing.T) {
+				status, _, _ := testServer.CallCreateTransactionWithBody([]byte(fmt.Sprintf(test.body, account.AccountID)))
+				if status != http.StatusBadRequest {
+					t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+				}
+			})
+		}
+	})
+
+	t.Run("Missing transaction", func(t *testing.T) {
+
+		status, _, _ := testServer.CallListTransactionInstallments(int64(testutils.GenerateRandomNumberInt(10)))
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestParseListTransactionsQuery(t *testing.T) {
+
+	type TestData struct {
+		description string
+		query       url.Values
+		expectErr   bool
+	}
+
+	tests := []TestData{
+		{description: "Empty query", query: url.Values{}},
+		{description: "Operation types", query: url.Values{"operation_type_id": {"1,2", "4"}}},
+		{description: "Date range", query: url.Values{"from": {"2024-01-01T00:00:00Z"}, "to": {"2024-02-01T00:00:00Z"}}},
+		{description: "Open status", query: url.Values{"status": {"open"}}},
+		{description: "Settled status", query: url.Values{"status": {"settled"}}},
+		{description: "Limit", query: url.Values{"limit": {"10"}}},
+		{description: "Invalid operation type", query: url.Values{"operation_type_id": {"one"}}, expectErr: true},
+		{description: "Invalid date", query: url.Values{"from": {"yesterday"}}, expectErr: true},
+		{description: "Inverted date range", query: url.Values{"from": {"2024-02-01T00:00:00Z"}, "to": {"2024-01-01T00:00:00Z"}}, expectErr: true},
+		{description: "Invalid status", query: url.Values{"status": {"closed"}}, expectErr: true},
+		{description: "Invalid cursor", query: url.Values{"cursor": {"not-a-cursor"}}, expectErr: true},
+		{description: "Limit too large", query: url.Values{"limit": {"1000"}}, expectErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := server.ParseListTransactionsQuery(1, test.query)
+			switch {
+			case test.expectErr && err == nil:
+				t.Errorf("expected error for %v", test.query)
+			case !test.expectErr && err != nil:
+				t.Errorf("unexpected error for %v [%s]", test.query, err)
+			}
+		})
+	}
+
+	t.Run("Filter values", func(t *testing.T) {
+
+		cursor := models.TransactionCursor{ID: 7}
+		filter, err := server.ParseListTransactionsQuery(3, url.Values{
+			"operation_type_id": {"1,2", "4"},
+			"status":            {"open"},
+			"cursor":            {cursor.Encode()},
+			"limit":             {"10"},
+		})
+		switch {
+		case err != nil:
+			t.Errorf("unexpected error [%s]", err)
+		case filter.AccountID != 3:
+			t.Errorf("expected account id 3 got %d", filter.AccountID)
+		case len(filter.OperationTypeIDs) != 3:
+			t.Errorf("expected 3 operation types got %v", filter.OperationTypeIDs)
+		case filter.Settled == nil || *filter.Settled:
+			t.Errorf("expected open transactions filter")
+		case filter.After == nil || filter.After.ID != 7:
+			t.Errorf("expected cursor with id 7 got %v", filter.After)
+		case filter.Limit != 10:
+			t.Errorf("expected limit 10 got %d", filter.Limit)
+		}
+	})
+}
+
+func TestListAccountTransactions(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	account, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber: testutils.GenerateRandomNumber(10),
+	})
+	if err != nil {
+		t.Errorf("unable to create account [%s]", err)
+	}
+
+	// two purchases, a withdrawal and a credit voucher settling the first purchase
+	for _, req := range []server.CreateTransactionRequest{
+		{AccountID: account.AccountID, OperationTypeID: 1, Amount: models.MustParseMoney("10")},
+		{AccountID: account.AccountID, OperationTypeID: 1, Amount: models.MustParseMoney("20")},
+		{AccountID: account.AccountID, OperationTypeID: 3, Amount: models.MustParseMoney("30")},
+		{AccountID: account.AccountID, OperationTypeID: 4, Amount: models.MustParseMoney("10")},
+	} {
+		req := req
+		if status, _, err := testServer.CallCreateTransaction(&req); err != nil || status != http.StatusCreated {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+	}
+
+	t.Run("Paginate newest first", func(t *testing.T) {
+
+		ids := make([]int64, 0)
+		query := url.Values{"limit": {"3"}}
+
+		for {
+			status, resp, err := testServer.CallListAccountTransactions(account.AccountID, query)
+			if err != nil || status != http.StatusOK || resp == nil {
+				t.Fatalf("unable to list transactions status %d err %v", status, err)
+			}
+
+			for _, transaction := range resp.Transactions {
+				ids = append(ids, transaction.TransactionID)
+			}
+
+			if resp.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", resp.NextCursor)
+		}
+
+		if len(ids) != 4 {
+			t.Fatalf("expected 4 transactions got %d", len(ids))
+		}
+
+		for i := 1; i < len(ids); i++ {
+			if ids[i] >= ids[i-1] {
+				t.Errorf("transactions not ordered newest first %v", ids)
+			}
+		}
+	})
+
+	t.Run("Filter by operation type", func(t *testing.T) {
+
+		status, resp, err := testServer.CallListAccountTransactions(account.AccountID, url.Values{"operation_type_id": {"3"}})
+		switch {
+		case err != nil || status != http.StatusOK || resp == nil:
+			t.Errorf("unable to list transactions status %d err %v", status, err)
+		case len(resp.Transactions) != 1:
+			t.Errorf("expected 1 transaction got %d", len(resp.Transactions))
+		case resp.Transactions[0].OperationType.OperationTypeID != 3:
+			t.Errorf("expected operation type 3 got %d", resp.Transactions[0].OperationType.OperationTypeID)
+		}
+	})
+
+	t.Run("Filter by settlement status", func(t *testing.T) {
+
+		status, resp, err := testServer.CallListAccountTransactions(account.AccountID, url.Values{"status": {"open"}})
+		switch {
+		case err != nil || status != http.StatusOK || resp == nil:
+			t.Errorf("unable to list transactions status %d err %v", status, err)
+		case len(resp.Transactions) != 2:
+			t.Errorf("expected 2 open transactions got %d", len(resp.Transactions))
+		}
+
+		status, resp, err = testServer.CallListAccountTransactions(account.AccountID, url.Values{"status": {"settled"}})
+		switch {
+		case err != nil || status != http.StatusOK || resp == nil:
+			t.Errorf("unable to list transactions status %d err %v", status, err)
+		case len(resp.Transactions) != 2:
+			t.Errorf("expected 2 settled transactions got %d", len(resp.Transactions))
+		}
+	})
+
+	t.Run("List for an account that does not exist", func(t *testing.T) {
+
+		status, _, _ := testServer.CallListAccountTransactions(int64(testutils.GenerateRandomNumberInt(10)), url.Values{})
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestOperationTypes(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	t.Run("List seeded operation types", func(t *testing.T) {
+
+		status, resp, err := testServer.CallListOperationTypes()
+		if err != nil || status != http.StatusOK || resp == nil {
+			t.Fatalf("unable to list operation types status %d err %v", status, err)
+		}
+
+		seeded := map[int64]models.OperationType{}
+		for _, operationType := range resp.OperationTypes {
+			seeded[operationType.ID] = operationType
+		}
+
+		for _, expected := range models.DefaultOperationTypes() {
+			operationType, ok := seeded[expected.ID]
+			switch {
+			case !ok:
+				t.Errorf("operation type %d missing", expected.ID)
+			case operationType.Direction != expected.Direction:
+				t.Errorf("operation type %d expected direction %s got %s", expected.ID, expected.Direction, operationType.Direction)
+			}
+		}
+	})
+
+	t.Run("Bad requests", func(t *testing.T) {
+
+		for _, req := range []server.CreateOperationTypeRequest{
+			{Description: "", Direction: models.DebitDirection},
+			{Description: "Chargeback", Direction: "sideways"},
+		} {
+			req := req
+			status, _, err := testServer.CallCreateOperationType(&req)
+			if err != nil {
+				t.Errorf("create request failed [%s]", err)
+			}
+
+			if status != http.StatusBadRequest {
+				t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+			}
+		}
+	})
+
+	t.Run("Book transactions with a new operation type", func(t *testing.T) {
+
+		status, operationType, err := testServer.CallCreateOperationType(&server.CreateOperationTypeRequest{
+			Description: "Refund " + testutils.GenerateRandomNumber(6),
+			Direction:   models.CreditDirection,
+		})
+		if err != nil || status != http.StatusCreated || operationType == nil {
+			t.Fatalf("unable to create operation type status %d err %v", status, err)
+		}
+
+		if operationType.ID < 100 || !operationType.Active {
+			t.Errorf("unexpected operation type %v", *operationType)
+		}
+
+		account, err := testServer.AccountsService.Create(ctx, models.Account{
+			DocumentNumber: testutils.GenerateRandomNumber(10),
+		})
+		if err != nil {
+			t.Errorf("unable to create account [%s]", err)
+		}
+
+		req := &server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: operationType.ID,
+			Amount:          models.MustParseMoney("15"),
+		}
+
+		status, resp, err := testServer.CallCreateTransaction(req)
+		if err != nil || status != http.StatusCreated || resp == nil {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+
+		transaction, err := testServer.TransactionService.GetForID(ctx, resp.TransactionID)
+		switch {
+		case err != nil:
+			t.Errorf("unable to fetch created transaction [%s]", err)
+		case transaction.Amount != req.Amount:
+			t.Errorf("expected credit %s got %s", req.Amount, transaction.Amount)
+		}
+
+		active := false
+		status, operationType, err = testServer.CallUpdateOperationType(operationType.ID, &server.UpdateOperationTypeRequest{Active: &active})
+		if err != nil || status != http.StatusOK || operationType == nil {
+			t.Fatalf("unable to deactivate operation type status %d err %v", status, err)
+		}
+
+		status, _, err = testServer.CallCreateTransaction(req)
+		if err != nil {
+			t.Errorf("error creating the transaction [%s]", err)
+		}
+
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d for an inactive operation type got %d", http.StatusBadRequest, status)
+		}
+	})
+
+	t.Run("Update an operation type that does not exist", func(t *testing.T) {
+
+		active := true
+		status, _, _ := testServer.CallUpdateOperationType(int64(testutils.GenerateRandomNumberInt(9)), &server.UpdateOperationTypeRequest{Active: &active})
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
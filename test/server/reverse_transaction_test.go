@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	createPurchase := func(t *testing.T, amount string) *server.CreateTransactionResponse {
+		account, err := testServer.AccountsService.Create(ctx, models.Account{
+			DocumentNumber: testutils.GenerateRandomNumber(10),
+		})
+		if err != nil {
+			t.Fatalf("unable to create account [%s]", err)
+		}
+
+		status, created, err := testServer.CallCreateTransaction(&server.CreateTransactionRequest{
+			AccountID:       account.AccountID,
+			OperationTypeID: int64(models.NormalPurchase),
+			Amount:          models.MustParseMoney(amount),
+		})
+		if err != nil || status != http.StatusCreated || created == nil {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+		return created
+	}
+
+	t.Run("Full reversal", func(t *testing.T) {
+
+		purchase := createPurchase(t, "50.25")
+
+		status, reversal, err := testServer.CallReverseTransaction(purchase.TransactionID, nil)
+		if err != nil || status != http.StatusCreated || reversal == nil {
+			t.Fatalf("unable to reverse transaction status %d err %v", status, err)
+		}
+
+		status, transaction, err := testServer.CallGetTransaction(reversal.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || transaction == nil:
+			t.Errorf("unable to fetch reversal status %d err %v", status, err)
+		case transaction.AccountID != purchase.AccountID:
+			t.Errorf("expected account id %d got %d", purchase.AccountID, transaction.AccountID)
+		case transaction.OperationType.OperationTypeID != int64(models.PurchaseReversal):
+			t.Errorf("expected operation type %d got %d", models.PurchaseReversal, transaction.OperationType.OperationTypeID)
+		case transaction.Amount != models.MustParseMoney("50.25"):
+			t.Errorf("expected amount 50.25 got %s", transaction.Amount)
+		case transaction.ReversesTransactionID == nil || *transaction.ReversesTransactionID != purchase.TransactionID:
+			t.Errorf("expected reversal of %d got %v", purchase.TransactionID, transaction.ReversesTransactionID)
+		}
+
+		status, original, err := testServer.CallGetTransaction(purchase.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || original == nil:
+			t.Errorf("unable to fetch original status %d err %v", status, err)
+		case original.Balance != 0:
+			t.Errorf("expected original balance 0.00 got %s", original.Balance)
+		}
+
+		status, _, _ = testServer.CallReverseTransaction(purchase.TransactionID, nil)
+		if status != http.StatusConflict {
+			t.Errorf("expected status %d got %d", http.StatusConflict, status)
+		}
+	})
+
+	t.Run("Partial reversal", func(t *testing.T) {
+
+		purchase := createPurchase(t, "50")
+
+		status, reversal, err := testServer.CallReverseTransaction(purchase.TransactionID, &server.ReverseTransactionRequest{
+			Amount: models.MustParseMoney("20"),
+		})
+		if err != nil || status != http.StatusCreated || reversal == nil {
+			t.Fatalf("unable to reverse transaction status %d err %v", status, err)
+		}
+
+		status, original, err := testServer.CallGetTransaction(purchase.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || original == nil:
+			t.Errorf("unable to fetch original status %d err %v", status, err)
+		case original.Balance != models.MustParseMoney("-30"):
+			t.Errorf("expected original balance -30.00 got %s", original.Balance)
+		}
+
+		status, reversal, err = testServer.CallReverseTransaction(purchase.TransactionID, nil)
+		switch {
+		case err != nil || status != http.StatusCreated || reversal == nil:
+			t.Fatalf("unable to reverse the rest of the transaction status %d err %v", status, err)
+		}
+
+		status, original, err = testServer.CallGetTransaction(purchase.TransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || original == nil:
+			t.Errorf("unable to fetch original status %d err %v", status, err)
+		case original.Balance != 0:
+			t.Errorf("expected original balance 0.00 got %s", original.Balance)
+		}
+	})
+
+	t.Run("Amount exceeding the transaction", func(t *testing.T) {
+
+		purchase := createPurchase(t, "50")
+
+		status, _, _ := testServer.CallReverseTransaction(purchase.TransactionID, &server.ReverseTransactionRequest{
+			Amount: models.MustParseMoney("50.01"),
+		})
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d got %d", http.StatusUnprocessableEntity, status)
+		}
+	})
+
+	t.Run("Reverse a credit", func(t *testing.T) {
+
+		purchase := createPurchase(t, "50")
+
+		status, reversal, err := testServer.CallReverseTransaction(purchase.TransactionID, nil)
+		if err != nil || status != http.StatusCreated || reversal == nil {
+			t.Fatalf("unable to reverse transaction status %d err %v", status, err)
+		}
+
+		status, _, _ = testServer.CallReverseTransaction(reversal.TransactionID, nil)
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d got %d", http.StatusUnprocessableEntity, status)
+		}
+	})
+
+	t.Run("Invalid amount", func(t *testing.T) {
+
+		purchase := createPurchase(t, "50")
+
+		status, _, _ := testServer.CallReverseTransaction(purchase.TransactionID, &server.ReverseTransactionRequest{
+			Amount: models.MustParseMoney("-1"),
+		})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+		}
+	})
+
+	t.Run("Missing transaction", func(t *testing.T) {
+
+		status, _, _ := testServer.CallReverseTransaction(int64(testutils.GenerateRandomNumberInt(10)), nil)
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func TestSchedules(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	account, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber: testutils.GenerateRandomNumber(10),
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	// the scheduler job only runs every minute, the tests run one of their own
+	scheduler := models.NewScheduler(testServer.ScheduleService, testServer.TransactionService, testServer.OperationTypeService, testServer.IdempotencyService, time.Minute)
+
+	t.Run("Recurring purchase", func(t *testing.T) {
+
+		startAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		status, schedule, err := testServer.CallCreateSchedule(map[string]any{
+			"account_id":        account.AccountID,
+			"operation_type_id": models.NormalPurchase,
+			"amount":            "12.50",
+			"frequency":         models.WeeklySchedule,
+			"start_at":          startAt,
+		})
+		switch {
+		case err != nil || status != http.StatusCreated || schedule == nil:
+			t.Fatalf("unable to create schedule status %d err %v", status, err)
+		case schedule.Amount != models.MustParseMoney("-12.50"):
+			t.Errorf("expected amount -12.50 got %s", schedule.Amount)
+		case schedule.Status != models.ScheduleActive || !schedule.NextRunAt.Equal(startAt) || schedule.Interval != 1:
+			t.Errorf("expected an active weekly schedule from %s got %+v", startAt, schedule)
+		}
+
+		if runs, err := scheduler.RunDue(ctx, time.Now()); err != nil || runs != 0 {
+			t.Fatalf("expected no runs before the start got %d err %v", runs, err)
+		}
+
+		if runs, err := scheduler.RunDue(ctx, startAt); err != nil || runs != 1 {
+			t.Fatalf("expected 1 run got %d err %v", runs, err)
+		}
+
+		status, schedule, err = testServer.CallGetSchedule(schedule.ID)
+		switch {
+		case err != nil || status != http.StatusOK || schedule == nil:
+			t.Fatalf("unable to fetch schedule status %d err %v", status, err)
+		case schedule.Occurrences != 1 || !schedule.NextRunAt.Equal(startAt.AddDate(0, 0, 7)):
+			t.Errorf("expected the next run a week after the start got %+v", schedule)
+		}
+
+		status, list, err := testServer.CallListScheduleRuns(schedule.ID)
+		switch {
+		case err != nil || status != http.StatusOK || list == nil:
+			t.Fatalf("unable to list runs status %d err %v", status, err)
+		case len(list.Runs) != 1:
+			t.Fatalf("expected 1 run got %+v", list.Runs)
+		case list.Runs[0].Status != models.ScheduleRunSucceeded || list.Runs[0].TransactionID == nil:
+			t.Fatalf("expected a succeeded run with a transaction got %+v", list.Runs[0])
+		}
+
+		transaction, err := testServer.TransactionService.GetForID(ctx, *list.Runs[0].TransactionID)
+		switch {
+		case err != nil:
+			t.Fatalf("unable to fetch transaction [%s]", err)
+		case transaction.AccountID != account.AccountID || transaction.Amount != models.MustParseMoney("-12.50"):
+			t.Errorf("expected a purchase of 12.50 got %+v", transaction)
+		}
+
+		status, cancelled, err := testServer.CallCancelSchedule(schedule.ID)
+		switch {
+		case err != nil || status != http.StatusOK || cancelled == nil:
+			t.Fatalf("unable to cancel schedule status %d err %v", status, err)
+		case cancelled.Status != models.ScheduleCancelled:
+			t.Errorf("expected status %s got %s", models.ScheduleCancelled, cancelled.Status)
+		}
+
+		if runs, err := scheduler.RunDue(ctx, startAt.AddDate(0, 0, 7)); err != nil || runs != 0 {
+			t.Errorf("expected no runs after the cancellation got %d err %v", runs, err)
+		}
+
+		if status, _, _ := testServer.CallCancelSchedule(schedule.ID); status != http.StatusConflict {
+			t.Errorf("expected status %d got %d", http.StatusConflict, status)
+		}
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+
+		requests := map[string]map[string]any{
+			"Missing amount": {
+				"account_id":        account.AccountID,
+				"operation_type_id": models.NormalPurchase,
+			},
+			"Unknown frequency": {
+				"account_id":        account.AccountID,
+				"operation_type_id": models.NormalPurchase,
+				"amount":            "10",
+				"frequency":         "hourly",
+			},
+			"Start in the past": {
+				"account_id":        account.AccountID,
+				"operation_type_id": models.NormalPurchase,
+				"amount":            "10",
+				"start_at":          time.Now().Add(-time.Hour),
+			},
+			"Interval of a single run": {
+				"account_id":        account.AccountID,
+				"operation_type_id": models.NormalPurchase,
+				"amount":            "10",
+				"interval":          2,
+			},
+			"Interval over a year": {
+				"account_id":        account.AccountID,
+				"operation_type_id": models.NormalPurchase,
+				"amount":            "10",
+				"frequency":         models.MonthlySchedule,
+				"interval":          13,
+			},
+			"Interval out of range": {
+				"account_id":        account.AccountID,
+				"operation_type_id": models.NormalPurchase,
+				"amount":            "10",
+				"frequency":         models.DailySchedule,
+				"interval":          1 << 40,
+			},
+			"End before the start": {
+				"account_id":        account.AccountID,
+				"operation_type_id": models.NormalPurchase,
+				"amount":            "10",
+				"frequency":         models.DailySchedule,
+				"start_at":          time.Now().Add(2 * time.Hour),
+				"end_at":            time.Now().Add(time.Hour),
+			},
+			"Internal operation type": {
+				"account_id":        account.AccountID,
+				"operation_type_id": models.PurchaseReversal,
+				"amount":            "10",
+			},
+		}
+
+		for name, req := range requests {
+			t.Run(name, func(t *testing.T) {
+				if status, _, _ := testServer.CallCreateSchedule(req); status != http.StatusBadRequest {
+					t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+				}
+			})
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+
+		status, _, _ := testServer.CallCreateSchedule(map[string]any{
+			"account_id":        testutils.GenerateRandomNumberInt(10),
+			"operation_type_id": models.NormalPurchase,
+			"amount":            "10",
+		})
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+
+	t.Run("Missing schedule", func(t *testing.T) {
+
+		if status, _, _ := testServer.CallGetSchedule(int64(testutils.GenerateRandomNumberInt(10))); status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+
+		if status, _, _ := testServer.CallListScheduleRuns(int64(testutils.GenerateRandomNumberInt(10))); status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
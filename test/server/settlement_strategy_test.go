@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestSettlementStrategy(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	lifo := models.LIFOSettlementStrategy
+	status, account, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+		DocumentNumber:     testutils.GenerateCPF(),
+		SettlementStrategy: &lifo,
+	})
+	switch {
+	case err != nil || status != http.StatusCreated || account == nil:
+		t.Fatalf("unable to create account status %d err %v", status, err)
+	case account.SettlementStrategy == nil || *account.SettlementStrategy != lifo:
+		t.Fatalf("expected settlement strategy %s got %v", lifo, account.SettlementStrategy)
+	}
+
+	t.Run("Newest debits are paid first", func(t *testing.T) {
+
+		ids := []int64{}
+		for _, req := range []server.CreateTransactionRequest{
+			{AccountID: account.AccountID, OperationTypeID: int64(models.NormalPurchase), Amount: models.MustParseMoney("10")},
+			{AccountID: account.AccountID, OperationTypeID: int64(models.NormalPurchase), Amount: models.MustParseMoney("20")},
+			{AccountID: account.AccountID, OperationTypeID: int64(models.CreditVoucher), Amount: models.MustParseMoney("25")},
+		} {
+			status, created, err := testServer.CallCreateTransaction(&req)
+			if err != nil || status != http.StatusCreated || created == nil {
+				t.Fatalf("unable to create transaction status %d err %v", status, err)
+			}
+			ids = append(ids, created.TransactionID)
+		}
+
+		status, transaction, err := testServer.CallGetTransaction(ids[0])
+		switch {
+		case err != nil || status != http.StatusOK || transaction == nil:
+			t.Errorf("unable to fetch transaction status %d err %v", status, err)
+		case transaction.Balance != models.MustParseMoney("-5"):
+			t.Errorf("expected balance -5.00 got %s", transaction.Balance)
+		}
+	})
+
+	t.Run("Update the strategy", func(t *testing.T) {
+
+		priority := models.PrioritySettlementStrategy
+		status, raccount, err := testServer.CallUpdateSettlementStrategy(account.AccountID, &server.UpdateSettlementStrategyRequest{
+			SettlementStrategy: &priority,
+		})
+		switch {
+		case err != nil || status != http.StatusOK || raccount == nil:
+			t.Fatalf("unable to update settlement strategy status %d err %v", status, err)
+		case raccount.SettlementStrategy == nil || *raccount.SettlementStrategy != priority:
+			t.Errorf("expected settlement strategy %s got %v", priority, raccount.SettlementStrategy)
+		}
+
+		status, raccount, err = testServer.CallUpdateSettlementStrategy(account.AccountID, &server.UpdateSettlementStrategyRequest{})
+		switch {
+		case err != nil || status != http.StatusOK || raccount == nil:
+			t.Fatalf("unable to update settlement strategy status %d err %v", status, err)
+		case raccount.SettlementStrategy != nil:
+			t.Errorf("expected the default settlement strategy got %s", *raccount.SettlementStrategy)
+		}
+	})
+
+	t.Run("Unknown strategy", func(t *testing.T) {
+
+		unknown := "random"
+		status, _, _ := testServer.CallUpdateSettlementStrategy(account.AccountID, &server.UpdateSettlementStrategyRequest{
+			SettlementStrategy: &unknown,
+		})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+		}
+
+		status, _, _ = testServer.CallCreateAccount(&server.CreateAccountRequest{
+			DocumentNumber:     testutils.GenerateCPF(),
+			SettlementStrategy: &unknown,
+		})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+
+		status, _, _ := testServer.CallUpdateSettlementStrategy(int64(testutils.GenerateRandomNumberInt(10)), &server.UpdateSettlementStrategyRequest{})
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
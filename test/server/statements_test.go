@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+	"time"
+)
+
+func TestStatements(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	closingDay, dueDay := 5, 15
+	status, account, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+		DocumentNumber: testutils.GenerateCPF(),
+		ClosingDay:     &closingDay,
+		DueDay:         &dueDay,
+	})
+	switch {
+	case err != nil || status != http.StatusCreated || account == nil:
+		t.Fatalf("unable to create account status %d err %v", status, err)
+	case account.ClosingDay == nil || *account.ClosingDay != closingDay:
+		t.Fatalf("expected closing day %d got %v", closingDay, account.ClosingDay)
+	}
+
+	for _, req := range []server.CreateTransactionRequest{
+		{AccountID: account.AccountID, OperationTypeID: int64(models.NormalPurchase), Amount: models.MustParseMoney("100")},
+		{AccountID: account.AccountID, OperationTypeID: int64(models.CreditVoucher), Amount: models.MustParseMoney("30")},
+	} {
+		status, created, err := testServer.CallCreateTransaction(&req)
+		if err != nil || status != http.StatusCreated || created == nil {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+	}
+
+	// the cycle that closes within the next month holds the transactions created now
+	if _, err := testServer.StatementService.GenerateDue(ctx, time.Now().AddDate(0, 1, 0)); err != nil {
+		t.Fatalf("unable to generate statements [%s]", err)
+	}
+
+	status, list, err := testServer.CallListAccountStatements(account.AccountID)
+	switch {
+	case err != nil || status != http.StatusOK || list == nil:
+		t.Fatalf("unable to list statements status %d err %v", status, err)
+	case len(list.Statements) != 1:
+		t.Fatalf("expected 1 statement got %d", len(list.Statements))
+	case len(list.Statements[0].Lines) != 0:
+		t.Errorf("expected statements to be listed without lines got %d", len(list.Statements[0].Lines))
+	}
+
+	t.Run("Get statement", func(t *testing.T) {
+
+		status, statement, err := testServer.CallGetStatement(list.Statements[0].ID)
+		switch {
+		case err != nil || status != http.StatusOK || statement == nil:
+			t.Fatalf("unable to fetch statement status %d err %v", status, err)
+		case len(statement.Lines) != 2:
+			t.Errorf("expected 2 lines got %d", len(statement.Lines))
+		case statement.OpeningBalance != 0:
+			t.Errorf("expected opening balance 0.00 got %s", statement.OpeningBalance)
+		case statement.ClosingBalance != models.MustParseMoney("-70"):
+			t.Errorf("expected closing balance -70.00 got %s", statement.ClosingBalance)
+		case statement.MinimumPayment != models.MustParseMoney("10.50"):
+			t.Errorf("expected minimum payment 10.50 got %s", statement.MinimumPayment)
+		case statement.PeriodEnd.Day() != closingDay || statement.DueDate.Day() != dueDay:
+			t.Errorf("expected the cycle to close on day %d and be due on day %d got %s and %s", closingDay, dueDay, statement.PeriodEnd, statement.DueDate)
+		}
+	})
+
+	t.Run("Cycle is generated once", func(t *testing.T) {
+
+		if _, err := testServer.StatementService.GenerateDue(ctx, time.Now().AddDate(0, 1, 0)); err != nil {
+			t.Fatalf("unable to generate statements [%s]", err)
+		}
+
+		status, list, err := testServer.CallListAccountStatements(account.AccountID)
+		switch {
+		case err != nil || status != http.StatusOK || list == nil:
+			t.Fatalf("unable to list statements status %d err %v", status, err)
+		case len(list.Statements) != 1:
+			t.Errorf("expected 1 statement got %d", len(list.Statements))
+		}
+	})
+
+	t.Run("Missing statement", func(t *testing.T) {
+
+		status, _, _ := testServer.CallGetStatement(int64(testutils.GenerateRandomNumberInt(10)))
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+
+		status, _, _ = testServer.CallListAccountStatements(int64(testutils.GenerateRandomNumberInt(10)))
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
+
+func TestUpdateBillingCycle(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	account, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber: testutils.GenerateRandomNumber(10),
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	t.Run("Update the cycle", func(t *testing.T) {
+
+		closingDay, dueDay := 20, 2
+		status, raccount, err := testServer.CallUpdateBillingCycle(account.AccountID, &server.UpdateBillingCycleRequest{
+			ClosingDay: &closingDay,
+			DueDay:     &dueDay,
+		})
+		switch {
+		case err != nil || status != http.StatusOK || raccount == nil:
+			t.Fatalf("unable to update billing cycle status %d err %v", status, err)
+		case raccount.ClosingDay == nil || *raccount.ClosingDay != closingDay:
+			t.Errorf("expected closing day %d got %v", closingDay, raccount.ClosingDay)
+		case raccount.DueDay == nil || *raccount.DueDay != dueDay:
+			t.Errorf("expected due day %d got %v", dueDay, raccount.DueDay)
+		}
+
+		status, raccount, err = testServer.CallUpdateBillingCycle(account.AccountID, &server.UpdateBillingCycleRequest{})
+		switch {
+		case err != nil || status != http.StatusOK || raccount == nil:
+			t.Fatalf("unable to update billing cycle status %d err %v", status, err)
+		case raccount.ClosingDay != nil || raccount.DueDay != nil:
+			t.Errorf("expected the default billing cycle got %v and %v", raccount.ClosingDay, raccount.DueDay)
+		}
+	})
+
+	t.Run("Invalid days", func(t *testing.T) {
+
+		for _, day := range []int{0, 29, 31} {
+			status, _, _ := testServer.CallUpdateBillingCycle(account.AccountID, &server.UpdateBillingCycleRequest{
+				ClosingDay: &day,
+			})
+			if status != http.StatusBadRequest {
+				t.Errorf("expected status %d for day %d got %d", http.StatusBadRequest, day, status)
+			}
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+
+		status, _, _ := testServer.CallUpdateBillingCycle(int64(testutils.GenerateRandomNumberInt(10)), &server.UpdateBillingCycleRequest{})
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
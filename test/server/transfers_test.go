@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"testing"
+)
+
+func TestTransfers(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	limit := models.MustParseMoney("50")
+	source, err := testServer.AccountsService.Create(ctx, models.Account{
+		DocumentNumber:       testutils.GenerateRandomNumber(10),
+		AvailableCreditLimit: &limit,
+	})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	destination, err := testServer.AccountsService.Create(ctx, models.Account{DocumentNumber: testutils.GenerateRandomNumber(10)})
+	if err != nil {
+		t.Fatalf("unable to create account [%s]", err)
+	}
+
+	t.Run("Transfer", func(t *testing.T) {
+
+		status, transfer, err := testServer.CallCreateTransfer(&server.CreateTransferRequest{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: destination.AccountID,
+			Amount:               models.MustParseMoney("20"),
+		})
+		switch {
+		case err != nil || status != http.StatusCreated || transfer == nil:
+			t.Fatalf("unable to create transfer status %d err %v", status, err)
+		case transfer.DebitTransactionID == nil || transfer.CreditTransactionID == nil:
+			t.Fatalf("expected both transactions of the transfer")
+		}
+
+		status, debit, err := testServer.CallGetTransaction(*transfer.DebitTransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || debit == nil:
+			t.Errorf("unable to fetch transaction status %d err %v", status, err)
+		case debit.AccountID != source.AccountID || debit.Amount != models.MustParseMoney("-20"):
+			t.Errorf("expected a debit of -20.00 on account %d got %s on %d", source.AccountID, debit.Amount, debit.AccountID)
+		case debit.TransferID == nil || *debit.TransferID != transfer.ID:
+			t.Errorf("expected transfer id %d got %v", transfer.ID, debit.TransferID)
+		}
+
+		status, credit, err := testServer.CallGetTransaction(*transfer.CreditTransactionID)
+		switch {
+		case err != nil || status != http.StatusOK || credit == nil:
+			t.Errorf("unable to fetch transaction status %d err %v", status, err)
+		case credit.AccountID != destination.AccountID || credit.Amount != models.MustParseMoney("20"):
+			t.Errorf("expected a credit of 20.00 on account %d got %s on %d", destination.AccountID, credit.Amount, credit.AccountID)
+		}
+
+		status, fetched, err := testServer.CallGetTransfer(transfer.ID)
+		switch {
+		case err != nil || status != http.StatusOK || fetched == nil:
+			t.Errorf("unable to fetch transfer status %d err %v", status, err)
+		case fetched.Amount != models.MustParseMoney("20"):
+			t.Errorf("expected amount 20.00 got %s", fetched.Amount)
+		}
+	})
+
+	t.Run("Transfer over the limit", func(t *testing.T) {
+
+		status, _, _ := testServer.CallCreateTransfer(&server.CreateTransferRequest{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: destination.AccountID,
+			Amount:               models.MustParseMoney("1000"),
+		})
+		if status != http.StatusUnprocessableEntity {
+			t.Errorf("expected status %d got %d", http.StatusUnprocessableEntity, status)
+		}
+	})
+
+	t.Run("Same account", func(t *testing.T) {
+
+		status, _, _ := testServer.CallCreateTransfer(&server.CreateTransferRequest{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: source.AccountID,
+			Amount:               models.MustParseMoney("10"),
+		})
+		if status != http.StatusBadRequest {
+			t.Errorf("expected status %d got %d", http.StatusBadRequest, status)
+		}
+	})
+
+	t.Run("Missing account", func(t *testing.T) {
+
+		status, _, _ := testServer.CallCreateTransfer(&server.CreateTransferRequest{
+			SourceAccountID:      source.AccountID,
+			DestinationAccountID: int64(testutils.GenerateRandomNumberInt(10)),
+			Amount:               models.MustParseMoney("10"),
+		})
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+
+	t.Run("Missing transfer", func(t *testing.T) {
+
+		status, _, _ := testServer.CallGetTransfer(int64(testutils.GenerateRandomNumberInt(10)))
+		if status != http.StatusNotFound {
+			t.Errorf("expected status %d got %d", http.StatusNotFound, status)
+		}
+	})
+}
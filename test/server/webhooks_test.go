@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+	"payments-backend-app/test/testutils"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhooks(t *testing.T) {
+	ctx := context.Background()
+	testServer := testutils.NewTestServer(t)
+	testServer.Start(ctx)
+	defer testServer.Stop(ctx)
+	testServer.WaitForRunnning(t)
+
+	status, account, err := testServer.CallCreateAccount(&server.CreateAccountRequest{
+		DocumentNumber: testutils.GenerateCPF(),
+	})
+	if err != nil || status != http.StatusCreated || account == nil {
+		t.Fatalf("unable to create account status %d err %v", status, err)
+	}
+
+	t.Run("Invalid webhooks", func(t *testing.T) {
+		for _, body := range []string{
+			`{"url": "ftp://localhost/hook", "event_types": ["TransactionCreated"]}`,
+			`{"url": "/hook", "event_types": ["TransactionCreated"]}`,
+			`{"url": "http://localhost/hook", "event_types": []}`,
+			`{"url": "http://localhost/hook", "event_types": ["TransactionDeleted"]}`,
+			`{"url": "http://localhost/hook", "event_types": ["TransactionCreated"], "account_id": 0}`,
+		} {
+			if status, _, _ := testServer.CallCreateWebhook(json.RawMessage(body)); status != http.StatusBadRequest {
+				t.Errorf("expected status %d for %s got %d", http.StatusBadRequest, body, status)
+			}
+		}
+
+		missing := int64(1_000_000_000)
+		if status, _, _ := testServer.CallCreateWebhook(&server.CreateWebhookRequest{
+			URL:        "http://localhost/hook",
+			EventTypes: []models.EventType{models.TransactionCreatedEvent},
+			AccountID:  &missing,
+		}); status != http.StatusNotFound {
+			t.Errorf("expected status %d for a missing account got %d", http.StatusNotFound, status)
+		}
+
+		if status, _, _ := testServer.CallListWebhookDeliveries(missing); status != http.StatusNotFound {
+			t.Errorf("expected status %d for a missing webhook got %d", http.StatusNotFound, status)
+		}
+	})
+
+	t.Run("Signed deliveries", func(t *testing.T) {
+		var (
+			mu       sync.Mutex
+			secret   string
+			received []string
+		)
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			body, _ := io.ReadAll(r.Body)
+			timestamp, _ := strconv.ParseInt(r.Header.Get(models.WebhookTimestampHeader), 10, 64)
+			if r.Header.Get(models.WebhookSignatureHeader) != models.SignWebhookPayload(secret, timestamp, body) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			received = append(received, r.Header.Get(models.WebhookEventHeader))
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer receiver.Close()
+
+		mu.Lock()
+		status, webhook, err := testServer.CallCreateWebhook(&server.CreateWebhookRequest{
+			URL:        receiver.URL,
+			EventTypes: []models.EventType{models.TransactionCreatedEvent},
+			AccountID:  &account.AccountID,
+		})
+		if err != nil || status != http.StatusCreated || webhook == nil {
+			mu.Unlock()
+			t.Fatalf("unable to create webhook status %d err %v", status, err)
+		}
+		secret = webhook.Secret
+		mu.Unlock()
+
+		if secret == "" {
+			t.Fatalf("expected the secret returned on create")
+		}
+
+		req := server.CreateTransactionRequest{AccountID: account.AccountID, OperationTypeID: int64(models.NormalPurchase), Amount: models.MustParseMoney("10")}
+		if status, _, err := testServer.CallCreateTransaction(&req); err != nil || status != http.StatusCreated {
+			t.Fatalf("unable to create transaction status %d err %v", status, err)
+		}
+
+		// the dispatcher runs every second
+		var deliveries []models.WebhookDelivery
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+			status, resp, err := testServer.CallListWebhookDeliveries(webhook.ID)
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("unable to list deliveries status %d err %v", status, err)
+			}
+
+			deliveries = resp.Deliveries
+			if len(deliveries) == 1 && deliveries[0].Status == models.WebhookDeliverySucceeded {
+				break
+			}
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case len(deliveries) != 1 || deliveries[0].Status != models.WebhookDeliverySucceeded:
+			t.Fatalf("expected a succeeded delivery got %+v", deliveries)
+		case deliveries[0].Attempts != 1 || deliveries[0].ResponseStatus == nil || *deliveries[0].ResponseStatus != http.StatusOK:
+			t.Errorf("expected a single successful attempt got %+v", deliveries[0])
+		case fmt.Sprint(received) != fmt.Sprint([]models.EventType{models.TransactionCreatedEvent}):
+			t.Errorf("expected a signed TransactionCreated delivery got %v", received)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package testutils
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ServerSentEvent is an event read from an event stream
+type ServerSentEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// CallStreamAccountEvents opens the event stream of the account, the caller must close the body of the response
+func (ta *TestApp) CallStreamAccountEvents(accountID int64, lastEventID string) (*http.Response, error) {
+	url := ta.baseUrl + fmt.Sprintf("/accounts/%d/events", accountID)
+
+	httpreq, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpreq.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		httpreq.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	return http.DefaultClient.Do(httpreq)
+}
+
+// ReadServerSentEvent reads the next event of a stream, skipping its comments
+func ReadServerSentEvent(reader *bufio.Reader) (ServerSentEvent, error) {
+
+	event := ServerSentEvent{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return event, err
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event != (ServerSentEvent{}):
+			return event, nil
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
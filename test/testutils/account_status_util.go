@@ -0,0 +1,84 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/server"
+)
+
+func (ta *TestApp) CallFreezeAccount(accountID int64, req *server.UpdateAccountStatusRequest) (int, *server.GetAccountResponse, error) {
+	return ta.callUpdateAccountStatus(fmt.Sprintf("/accounts/%d/freeze", accountID), req)
+}
+
+func (ta *TestApp) CallUnfreezeAccount(accountID int64, req *server.UpdateAccountStatusRequest) (int, *server.GetAccountResponse, error) {
+	return ta.callUpdateAccountStatus(fmt.Sprintf("/accounts/%d/unfreeze", accountID), req)
+}
+
+func (ta *TestApp) CallCloseAccount(accountID int64, req *server.UpdateAccountStatusRequest) (int, *server.GetAccountResponse, error) {
+	return ta.callUpdateAccountStatus(fmt.Sprintf("/accounts/%d/close", accountID), req)
+}
+
+func (ta *TestApp) CallListAccountStatusHistory(accountID int64) (int, *server.ListAccountStatusHistoryResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/accounts/%d/status-history", accountID)
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.ListAccountStatusHistoryResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+// callUpdateAccountStatus posts req to extension, a nil req sends no body
+func (ta *TestApp) callUpdateAccountStatus(extension string, req *server.UpdateAccountStatusRequest) (int, *server.GetAccountResponse, error) {
+	url := ta.baseUrl + extension
+	body := &bytes.Buffer{}
+
+	if req != nil {
+		ba, err := json.Marshal(*req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+		}
+		body = bytes.NewBuffer(ba)
+	}
+
+	httpresp, err := http.Post(url, "application/json", body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.GetAccountResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
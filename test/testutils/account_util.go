@@ -73,6 +73,38 @@ func (ta *TestApp) CallCreateAccount(req *server.CreateAccountRequest) (int, *se
 	return status, &resp, nil
 }
 
+// CallCreateAccountWithDocumentErr creates an account expecting the document to be rejected
+func (ta *TestApp) CallCreateAccountWithDocumentErr(req *server.CreateAccountRequest) (int, *models.DocumentError, error) {
+	url := ta.baseUrl + "/accounts"
+
+	ba, err := json.Marshal(*req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	httpresp, err := http.Post(url, "application/json", bytes.NewBuffer(ba))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusBadRequest {
+		return status, nil, nil
+	}
+
+	ba, err = io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := models.DocumentError{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
 func (ta *TestApp) CallCreateAccountWithoutBody() (int, *models.Account, error) {
 	var err error
 	url := ta.baseUrl + "/accounts"
@@ -96,3 +128,140 @@ func (ta *TestApp) CallCreateAccountWithoutBody() (int, *models.Account, error)
 
 	return status, &resp, nil
 }
+
+func (ta *TestApp) CallGetAccountBalance(accountID int64) (int, *server.GetAccountBalanceResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/accounts/%d/balance", accountID)
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.GetAccountBalanceResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallUpdateCreditLimit(accountID int64, req *server.UpdateCreditLimitRequest) (int, *server.GetAccountResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/accounts/%d/limit", accountID)
+
+	ba, err := json.Marshal(*req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	httpreq, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(ba))
+	if err != nil {
+		return 0, nil, err
+	}
+	httpreq.Header.Set("Content-Type", "application/json")
+
+	httpresp, err := http.DefaultClient.Do(httpreq)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err = io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.GetAccountResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallUpdateSettlementStrategy(accountID int64, req *server.UpdateSettlementStrategyRequest) (int, *server.GetAccountResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/accounts/%d/settlement-strategy", accountID)
+
+	ba, err := json.Marshal(*req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	httpreq, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(ba))
+	if err != nil {
+		return 0, nil, err
+	}
+	httpreq.Header.Set("Content-Type", "application/json")
+
+	httpresp, err := http.DefaultClient.Do(httpreq)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err = io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.GetAccountResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallUpdateBillingCycle(accountID int64, req *server.UpdateBillingCycleRequest) (int, *server.GetAccountResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/accounts/%d/billing-cycle", accountID)
+
+	ba, err := json.Marshal(*req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	httpreq, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(ba))
+	if err != nil {
+		return 0, nil, err
+	}
+	httpreq.Header.Set("Content-Type", "application/json")
+
+	httpresp, err := http.DefaultClient.Do(httpreq)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err = io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.GetAccountResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
@@ -0,0 +1,67 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/server"
+)
+
+func (ta *TestApp) CallAccrue(req *server.AccrueRequest) (int, *server.AccrueResponse, error) {
+	url := ta.baseUrl + "/accruals"
+
+	ba, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	httpresp, err := http.Post(url, "application/json", bytes.NewBuffer(ba))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err = io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.AccrueResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallListAccountAccruals(accountID int64) (int, *server.ListAccrualsResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/accounts/%d/accruals", accountID)
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.ListAccrualsResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
@@ -0,0 +1,47 @@
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+)
+
+func (ta *TestApp) CallCreateAuthorization(req *server.CreateAuthorizationRequest) (int, *models.Authorization, error) {
+	url := ta.baseUrl + "/authorizations"
+
+	ba, err := json.Marshal(*req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	return call[models.Authorization](http.MethodPost, url, ba, http.StatusCreated)
+}
+
+func (ta *TestApp) CallGetAuthorization(authorizationID int64) (int, *models.Authorization, error) {
+	url := ta.baseUrl + fmt.Sprintf("/authorizations/%d", authorizationID)
+
+	return call[models.Authorization](http.MethodGet, url, nil, http.StatusOK)
+}
+
+func (ta *TestApp) CallCaptureAuthorization(authorizationID int64, req *server.CaptureAuthorizationRequest) (int, *models.Authorization, error) {
+	url := ta.baseUrl + fmt.Sprintf("/authorizations/%d/capture", authorizationID)
+
+	var ba []byte
+	if req != nil {
+		var err error
+		ba, err = json.Marshal(*req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+		}
+	}
+
+	return call[models.Authorization](http.MethodPost, url, ba, http.StatusOK)
+}
+
+func (ta *TestApp) CallReleaseAuthorization(authorizationID int64) (int, *models.Authorization, error) {
+	url := ta.baseUrl + fmt.Sprintf("/authorizations/%d/release", authorizationID)
+
+	return call[models.Authorization](http.MethodPost, url, nil, http.StatusOK)
+}
@@ -0,0 +1,43 @@
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+)
+
+func (ta *TestApp) CallSetFXRate(req any) (int, *models.FXRate, error) {
+	url := ta.baseUrl + "/fx/rates"
+
+	ba, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	return call[models.FXRate](http.MethodPut, url, ba, http.StatusOK)
+}
+
+func (ta *TestApp) CallListFXRates() (int, *server.ListFXRatesResponse, error) {
+	url := ta.baseUrl + "/fx/rates"
+
+	return call[server.ListFXRatesResponse](http.MethodGet, url, nil, http.StatusOK)
+}
+
+func (ta *TestApp) CallCreateFXQuote(req any) (int, *models.FXQuote, error) {
+	url := ta.baseUrl + "/fx/quotes"
+
+	ba, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	return call[models.FXQuote](http.MethodPost, url, ba, http.StatusCreated)
+}
+
+func (ta *TestApp) CallGetFXQuote(quoteID int64) (int, *models.FXQuote, error) {
+	url := ta.baseUrl + fmt.Sprintf("/fx/quotes/%d", quoteID)
+
+	return call[models.FXQuote](http.MethodGet, url, nil, http.StatusOK)
+}
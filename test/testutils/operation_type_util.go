@@ -0,0 +1,105 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+)
+
+func (ta *TestApp) CallListOperationTypes() (int, *server.ListOperationTypesResponse, error) {
+	url := ta.baseUrl + "/operation-types"
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.ListOperationTypesResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallCreateOperationType(req *server.CreateOperationTypeRequest) (int, *models.OperationType, error) {
+	url := ta.baseUrl + "/operation-types"
+
+	ba, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	httpresp, err := http.Post(url, "application/json", bytes.NewBuffer(ba))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusCreated {
+		return status, nil, nil
+	}
+
+	ba, err = io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := models.OperationType{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallUpdateOperationType(operationTypeID int64, req *server.UpdateOperationTypeRequest) (int, *models.OperationType, error) {
+	url := ta.baseUrl + fmt.Sprintf("/operation-types/%d", operationTypeID)
+
+	ba, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	httpreq, err := http.NewRequest(http.MethodPatch, url, bytes.NewBuffer(ba))
+	if err != nil {
+		return 0, nil, err
+	}
+	httpreq.Header.Set("Content-Type", "application/json")
+
+	httpresp, err := http.DefaultClient.Do(httpreq)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err = io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := models.OperationType{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
@@ -0,0 +1,38 @@
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+)
+
+func (ta *TestApp) CallCreateSchedule(req any) (int, *models.Schedule, error) {
+	url := ta.baseUrl + "/schedules"
+
+	ba, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	return call[models.Schedule](http.MethodPost, url, ba, http.StatusCreated)
+}
+
+func (ta *TestApp) CallGetSchedule(scheduleID int64) (int, *models.Schedule, error) {
+	url := ta.baseUrl + fmt.Sprintf("/schedules/%d", scheduleID)
+
+	return call[models.Schedule](http.MethodGet, url, nil, http.StatusOK)
+}
+
+func (ta *TestApp) CallCancelSchedule(scheduleID int64) (int, *models.Schedule, error) {
+	url := ta.baseUrl + fmt.Sprintf("/schedules/%d/cancel", scheduleID)
+
+	return call[models.Schedule](http.MethodPost, url, nil, http.StatusOK)
+}
+
+func (ta *TestApp) CallListScheduleRuns(scheduleID int64) (int, *server.ListScheduleRunsResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/schedules/%d/runs", scheduleID)
+
+	return call[server.ListScheduleRunsResponse](http.MethodGet, url, nil, http.StatusOK)
+}
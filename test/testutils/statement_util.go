@@ -0,0 +1,62 @@
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+)
+
+func (ta *TestApp) CallListAccountStatements(accountID int64) (int, *server.ListStatementsResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/accounts/%d/statements", accountID)
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.ListStatementsResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallGetStatement(statementID int64) (int, *models.Statement, error) {
+	url := ta.baseUrl + fmt.Sprintf("/statements/%d", statementID)
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := models.Statement{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
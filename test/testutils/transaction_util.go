@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"payments-backend-app/pkg/server"
 )
 
@@ -44,3 +45,168 @@ func (ta *TestApp) CallCreateTransaction(req *server.CreateTransactionRequest) (
 
 	return status, &resp, nil
 }
+
+func (ta *TestApp) CallCreateTransactionWithBody(body []byte) (int, *server.CreateTransactionResponse, error) {
+	url := ta.baseUrl + "/transactions"
+
+	httpresp, err := http.Post(url, "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusCreated {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.CreateTransactionResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallGetTransaction(transactionID int64) (int, *server.GetTransactionResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/transactions/%d", transactionID)
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.GetTransactionResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallListAccountTransactions(accountID int64, query url.Values) (int, *server.ListTransactionsResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/accounts/%d/transactions?%s", accountID, query.Encode())
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.ListTransactionsResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallReverseTransaction(transactionID int64, req *server.ReverseTransactionRequest) (int, *server.CreateTransactionResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/transactions/%d/reverse", transactionID)
+	body := &bytes.Buffer{}
+
+	if req != nil {
+		ba, err := json.Marshal(*req)
+		if err != nil {
+			return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+		}
+		body = bytes.NewBuffer(ba)
+	}
+
+	httpresp, err := http.Post(url, "application/json", body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusCreated {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.CreateTransactionResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallListTransactionInstallments(transactionID int64) (int, *server.ListInstallmentsResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/transactions/%d/installments", transactionID)
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.ListInstallmentsResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
+
+func (ta *TestApp) CallListTransactionAllocations(transactionID int64) (int, *server.ListAllocationsResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/transactions/%d/allocations", transactionID)
+
+	httpresp, err := http.Get(url)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	status := httpresp.StatusCode
+	if status != http.StatusOK {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := server.ListAllocationsResponse{}
+	if err := json.Unmarshal(ba, &resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, &resp, nil
+}
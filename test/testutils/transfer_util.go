@@ -0,0 +1,26 @@
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+)
+
+func (ta *TestApp) CallCreateTransfer(req *server.CreateTransferRequest) (int, *models.Transfer, error) {
+	url := ta.baseUrl + "/transfers"
+
+	ba, err := json.Marshal(*req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	return call[models.Transfer](http.MethodPost, url, ba, http.StatusCreated)
+}
+
+func (ta *TestApp) CallGetTransfer(transferID int64) (int, *models.Transfer, error) {
+	url := ta.baseUrl + fmt.Sprintf("/transfers/%d", transferID)
+
+	return call[models.Transfer](http.MethodGet, url, nil, http.StatusOK)
+}
@@ -1,10 +1,15 @@
 package testutils
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
+	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -14,10 +19,14 @@ import (
 
 	"payments-backend-app/builder"
 	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
 )
 
 var (
 	addr = ":8080"
+
+	// TEST_WITHOUT_DATABASE_ENV runs the test server against the in-memory services
+	TEST_WITHOUT_DATABASE_ENV = "TEST_WITHOUT_DATABASE"
 )
 
 func GetBaseUrl() string {
@@ -25,10 +34,19 @@ func GetBaseUrl() string {
 }
 
 type TestApp struct {
-	baseUrl            string
-	AccountsService    models.AccountsService
-	TransactionService models.TransactionService
-	runner             builder.Runner
+	baseUrl              string
+	AccountsService      models.AccountsService
+	TransactionService   models.TransactionService
+	OperationTypeService models.OperationTypeService
+	StatementService     models.StatementService
+	AccrualService       models.AccrualService
+	ScheduleService      models.ScheduleService
+	IdempotencyService   models.IdempotencyService
+	FXService            models.FXService
+	currencyConverter    models.CurrencyConverter
+	eventPublisher       models.EventPublisher
+	runner               builder.Runner
+	withoutDatabase      bool
 }
 
 type TestDatabase struct {
@@ -49,6 +67,33 @@ func WithTransactionService(transactionService models.TransactionService) Option
 	}
 }
 
+// WithCurrencyConverter converts the transactions that request a conversion
+func WithCurrencyConverter(converter models.CurrencyConverter) Option {
+	return func(ta *TestApp) {
+		ta.currencyConverter = converter
+	}
+}
+
+// WithEventPublisher relays the events of the outbox to publisher
+func WithEventPublisher(publisher models.EventPublisher) Option {
+	return func(ta *TestApp) {
+		ta.eventPublisher = publisher
+	}
+}
+
+// WithoutDatabase runs the test server against the in-memory services
+func WithoutDatabase() Option {
+	return func(ta *TestApp) {
+		ta.withoutDatabase = true
+	}
+}
+
+// IsWithoutDatabase returns whether the tests are configured to run without postgres
+func IsWithoutDatabase() bool {
+	withoutDatabase, _ := strconv.ParseBool(os.Getenv(TEST_WITHOUT_DATABASE_ENV))
+	return withoutDatabase
+}
+
 func NewTestServer(t *testing.T, opts ...Option) *TestApp {
 
 	testApp := &TestApp{}
@@ -56,6 +101,10 @@ func NewTestServer(t *testing.T, opts ...Option) *TestApp {
 		opt(testApp)
 	}
 
+	if IsWithoutDatabase() {
+		testApp.withoutDatabase = true
+	}
+
 	envConfig := builder.GetEnvConfig()
 
 	paymentsAppBuilder := builder.
@@ -66,17 +115,32 @@ func NewTestServer(t *testing.T, opts ...Option) *TestApp {
 		WithDatabaseAddr(envConfig.DatabaseAddr).
 		WithDatabaseName(envConfig.DatabaseName).
 		WithDatabaseUser(envConfig.DatabaseUser).
-		WithDatabasePassword(envConfig.DatabasePassword)
+		WithDatabasePassword(envConfig.DatabasePassword).
+		WithCurrencyConverter(testApp.currencyConverter).
+		WithEventPublisher(testApp.eventPublisher).
+		// the receivers of the webhooks of the tests listen on loopback
+		WithWebhookHostCheck(func(context.Context, string) error { return nil }).
+		WithWebhookClient(&http.Client{Timeout: 10 * time.Second})
 
 	if envConfig.UseInsecureDatabase {
 		paymentsAppBuilder = paymentsAppBuilder.UseInsecureDatabaseConnection()
 	}
 
+	if testApp.withoutDatabase {
+		paymentsAppBuilder = paymentsAppBuilder.DisableDatabase()
+	}
+
 	paymentsAppRunner, err := paymentsAppBuilder.Build()
 	require.NoError(t, err)
 
 	testApp.AccountsService = paymentsAppBuilder.AccountsService
 	testApp.TransactionService = paymentsAppBuilder.TransactionService
+	testApp.OperationTypeService = paymentsAppBuilder.OperationTypeService
+	testApp.StatementService = paymentsAppBuilder.StatementService
+	testApp.AccrualService = paymentsAppBuilder.AccrualService
+	testApp.ScheduleService = paymentsAppBuilder.ScheduleService
+	testApp.IdempotencyService = paymentsAppBuilder.IdempotencyService
+	testApp.FXService = paymentsAppBuilder.FXService
 
 	testApp.baseUrl = "http://localhost" + envConfig.PaymentsAppAddr
 	testApp.runner = paymentsAppRunner
@@ -134,6 +198,36 @@ func GenerateRandomNumberInt(length int) int {
 	return rand.Intn(max-min+1) + min
 }
 
+// GenerateCPF returns a random CPF with valid check digits
+func GenerateCPF() string {
+	return CPFWithCheckDigits(GenerateRandomNumber(9))
+}
+
+// CPFWithCheckDigits appends the check digits to the first 9 digits of a CPF
+func CPFWithCheckDigits(base string) string {
+	return withCheckDigits(base, []int{10, 9, 8, 7, 6, 5, 4, 3, 2}, []int{11, 10, 9, 8, 7, 6, 5, 4, 3, 2})
+}
+
+// GenerateCNPJ returns a random CNPJ of a head office with valid check digits
+func GenerateCNPJ() string {
+	return withCheckDigits(GenerateRandomNumber(8)+"0001", []int{5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}, []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2})
+}
+
+func withCheckDigits(digits string, firstWeights []int, secondWeights []int) string {
+	for _, weights := range [][]int{firstWeights, secondWeights} {
+		sum := 0
+		for i, weight := range weights {
+			sum += int(digits[i]-'0') * weight
+		}
+		checkDigit := 0
+		if sum%11 >= 2 {
+			checkDigit = 11 - sum%11
+		}
+		digits += strconv.Itoa(checkDigit)
+	}
+	return digits
+}
+
 func pow(base, exp int) int {
 	result := 1
 	for exp > 0 {
@@ -145,3 +239,66 @@ func pow(base, exp int) int {
 	}
 	return result
 }
+
+func (ta *TestApp) CallPostWithIdempotencyKey(path string, req any, idempotencyKey string) (int, []byte, error) {
+	url := ta.baseUrl + path
+
+	ba, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	httpreq, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(ba))
+	if err != nil {
+		return 0, nil, err
+	}
+	httpreq.Header.Set("Content-Type", "application/json")
+	httpreq.Header.Set(server.IdempotencyKeyHeader, idempotencyKey)
+
+	httpresp, err := http.DefaultClient.Do(httpreq)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer httpresp.Body.Close()
+
+	body, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return httpresp.StatusCode, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	return httpresp.StatusCode, body, nil
+}
+
+// call sends the json body to url and decodes the response into a T when it has the expected status,
+// any other status is returned without a response
+func call[T any](method string, url string, body []byte, expectedStatus int) (int, *T, error) {
+
+	httpreq, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	httpreq.Header.Set("Content-Type", "application/json")
+
+	httpresp, err := http.DefaultClient.Do(httpreq)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer httpresp.Body.Close()
+
+	status := httpresp.StatusCode
+	if status != expectedStatus {
+		return status, nil, nil
+	}
+
+	ba, err := io.ReadAll(httpresp.Body)
+	if err != nil {
+		return status, nil, fmt.Errorf("unable to read response body [%s]", err.Error())
+	}
+
+	resp := new(T)
+	if err := json.Unmarshal(ba, resp); err != nil {
+		return status, nil, fmt.Errorf("unable to unmarshal response [%s]", err.Error())
+	}
+
+	return status, resp, nil
+}
@@ -0,0 +1,26 @@
+package testutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"payments-backend-app/pkg/models"
+	"payments-backend-app/pkg/server"
+)
+
+func (ta *TestApp) CallCreateWebhook(req any) (int, *models.Webhook, error) {
+	url := ta.baseUrl + "/webhooks"
+
+	ba, err := json.Marshal(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to marshal [%s]", err)
+	}
+
+	return call[models.Webhook](http.MethodPost, url, ba, http.StatusCreated)
+}
+
+func (ta *TestApp) CallListWebhookDeliveries(webhookID int64) (int, *server.ListWebhookDeliveriesResponse, error) {
+	url := ta.baseUrl + fmt.Sprintf("/webhooks/%d/deliveries", webhookID)
+
+	return call[server.ListWebhookDeliveriesResponse](http.MethodGet, url, nil, http.StatusOK)
+}